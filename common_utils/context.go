@@ -66,6 +66,12 @@ const (
 	DBUS_IMAGE_ACTIVATE
 	DBUS_DOCKER_LOAD
 	DBUS_CONFIG_REPLACE
+	CRL_REVOKED
+	CRL_FAIL
+	OCSP_GOOD
+	OCSP_REVOKED
+	OCSP_UNKNOWN
+	OCSP_FAIL
 	COUNTER_SIZE
 )
 
@@ -127,6 +133,18 @@ func (c CounterType) String() string {
 		return "DBUS docker load"
 	case DBUS_CONFIG_REPLACE:
 		return "DBUS config replace"
+	case CRL_REVOKED:
+		return "CRL revoked"
+	case CRL_FAIL:
+		return "CRL fail"
+	case OCSP_GOOD:
+		return "OCSP good"
+	case OCSP_REVOKED:
+		return "OCSP revoked"
+	case OCSP_UNKNOWN:
+		return "OCSP unknown"
+	case OCSP_FAIL:
+		return "OCSP fail"
 	default:
 		return ""
 	}
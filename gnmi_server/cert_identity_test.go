@@ -0,0 +1,127 @@
+package gnmi
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sonic-net/sonic-gnmi/common_utils"
+	"github.com/sonic-net/sonic-gnmi/pkg/certidentity"
+)
+
+func TestSetClientCertIdentitySources(t *testing.T) {
+	saved := clientCertIdentitySources
+	defer func() { clientCertIdentitySources = saved }()
+	if err := SetClientCertIdentitySources([]string{"uri", "cn"}); err != nil {
+		t.Errorf("SetClientCertIdentitySources failed: %v", err)
+	}
+	if !reflect.DeepEqual(clientCertIdentitySources, []string{"uri", "cn"}) {
+		t.Errorf("Got identity sources %v", clientCertIdentitySources)
+	}
+	if err := SetClientCertIdentitySources([]string{"uri", "serial"}); err == nil {
+		t.Errorf("Expected invalid identity source to fail")
+	}
+	if err := SetClientCertIdentitySources(nil); err == nil {
+		t.Errorf("Expected empty identity sources to fail")
+	}
+}
+
+func TestNewCertIdentityEntry(t *testing.T) {
+	tests := []struct {
+		desc   string
+		fields map[string]string
+		match  string
+		source string
+		roles  []string
+		fail   bool
+	}{
+		{"defaults", map[string]string{"role@": "gnmi_readwrite,gnoi_readwrite"}, "exact", "cn", []string{"gnmi_readwrite", "gnoi_readwrite"}, false},
+		{"single role schema", map[string]string{"role": "gnmi_readonly"}, "exact", "cn", []string{"gnmi_readonly"}, false},
+		{"match and source", map[string]string{"role@": "gnmi_readonly", "match": "glob", "source": "uri"}, "glob", "uri", []string{"gnmi_readonly"}, false},
+		{"invalid match", map[string]string{"role@": "gnmi_readonly", "match": "fuzzy"}, "", "", nil, true},
+		{"invalid source", map[string]string{"role@": "gnmi_readonly", "source": "serial"}, "", "", nil, true},
+	}
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			entry, err := newCertIdentityEntry("host1.example.com", test.fields)
+			if test.fail {
+				if err == nil {
+					t.Errorf("Expected entry %v to be rejected", test.fields)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("newCertIdentityEntry failed: %v", err)
+			}
+			if entry.Match != test.match || entry.Source != test.source || !reflect.DeepEqual(entry.Roles, test.roles) {
+				t.Errorf("Got match %v source %v roles %v, want %v %v %v", entry.Match, entry.Source, entry.Roles, test.match, test.source, test.roles)
+			}
+		})
+	}
+}
+
+func TestKeyspaceEventsEnabled(t *testing.T) {
+	for flags, want := range map[string]bool{"": false, "KEA": true, "AK": true, "Kgh": true, "Kh": false, "EA": false} {
+		if got := keyspaceEventsEnabled(flags); got != want {
+			t.Errorf("keyspaceEventsEnabled(%q) = %v, want %v", flags, got, want)
+		}
+	}
+}
+
+func TestPopulateAuthStructByCertIdentity(t *testing.T) {
+	const tableName = "GNMI_CLIENT_CERT_CACHE_TEST"
+	rclient := getConfigDbClient(t, "")
+	defer rclient.Close()
+	if err := rclient.ConfigSet("notify-keyspace-events", "KEA").Err(); err != nil {
+		t.Fatalf("failed to enable redis keyspace notification: %v", err)
+	}
+	defer rclient.Del(tableName+"|host1.example.com", tableName+"|spiffe://example.com/ns/sonic/sa/*")
+
+	rclient.HSet(tableName+"|host1.example.com", "role@", "gnmi_readonly")
+	rclient.HSet(tableName+"|spiffe://example.com/ns/sonic/sa/*", "role@", "gnmi_readwrite")
+	rclient.HSet(tableName+"|spiffe://example.com/ns/sonic/sa/*", "match", "glob")
+	rclient.HSet(tableName+"|spiffe://example.com/ns/sonic/sa/*", "source", "uri")
+
+	uri := certidentity.Identity{Source: certidentity.SourceURI, Value: "spiffe://example.com/ns/sonic/sa/gnmi"}
+	cn := certidentity.Identity{Source: certidentity.SourceCN, Value: "host1.example.com"}
+	populate := func(identities ...certidentity.Identity) (common_utils.AuthInfo, error) {
+		var auth common_utils.AuthInfo
+		err := PopulateAuthStructByCertIdentity(identities, &auth, tableName)
+		return auth, err
+	}
+
+	auth, err := populate(uri, cn)
+	if err != nil || auth.User != uri.Value || !reflect.DeepEqual(auth.Roles, []string{"gnmi_readwrite"}) {
+		t.Errorf("Expected the URI entry to match, got %v %v", auth, err)
+	}
+	// A common name is only matched by common name entries
+	if _, err := populate(certidentity.Identity{Source: certidentity.SourceCN, Value: uri.Value}); err == nil {
+		t.Errorf("Expected a common name not to match a URI entry")
+	}
+
+	// The cached entries are reloaded once the change is notified
+	rclient.HSet(tableName+"|host1.example.com", "role@", "gnmi_readwrite")
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		auth, err = populate(cn)
+		if err == nil && reflect.DeepEqual(auth.Roles, []string{"gnmi_readwrite"}) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Changed entry not reloaded, got %v %v", auth, err)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	rclient.Del(tableName + "|host1.example.com")
+	deadline = time.Now().Add(10 * time.Second)
+	for {
+		if _, err = populate(cn); err != nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Deleted entry still matched")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
@@ -3,6 +3,7 @@ package gnmi
 import (
 	"io/ioutil"
 	"os/exec"
+	"path/filepath"
 	"reflect"
 	"testing"
 
@@ -39,6 +40,24 @@ func MockNSEnterBGPSummary(t *testing.T, fileName string) *gomonkey.Patches {
 	return patches
 }
 
+// MockNSEnterVtysh runs vtysh commands given to nsenter with the fake vtysh script in testdata,
+// which prints the canned output for each command.
+func MockNSEnterVtysh(t *testing.T) *gomonkey.Patches {
+	script, err := filepath.Abs("../testdata/fake_vtysh.sh")
+	if err != nil {
+		t.Fatalf("failed to find fake vtysh: %v", err)
+	}
+	return gomonkey.ApplyFunc(exec.Command, func(name string, args ...string) *exec.Cmd {
+		for i := range args {
+			if args[i] == "--" && i+1 < len(args) && args[i+1] == "vtysh" {
+				return &exec.Cmd{Path: script, Args: append([]string{script}, args[i+2:]...)}
+			}
+		}
+		t.Errorf("unexpected command %v %v", name, args)
+		return &exec.Cmd{Path: "/bin/false", Args: []string{"/bin/false"}}
+	})
+}
+
 func MockReadFile(fileName string, fileContent string, fileReadErr error) {
 	sdc.ImplIoutilReadFile = func(filePath string) ([]byte, error) {
 		if filePath == fileName {
@@ -5,12 +5,11 @@ import (
 	"crypto/x509"
 	"io"
 	"net/http"
-	"strings"
 	"time"
 
 	"github.com/golang/glog"
 	"github.com/sonic-net/sonic-gnmi/common_utils"
-	"github.com/sonic-net/sonic-gnmi/swsscommon"
+	"github.com/sonic-net/sonic-gnmi/pkg/certidentity"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
@@ -30,8 +29,8 @@ type Crl struct {
 // CRL content cache
 var CrlCache map[string]*Crl = nil
 
-// CRL content cache
-var CrlDxpireDuration time.Duration = DEFAULT_CRL_EXPIRE_DURATION
+// CRL cache expire duration, protected by configLock as it can change while the server runs
+var crlExpireDuration time.Duration = DEFAULT_CRL_EXPIRE_DURATION
 
 func InitCrlCache() {
 	if CrlCache == nil {
@@ -55,11 +54,15 @@ func AppendCrlToCache(url string, rawCRL []byte) {
 }
 
 func GetCrlExpireDuration() time.Duration {
-	return CrlDxpireDuration
+	configLock.RLock()
+	defer configLock.RUnlock()
+	return crlExpireDuration
 }
 
 func SetCrlExpireDuration(duration time.Duration) {
-	CrlDxpireDuration = duration
+	configLock.Lock()
+	defer configLock.Unlock()
+	crlExpireDuration = duration
 }
 
 func CrlExpired(crl *Crl) bool {
@@ -126,20 +129,17 @@ func ClientCertAuthenAndAuthor(ctx context.Context, serviceConfigTableName strin
 		return ctx, status.Error(codes.Unauthenticated, "could not verify peer certificate")
 	}
 
-	var username string
-
-	username = tlsAuth.State.VerifiedChains[0][0].Subject.CommonName
-
-	if len(username) == 0 {
-		return ctx, status.Error(codes.Unauthenticated, "invalid username in certificate common name.")
+	identities := certidentity.FromCertificate(tlsAuth.State.VerifiedChains[0][0], clientCertIdentitySources)
+	if len(identities) == 0 {
+		return ctx, status.Error(codes.Unauthenticated, "invalid username in certificate, no identity found.")
 	}
 
 	if serviceConfigTableName != "" {
-		if err := PopulateAuthStructByCommonName(username, &rc.Auth, serviceConfigTableName); err != nil {
+		if err := PopulateAuthStructByCertIdentity(identities, &rc.Auth, serviceConfigTableName); err != nil {
 			return ctx, err
 		}
 	} else {
-		if err := PopulateAuthStruct(username, &rc.Auth, nil); err != nil {
+		if err := PopulateAuthStruct(identities[0].Value, &rc.Auth, nil); err != nil {
 			glog.Infof("[%s] Failed to retrieve authentication information; %v", rc.ID, err)
 			return ctx, status.Errorf(codes.Unauthenticated, "")
 		}
@@ -232,6 +232,7 @@ func VerifyCertCrl(tlsConnState tls.ConnectionState) error {
 		// Every certificate will contain multiple CRL distribution points.
 		// If all CRLs are not available, the certificate validation should be blocked.
 		glog.Infof("VerifyCertCrl can't download CRL and verify cert: %v", crlUriArray)
+		common_utils.IncCounter(common_utils.CRL_FAIL)
 		return status.Errorf(codes.Unauthenticated, "Can't download CRL and verify cert")
 	}
 
@@ -244,6 +245,7 @@ func VerifyCertCrl(tlsConnState tls.ConnectionState) error {
 
 	if err != nil {
 		glog.Infof("VerifyCertCrl peer certificate revoked: %v", err.Error())
+		common_utils.IncCounter(common_utils.CRL_REVOKED)
 		return status.Error(codes.Unauthenticated, "Peer certificate revoked")
 	}
 
@@ -251,34 +253,8 @@ func VerifyCertCrl(tlsConnState tls.ConnectionState) error {
 	return nil
 }
 
+// PopulateAuthStructByCommonName sets the roles of the client cert table entry matching the common name.
 func PopulateAuthStructByCommonName(certCommonName string, auth *common_utils.AuthInfo, serviceConfigTableName string) error {
-	if serviceConfigTableName == "" {
-		return status.Errorf(codes.Unauthenticated, "Service config table name should not be empty")
-	}
-
-	var configDbConnector = swsscommon.NewConfigDBConnector()
-	defer swsscommon.DeleteConfigDBConnector_Native(configDbConnector.ConfigDBConnector_Native)
-	configDbConnector.Connect(false)
-
-	var fieldValuePairs = configDbConnector.Get_entry(serviceConfigTableName, certCommonName)
-	if fieldValuePairs.Size() > 0 {
-		if fieldValuePairs.Has_key("role@") {
-			var role = fieldValuePairs.Get("role@")
-			auth.Roles = strings.Split(role, ",")
-		} else if fieldValuePairs.Has_key("role") {
-			// Backward compatibility for single role DB schema
-			var role = fieldValuePairs.Get("role")
-			auth.Roles = []string{role}
-		}
-	} else {
-		glog.Warningf("Failed to retrieve cert common name mapping; %s", certCommonName)
-	}
-
-	swsscommon.DeleteFieldValueMap(fieldValuePairs)
-
-	if len(auth.Roles) == 0 {
-		return status.Errorf(codes.Unauthenticated, "Invalid cert cname:'%s', not a trusted cert common name.", certCommonName)
-	} else {
-		return nil
-	}
+	identities := []certidentity.Identity{{Source: certidentity.SourceCN, Value: certCommonName}}
+	return PopulateAuthStructByCertIdentity(identities, auth, serviceConfigTableName)
 }
@@ -0,0 +1,253 @@
+package gnmi
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/golang/glog"
+	"github.com/sonic-net/sonic-gnmi/common_utils"
+	"github.com/sonic-net/sonic-gnmi/pkg/certidentity"
+	sdcfg "github.com/sonic-net/sonic-gnmi/sonic_db_config"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Delay before subscribing again to client cert table changes after a failure
+const certIdentityWatchRetry = 5 * time.Second
+
+// Parts of the client certificate identities are taken from, in order of precedence
+var clientCertIdentitySources = []string{certidentity.SourceCN}
+
+// SetClientCertIdentitySources sets the parts of client certificates identities are taken from.
+// When several are set, the first identity matching a client cert table entry is used.
+func SetClientCertIdentitySources(sources []string) error {
+	if err := certidentity.ValidateSources(sources); err != nil {
+		return err
+	}
+	clientCertIdentitySources = sources
+	return nil
+}
+
+// newCertIdentityEntry returns the client cert table entry of key from its fields: "match" sets how
+// the key matches identities, "source" the certificate part they are taken from.
+func newCertIdentityEntry(key string, fields map[string]string) (*certidentity.Entry, error) {
+	var roles []string
+	if role, ok := fields["role@"]; ok {
+		roles = strings.Split(role, ",")
+	} else if role, ok := fields["role"]; ok {
+		// Backward compatibility for single role DB schema
+		roles = []string{role}
+	}
+	return certidentity.NewEntry(key, fields["match"], fields["source"], roles)
+}
+
+// certIdentityTable caches the entries of a client cert table. Entries are kept while the table's
+// keyspace notifications are received, and reloaded after a notification reports a change.
+// Without notifications, entries are reloaded on every lookup.
+type certIdentityTable struct {
+	mu      sync.Mutex
+	entries []*certidentity.Entry
+	valid   bool // entries are up to date
+	watched bool // keyspace notifications of the table are received
+}
+
+var certIdentityTables = struct {
+	sync.Mutex
+	tables map[string]*certIdentityTable
+}{tables: map[string]*certIdentityTable{}}
+
+// getCertIdentityEntries returns the entries of a client cert table, starting to watch the table on first use.
+func getCertIdentityEntries(serviceConfigTableName string) ([]*certidentity.Entry, error) {
+	certIdentityTables.Lock()
+	table, ok := certIdentityTables.tables[serviceConfigTableName]
+	if !ok {
+		table = &certIdentityTable{}
+		certIdentityTables.tables[serviceConfigTableName] = table
+		go table.watch(serviceConfigTableName)
+	}
+	certIdentityTables.Unlock()
+
+	// Changes notified while loading invalidate the entries once loaded, as invalidate waits for mu
+	table.mu.Lock()
+	defer table.mu.Unlock()
+	if table.valid {
+		return table.entries, nil
+	}
+	entries, err := loadCertIdentityEntries(serviceConfigTableName)
+	if err != nil {
+		return nil, err
+	}
+	table.entries = entries
+	table.valid = table.watched
+	return entries, nil
+}
+
+// invalidate marks the entries for reload, and sets whether the table's notifications are received.
+func (t *certIdentityTable) invalidate(watched bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.valid = false
+	t.watched = watched
+}
+
+// resetCertIdentityTables reloads the entries of all client cert tables on their next lookup.
+// FLUSHDB sends no keyspace notification, so tests flushing CONFIG_DB call it.
+func resetCertIdentityTables() {
+	certIdentityTables.Lock()
+	defer certIdentityTables.Unlock()
+	for _, table := range certIdentityTables.tables {
+		table.mu.Lock()
+		table.valid = false
+		table.mu.Unlock()
+	}
+}
+
+// watch invalidates the table entries on each keyspace notification of the table.
+func (t *certIdentityTable) watch(serviceConfigTableName string) {
+	for {
+		if err := t.watchOnce(serviceConfigTableName); err != nil {
+			glog.Warningf("Not watching client cert table %v, reloading it on every lookup: %v", serviceConfigTableName, err)
+		}
+		t.invalidate(false)
+		time.Sleep(certIdentityWatchRetry)
+	}
+}
+
+func (t *certIdentityTable) watchOnce(serviceConfigTableName string) error {
+	client, db, separator, err := newConfigDbClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	// Cached entries would never be reloaded if the notifications are disabled
+	config, err := client.ConfigGet("notify-keyspace-events").Result()
+	if err != nil {
+		return err
+	}
+	var flags string
+	if len(config) == 2 {
+		flags, _ = config[1].(string)
+	}
+	if !keyspaceEventsEnabled(flags) {
+		return fmt.Errorf("keyspace notifications disabled, notify-keyspace-events is %q", flags)
+	}
+
+	pattern := "__keyspace@" + strconv.Itoa(db) + "__:" + serviceConfigTableName + separator + "*"
+	pubsub := client.PSubscribe(pattern)
+	defer pubsub.Close()
+
+	for {
+		msgi, err := pubsub.ReceiveTimeout(time.Minute)
+		if err != nil {
+			if neterr, ok := err.(net.Error); ok && neterr.Timeout() {
+				continue
+			}
+			return err
+		}
+		switch msg := msgi.(type) {
+		case *redis.Subscription:
+			// Also received after reconnecting, changes may have been missed since the last subscription
+			glog.V(2).Infof("Watching client cert table %v: %v", serviceConfigTableName, msg)
+			t.invalidate(true)
+		case *redis.Message:
+			glog.V(2).Infof("Client cert table %v changed: %v", serviceConfigTableName, msg)
+			t.invalidate(true)
+		}
+	}
+}
+
+// keyspaceEventsEnabled reports whether the notify-keyspace-events setting notifies hash and key changes.
+func keyspaceEventsEnabled(flags string) bool {
+	return strings.Contains(flags, "K") &&
+		(strings.Contains(flags, "A") || (strings.Contains(flags, "g") && strings.Contains(flags, "h")))
+}
+
+// newConfigDbClient connects to CONFIG_DB, returning its id and key separator.
+func newConfigDbClient() (*redis.Client, int, string, error) {
+	ns, _ := sdcfg.GetDbDefaultNamespace()
+	addr, err := sdcfg.GetDbTcpAddr("CONFIG_DB", ns)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	db, err := sdcfg.GetDbId("CONFIG_DB", ns)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	separator, err := sdcfg.GetDbSeparator("CONFIG_DB", ns)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	client := redis.NewClient(&redis.Options{
+		Network:     "tcp",
+		Addr:        addr,
+		Password:    "",
+		DB:          db,
+		DialTimeout: 0,
+	})
+	return client, db, separator, nil
+}
+
+// loadCertIdentityEntries reads the client cert table from CONFIG_DB.
+func loadCertIdentityEntries(serviceConfigTableName string) ([]*certidentity.Entry, error) {
+	client, _, separator, err := newConfigDbClient()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	tablePrefix := serviceConfigTableName + separator
+	keys, err := client.Keys(tablePrefix + "*").Result()
+	if err != nil {
+		return nil, err
+	}
+	var entries []*certidentity.Entry
+	for _, key := range keys {
+		fields, err := client.HGetAll(key).Result()
+		if err != nil {
+			return nil, err
+		}
+		entry, err := newCertIdentityEntry(strings.TrimPrefix(key, tablePrefix), fields)
+		if err != nil {
+			glog.Warningf("Skipping client cert entry %v: %v", key, err)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	certidentity.Sort(entries)
+	glog.V(2).Infof("Loaded %d entries of client cert table %v", len(entries), serviceConfigTableName)
+	return entries, nil
+}
+
+// PopulateAuthStructByCertIdentity sets the user and roles of the client cert table entry matching
+// one of the certificate identities, see certidentity.Match for the precedence.
+func PopulateAuthStructByCertIdentity(identities []certidentity.Identity, auth *common_utils.AuthInfo, serviceConfigTableName string) error {
+	if serviceConfigTableName == "" {
+		return status.Errorf(codes.Unauthenticated, "Service config table name should not be empty")
+	}
+
+	entries, err := getCertIdentityEntries(serviceConfigTableName)
+	if err != nil {
+		glog.Errorf("Failed to read client cert table %v: %v", serviceConfigTableName, err)
+		return status.Errorf(codes.Unauthenticated, "Failed to read client cert table")
+	}
+
+	identity, entry := certidentity.Match(identities, entries)
+	if entry == nil || len(entry.Roles) == 0 {
+		desc := make([]string, 0, len(identities))
+		for _, i := range identities {
+			desc = append(desc, i.String())
+		}
+		glog.Warningf("Failed to retrieve cert identity mapping; %v", desc)
+		return status.Errorf(codes.Unauthenticated, "Invalid cert identity:'%s', not a trusted cert identity.", strings.Join(desc, ","))
+	}
+	glog.V(2).Infof("Cert identity %v matched %v entry %v", identity, entry.Match, entry.Key)
+	auth.User = identity.Value
+	auth.Roles = entry.Roles
+	return nil
+}
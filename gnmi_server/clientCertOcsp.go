@@ -0,0 +1,270 @@
+package gnmi
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/sonic-net/sonic-gnmi/common_utils"
+	"golang.org/x/crypto/ocsp"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// OCSP policies, deciding if a client certificate whose revocation status can't be determined is accepted
+const (
+	OCSP_SOFT_FAIL = "soft-fail"
+	OCSP_HARD_FAIL = "hard-fail"
+)
+
+// Time allowed between the clocks of the OCSP responder and the server
+const ocspClockSkew = 5 * time.Minute
+
+// Maximum number of cached OCSP responses
+const ocspCacheSize = 1024
+
+// Timeout of a query to an OCSP responder
+var OcspQueryTimeout = 5 * time.Second
+
+// How long a response without nextUpdate is cached
+var OcspResponseTTL = 5 * time.Minute
+
+// How long a failed query is cached, so that a responder which is down does not delay every RPC
+var OcspFailureTTL = 30 * time.Second
+
+// OCSP policy, protected by configLock as it can change while the server runs
+var ocspPolicy = OCSP_SOFT_FAIL
+
+// ocspCacheEntry is a response of an OCSP responder, or the error querying it, cached until expires
+type ocspCacheEntry struct {
+	resp    *ocsp.Response
+	err     error
+	expires time.Time
+}
+
+// OCSP response cache, by issuer and serial number of the certificate
+var ocspCache = struct {
+	sync.Mutex
+	entries map[string]*ocspCacheEntry
+}{entries: map[string]*ocspCacheEntry{}}
+
+func SetOcspPolicy(policy string) error {
+	switch policy {
+	case OCSP_SOFT_FAIL, OCSP_HARD_FAIL:
+		configLock.Lock()
+		defer configLock.Unlock()
+		ocspPolicy = policy
+		return nil
+	}
+	return fmt.Errorf("invalid OCSP policy %q, expecting %v or %v", policy, OCSP_SOFT_FAIL, OCSP_HARD_FAIL)
+}
+
+func GetOcspPolicy() string {
+	configLock.RLock()
+	defer configLock.RUnlock()
+	return ocspPolicy
+}
+
+func ReleaseOcspCache() {
+	ocspCache.Lock()
+	defer ocspCache.Unlock()
+	ocspCache.entries = map[string]*ocspCacheEntry{}
+}
+
+func ocspCacheKey(cert *x509.Certificate, issuer *x509.Certificate) string {
+	return fmt.Sprintf("%x:%v", issuer.RawSubjectPublicKeyInfo, cert.SerialNumber)
+}
+
+// searchOcspCache returns the cached entry of a certificate, removing it once it has expired.
+func searchOcspCache(key string) *ocspCacheEntry {
+	ocspCache.Lock()
+	defer ocspCache.Unlock()
+	entry, exist := ocspCache.entries[key]
+	if exist && !time.Now().Before(entry.expires) {
+		glog.V(2).Infof("OCSP cached response expired: %v", key)
+		delete(ocspCache.entries, key)
+		return nil
+	}
+	return entry
+}
+
+// appendOcspCache caches a response until its nextUpdate. Responses without nextUpdate, for which
+// the responder has newer information available at any time, are cached for OcspResponseTTL.
+func appendOcspCache(key string, resp *ocsp.Response) {
+	expires := resp.NextUpdate
+	if expires.IsZero() {
+		expires = time.Now().Add(OcspResponseTTL)
+	}
+	cacheOcspEntry(key, &ocspCacheEntry{resp: resp, expires: expires})
+}
+
+// appendOcspFailure caches the error querying the responders of a certificate for OcspFailureTTL.
+func appendOcspFailure(key string, err error) {
+	cacheOcspEntry(key, &ocspCacheEntry{err: err, expires: time.Now().Add(OcspFailureTTL)})
+}
+
+// cacheOcspEntry adds an entry to the cache. When the cache is full, expired entries are removed,
+// then the entry expiring first.
+func cacheOcspEntry(key string, entry *ocspCacheEntry) {
+	ocspCache.Lock()
+	defer ocspCache.Unlock()
+	if _, exist := ocspCache.entries[key]; !exist && len(ocspCache.entries) >= ocspCacheSize {
+		now := time.Now()
+		oldest := ""
+		for k, cached := range ocspCache.entries {
+			if !now.Before(cached.expires) {
+				delete(ocspCache.entries, k)
+			} else if oldest == "" || cached.expires.Before(ocspCache.entries[oldest].expires) {
+				oldest = k
+			}
+		}
+		if len(ocspCache.entries) >= ocspCacheSize {
+			glog.V(2).Infof("OCSP cache full, evict response: %v", oldest)
+			delete(ocspCache.entries, oldest)
+		}
+	}
+	ocspCache.entries[key] = entry
+}
+
+// checkOcspResponse validates the signature and freshness of a response for cert. The response must be
+// signed by the issuer, or by a responder certificate the issuer delegated OCSP signing to.
+func checkOcspResponse(der []byte, cert *x509.Certificate, issuer *x509.Certificate) (*ocsp.Response, error) {
+	resp, err := ocsp.ParseResponseForCert(der, cert, issuer)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	// ParseResponseForCert only checks the issuer signed the responder certificate, any leaf would do
+	if responder := resp.Certificate; responder != nil && !responder.Equal(issuer) {
+		delegated := false
+		for _, usage := range responder.ExtKeyUsage {
+			delegated = delegated || usage == x509.ExtKeyUsageOCSPSigning
+		}
+		if !delegated {
+			return nil, fmt.Errorf("OCSP responder certificate %q is not delegated OCSP signing", responder.Subject)
+		}
+		if now.Before(responder.NotBefore) || now.After(responder.NotAfter) {
+			return nil, fmt.Errorf("OCSP responder certificate %q is expired or not yet valid", responder.Subject)
+		}
+	}
+	if resp.ThisUpdate.After(now.Add(ocspClockSkew)) {
+		return nil, fmt.Errorf("OCSP response thisUpdate %v is in the future", resp.ThisUpdate)
+	}
+	if !resp.NextUpdate.IsZero() && resp.NextUpdate.Add(ocspClockSkew).Before(now) {
+		return nil, fmt.Errorf("OCSP response nextUpdate %v has passed", resp.NextUpdate)
+	}
+	return resp, nil
+}
+
+// queryOcspResponder asks the OCSP responders in the certificate's AIA for its status.
+func queryOcspResponder(cert *x509.Certificate, issuer *x509.Certificate) (*ocsp.Response, error) {
+	if len(cert.OCSPServer) == 0 {
+		return nil, fmt.Errorf("certificate has no OCSP responder")
+	}
+	req, err := ocsp.CreateRequest(cert, issuer, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{Timeout: OcspQueryTimeout}
+	var lastErr error
+	for _, url := range cert.OCSPServer {
+		glog.V(2).Infof("Query OCSP responder: %s", url)
+		httpResp, err := client.Post(url, "application/ocsp-request", bytes.NewReader(req))
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		body, err := io.ReadAll(httpResp.Body)
+		httpResp.Body.Close()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if httpResp.StatusCode != http.StatusOK {
+			lastErr = fmt.Errorf("OCSP responder %s returned %v", url, httpResp.Status)
+			continue
+		}
+		resp, err := checkOcspResponse(body, cert, issuer)
+		if err != nil {
+			lastErr = fmt.Errorf("invalid response of OCSP responder %s: %v", url, err)
+			continue
+		}
+		return resp, nil
+	}
+	return nil, lastErr
+}
+
+// ocspUndetermined applies the OCSP policy to a certificate whose status can't be determined.
+func ocspUndetermined(reason string) error {
+	if GetOcspPolicy() == OCSP_HARD_FAIL {
+		glog.Infof("VerifyCertOcsp reject cert: %v", reason)
+		return status.Errorf(codes.Unauthenticated, "Can't verify cert with OCSP: %v", reason)
+	}
+	glog.Infof("VerifyCertOcsp soft-fail accept cert: %v", reason)
+	return nil
+}
+
+// VerifyCertOcsp checks the revocation status of the peer certificate with OCSP, using a cached response,
+// else querying the responder. A response stapled by the peer is ignored: a client could staple a good
+// response obtained before its certificate was revoked.
+func VerifyCertOcsp(tlsConnState tls.ConnectionState) error {
+	chain := tlsConnState.VerifiedChains[0]
+	if len(chain) < 2 {
+		common_utils.IncCounter(common_utils.OCSP_FAIL)
+		return ocspUndetermined("peer certificate issuer unknown")
+	}
+	cert, issuer := chain[0], chain[1]
+	key := ocspCacheKey(cert, issuer)
+
+	entry := searchOcspCache(key)
+	if entry == nil {
+		resp, err := queryOcspResponder(cert, issuer)
+		if err != nil {
+			appendOcspFailure(key, err)
+		} else {
+			appendOcspCache(key, resp)
+		}
+		entry = &ocspCacheEntry{resp: resp, err: err}
+	}
+	if entry.err != nil {
+		common_utils.IncCounter(common_utils.OCSP_FAIL)
+		return ocspUndetermined(entry.err.Error())
+	}
+	resp := entry.resp
+
+	switch resp.Status {
+	case ocsp.Good:
+		common_utils.IncCounter(common_utils.OCSP_GOOD)
+		glog.V(2).Infof("VerifyCertOcsp verify cert passed, serial %v", cert.SerialNumber)
+		return nil
+	case ocsp.Revoked:
+		common_utils.IncCounter(common_utils.OCSP_REVOKED)
+		glog.Infof("VerifyCertOcsp peer certificate revoked at %v, serial %v", resp.RevokedAt, cert.SerialNumber)
+		return status.Error(codes.Unauthenticated, "Peer certificate revoked")
+	}
+	common_utils.IncCounter(common_utils.OCSP_UNKNOWN)
+	return ocspUndetermined("OCSP responder doesn't know the peer certificate")
+}
+
+// VerifyPeerCertOcsp checks the revocation status of the certificate of the peer in ctx with OCSP.
+func VerifyPeerCertOcsp(ctx context.Context) error {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return status.Error(codes.Unauthenticated, "no peer found")
+	}
+	tlsAuth, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsAuth.State.VerifiedChains) == 0 || len(tlsAuth.State.VerifiedChains[0]) == 0 {
+		return status.Error(codes.Unauthenticated, "could not verify peer certificate")
+	}
+	return VerifyCertOcsp(tlsAuth.State)
+}
@@ -1,6 +1,7 @@
 package gnmi
 
 import (
+	"errors"
 	"fmt"
 	"github.com/Workiva/go-datastructures/queue"
 	log "github.com/golang/glog"
@@ -31,8 +32,15 @@ type Client struct {
 	w        sync.WaitGroup
 	fatal    bool
 	logLevel int
+	// closeOnSync ends the RPC once the sync_response has been sent
+	closeOnSync bool
 }
 
+// errOnceComplete is returned by send once the sync_response of a ONCE
+// subscription on a native origin has been delivered, so that the RPC is
+// closed with OK status.
+var errOnceComplete = errors.New("once subscription complete")
+
 // Syslog level for error
 const logLevelError int = 3
 const logLevelDebug int = 7
@@ -94,7 +102,9 @@ func (c *Client) populateDbPathSubscrition(sublist *gnmipb.SubscriptionList) ([]
 // Run starts the subscribe client. The first message received must be a
 // SubscriptionList. Once the client is started, it will run until the stream
 // is closed or the schedule completes. For Poll queries the Run will block
-// internally after sync until a Poll request is made to the server.
+// internally after sync until a Poll request is made to the server. For Once
+// queries on a native origin the stream is closed after the sync_response has
+// been sent; other targets keep their existing behaviour.
 func (c *Client) Run(stream gnmipb.GNMI_SubscribeServer, config *Config) (err error) {
 	defer log.V(1).Infof("Client %s shutdown", c)
 	ctx := stream.Context()
@@ -165,6 +175,7 @@ func (c *Client) Run(stream gnmipb.GNMI_SubscribeServer, config *Config) (err er
 		var targetDbName string
 		dc, err = sdc.NewMixedDbClient(paths, prefix, origin, gnmipb.Encoding_JSON_IETF, "", "", &targetDbName)
 		authTarget = "gnmi_" + targetDbName
+		c.closeOnSync = mode == gnmipb.SubscriptionList_ONCE
 	} else if len(origin) != 0 {
 		return grpc.Errorf(codes.Unimplemented, "Unsupported origin: %s", origin)
 	} else if target == "" {
@@ -228,6 +239,9 @@ func (c *Client) Run(stream gnmipb.GNMI_SubscribeServer, config *Config) (err er
 	c.Close()
 	// Wait until all child go routines exited
 	c.w.Wait()
+	if err == errOnceComplete {
+		return nil
+	}
 	return grpc.Errorf(codes.InvalidArgument, "%s", err)
 }
 
@@ -335,5 +349,10 @@ func (c *Client) send(stream gnmipb.GNMI_SubscribeServer, dc sdc.Client) error {
 
 		dc.SentOne(val)
 		log.V(5).Infof("Client %s done sending, msg count %d, msg %v", c, c.sendMsg, resp)
+
+		if c.closeOnSync && resp.GetSyncResponse() {
+			log.V(1).Infof("Client %s once subscription complete", c)
+			return errOnceComplete
+		}
 	}
 }
@@ -0,0 +1,151 @@
+package gnmi
+
+import (
+	"sync"
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/agiledragon/gomonkey/v2"
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+	spb "github.com/sonic-net/sonic-gnmi/proto"
+	sdc "github.com/sonic-net/sonic-gnmi/sonic_data_client"
+)
+
+type MockSubscribeServer struct {
+	MockServerStream
+	sent []*gnmipb.SubscribeResponse
+}
+
+func (x *MockSubscribeServer) Send(m *gnmipb.SubscribeResponse) error {
+	x.sent = append(x.sent, m)
+	return nil
+}
+
+func (x *MockSubscribeServer) Recv() (*gnmipb.SubscribeRequest, error) {
+	return nil, nil
+}
+
+// MockSubscribeDataClient only implements the callbacks used by send.
+type MockSubscribeDataClient struct {
+	sdc.Client
+}
+
+func (x *MockSubscribeDataClient) SentOne(*sdc.Value) {}
+
+func (x *MockSubscribeDataClient) FailedSend() {}
+
+func TestSendCloseOnSync(t *testing.T) {
+	tests := []struct {
+		desc        string
+		closeOnSync bool
+		wantErr     error
+		wantSent    int
+	}{
+		{
+			// sonic-db ONCE subscriptions end with OK after the sync_response
+			desc:        "native origin once",
+			closeOnSync: true,
+			wantErr:     errOnceComplete,
+			wantSent:    1,
+		},
+		{
+			// Other targets keep sending until their data client stops
+			desc:        "other targets",
+			closeOnSync: false,
+			wantSent:    2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			c := NewClient(nil)
+			c.subscribe = &gnmipb.SubscriptionList{Mode: gnmipb.SubscriptionList_ONCE}
+			c.closeOnSync = tt.closeOnSync
+
+			c.q.Put(sdc.Value{Value: &spb.Value{Timestamp: 1, SyncResponse: true}})
+			c.q.Put(sdc.Value{Value: &spb.Value{Timestamp: 2, Notification: &gnmipb.Notification{Timestamp: 2}}})
+			c.q.Put(sdc.Value{Value: &spb.Value{Timestamp: 3, Fatal: "done"}})
+
+			stream := &MockSubscribeServer{}
+			err := c.send(stream, &MockSubscribeDataClient{})
+			if tt.wantErr != nil && err != tt.wantErr {
+				t.Errorf("send() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && (err == nil || err == errOnceComplete) {
+				t.Errorf("send() error = %v, want the fatal error", err)
+			}
+			if len(stream.sent) != tt.wantSent {
+				t.Errorf("send() sent %d responses, want %d", len(stream.sent), tt.wantSent)
+			}
+		})
+	}
+}
+
+// TestCloseEventsAggregation stops an EVENTS subscription with pending aggregated events
+// through Client.Close, which disposes the queue before the aggregator notices the stop.
+func TestCloseEventsAggregation(t *testing.T) {
+	var mu sync.Mutex
+	received := 0
+	mock1 := gomonkey.ApplyFunc(sdc.C_init_subs, func(use_cache bool) unsafe.Pointer {
+		return nil
+	})
+	defer mock1.Reset()
+	mock2 := gomonkey.ApplyFunc(sdc.C_recv_evt, func(h unsafe.Pointer) (int, sdc.Evt_rcvd) {
+		mu.Lock()
+		defer mu.Unlock()
+		if received < 2 {
+			received++
+			return 0, sdc.Evt_rcvd{Event_str: `{"sonic-events-bgp:bgp-state": {"ip": "10.0.0.1", "status": "down"}}`, Publish_epoch_ms: int64(received)}
+		}
+		time.Sleep(10 * time.Millisecond)
+		return -1, sdc.Evt_rcvd{}
+	})
+	defer mock2.Reset()
+	mock3 := gomonkey.ApplyFunc(sdc.C_deinit_subs, func(h unsafe.Pointer) {})
+	defer mock3.Reset()
+
+	path := &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "all", Key: map[string]string{"aggregate": "600"}}}}
+	dc, err := sdc.NewEventClient([]*gnmipb.Path{path}, &gnmipb.Path{Target: "EVENTS"}, logLevelError)
+	if err != nil {
+		t.Fatalf("NewEventClient failed: %v", err)
+	}
+	c := NewClient(nil)
+	c.subscribe = &gnmipb.SubscriptionList{Mode: gnmipb.SubscriptionList_STREAM}
+	c.stop = make(chan struct{}, 1)
+	c.w.Add(1)
+	go dc.StreamRun(c.q, c.stop, &c.w, c.subscribe)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mu.Lock()
+		done := received == 2
+		mu.Unlock()
+		if done {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Events were not received")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if c.q.Len() != 0 {
+		t.Fatalf("Expected the events to be pending aggregation, got %d queued", c.q.Len())
+	}
+
+	// The routines of the subscription exit, discarding the pending aggregate
+	c.Close()
+	exited := make(chan struct{})
+	go func() {
+		c.w.Wait()
+		close(exited)
+	}()
+	select {
+	case <-exited:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("EVENTS routines did not exit after Close")
+	}
+	if !c.q.Disposed() || c.q.Len() != 0 {
+		t.Errorf("Expected the queue to be disposed and empty, got disposed %v, queued %d", c.q.Disposed(), c.q.Len())
+	}
+}
@@ -22,9 +22,18 @@ type ConnectionManager struct {
 }
 
 func (cm *ConnectionManager) GetThreshold() int {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
 	return cm.threshold
 }
 
+// SetThreshold changes the max number of connections, keeping the existing ones.
+func (cm *ConnectionManager) SetThreshold(threshold int) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	cm.threshold = threshold
+}
+
 func (cm *ConnectionManager) PrepareRedis() {
 	ns, _ := sdcfg.GetDbDefaultNamespace()
 	addr, err := sdcfg.GetDbTcpAddr("STATE_DB", ns)
@@ -3,8 +3,8 @@ package gnmi
 import (
 	"context"
 	"encoding/json"
-	jwt "github.com/dgrijalva/jwt-go"
 	log "github.com/golang/glog"
+	"github.com/sonic-net/sonic-gnmi/common_utils"
 	spb "github.com/sonic-net/sonic-gnmi/proto/gnoi"
 	spb_jwt "github.com/sonic-net/sonic-gnmi/proto/gnoi/jwt"
 	transutil "github.com/sonic-net/sonic-gnmi/transl_utils"
@@ -26,7 +26,7 @@ func (srv *Server) Authenticate(ctx context.Context, req *spb_jwt.AuthenticateRe
 	// }
 	log.V(1).Info("gNOI: Sonic Authenticate")
 
-	if !srv.config.UserAuth.Enabled("jwt") {
+	if !srv.config.userAuth().Enabled("jwt") {
 		return nil, status.Errorf(codes.Unimplemented, "")
 	}
 	auth_success, _ := UserPwAuth(req.Username, req.Password)
@@ -50,7 +50,7 @@ func (srv *Server) Refresh(ctx context.Context, req *spb_jwt.RefreshRequest) (*s
 	}
 	log.V(1).Info("gNOI: Sonic Refresh")
 
-	if !srv.config.UserAuth.Enabled("jwt") {
+	if !srv.config.userAuth().Enabled("jwt") {
 		return nil, status.Errorf(codes.Unimplemented, "")
 	}
 
@@ -59,11 +59,11 @@ func (srv *Server) Refresh(ctx context.Context, req *spb_jwt.RefreshRequest) (*s
 		return nil, err
 	}
 
-	claims := &Claims{}
-	jwt.ParseWithClaims(token.AccessToken, claims, func(token *jwt.Token) (interface{}, error) {
-		return hmacSampleSecret, nil
-	})
-	if time.Unix(claims.ExpiresAt, 0).Sub(time.Now()) > JwtRefreshInt {
+	claims, err := parseJwtClaims(token.AccessToken)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Invalid JWT Token")
+	}
+	if time.Unix(claims.ExpiresAt, 0).Sub(time.Now()) > GetJwtRefreshInt() {
 		return nil, status.Errorf(codes.InvalidArgument, "Invalid JWT Token")
 	}
 
@@ -71,6 +71,62 @@ func (srv *Server) Refresh(ctx context.Context, req *spb_jwt.RefreshRequest) (*s
 
 }
 
+func (srv *Server) Revoke(ctx context.Context, req *spb_jwt.RevokeRequest) (*spb_jwt.RevokeResponse, error) {
+	// Users may revoke their own tokens with read access, the tokens of others need write access
+	ctx, err := authenticate(srv.config, ctx, "gnoi", false)
+	if err != nil {
+		return nil, err
+	}
+	log.V(1).Info("gNOI: Sonic Revoke")
+
+	if !srv.config.userAuth().Enabled("jwt") {
+		return nil, status.Errorf(codes.Unimplemented, "")
+	}
+
+	rc, _ := common_utils.GetContext(ctx)
+	if req.GetUsername() != "" && req.GetAccessToken() == "" {
+		if req.GetUsername() != rc.Auth.User {
+			// Only admins may revoke the tokens of other users
+			if !hasRole(&rc.Auth, jwtAdminRole) {
+				return nil, status.Errorf(codes.PermissionDenied, "%v may not revoke the tokens of %v", rc.Auth.User, req.GetUsername())
+			}
+			if _, err = authenticate(srv.config, ctx, "gnoi", true); err != nil {
+				return nil, err
+			}
+		}
+		err = jwtRevoked.revokeUser(req.GetUsername())
+	} else {
+		tokenString := req.GetAccessToken()
+		if tokenString == "" {
+			// Revoke the caller's own token
+			var token *spb_jwt.JwtToken
+			token, _, err = JwtAuthenAndAuthor(ctx)
+			if err != nil {
+				return nil, err
+			}
+			tokenString = token.AccessToken
+		}
+		var claims *Claims
+		claims, err = parseJwtClaims(tokenString)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "Invalid JWT Token: %v", err)
+		}
+		if req.GetUsername() != "" && req.GetUsername() != claims.Username {
+			return nil, status.Errorf(codes.InvalidArgument, "JWT Token was not issued to %v", req.GetUsername())
+		}
+		if claims.Username != rc.Auth.User {
+			if _, err = authenticate(srv.config, ctx, "gnoi", true); err != nil {
+				return nil, err
+			}
+		}
+		err = jwtRevoked.revokeToken(claims)
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &spb_jwt.RevokeResponse{}, nil
+}
+
 func (srv *Server) ClearNeighbors(ctx context.Context, req *spb.ClearNeighborsRequest) (*spb.ClearNeighborsResponse, error) {
 	ctx, err := authenticate(srv.config, ctx, "gnoi", true)
 	if err != nil {
@@ -0,0 +1,63 @@
+package gnmi
+
+import (
+	"context"
+
+	log "github.com/golang/glog"
+	gnoi_checkpoint "github.com/sonic-net/sonic-gnmi/pkg/gnoi/checkpoint"
+	spb_checkpoint_gnoi "github.com/sonic-net/sonic-gnmi/proto/gnoi/checkpoint"
+	ssc "github.com/sonic-net/sonic-gnmi/sonic_service_client"
+)
+
+// connectCheckpointService opens a connection to the host service for the checkpoint manager.
+func connectCheckpointService() (gnoi_checkpoint.Service, error) {
+	return ssc.NewDbusClient()
+}
+
+func (srv *CheckpointServer) Create(ctx context.Context, req *spb_checkpoint_gnoi.CreateCheckpointRequest) (*spb_checkpoint_gnoi.CreateCheckpointResponse, error) {
+	if _, err := authenticate(srv.config, ctx, "gnoi", true); err != nil {
+		return nil, err
+	}
+	log.V(1).Infof("gNOI: Sonic Checkpoint Create %q, confirm timeout %ds", req.GetName(), req.GetConfirmTimeout())
+	return srv.manager.Create(ctx, req)
+}
+
+func (srv *CheckpointServer) List(ctx context.Context, req *spb_checkpoint_gnoi.ListCheckpointsRequest) (*spb_checkpoint_gnoi.ListCheckpointsResponse, error) {
+	if _, err := authenticate(srv.config, ctx, "gnoi", false); err != nil {
+		return nil, err
+	}
+	log.V(1).Info("gNOI: Sonic Checkpoint List")
+	return srv.manager.List(ctx, req)
+}
+
+func (srv *CheckpointServer) Diff(ctx context.Context, req *spb_checkpoint_gnoi.DiffCheckpointRequest) (*spb_checkpoint_gnoi.DiffCheckpointResponse, error) {
+	if _, err := authenticate(srv.config, ctx, "gnoi", false); err != nil {
+		return nil, err
+	}
+	log.V(1).Infof("gNOI: Sonic Checkpoint Diff %q", req.GetName())
+	return srv.manager.Diff(ctx, req)
+}
+
+func (srv *CheckpointServer) Rollback(ctx context.Context, req *spb_checkpoint_gnoi.RollbackCheckpointRequest) (*spb_checkpoint_gnoi.RollbackCheckpointResponse, error) {
+	if _, err := authenticate(srv.config, ctx, "gnoi", true); err != nil {
+		return nil, err
+	}
+	log.V(1).Infof("gNOI: Sonic Checkpoint Rollback %q", req.GetName())
+	return srv.manager.Rollback(ctx, req)
+}
+
+func (srv *CheckpointServer) Delete(ctx context.Context, req *spb_checkpoint_gnoi.DeleteCheckpointRequest) (*spb_checkpoint_gnoi.DeleteCheckpointResponse, error) {
+	if _, err := authenticate(srv.config, ctx, "gnoi", true); err != nil {
+		return nil, err
+	}
+	log.V(1).Infof("gNOI: Sonic Checkpoint Delete %q", req.GetName())
+	return srv.manager.Delete(ctx, req)
+}
+
+func (srv *CheckpointServer) Confirm(ctx context.Context, req *spb_checkpoint_gnoi.ConfirmCheckpointRequest) (*spb_checkpoint_gnoi.ConfirmCheckpointResponse, error) {
+	if _, err := authenticate(srv.config, ctx, "gnoi", true); err != nil {
+		return nil, err
+	}
+	log.V(1).Infof("gNOI: Sonic Checkpoint Confirm %q", req.GetName())
+	return srv.manager.Confirm(ctx, req)
+}
@@ -0,0 +1,90 @@
+package gnmi
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/agiledragon/gomonkey/v2"
+	gnoi_checkpoint "github.com/sonic-net/sonic-gnmi/pkg/gnoi/checkpoint"
+	spb_checkpoint_gnoi "github.com/sonic-net/sonic-gnmi/proto/gnoi/checkpoint"
+	ssc "github.com/sonic-net/sonic-gnmi/sonic_service_client"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newCheckpointServer(t *testing.T) *CheckpointServer {
+	originalDir := gnoi_checkpoint.CHECKPOINT_DIR
+	t.Cleanup(func() { gnoi_checkpoint.CHECKPOINT_DIR = originalDir })
+	gnoi_checkpoint.CHECKPOINT_DIR = filepath.Join(t.TempDir(), "checkpoints")
+
+	manager := gnoi_checkpoint.NewManager(connectCheckpointService)
+	t.Cleanup(manager.Close)
+	return &CheckpointServer{
+		Server:  &Server{config: &Config{}},
+		manager: manager,
+	}
+}
+
+func TestCheckpointServer_AuthFailure(t *testing.T) {
+	patches := gomonkey.NewPatches()
+	defer patches.Reset()
+
+	var writeAccess []bool
+	patches.ApplyFunc(authenticate, func(_ *Config, ctx context.Context, _ string, write bool) (context.Context, error) {
+		writeAccess = append(writeAccess, write)
+		return nil, status.Error(codes.PermissionDenied, "denied")
+	})
+
+	server := newCheckpointServer(t)
+	ctx := context.Background()
+	errs := []error{}
+	_, err := server.Create(ctx, &spb_checkpoint_gnoi.CreateCheckpointRequest{Name: "cp"})
+	errs = append(errs, err)
+	_, err = server.List(ctx, &spb_checkpoint_gnoi.ListCheckpointsRequest{})
+	errs = append(errs, err)
+	_, err = server.Diff(ctx, &spb_checkpoint_gnoi.DiffCheckpointRequest{Name: "cp"})
+	errs = append(errs, err)
+	_, err = server.Rollback(ctx, &spb_checkpoint_gnoi.RollbackCheckpointRequest{Name: "cp"})
+	errs = append(errs, err)
+	_, err = server.Delete(ctx, &spb_checkpoint_gnoi.DeleteCheckpointRequest{Name: "cp"})
+	errs = append(errs, err)
+	_, err = server.Confirm(ctx, &spb_checkpoint_gnoi.ConfirmCheckpointRequest{Name: "cp"})
+	errs = append(errs, err)
+
+	for i, err := range errs {
+		if status.Code(err) != codes.PermissionDenied {
+			t.Errorf("RPC %d: expected PermissionDenied, got %v", i, err)
+		}
+	}
+
+	// Only List and Diff are permitted to read-only users
+	expected := []bool{true, false, false, true, true, true}
+	for i := range expected {
+		if i >= len(writeAccess) || writeAccess[i] != expected[i] {
+			t.Fatalf("expected write access checks %v, got %v", expected, writeAccess)
+		}
+	}
+}
+
+func TestCheckpointServer_Create(t *testing.T) {
+	patches := gomonkey.NewPatches()
+	defer patches.Reset()
+
+	patches.ApplyFunc(authenticate, func(_ *Config, ctx context.Context, _ string, _ bool) (context.Context, error) {
+		return ctx, nil
+	})
+	// The fake host service does not write the checkpoint, so Create reports it missing
+	patches.ApplyFuncReturn(ssc.NewDbusClient, &ssc.FakeClient{}, nil)
+
+	server := newCheckpointServer(t)
+	_, err := server.Create(context.Background(), &spb_checkpoint_gnoi.CreateCheckpointRequest{Name: "cp"})
+	if status.Code(err) != codes.Internal {
+		t.Errorf("expected Internal, got %v", err)
+	}
+
+	resp, err := server.List(context.Background(), &spb_checkpoint_gnoi.ListCheckpointsRequest{})
+	if err != nil || len(resp.GetCheckpoints()) != 0 {
+		t.Errorf("expected no checkpoints, got %v, %v", resp, err)
+	}
+}
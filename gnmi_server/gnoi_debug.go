@@ -13,19 +13,18 @@ func (srv *DebugServer) Debug(req *gnoi_debug_pb.DebugRequest, stream gnoi_debug
 	common_utils.GetUsername(stream.Context(), &username)
 	log.Infof("gNOI Debug RPC called by '%s': %+v", username, req)
 
-	_, readAccessErr := authenticate(srv.config, stream.Context(), "gnoi", false)
+	ctx, readAccessErr := authenticate(srv.config, stream.Context(), "gnoi", false)
 	if readAccessErr != nil {
 		// User cannot do anything, abort
 		log.Errorf("authentication failed in Debug RPC: %v", readAccessErr)
 		return readAccessErr
 	}
 
+	// Users without write access are limited to the read-only policy,
+	// unless one of their roles has a dedicated policy
 	_, writeAccessErr := authenticate(srv.config, stream.Context(), "gnoi", true)
-	if writeAccessErr != nil {
-		// User has read-only access
-		return gnoi_debug.HandleCommandRequest(req, stream, srv.readWhitelist)
-	}
+	rc, _ := common_utils.GetContext(ctx)
+	rules := srv.policy.Policy().RulesFor(rc.Auth.Roles, writeAccessErr == nil)
 
-	// Otherwise, this user has write access
-	return gnoi_debug.HandleCommandRequest(req, stream, srv.writeWhitelist)
+	return gnoi_debug.HandleCommandRequest(req, stream, rules)
 }
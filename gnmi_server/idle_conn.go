@@ -0,0 +1,159 @@
+package gnmi
+
+import (
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/golang/glog"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/stats"
+)
+
+// Interval between checks for idle connections
+var idleConnCheckInterval = time.Second
+
+// idleConnTracker closes the connections without active RPC for longer than the
+// IdleConnDuration of config. Unlike the keepalive options of gRPC, the duration can
+// be changed while the server runs.
+type idleConnTracker struct {
+	config *Config
+	mu     sync.Mutex
+	conns  map[*idleConn]bool
+	stop   chan struct{}
+	once   sync.Once
+}
+
+// idleConn is a connection tracked by an idleConnTracker
+type idleConn struct {
+	net.Conn
+	tracker *idleConnTracker
+	// number of active RPCs, and time the last one ended in unix nanoseconds
+	active    int32
+	idleSince int64
+}
+
+// idleListener tracks the connections it accepts
+type idleListener struct {
+	net.Listener
+	tracker *idleConnTracker
+}
+
+// idleConnAddr is the local address of a tracked connection. gRPC passes it to TagConn, telling
+// apart the connections of a unix socket, which all have the same addresses.
+type idleConnAddr struct {
+	net.Addr
+	conn *idleConn
+}
+
+type idleConnKey struct{}
+
+func newIdleConnTracker(config *Config) *idleConnTracker {
+	t := &idleConnTracker{
+		config: config,
+		conns:  map[*idleConn]bool{},
+		stop:   make(chan struct{}),
+	}
+	go t.run()
+	return t
+}
+
+// listener returns lis tracking its connections.
+func (t *idleConnTracker) listener(lis net.Listener) net.Listener {
+	return &idleListener{Listener: lis, tracker: t}
+}
+
+func (l *idleListener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	conn := &idleConn{
+		Conn:      c,
+		tracker:   l.tracker,
+		idleSince: time.Now().UnixNano(),
+	}
+	l.tracker.mu.Lock()
+	l.tracker.conns[conn] = true
+	l.tracker.mu.Unlock()
+	return conn, nil
+}
+
+func (c *idleConn) LocalAddr() net.Addr {
+	return idleConnAddr{Addr: c.Conn.LocalAddr(), conn: c}
+}
+
+func (c *idleConn) Close() error {
+	c.tracker.mu.Lock()
+	delete(c.tracker.conns, c)
+	c.tracker.mu.Unlock()
+	return c.Conn.Close()
+}
+
+func (t *idleConnTracker) run() {
+	ticker := time.NewTicker(idleConnCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			t.closeIdle(now)
+		case <-t.stop:
+			return
+		}
+	}
+}
+
+// closeIdle closes the connections idle at now for longer than IdleConnDuration.
+func (t *idleConnTracker) closeIdle(now time.Time) {
+	idle := time.Duration(t.config.Live().IdleConnDuration) * time.Second
+	if idle <= 0 { // inf
+		return
+	}
+	var conns []*idleConn
+	t.mu.Lock()
+	for c := range t.conns {
+		if atomic.LoadInt32(&c.active) == 0 && now.Sub(time.Unix(0, atomic.LoadInt64(&c.idleSince))) > idle {
+			conns = append(conns, c)
+		}
+	}
+	t.mu.Unlock()
+	for _, c := range conns {
+		log.V(2).Infof("Closing connection from %v idle for more than %v", c.RemoteAddr(), idle)
+		c.Close()
+	}
+}
+
+func (t *idleConnTracker) Stop() {
+	t.once.Do(func() { close(t.stop) })
+}
+
+// TagConn adds the tracked connection to the context of the connection, and so of its RPCs.
+func (t *idleConnTracker) TagConn(ctx context.Context, info *stats.ConnTagInfo) context.Context {
+	addr, ok := info.LocalAddr.(idleConnAddr)
+	if !ok || addr.conn.tracker != t {
+		return ctx
+	}
+	return context.WithValue(ctx, idleConnKey{}, addr.conn)
+}
+
+func (t *idleConnTracker) HandleConn(ctx context.Context, s stats.ConnStats) {}
+
+func (t *idleConnTracker) TagRPC(ctx context.Context, info *stats.RPCTagInfo) context.Context {
+	return ctx
+}
+
+// HandleRPC counts the active RPCs of the connection.
+func (t *idleConnTracker) HandleRPC(ctx context.Context, s stats.RPCStats) {
+	c, ok := ctx.Value(idleConnKey{}).(*idleConn)
+	if !ok {
+		return
+	}
+	switch s.(type) {
+	case *stats.Begin:
+		atomic.AddInt32(&c.active, 1)
+	case *stats.End:
+		atomic.StoreInt64(&c.idleSince, time.Now().UnixNano())
+		atomic.AddInt32(&c.active, -1)
+	}
+}
@@ -0,0 +1,112 @@
+package gnmi
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/stats"
+)
+
+func TestIdleConnTracker(t *testing.T) {
+	config := &Config{IdleConnDuration: 0}
+	tracker := newIdleConnTracker(config)
+	defer tracker.Stop()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	lis = tracker.listener(lis)
+	defer lis.Close()
+
+	client, err := net.Dial("tcp", lis.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer client.Close()
+	conn, err := lis.Accept()
+	if err != nil {
+		t.Fatalf("Failed to accept: %v", err)
+	}
+	closed := func() bool {
+		tracker.mu.Lock()
+		defer tracker.mu.Unlock()
+		return !tracker.conns[conn.(*idleConn)]
+	}
+
+	ctx := tracker.TagConn(context.Background(), &stats.ConnTagInfo{LocalAddr: conn.LocalAddr(), RemoteAddr: conn.RemoteAddr()})
+	ctx = tracker.TagRPC(ctx, &stats.RPCTagInfo{FullMethodName: "/gnmi.gNMI/Subscribe"})
+
+	// No idle timeout
+	tracker.closeIdle(time.Now().Add(time.Hour))
+	if closed() {
+		t.Fatalf("Expected connection to be kept without idle timeout")
+	}
+
+	// A connection with an active RPC is not idle
+	config.ApplyLive(LiveConfig{IdleConnDuration: 5})
+	tracker.HandleRPC(ctx, &stats.Begin{})
+	tracker.closeIdle(time.Now().Add(time.Hour))
+	if closed() {
+		t.Fatalf("Expected connection with active RPC to be kept")
+	}
+
+	tracker.HandleRPC(ctx, &stats.End{})
+	tracker.closeIdle(time.Now().Add(2 * time.Second))
+	if closed() {
+		t.Fatalf("Expected connection idle for less than IdleConnDuration to be kept")
+	}
+
+	// The idle timeout applies as soon as it changes
+	config.ApplyLive(LiveConfig{IdleConnDuration: 1})
+	tracker.closeIdle(time.Now().Add(2 * time.Second))
+	if !closed() {
+		t.Fatalf("Expected idle connection to be closed")
+	}
+	client.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := client.Read(make([]byte, 1)); err == nil {
+		t.Errorf("Expected connection to be closed")
+	}
+}
+
+func TestIdleConnTrackerUnix(t *testing.T) {
+	config := &Config{IdleConnDuration: 1}
+	tracker := newIdleConnTracker(config)
+	defer tracker.Stop()
+	lis, err := net.Listen("unix", t.TempDir()+"/gnmi.sock")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	lis = tracker.listener(lis)
+	defer lis.Close()
+
+	// Connections of a unix socket have the same addresses, each RPC is counted on its own connection
+	var conns []net.Conn
+	var ctxs []context.Context
+	for i := 0; i < 2; i++ {
+		client, err := net.Dial("unix", lis.Addr().String())
+		if err != nil {
+			t.Fatalf("Failed to dial: %v", err)
+		}
+		defer client.Close()
+		conn, err := lis.Accept()
+		if err != nil {
+			t.Fatalf("Failed to accept: %v", err)
+		}
+		conns = append(conns, conn)
+		ctxs = append(ctxs, tracker.TagConn(context.Background(), &stats.ConnTagInfo{LocalAddr: conn.LocalAddr(), RemoteAddr: conn.RemoteAddr()}))
+	}
+	if conns[0].LocalAddr().String() != conns[1].LocalAddr().String() || conns[0].LocalAddr().Network() != "unix" {
+		t.Errorf("Expected unix socket connections to keep their address, got %v and %v", conns[0].LocalAddr(), conns[1].LocalAddr())
+	}
+
+	tracker.HandleRPC(ctxs[0], &stats.Begin{})
+	tracker.closeIdle(time.Now().Add(2 * time.Second))
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	if !tracker.conns[conns[0].(*idleConn)] || tracker.conns[conns[1].(*idleConn)] {
+		t.Errorf("Expected only the idle unix socket connection to be closed")
+	}
+}
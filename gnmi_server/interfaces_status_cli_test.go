@@ -0,0 +1,124 @@
+package gnmi
+
+// interfaces_status_cli_test.go
+
+// Tests SHOW interfaces status
+
+import (
+	"crypto/tls"
+	"testing"
+	"time"
+
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+)
+
+func TestGetShowInterfacesStatus(t *testing.T) {
+	s := createServer(t, ServerPort)
+	go runServer(t, s)
+	defer s.ForceStop()
+	defer ResetDataSetsAndMappings(t)
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: true}
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig))}
+
+	conn, err := grpc.Dial(TargetAddr, opts...)
+	if err != nil {
+		t.Fatalf("Dialing to %q failed: %v", TargetAddr, err)
+	}
+	defer conn.Close()
+
+	gClient := pb.NewGNMIClient(conn)
+	ctx, cancel := context.WithTimeout(context.Background(), QueryTimeout*time.Second)
+	defer cancel()
+
+	emptyResp := `[]`
+
+	ethernet0 := `{"Interface": "Ethernet0", "Lanes": "2304,2305,2306,2307", "Speed": "100G", "MTU": "9100", "FEC": "rs", "Alias": "etp0", "Vlan": "PortChannel101", "Oper": "up", "Admin": "up", "Type": "QSFP28 or later", "Asym PFC": "off"}`
+	ethernet40 := `{"Interface": "Ethernet40", "Lanes": "2048,2049,2050,2051", "Speed": "100G", "MTU": "9100", "FEC": "rs", "Alias": "etp10", "Vlan": "trunk", "Oper": "up", "Admin": "up", "Type": "N/A", "Asym PFC": "off"}`
+	ethernet80 := `{"Interface": "Ethernet80", "Lanes": "2568,2569,2570,2571", "Speed": "100G", "MTU": "9100", "FEC": "rs", "Alias": "etp20", "Vlan": "untagged", "Oper": "up", "Admin": "up", "Type": "N/A", "Asym PFC": "off"}`
+	allPorts := `[` + ethernet0 + `,` + ethernet40 + `,` + ethernet80 + `]`
+	onePort := `[` + ethernet0 + `]`
+
+	portTableFileName := "../testdata/PORT_TABLE.txt"
+	vlanConfigFileName := "../testdata/VLAN_CONFIG.txt"
+	transceiverInfoFileName := "../testdata/TRANSCEIVER_INFO.txt"
+
+	initData := func() {
+		FlushDataSet(t, ApplDbNum)
+		FlushDataSet(t, ConfigDbNum)
+		FlushDataSet(t, StateDbNum)
+		AddDataSet(t, ApplDbNum, portTableFileName)
+		AddDataSet(t, ConfigDbNum, vlanConfigFileName)
+		AddDataSet(t, StateDbNum, transceiverInfoFileName)
+	}
+
+	tests := []struct {
+		desc        string
+		pathTarget  string
+		textPbPath  string
+		wantRetCode codes.Code
+		wantRespVal interface{}
+		valTest     bool
+		testInit    func()
+	}{
+		{
+			desc:       "query SHOW interfaces status - no data",
+			pathTarget: "SHOW",
+			textPbPath: `
+				elem: <name: "interfaces" >
+				elem: <name: "status" >
+			`,
+			wantRetCode: codes.OK,
+			wantRespVal: []byte(emptyResp),
+			valTest:     true,
+		},
+		{
+			desc:       "query SHOW interfaces status - all ports",
+			pathTarget: "SHOW",
+			textPbPath: `
+				elem: <name: "interfaces" >
+				elem: <name: "status" >
+			`,
+			wantRetCode: codes.OK,
+			wantRespVal: []byte(allPorts),
+			valTest:     true,
+			testInit:    initData,
+		},
+		{
+			desc:       "query SHOW interfaces status - single interface",
+			pathTarget: "SHOW",
+			textPbPath: `
+				elem: <name: "interfaces" >
+				elem: <name: "status" key: { key: "interface" value: "Ethernet0" } >
+			`,
+			wantRetCode: codes.OK,
+			wantRespVal: []byte(onePort),
+			valTest:     true,
+			testInit:    initData,
+		},
+		{
+			desc:       "query SHOW interfaces status - single non-existent interface",
+			pathTarget: "SHOW",
+			textPbPath: `
+				elem: <name: "interfaces" >
+				elem: <name: "status" key: { key: "interface" value: "Ethernet10" } >
+			`,
+			wantRetCode: codes.NotFound,
+			testInit:    initData,
+		},
+	}
+
+	for _, test := range tests {
+		if test.testInit != nil {
+			test.testInit()
+		}
+		t.Run(test.desc, func(t *testing.T) {
+			runTestGet(t, ctx, gClient, test.pathTarget, test.textPbPath, test.wantRetCode, test.wantRespVal, test.valTest)
+		})
+	}
+}
@@ -1,7 +1,21 @@
 package gnmi
 
 import (
+	"bytes"
+	"crypto/elliptic"
 	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
 	jwt "github.com/dgrijalva/jwt-go"
 	"github.com/golang/glog"
 	"github.com/sonic-net/sonic-gnmi/common_utils"
@@ -10,15 +24,51 @@ import (
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
-	"time"
 )
 
 var (
-	JwtRefreshInt    time.Duration
-	JwtValidInt      time.Duration
-	hmacSampleSecret = make([]byte, 16)
+	// Token refresh and validity intervals, protected by configLock as they can change while the server runs
+	jwtRefreshInt time.Duration
+	jwtValidInt   time.Duration
+	// JwtKeyGracePeriod is how long tokens signed with a replaced signing key are still accepted.
+	JwtKeyGracePeriod time.Duration
+
+	jwtKeys    = &jwtKeyRing{}
+	jwtRevoked = &jwtRevocationList{Tokens: map[string]int64{}, Users: map[string]int64{}}
+)
+
+const (
+	jwtMinSecretLen = 16
+	// jwtAdminRole is the role allowed to revoke the tokens of other users.
+	jwtAdminRole = "admin"
 )
 
+// GetJwtRefreshInt returns how long before its expiry a token can be refreshed.
+func GetJwtRefreshInt() time.Duration {
+	configLock.RLock()
+	defer configLock.RUnlock()
+	return jwtRefreshInt
+}
+
+func SetJwtRefreshInt(interval time.Duration) {
+	configLock.Lock()
+	defer configLock.Unlock()
+	jwtRefreshInt = interval
+}
+
+// GetJwtValidInt returns how long a token is valid.
+func GetJwtValidInt() time.Duration {
+	configLock.RLock()
+	defer configLock.RUnlock()
+	return jwtValidInt
+}
+
+func SetJwtValidInt(interval time.Duration) {
+	configLock.Lock()
+	defer configLock.Unlock()
+	jwtValidInt = interval
+}
+
 type Credentials struct {
 	Password string `json:"password"`
 	Username string `json:"username"`
@@ -30,7 +80,242 @@ type Claims struct {
 	jwt.StandardClaims
 }
 
+// jwtKey is a key tokens are signed with, or were signed with until it was replaced.
+// Tokens carry the key id in their "kid" header.
+type jwtKey struct {
+	id        string
+	method    jwt.SigningMethod
+	signKey   interface{}
+	verifyKey interface{}
+	retireAt  time.Time
+}
+
+// jwtKeyRing holds the current signing key, and the replaced keys until their grace period ends.
+type jwtKeyRing struct {
+	mu      sync.Mutex
+	current *jwtKey
+	retired []*jwtKey
+}
+
+func newHmacJwtKey(secret []byte) *jwtKey {
+	sum := sha256.Sum256(secret)
+	return &jwtKey{
+		id:        hex.EncodeToString(sum[:8]),
+		method:    jwt.SigningMethodHS256,
+		signKey:   secret,
+		verifyKey: secret,
+	}
+}
+
+func newPublicJwtKey(method jwt.SigningMethod, signKey interface{}, verifyKey interface{}) (*jwtKey, error) {
+	der, err := x509.MarshalPKIXPublicKey(verifyKey)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(der)
+	return &jwtKey{
+		id:        hex.EncodeToString(sum[:8]),
+		method:    method,
+		signKey:   signKey,
+		verifyKey: verifyKey,
+	}, nil
+}
+
+// newJwtKey parses a signing key: the shared secret for HS256, or a PEM private key for RS256 and ES256.
+func newJwtKey(method string, data []byte) (*jwtKey, error) {
+	switch method {
+	case "HS256":
+		secret := bytes.TrimSpace(data)
+		if len(secret) < jwtMinSecretLen {
+			return nil, fmt.Errorf("HS256 secret must be at least %d bytes", jwtMinSecretLen)
+		}
+		return newHmacJwtKey(secret), nil
+	case "RS256":
+		key, err := jwt.ParseRSAPrivateKeyFromPEM(data)
+		if err != nil {
+			return nil, err
+		}
+		return newPublicJwtKey(jwt.SigningMethodRS256, key, &key.PublicKey)
+	case "ES256":
+		key, err := jwt.ParseECPrivateKeyFromPEM(data)
+		if err != nil {
+			return nil, err
+		}
+		if key.Curve != elliptic.P256() {
+			return nil, fmt.Errorf("ES256 requires a P-256 key")
+		}
+		return newPublicJwtKey(jwt.SigningMethodES256, key, &key.PublicKey)
+	}
+	return nil, fmt.Errorf("unsupported JWT signing method %v, expecting HS256, RS256 or ES256", method)
+}
+
+// rotate makes key the signing key. The previous signing key keeps validating
+// tokens for JwtKeyGracePeriod.
+func (r *jwtKeyRing) rotate(key *jwtKey) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	var retired []*jwtKey
+	for _, k := range r.retired {
+		if now.Before(k.retireAt) && k.id != key.id {
+			retired = append(retired, k)
+		}
+	}
+	if r.current != nil && r.current.id != key.id {
+		r.current.retireAt = now.Add(JwtKeyGracePeriod)
+		retired = append(retired, r.current)
+		glog.V(1).Infof("JWT signing key %v replaced by %v", r.current.id, key.id)
+	}
+	r.current = key
+	r.retired = retired
+}
+
+// signingKey returns the current signing key, generating a random one if none was set.
+func (r *jwtKeyRing) signingKey() *jwtKey {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.current == nil {
+		secret := make([]byte, jwtMinSecretLen)
+		rand.Read(secret)
+		r.current = newHmacJwtKey(secret)
+	}
+	return r.current
+}
+
+// verifyKey is the jwt.Keyfunc which returns the key a token was signed with.
+func (r *jwtKeyRing) verifyKey(token *jwt.Token) (interface{}, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := r.current
+	if kid, ok := token.Header["kid"].(string); ok && (key == nil || kid != key.id) {
+		key = nil
+		for _, k := range r.retired {
+			if k.id == kid && time.Now().Before(k.retireAt) {
+				key = k
+			}
+		}
+	}
+	if key == nil {
+		return nil, fmt.Errorf("JWT Token signed with unknown key")
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("unexpected JWT signing method %v", token.Method.Alg())
+	}
+	return key.verifyKey, nil
+}
+
+// jwtRevocationList holds revoked token ids until the tokens expire, and per user the time
+// up to which all their tokens are revoked. It is saved to fileName, when set, so that
+// revocations survive a restart.
+type jwtRevocationList struct {
+	mu       sync.Mutex
+	fileName string
+	Tokens   map[string]int64 `json:"tokens"`
+	Users    map[string]int64 `json:"users"`
+}
+
+func (l *jwtRevocationList) isRevoked(claims *Claims) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.Tokens[claims.Id]; ok && claims.Id != "" {
+		return true
+	}
+	if before, ok := l.Users[claims.Username]; ok && claims.IssuedAt <= before {
+		return true
+	}
+	return false
+}
+
+func (l *jwtRevocationList) revokeToken(claims *Claims) error {
+	if claims.Id == "" {
+		// Tokens issued before revocation was supported have no id
+		return fmt.Errorf("JWT Token has no id, revoke its user instead")
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.Tokens[claims.Id] = claims.ExpiresAt
+	glog.V(1).Infof("Revoked JWT Token %v of user %v", claims.Id, claims.Username)
+	return l.save()
+}
+
+func (l *jwtRevocationList) revokeUser(username string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.Users[username] = time.Now().Unix()
+	glog.V(1).Infof("Revoked all JWT Tokens of user %v", username)
+	return l.save()
+}
+
+// save drops entries for tokens which have expired anyway, then writes the list to fileName.
+func (l *jwtRevocationList) save() error {
+	now := time.Now()
+	for id, expiresAt := range l.Tokens {
+		if now.Unix() > expiresAt {
+			delete(l.Tokens, id)
+		}
+	}
+	for user, before := range l.Users {
+		if now.Add(-GetJwtValidInt()).Unix() > before {
+			delete(l.Users, user)
+		}
+	}
+
+	if l.fileName == "" {
+		return nil
+	}
+	data, err := json.Marshal(l)
+	if err != nil {
+		return err
+	}
+	tmpFile, err := ioutil.TempFile(filepath.Dir(l.fileName), filepath.Base(l.fileName))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile.Name(), l.fileName)
+}
+
+// LoadJwtRevocationList loads the token revocation list from fileName, and saves revocations to it.
+// A missing file is an empty list.
+func LoadJwtRevocationList(fileName string) error {
+	list := &jwtRevocationList{fileName: fileName}
+	data, err := ioutil.ReadFile(fileName)
+	if err == nil {
+		if err := json.Unmarshal(data, list); err != nil {
+			return fmt.Errorf("invalid JWT revocation list %v: %v", fileName, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	if list.Tokens == nil {
+		list.Tokens = map[string]int64{}
+	}
+	if list.Users == nil {
+		list.Users = map[string]int64{}
+	}
+
+	jwtRevoked.mu.Lock()
+	defer jwtRevoked.mu.Unlock()
+	jwtRevoked.fileName = list.fileName
+	jwtRevoked.Tokens = list.Tokens
+	jwtRevoked.Users = list.Users
+	return nil
+}
+
 func generateJWT(username string, roles []string, expire_dt time.Time) string {
+	key := jwtKeys.signingKey()
+	id := make([]byte, 16)
+	rand.Read(id)
+
 	// Create a new token object, specifying signing method and the claims
 	// you would like it to contain.
 	claims := &Claims{
@@ -39,25 +324,71 @@ func generateJWT(username string, roles []string, expire_dt time.Time) string {
 		StandardClaims: jwt.StandardClaims{
 			// In JWT, the expiry time is expressed as unix milliseconds
 			ExpiresAt: expire_dt.Unix(),
+			IssuedAt:  time.Now().Unix(),
+			Id:        hex.EncodeToString(id),
 		},
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.id
 
-	// Sign and get the complete encoded token as a string using the secret
-	tokenString, _ := token.SignedString(hmacSampleSecret)
+	// Sign and get the complete encoded token as a string using the key
+	tokenString, _ := token.SignedString(key.signKey)
 
 	return tokenString
 }
+
+// GenerateJwtSecretKey signs tokens with a random HS256 secret, which is lost on restart.
 func GenerateJwtSecretKey() {
-	rand.Read(hmacSampleSecret)
+	secret := make([]byte, jwtMinSecretLen)
+	rand.Read(secret)
+	jwtKeys.rotate(newHmacJwtKey(secret))
+}
+
+// LoadJwtSigningKey signs tokens with the key in fileName, using method HS256, RS256 or ES256.
+// Loading a different key rotates the signing key.
+func LoadJwtSigningKey(fileName string, method string) error {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return err
+	}
+	key, err := newJwtKey(method, data)
+	if err != nil {
+		return fmt.Errorf("invalid JWT signing key %v: %v", fileName, err)
+	}
+	jwtKeys.rotate(key)
+	return nil
 }
 
 func tokenResp(username string, roles []string) *spb.JwtToken {
-	exp_tm := time.Now().Add(JwtValidInt)
-	token := spb.JwtToken{AccessToken: generateJWT(username, roles, exp_tm), Type: "Bearer", ExpiresIn: int64(JwtValidInt / time.Second)}
+	validInt := GetJwtValidInt()
+	exp_tm := time.Now().Add(validInt)
+	token := spb.JwtToken{AccessToken: generateJWT(username, roles, exp_tm), Type: "Bearer", ExpiresIn: int64(validInt / time.Second)}
 	return &token
 }
 
+// hasRole returns true if auth has the role.
+func hasRole(auth *common_utils.AuthInfo, role string) bool {
+	for _, r := range auth.Roles {
+		if strings.TrimSpace(r) == role {
+			return true
+		}
+	}
+	return false
+}
+
+// parseJwtClaims validates a token, and returns its claims.
+func parseJwtClaims(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	tkn, err := jwt.ParseWithClaims(tokenString, claims, jwtKeys.verifyKey)
+	if err != nil {
+		return nil, err
+	}
+	if !tkn.Valid {
+		return nil, fmt.Errorf("Invalid JWT Token")
+	}
+	return claims, nil
+}
+
 func JwtAuthenAndAuthor(ctx context.Context) (*spb.JwtToken, context.Context, error) {
 	rc, ctx := common_utils.GetContext(ctx)
 	var token spb.JwtToken
@@ -72,15 +403,12 @@ func JwtAuthenAndAuthor(ctx context.Context) (*spb.JwtToken, context.Context, er
 		return nil, ctx, status.Errorf(codes.Unauthenticated, "No JWT Token Provided")
 	}
 
-	claims := &Claims{}
-	tkn, err := jwt.ParseWithClaims(token.AccessToken, claims, func(token *jwt.Token) (interface{}, error) {
-		return hmacSampleSecret, nil
-	})
+	claims, err := parseJwtClaims(token.AccessToken)
 	if err != nil {
 		return &token, ctx, status.Errorf(codes.Unauthenticated, err.Error())
 	}
-	if !tkn.Valid {
-		return &token, ctx, status.Errorf(codes.Unauthenticated, "Invalid JWT Token")
+	if jwtRevoked.isRevoked(claims) {
+		return &token, ctx, status.Errorf(codes.Unauthenticated, "JWT Token has been revoked")
 	}
 	if err := PopulateAuthStruct(claims.Username, &rc.Auth, claims.Roles); err != nil {
 		glog.Infof("[%s] Failed to retrieve authentication information; %v", rc.ID, err)
@@ -0,0 +1,275 @@
+package gnmi
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	spb_jwt "github.com/sonic-net/sonic-gnmi/proto/gnoi/jwt"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// resetJwtState gives a test its own signing keys and revocation list.
+func resetJwtState(t *testing.T) {
+	savedKeys, savedRevoked, savedGrace := jwtKeys, jwtRevoked, JwtKeyGracePeriod
+	jwtKeys = &jwtKeyRing{}
+	jwtRevoked = &jwtRevocationList{Tokens: map[string]int64{}, Users: map[string]int64{}}
+	t.Cleanup(func() {
+		jwtKeys, jwtRevoked, JwtKeyGracePeriod = savedKeys, savedRevoked, savedGrace
+	})
+}
+
+func writeJwtKeyFile(t *testing.T, method string) string {
+	var data []byte
+	switch method {
+	case "HS256":
+		data = []byte("0123456789abcdef0123456789abcdef\n")
+	case "RS256":
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatalf("Failed to generate RSA key: %v", err)
+		}
+		data = pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	case "ES256":
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatalf("Failed to generate EC key: %v", err)
+		}
+		der, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			t.Fatalf("Failed to marshal EC key: %v", err)
+		}
+		data = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+	}
+	fileName := filepath.Join(t.TempDir(), "jwt_"+method+".key")
+	if err := ioutil.WriteFile(fileName, data, 0600); err != nil {
+		t.Fatalf("Failed to write key file: %v", err)
+	}
+	return fileName
+}
+
+func TestJwtSigningKeyFile(t *testing.T) {
+	for _, method := range []string{"HS256", "RS256", "ES256"} {
+		t.Run(method, func(t *testing.T) {
+			resetJwtState(t)
+			if err := LoadJwtSigningKey(writeJwtKeyFile(t, method), method); err != nil {
+				t.Fatalf("LoadJwtSigningKey failed: %v", err)
+			}
+			tokenString := generateJWT("admin", []string{"admin"}, time.Now().Add(time.Hour))
+			claims, err := parseJwtClaims(tokenString)
+			if err != nil {
+				t.Fatalf("Token signed with %v failed validation: %v", method, err)
+			}
+			if claims.Username != "admin" || claims.Id == "" || claims.IssuedAt == 0 {
+				t.Errorf("Unexpected claims %+v", claims)
+			}
+			token, _, _ := new(jwt.Parser).ParseUnverified(tokenString, &Claims{})
+			if token.Method.Alg() != method {
+				t.Errorf("Token signed with %v, want %v", token.Method.Alg(), method)
+			}
+		})
+	}
+
+	resetJwtState(t)
+	if err := LoadJwtSigningKey(writeJwtKeyFile(t, "HS256"), "none"); err == nil {
+		t.Errorf("Expected unsupported signing method to fail")
+	}
+	if err := LoadJwtSigningKey(writeJwtKeyFile(t, "HS256"), "RS256"); err == nil {
+		t.Errorf("Expected HS256 secret used as RS256 key to fail")
+	}
+	shortSecret := filepath.Join(t.TempDir(), "short.key")
+	ioutil.WriteFile(shortSecret, []byte("secret"), 0600)
+	if err := LoadJwtSigningKey(shortSecret, "HS256"); err == nil {
+		t.Errorf("Expected short HS256 secret to fail")
+	}
+	if err := LoadJwtSigningKey(filepath.Join(t.TempDir(), "missing.key"), "HS256"); err == nil {
+		t.Errorf("Expected missing key file to fail")
+	}
+}
+
+func TestJwtSigningKeyRotation(t *testing.T) {
+	resetJwtState(t)
+	JwtKeyGracePeriod = time.Hour
+	if err := LoadJwtSigningKey(writeJwtKeyFile(t, "RS256"), "RS256"); err != nil {
+		t.Fatalf("LoadJwtSigningKey failed: %v", err)
+	}
+	oldToken := generateJWT("admin", nil, time.Now().Add(time.Hour))
+
+	// Tokens of the replaced key are accepted during the grace period
+	if err := LoadJwtSigningKey(writeJwtKeyFile(t, "ES256"), "ES256"); err != nil {
+		t.Fatalf("LoadJwtSigningKey failed: %v", err)
+	}
+	newToken := generateJWT("admin", nil, time.Now().Add(time.Hour))
+	if _, err := parseJwtClaims(oldToken); err != nil {
+		t.Errorf("Token of replaced key rejected during grace period: %v", err)
+	}
+	if _, err := parseJwtClaims(newToken); err != nil {
+		t.Errorf("Token of current key rejected: %v", err)
+	}
+
+	// and rejected once it has passed, while the grace period of an earlier key still runs
+	JwtKeyGracePeriod = 0
+	GenerateJwtSecretKey()
+	if _, err := parseJwtClaims(newToken); err == nil {
+		t.Errorf("Token of retired key accepted after grace period")
+	}
+	if _, err := parseJwtClaims(oldToken); err != nil {
+		t.Errorf("Token of replaced key rejected during grace period: %v", err)
+	}
+
+	// A token can't switch to another algorithm under the id of the current key
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, &Claims{Username: "admin"})
+	forged.Header["kid"] = jwtKeys.signingKey().id
+	forged.Method = jwt.SigningMethodHS384
+	forgedString, _ := forged.SignedString(jwtKeys.signingKey().signKey)
+	if _, err := parseJwtClaims(forgedString); err == nil {
+		t.Errorf("Token with a different signing method accepted")
+	}
+}
+
+func TestJwtRevocation(t *testing.T) {
+	resetJwtState(t)
+	SetJwtValidInt(time.Hour)
+	revocationFile := filepath.Join(t.TempDir(), "jwt_revoked.json")
+	if err := LoadJwtRevocationList(revocationFile); err != nil {
+		t.Fatalf("LoadJwtRevocationList of missing file failed: %v", err)
+	}
+
+	expiry := time.Now().Add(time.Hour)
+	revokedToken := generateJWT("admin", nil, expiry)
+	otherToken := generateJWT("admin", nil, expiry)
+	claims, _ := parseJwtClaims(revokedToken)
+	if err := jwtRevoked.revokeToken(claims); err != nil {
+		t.Fatalf("revokeToken failed: %v", err)
+	}
+	if !jwtRevoked.isRevoked(claims) {
+		t.Errorf("Revoked token not revoked")
+	}
+	otherClaims, _ := parseJwtClaims(otherToken)
+	if jwtRevoked.isRevoked(otherClaims) {
+		t.Errorf("Other token of the same user revoked")
+	}
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("access_token", revokedToken))
+	_, _, err := JwtAuthenAndAuthor(ctx)
+	if st, _ := status.FromError(err); st.Code() != codes.Unauthenticated || !strings.Contains(st.Message(), "revoked") {
+		t.Errorf("Expected revoked token to be rejected, got %v", err)
+	}
+
+	if err := jwtRevoked.revokeUser("admin"); err != nil {
+		t.Fatalf("revokeUser failed: %v", err)
+	}
+	if !jwtRevoked.isRevoked(otherClaims) {
+		t.Errorf("Token of revoked user not revoked")
+	}
+	if jwtRevoked.isRevoked(&Claims{Username: "guest"}) {
+		t.Errorf("Token of other user revoked")
+	}
+
+	// Revocations are reloaded from the file
+	jwtRevoked = &jwtRevocationList{Tokens: map[string]int64{}, Users: map[string]int64{}}
+	if err := LoadJwtRevocationList(revocationFile); err != nil {
+		t.Fatalf("LoadJwtRevocationList failed: %v", err)
+	}
+	if !jwtRevoked.isRevoked(claims) || !jwtRevoked.isRevoked(otherClaims) {
+		t.Errorf("Revocations not restored from %v", revocationFile)
+	}
+
+	ioutil.WriteFile(revocationFile, []byte("not json"), 0600)
+	if err := LoadJwtRevocationList(revocationFile); err == nil {
+		t.Errorf("Expected invalid revocation list to fail")
+	}
+}
+
+func TestJwtRevokeRPC(t *testing.T) {
+	resetJwtState(t)
+	SetJwtValidInt(time.Hour)
+	GenerateJwtSecretKey()
+	srv := &Server{config: &Config{UserAuth: AuthTypes{"jwt": true}}}
+
+	tokenCtx := func(token string) context.Context {
+		return metadata.NewIncomingContext(context.Background(), metadata.Pairs("access_token", token))
+	}
+	expiry := time.Now().Add(time.Hour)
+	adminToken := generateJWT("admin", []string{"admin"}, expiry)
+	guestToken := generateJWT("guest", []string{"operator"}, expiry)
+	otherToken := generateJWT("other", []string{"operator"}, expiry)
+
+	// A user may not revoke the tokens of another user
+	_, err := srv.Revoke(tokenCtx(guestToken), &spb_jwt.RevokeRequest{Username: "other"})
+	if st, _ := status.FromError(err); st.Code() != codes.PermissionDenied {
+		t.Errorf("Expected PermissionDenied revoking another user, got %v", err)
+	}
+	otherClaims, _ := parseJwtClaims(otherToken)
+	if jwtRevoked.isRevoked(otherClaims) {
+		t.Errorf("Token of other user revoked by a non-admin")
+	}
+
+	// A token given in the request must be issued to the given user
+	_, err = srv.Revoke(tokenCtx(guestToken), &spb_jwt.RevokeRequest{Username: "guest", AccessToken: otherToken})
+	if st, _ := status.FromError(err); st.Code() != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for a token of another user, got %v", err)
+	}
+	_, err = srv.Revoke(tokenCtx(guestToken), &spb_jwt.RevokeRequest{AccessToken: "not a token"})
+	if st, _ := status.FromError(err); st.Code() != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for an invalid token, got %v", err)
+	}
+
+	// An admin may revoke the tokens of any user
+	if _, err := srv.Revoke(tokenCtx(adminToken), &spb_jwt.RevokeRequest{Username: "other"}); err != nil {
+		t.Fatalf("Revoke of other user by admin failed: %v", err)
+	}
+	if !jwtRevoked.isRevoked(otherClaims) {
+		t.Errorf("Token of other user not revoked by admin")
+	}
+
+	// Without a user or token, the caller's own token is revoked
+	if _, err := srv.Revoke(tokenCtx(guestToken), &spb_jwt.RevokeRequest{}); err != nil {
+		t.Fatalf("Revoke of own token failed: %v", err)
+	}
+	if _, err := srv.Revoke(tokenCtx(guestToken), &spb_jwt.RevokeRequest{}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected revoked token to be rejected, got %v", err)
+	}
+	adminClaims, _ := parseJwtClaims(adminToken)
+	if jwtRevoked.isRevoked(adminClaims) {
+		t.Errorf("Token of another user revoked with the caller's token")
+	}
+
+	// On a read-only listener, users may only revoke their own tokens
+	readOnlyCtx := func(token string) context.Context {
+		listener := &ListenerConfig{Name: "readonly", ReadOnly: true, UserAuth: AuthTypes{"jwt": true}}
+		return context.WithValue(tokenCtx(token), listenerKey{}, listener)
+	}
+	operatorToken := generateJWT("operator", []string{"operator"}, expiry)
+	if _, err := srv.Revoke(readOnlyCtx(adminToken), &spb_jwt.RevokeRequest{Username: "operator"}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected PermissionDenied revoking another user without write access, got %v", err)
+	}
+	if _, err := srv.Revoke(readOnlyCtx(adminToken), &spb_jwt.RevokeRequest{AccessToken: operatorToken}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected PermissionDenied revoking a token of another user without write access, got %v", err)
+	}
+	if _, err := srv.Revoke(readOnlyCtx(operatorToken), &spb_jwt.RevokeRequest{Username: "operator"}); err != nil {
+		t.Fatalf("Revoke of own tokens without write access failed: %v", err)
+	}
+	operatorClaims, _ := parseJwtClaims(operatorToken)
+	if !jwtRevoked.isRevoked(operatorClaims) {
+		t.Errorf("Own tokens not revoked without write access")
+	}
+
+	// Revoke is not available when JWT authentication is disabled
+	srv.config.UserAuth = AuthTypes{}
+	if _, err := srv.Revoke(tokenCtx(adminToken), &spb_jwt.RevokeRequest{}); status.Code(err) != codes.Unimplemented {
+		t.Errorf("Expected Unimplemented without JWT authentication, got %v", err)
+	}
+}
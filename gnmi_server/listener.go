@@ -0,0 +1,222 @@
+package gnmi
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+
+	log "github.com/golang/glog"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+// ListenerConfig describes an additional listener of the Server. It serves the same
+// services as the main listener, with its own transport and auth policy.
+type ListenerConfig struct {
+	Name string
+	// Network is tcp or unix
+	Network string
+	// Address is host:port for tcp and the socket path for unix
+	Address string
+	// VRF device the tcp listener is bound to, none when empty
+	Vrf string
+	// TLS enables TLS on the listener, with ClientCert as client certificate policy
+	TLS        bool
+	ClientCert tls.ClientAuthType
+	// Auth modes of the listener, replacing Config.UserAuth for its clients
+	UserAuth AuthTypes
+	// ReadOnly rejects every request needing write access
+	ReadOnly bool
+	// Server options of the listener, e.g. its transport credentials
+	Opts []grpc.ServerOption
+}
+
+// listener is a running additional listener
+type listener struct {
+	config *ListenerConfig
+	s      *grpc.Server
+	lis    net.Listener
+}
+
+type listenerKey struct{}
+
+// NewAuthTypes returns AuthTypes with all supported modes disabled.
+func NewAuthTypes() AuthTypes {
+	return AuthTypes{"password": false, "cert": false, "jwt": false, "oidc": false}
+}
+
+// ParseListenerConfig parses a listener spec, a comma separated list of key=value:
+//
+//	name=<name>                listener name used in logs, defaults to the address
+//	addr=<host:port>|unix:<path>
+//	vrf=<device>               VRF device to bind the tcp listener to
+//	tls=true|false             defaults to true for tcp and false for unix sockets
+//	client_cert=require|request|none
+//	                           client certificate policy of TLS, defaults to require
+//	auth=<mode>[+<mode>...]    none, cert, password, jwt or oidc, defaults to none
+//	readonly=true|false        reject write requests, defaults to false
+//
+// e.g. "name=inband,addr=10.0.0.1:8080,auth=password+jwt,client_cert=none"
+// or "addr=unix:/var/run/gnmi/gnmi.sock,readonly=true".
+func ParseListenerConfig(spec string) (*ListenerConfig, error) {
+	l := &ListenerConfig{
+		Network:    "tcp",
+		ClientCert: tls.RequireAndVerifyClientCert,
+		UserAuth:   NewAuthTypes(),
+	}
+	tlsSet := false
+	for _, field := range strings.Split(spec, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid listener field %q, expecting key=value", field)
+		}
+		key, value := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+		var err error
+		switch key {
+		case "name":
+			l.Name = value
+		case "addr":
+			if path := strings.TrimPrefix(value, "unix:"); path != value {
+				l.Network, l.Address = "unix", path
+			} else {
+				l.Network, l.Address = "tcp", value
+			}
+		case "vrf":
+			l.Vrf = value
+		case "tls":
+			l.TLS, err = strconv.ParseBool(value)
+			tlsSet = true
+		case "client_cert":
+			switch value {
+			case "require":
+				l.ClientCert = tls.RequireAndVerifyClientCert
+			case "request":
+				l.ClientCert = tls.VerifyClientCertIfGiven
+			case "none":
+				l.ClientCert = tls.NoClientCert
+			default:
+				err = fmt.Errorf("expecting require, request or none")
+			}
+		case "auth":
+			err = l.UserAuth.Set(strings.Replace(value, "+", ",", -1))
+		case "readonly":
+			l.ReadOnly, err = strconv.ParseBool(value)
+		default:
+			return nil, fmt.Errorf("unknown listener field %q", key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid listener %s %q: %v", key, value, err)
+		}
+	}
+
+	if l.Address == "" {
+		return nil, fmt.Errorf("listener addr must be set")
+	}
+	if !tlsSet {
+		l.TLS = l.Network == "tcp"
+	}
+	if l.Network == "unix" && l.Vrf != "" {
+		return nil, fmt.Errorf("listener %s: vrf can't be set for a unix socket", l.Address)
+	}
+	if l.UserAuth.Enabled("cert") && (!l.TLS || l.ClientCert == tls.NoClientCert) {
+		return nil, fmt.Errorf("listener %s: auth mode cert requires tls with a client certificate", l.Address)
+	}
+	if l.Name == "" {
+		l.Name = l.Address
+	}
+	return l, nil
+}
+
+// listen opens the socket of a listener, bound to its VRF if set.
+func (l *ListenerConfig) listen() (net.Listener, error) {
+	if l.Network == "unix" {
+		// remove the socket left by a previous server
+		if err := os.Remove(l.Address); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		return net.Listen("unix", l.Address)
+	}
+	lc := net.ListenConfig{}
+	if l.Vrf != "" {
+		lc.Control = func(network, address string, c syscall.RawConn) error {
+			var serr error
+			err := c.Control(func(fd uintptr) {
+				serr = syscall.SetsockoptString(int(fd), syscall.SOL_SOCKET, syscall.SO_BINDTODEVICE, l.Vrf)
+			})
+			if err != nil {
+				return err
+			}
+			if serr != nil {
+				return fmt.Errorf("failed to bind to vrf %s: %v", l.Vrf, serr)
+			}
+			return nil
+		}
+	}
+	return lc.Listen(context.Background(), "tcp", l.Address)
+}
+
+// newListener creates the gRPC server of an additional listener. Its interceptors add
+// the listener to the context of every request, so authenticate applies its policy.
+func (srv *Server) newListener(config *ListenerConfig) (*listener, error) {
+	lis, err := config.listen()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open listener %s: %v", config.Name, err)
+	}
+	lis = srv.idleConns.listener(lis)
+	opts := append(append([]grpc.ServerOption{}, config.Opts...),
+		grpc.StatsHandler(srv.idleConns),
+		grpc.ChainUnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			return handler(context.WithValue(ctx, listenerKey{}, config), req)
+		}),
+		grpc.ChainStreamInterceptor(func(svc interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			return handler(svc, &listenerStream{ServerStream: ss, ctx: context.WithValue(ss.Context(), listenerKey{}, config)})
+		}),
+	)
+	s := grpc.NewServer(opts...)
+	reflection.Register(s)
+	srv.registerServices(s)
+	log.V(1).Infof("Created listener %s on %s, auth: %v, read-only: %t", config.Name, lis.Addr(), config.UserAuth, config.ReadOnly)
+	return &listener{config: config, s: s, lis: lis}, nil
+}
+
+// closeListeners closes the sockets of listeners that aren't served.
+func (srv *Server) closeListeners() {
+	for _, l := range srv.listeners {
+		l.lis.Close()
+	}
+	srv.listeners = nil
+}
+
+// listenerStream overrides the context of a stream
+type listenerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *listenerStream) Context() context.Context {
+	return s.ctx
+}
+
+// listenerUserAuth returns the auth modes of the listener a request was received on,
+// those of config for the main listener.
+func listenerUserAuth(config *Config, ctx context.Context, writeAccess bool) (AuthTypes, error) {
+	l, ok := ctx.Value(listenerKey{}).(*ListenerConfig)
+	if !ok {
+		return config.userAuth(), nil
+	}
+	if writeAccess && l.ReadOnly {
+		return nil, status.Errorf(codes.PermissionDenied, "listener %s is read-only", l.Name)
+	}
+	return l.UserAuth, nil
+}
@@ -0,0 +1,194 @@
+package gnmi
+
+import (
+	"crypto/tls"
+	"path/filepath"
+	"testing"
+	"time"
+
+	spb_jwt "github.com/sonic-net/sonic-gnmi/proto/gnoi/jwt"
+	testcert "github.com/sonic-net/sonic-gnmi/testdata/tls"
+
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnoi/factory_reset"
+	gnoi_file_pb "github.com/openconfig/gnoi/file"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+func TestParseListenerConfig(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    ListenerConfig
+		auth    []string
+		wantErr bool
+	}{
+		{
+			spec: "name=mgmt,addr=:50052,vrf=mgmt,auth=cert",
+			want: ListenerConfig{Name: "mgmt", Network: "tcp", Address: ":50052", Vrf: "mgmt", TLS: true, ClientCert: tls.RequireAndVerifyClientCert},
+			auth: []string{"cert"},
+		},
+		{
+			spec: "addr=10.0.0.1:8080, auth=password+jwt, client_cert=none",
+			want: ListenerConfig{Name: "10.0.0.1:8080", Network: "tcp", Address: "10.0.0.1:8080", TLS: true, ClientCert: tls.NoClientCert},
+			auth: []string{"password", "jwt"},
+		},
+		{
+			spec: "addr=unix:/var/run/gnmi.sock,readonly=true",
+			want: ListenerConfig{Name: "/var/run/gnmi.sock", Network: "unix", Address: "/var/run/gnmi.sock", ClientCert: tls.RequireAndVerifyClientCert, ReadOnly: true},
+		},
+		{
+			spec: "addr=:8080,tls=false,auth=none,client_cert=request",
+			want: ListenerConfig{Name: ":8080", Network: "tcp", Address: ":8080", ClientCert: tls.VerifyClientCertIfGiven},
+		},
+		{spec: "name=noaddr,auth=password", wantErr: true},
+		{spec: "addr=unix:/var/run/gnmi.sock,vrf=mgmt", wantErr: true},
+		{spec: "addr=:8080,auth=cert,client_cert=none", wantErr: true},
+		{spec: "addr=unix:/var/run/gnmi.sock,auth=cert", wantErr: true},
+		{spec: "addr=:8080,auth=kerberos", wantErr: true},
+		{spec: "addr=:8080,client_cert=maybe", wantErr: true},
+		{spec: "addr=:8080,readonly=sometimes", wantErr: true},
+		{spec: "addr=:8080,port=8080", wantErr: true},
+		{spec: "addr", wantErr: true},
+	}
+	for _, test := range tests {
+		l, err := ParseListenerConfig(test.spec)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("ParseListenerConfig(%q) expected to fail", test.spec)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseListenerConfig(%q) failed: %v", test.spec, err)
+			continue
+		}
+		if l.Name != test.want.Name || l.Network != test.want.Network || l.Address != test.want.Address ||
+			l.Vrf != test.want.Vrf || l.TLS != test.want.TLS || l.ClientCert != test.want.ClientCert || l.ReadOnly != test.want.ReadOnly {
+			t.Errorf("ParseListenerConfig(%q) = %+v, want %+v", test.spec, *l, test.want)
+		}
+		for _, mode := range test.auth {
+			if !l.UserAuth.Enabled(mode) {
+				t.Errorf("ParseListenerConfig(%q) auth mode %s not enabled", test.spec, mode)
+			}
+		}
+		if len(test.auth) == 0 && l.UserAuth.Any() {
+			t.Errorf("ParseListenerConfig(%q) expected no auth, got %v", test.spec, l.UserAuth)
+		}
+	}
+}
+
+func TestListenerUserAuth(t *testing.T) {
+	config := &Config{UserAuth: AuthTypes{"password": true, "cert": false, "jwt": true, "oidc": false}}
+	readonly, _ := ParseListenerConfig("addr=unix:/tmp/gnmi.sock,readonly=true")
+	password, _ := ParseListenerConfig("addr=:8080,auth=password,client_cert=none")
+
+	if auth, err := listenerUserAuth(config, context.Background(), true); err != nil || !auth.Enabled("jwt") {
+		t.Errorf("Expected auth modes of the main listener, got %v, %v", auth, err)
+	}
+
+	ctx := context.WithValue(context.Background(), listenerKey{}, readonly)
+	if _, err := listenerUserAuth(config, ctx, true); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected write on read-only listener to be denied, got %v", err)
+	}
+	if auth, err := listenerUserAuth(config, ctx, false); err != nil || auth.Any() {
+		t.Errorf("Expected read-only listener without auth, got %v, %v", auth, err)
+	}
+
+	ctx = context.WithValue(context.Background(), listenerKey{}, password)
+	if auth, err := listenerUserAuth(config, ctx, true); err != nil || !auth.Enabled("password") || auth.Enabled("jwt") {
+		t.Errorf("Expected password listener auth modes, got %v, %v", auth, err)
+	}
+}
+
+func TestServerListeners(t *testing.T) {
+	certificate, err := testcert.NewCert()
+	if err != nil {
+		t.Fatalf("could not load server key pair: %s", err)
+	}
+	tlsCfg := &tls.Config{
+		ClientAuth:   tls.RequestClientCert,
+		Certificates: []tls.Certificate{certificate},
+	}
+
+	sock := filepath.Join(t.TempDir(), "gnmi.sock")
+	agent, err := ParseListenerConfig("name=agent,addr=unix:" + sock + ",readonly=true")
+	if err != nil {
+		t.Fatalf("ParseListenerConfig failed: %v", err)
+	}
+	inband, err := ParseListenerConfig("name=inband,addr=127.0.0.1:0,client_cert=none")
+	if err != nil {
+		t.Fatalf("ParseListenerConfig failed: %v", err)
+	}
+	inband.Opts = []grpc.ServerOption{grpc.Creds(credentials.NewTLS(tlsCfg))}
+
+	cfg := &Config{
+		Port:                8081,
+		EnableTranslibWrite: true,
+		UserAuth:            AuthTypes{"password": true, "cert": false, "jwt": false, "oidc": false},
+		ImgDir:              "/tmp",
+		Listeners:           []*ListenerConfig{agent, inband},
+	}
+	s, err := NewServer(cfg, []grpc.ServerOption{grpc.Creds(credentials.NewTLS(tlsCfg))})
+	if err != nil {
+		t.Fatalf("Failed to create gNMI server: %v", err)
+	}
+	go runServer(t, s)
+	defer s.ForceStop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	tlsCreds := credentials.NewTLS(&tls.Config{InsecureSkipVerify: true})
+	dial := func(target string, creds credentials.TransportCredentials) gnmipb.GNMIClient {
+		conn, err := grpc.Dial(target, grpc.WithTransportCredentials(creds))
+		if err != nil {
+			t.Fatalf("Dialing to %s failed: %v", target, err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		return gnmipb.NewGNMIClient(conn)
+	}
+
+	// The main listener requires a password
+	if _, err := dial("127.0.0.1:8081", tlsCreds).Capabilities(ctx, &gnmipb.CapabilityRequest{}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected main listener to require auth, got %v", err)
+	}
+
+	// The agent listener allows reads without auth, and rejects writes
+	conn, err := grpc.Dial("unix:"+sock, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Dialing to agent listener failed: %v", err)
+	}
+	defer conn.Close()
+	if _, err := gnmipb.NewGNMIClient(conn).Capabilities(ctx, &gnmipb.CapabilityRequest{}); err != nil {
+		t.Errorf("Capabilities on agent listener failed: %v", err)
+	}
+	if _, err := spb_jwt.NewSonicJwtServiceClient(conn).Refresh(ctx, &spb_jwt.RefreshRequest{}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected write access on agent listener to be denied, got %v", err)
+	}
+	put, err := gnoi_file_pb.NewFileClient(conn).Put(ctx)
+	if err != nil {
+		t.Fatalf("File.Put on agent listener failed to start: %v", err)
+	}
+	if _, err := put.CloseAndRecv(); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected File.Put on agent listener to be denied, got %v", err)
+	}
+	if _, err := gnoi_file_pb.NewFileClient(conn).Remove(ctx, &gnoi_file_pb.RemoveRequest{RemoteFile: "/tmp/x"}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected File.Remove on agent listener to be denied, got %v", err)
+	}
+	if _, err := factory_reset.NewFactoryResetClient(conn).Start(ctx, &factory_reset.StartRequest{}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected FactoryReset.Start on agent listener to be denied, got %v", err)
+	}
+
+	// The inband listener uses TLS and allows all without auth
+	inbandAddr := s.listeners[1].lis.Addr().String()
+	if _, err := dial(inbandAddr, tlsCreds).Capabilities(ctx, &gnmipb.CapabilityRequest{}); err != nil {
+		t.Errorf("Capabilities on inband listener failed: %v", err)
+	}
+	if _, err := dial(inbandAddr, insecure.NewCredentials()).Capabilities(ctx, &gnmipb.CapabilityRequest{}); err == nil {
+		t.Errorf("Expected plaintext connection to inband listener to fail")
+	}
+}
@@ -0,0 +1,52 @@
+package gnmi
+
+import (
+	"sync"
+)
+
+// configLock protects the settings of Config that can be changed while the server runs,
+// and the package settings changed along, e.g. by SetOcspPolicy
+var configLock sync.RWMutex
+
+// LiveConfig holds the settings of Config applied to a running server by ApplyLive.
+type LiveConfig struct {
+	Threshold        int
+	LogLevel         int
+	UserAuth         AuthTypes
+	IdleConnDuration int
+}
+
+// Live returns the current live settings of c.
+func (c *Config) Live() LiveConfig {
+	configLock.RLock()
+	defer configLock.RUnlock()
+	return LiveConfig{
+		Threshold:        c.Threshold,
+		LogLevel:         c.LogLevel,
+		UserAuth:         c.UserAuth,
+		IdleConnDuration: c.IdleConnDuration,
+	}
+}
+
+// ApplyLive changes the live settings of c. They apply to the requests and connections
+// of a server using c from now on, existing subscriptions are kept.
+// UserAuth is replaced, so lc.UserAuth must not be modified afterwards.
+func (c *Config) ApplyLive(lc LiveConfig) {
+	configLock.Lock()
+	c.Threshold = lc.Threshold
+	c.LogLevel = lc.LogLevel
+	c.UserAuth = lc.UserAuth
+	c.IdleConnDuration = lc.IdleConnDuration
+	configLock.Unlock()
+
+	if connectionManager != nil {
+		connectionManager.SetThreshold(lc.Threshold)
+	}
+}
+
+// userAuth returns the auth modes of c.
+func (c *Config) userAuth() AuthTypes {
+	configLock.RLock()
+	defer configLock.RUnlock()
+	return c.UserAuth
+}
@@ -0,0 +1,50 @@
+package gnmi
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestLiveSettingsConcurrency changes the live settings while requests read them, run with -race.
+func TestLiveSettingsConcurrency(t *testing.T) {
+	config := &Config{UserAuth: NewAuthTypes()}
+	savedRefresh, savedValid, savedCrl, savedOcsp := GetJwtRefreshInt(), GetJwtValidInt(), GetCrlExpireDuration(), GetOcspPolicy()
+	defer func() {
+		SetJwtRefreshInt(savedRefresh)
+		SetJwtValidInt(savedValid)
+		SetCrlExpireDuration(savedCrl)
+		SetOcspPolicy(savedOcsp)
+	}()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			SetJwtRefreshInt(time.Duration(i) * time.Second)
+			SetJwtValidInt(time.Duration(i) * time.Second)
+			SetCrlExpireDuration(time.Duration(i) * time.Second)
+			SetOcspPolicy([]string{OCSP_SOFT_FAIL, OCSP_HARD_FAIL}[i%2])
+			auth := NewAuthTypes()
+			auth.Set("password")
+			config.ApplyLive(LiveConfig{Threshold: i, UserAuth: auth, IdleConnDuration: i})
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		GetJwtRefreshInt()
+		GetJwtValidInt()
+		GetCrlExpireDuration()
+		GetOcspPolicy()
+		config.userAuth().Enabled("password")
+		config.Live()
+	}
+	wg.Wait()
+
+	if live := config.Live(); live.Threshold != 99 || !live.UserAuth.Enabled("password") {
+		t.Errorf("Unexpected live config %+v", live)
+	}
+	if GetJwtValidInt() != 99*time.Second || GetOcspPolicy() != OCSP_HARD_FAIL {
+		t.Errorf("Expected the last settings to be applied")
+	}
+}
@@ -0,0 +1,114 @@
+package gnmi
+
+// lldp_cli_test.go
+
+// Tests SHOW lldp neighbors
+
+import (
+	"crypto/tls"
+	"testing"
+	"time"
+
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+)
+
+func TestGetShowLldpNeighbors(t *testing.T) {
+	s := createServer(t, ServerPort)
+	go runServer(t, s)
+	defer s.ForceStop()
+	defer ResetDataSetsAndMappings(t)
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: true}
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig))}
+
+	conn, err := grpc.Dial(TargetAddr, opts...)
+	if err != nil {
+		t.Fatalf("Dialing to %q failed: %v", TargetAddr, err)
+	}
+	defer conn.Close()
+
+	gClient := pb.NewGNMIClient(conn)
+	ctx, cancel := context.WithTimeout(context.Background(), QueryTimeout*time.Second)
+	defer cancel()
+
+	emptyResp := `{}`
+	ethernet0 := `"Ethernet0": {"ChassisID": "00:11:22:33:44:55", "ChassisIDSubtype": "4", "SysName": "ARISTA01T1", "SysDescr": "Arista Networks EOS version 4.20.0F", "MgmtIP": "10.250.0.51", "Capability": "28 00", "PortID": "Ethernet1", "PortIDSubtype": "5"}`
+	ethernet40 := `"Ethernet40": {"ChassisID": "00:11:22:33:44:66", "ChassisIDSubtype": "4", "SysName": "Servers4", "PortID": "eth0", "PortIDSubtype": "7", "PortDescr": "eth0"}`
+	allNeighbors := `{` + ethernet0 + `,` + ethernet40 + `}`
+	oneNeighbor := `{` + ethernet40 + `}`
+
+	lldpEntryTableFileName := "../testdata/LLDP_ENTRY_TABLE.txt"
+
+	tests := []struct {
+		desc        string
+		pathTarget  string
+		textPbPath  string
+		wantRetCode codes.Code
+		wantRespVal interface{}
+		valTest     bool
+		testInit    func()
+	}{
+		{
+			desc:       "query SHOW lldp neighbors - no data",
+			pathTarget: "SHOW",
+			textPbPath: `
+				elem: <name: "lldp" >
+				elem: <name: "neighbors" >
+			`,
+			wantRetCode: codes.OK,
+			wantRespVal: []byte(emptyResp),
+			valTest:     true,
+		},
+		{
+			desc:       "query SHOW lldp neighbors - all interfaces",
+			pathTarget: "SHOW",
+			textPbPath: `
+				elem: <name: "lldp" >
+				elem: <name: "neighbors" >
+			`,
+			wantRetCode: codes.OK,
+			wantRespVal: []byte(allNeighbors),
+			valTest:     true,
+			testInit: func() {
+				FlushDataSet(t, ApplDbNum)
+				AddDataSet(t, ApplDbNum, lldpEntryTableFileName)
+			},
+		},
+		{
+			desc:       "query SHOW lldp neighbors - single interface",
+			pathTarget: "SHOW",
+			textPbPath: `
+				elem: <name: "lldp" >
+				elem: <name: "neighbors" key: { key: "interface" value: "Ethernet40" } >
+			`,
+			wantRetCode: codes.OK,
+			wantRespVal: []byte(oneNeighbor),
+			valTest:     true,
+		},
+		{
+			desc:       "query SHOW lldp neighbors - interface without neighbor",
+			pathTarget: "SHOW",
+			textPbPath: `
+				elem: <name: "lldp" >
+				elem: <name: "neighbors" key: { key: "interface" value: "Ethernet80" } >
+			`,
+			wantRetCode: codes.OK,
+			wantRespVal: []byte(emptyResp),
+			valTest:     true,
+		},
+	}
+
+	for _, test := range tests {
+		if test.testInit != nil {
+			test.testInit()
+		}
+		t.Run(test.desc, func(t *testing.T) {
+			runTestGet(t, ctx, gClient, test.pathTarget, test.textPbPath, test.wantRetCode, test.wantRespVal, test.valTest)
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package gnmi
+
+// mac_cli_test.go
+
+// Tests SHOW mac
+
+import (
+	"crypto/tls"
+	"testing"
+	"time"
+
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+)
+
+func TestGetShowMac(t *testing.T) {
+	s := createServer(t, ServerPort)
+	go runServer(t, s)
+	defer s.ForceStop()
+	defer ResetDataSetsAndMappings(t)
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: true}
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig))}
+
+	conn, err := grpc.Dial(TargetAddr, opts...)
+	if err != nil {
+		t.Fatalf("Dialing to %q failed: %v", TargetAddr, err)
+	}
+	defer conn.Close()
+
+	gClient := pb.NewGNMIClient(conn)
+	ctx, cancel := context.WithTimeout(context.Background(), QueryTimeout*time.Second)
+	defer cancel()
+
+	emptyResp := `[]`
+	allEntries := `[
+		{"No.": "1", "Vlan": "1000", "MacAddress": "00:00:00:00:00:01", "Port": "Ethernet40", "Type": "static"},
+		{"No.": "2", "Vlan": "1000", "MacAddress": "00:00:00:00:00:02", "Port": "Ethernet0", "Type": "dynamic"},
+		{"No.": "3", "Vlan": "2000", "MacAddress": "00:00:00:00:00:03", "Port": "PortChannel101", "Type": "dynamic"}
+	]`
+	vlanEntries := `[
+		{"No.": "1", "Vlan": "2000", "MacAddress": "00:00:00:00:00:03", "Port": "PortChannel101", "Type": "dynamic"}
+	]`
+	portEntries := `[
+		{"No.": "1", "Vlan": "1000", "MacAddress": "00:00:00:00:00:02", "Port": "Ethernet0", "Type": "dynamic"}
+	]`
+
+	asicFdbTableFileName := "../testdata/ASIC_FDB_TABLE.txt"
+	countersNameMapFileName := "../testdata/FDB_COUNTERS_NAME_MAP.txt"
+
+	tests := []struct {
+		desc        string
+		pathTarget  string
+		textPbPath  string
+		wantRetCode codes.Code
+		wantRespVal interface{}
+		valTest     bool
+		testInit    func()
+	}{
+		{
+			desc:       "query SHOW mac - no data",
+			pathTarget: "SHOW",
+			textPbPath: `
+				elem: <name: "mac" >
+			`,
+			wantRetCode: codes.OK,
+			wantRespVal: []byte(emptyResp),
+			valTest:     true,
+		},
+		{
+			desc:       "query SHOW mac - all entries",
+			pathTarget: "SHOW",
+			textPbPath: `
+				elem: <name: "mac" >
+			`,
+			wantRetCode: codes.OK,
+			wantRespVal: []byte(allEntries),
+			valTest:     true,
+			testInit: func() {
+				FlushDataSet(t, AsicDbNum)
+				FlushDataSet(t, CountersDbNum)
+				AddDataSet(t, AsicDbNum, asicFdbTableFileName)
+				AddDataSet(t, CountersDbNum, countersNameMapFileName)
+			},
+		},
+		{
+			desc:       "query SHOW mac - filter by vlan",
+			pathTarget: "SHOW",
+			textPbPath: `
+				elem: <name: "mac" key: { key: "vlan" value: "2000" } >
+			`,
+			wantRetCode: codes.OK,
+			wantRespVal: []byte(vlanEntries),
+			valTest:     true,
+		},
+		{
+			desc:       "query SHOW mac - filter by port",
+			pathTarget: "SHOW",
+			textPbPath: `
+				elem: <name: "mac" key: { key: "port" value: "Ethernet0" } >
+			`,
+			wantRetCode: codes.OK,
+			wantRespVal: []byte(portEntries),
+			valTest:     true,
+		},
+		{
+			desc:       "query SHOW mac - invalid vlan",
+			pathTarget: "SHOW",
+			textPbPath: `
+				elem: <name: "mac" key: { key: "vlan" value: "abc" } >
+			`,
+			wantRetCode: codes.InvalidArgument,
+		},
+	}
+
+	for _, test := range tests {
+		if test.testInit != nil {
+			test.testInit()
+		}
+		t.Run(test.desc, func(t *testing.T) {
+			runTestGet(t, ctx, gClient, test.pathTarget, test.textPbPath, test.wantRetCode, test.wantRespVal, test.valTest)
+		})
+	}
+}
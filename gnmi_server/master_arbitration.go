@@ -0,0 +1,277 @@
+package gnmi
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis"
+	log "github.com/golang/glog"
+	"github.com/google/gnxi/utils/xpath"
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+	sdcfg "github.com/sonic-net/sonic-gnmi/sonic_db_config"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// STATE_DB table where the current master of each role is published
+	masterArbitrationTable = "GNMI_MASTER_ARBITRATION"
+	// Requests without a Role in their MasterArbitration extension belong to the default role
+	masterArbitrationDefaultRole = "default"
+	// Most roles without role paths which are tracked, so that clients cannot grow the tracked
+	// roles, and STATE_DB, without bound
+	maxMasterArbitrationRoles = 64
+)
+
+// RolePaths holds, per Master Arbitration role, the paths a master of that role may write.
+// Roles without an entry may write any path.
+type RolePaths map[string][]*gnmipb.Path
+
+// ParseRolePaths parses a role path spec of the form
+// "role1=path1,path2;role2=path3", where each path is an xpath such as
+// "sonic-db:CONFIG_DB/localhost/ACL_TABLE" or "/interfaces/interface[name=Ethernet0]".
+func ParseRolePaths(spec string) (RolePaths, error) {
+	rolePaths := RolePaths{}
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		role := strings.TrimSpace(parts[0])
+		if len(parts) != 2 || role == "" {
+			return nil, fmt.Errorf("invalid role paths entry %q, expecting role=path[,path...]", entry)
+		}
+		for _, p := range strings.Split(parts[1], ",") {
+			p = strings.TrimSpace(p)
+			if p == "" {
+				continue
+			}
+			// The origin, e.g. 'sonic-db:', is not part of the xpath
+			origin, xp := "", p
+			if i := strings.Index(p, ":"); i >= 0 && !strings.ContainsAny(p[:i], "/[") {
+				origin, xp = p[:i], p[i+1:]
+			}
+			path, err := xpath.ToGNMIPath(xp)
+			if err != nil {
+				return nil, fmt.Errorf("invalid path %q for role %v: %v", p, role, err)
+			}
+			path.Origin = origin
+			rolePaths[role] = append(rolePaths[role], path)
+		}
+		if len(rolePaths[role]) == 0 {
+			return nil, fmt.Errorf("no paths given for role %v", role)
+		}
+	}
+	return rolePaths, nil
+}
+
+// masterArbitrationRole returns the role of the last MasterArbitration extension in the request,
+// matching the election ID that is used, or the default role.
+func masterArbitrationRole(req *gnmipb.SetRequest) string {
+	role := masterArbitrationDefaultRole
+	for _, e := range req.GetExtension() {
+		ma := e.GetMasterArbitration()
+		if ma == nil {
+			continue
+		}
+		role = masterArbitrationDefaultRole
+		if id := ma.GetRole().GetId(); id != "" {
+			role = id
+		}
+	}
+	return role
+}
+
+// masterEIDForRole returns the tracked master election ID of a role. Roles with role paths are
+// always tracked, while at most maxMasterArbitrationRoles other roles are.
+func (s *Server) masterEIDForRole(role string) (*uint128, error) {
+	if role == masterArbitrationDefaultRole {
+		return &s.masterEID, nil
+	}
+	maMu.Lock()
+	defer maMu.Unlock()
+	eid, ok := s.roleMasterEIDs[role]
+	if !ok {
+		if _, configured := s.config.RolePaths[role]; !configured {
+			untracked := 0
+			for name := range s.roleMasterEIDs {
+				if _, configured := s.config.RolePaths[name]; !configured {
+					untracked++
+				}
+			}
+			if untracked >= maxMasterArbitrationRoles {
+				return nil, status.Errorf(codes.ResourceExhausted, "MA: too many roles, role %v is not tracked", role)
+			}
+		}
+		eid = &uint128{High: 0, Low: 0}
+		s.roleMasterEIDs[role] = eid
+	}
+	return eid, nil
+}
+
+// checkRolePaths verifies that every path of the request may be written by the request's role.
+func (s *Server) checkRolePaths(req *gnmipb.SetRequest) error {
+	role := masterArbitrationRole(req)
+	allowed, ok := s.config.RolePaths[role]
+	if !ok {
+		return nil
+	}
+
+	prefix := req.GetPrefix()
+	paths := req.GetDelete()
+	for _, update := range req.GetReplace() {
+		paths = append(paths, update.GetPath())
+	}
+	for _, update := range req.GetUpdate() {
+		paths = append(paths, update.GetPath())
+	}
+	for _, path := range paths {
+		if !pathAllowed(prefix, path, allowed) {
+			return status.Errorf(codes.PermissionDenied, "MA: role %v may not write path %v", role, path)
+		}
+	}
+	return nil
+}
+
+// pathAllowed returns true if the prefixed path is at or below one of the allowed paths.
+// Keys given in an allowed path must match; keys it omits match any value.
+func pathAllowed(prefix, path *gnmipb.Path, allowed []*gnmipb.Path) bool {
+	origin := path.GetOrigin()
+	if origin == "" {
+		origin = prefix.GetOrigin()
+	}
+	elems := append(append([]*gnmipb.PathElem{}, prefix.GetElem()...), path.GetElem()...)
+
+	for _, a := range allowed {
+		if a.GetOrigin() != "" && a.GetOrigin() != origin {
+			continue
+		}
+		if len(a.GetElem()) > len(elems) {
+			continue
+		}
+		match := true
+		for i, ae := range a.GetElem() {
+			if ae.GetName() != elems[i].GetName() {
+				match = false
+				break
+			}
+			for k, v := range ae.GetKey() {
+				if elems[i].GetKey()[k] != v {
+					match = false
+					break
+				}
+			}
+			if !match {
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	// maClient is the STATE_DB client of the master arbitration table, set by prepareMasterEIDs
+	maClient    *redis.Client
+	maSeparator string
+
+	// Masters elected since the last write to STATE_DB, by role. They are written by
+	// maPublisher, which is woken through maPublish.
+	maPendingMu sync.Mutex
+	maPending   = map[string]map[string]interface{}{}
+	maPublish   = make(chan struct{}, 1)
+	maPublisher sync.Once
+)
+
+// prepareMasterEIDs connects to STATE_DB, and removes the masters published by a previous
+// server, as every role starts without a master. It is only called when Master Arbitration is
+// enabled.
+func prepareMasterEIDs() {
+	ns, _ := sdcfg.GetDbDefaultNamespace()
+	addr, err := sdcfg.GetDbTcpAddr("STATE_DB", ns)
+	if err != nil {
+		log.Errorf("MA: failed to get STATE_DB address: %v", err)
+		return
+	}
+	db, err := sdcfg.GetDbId("STATE_DB", ns)
+	if err != nil {
+		log.Errorf("MA: failed to get STATE_DB id: %v", err)
+		return
+	}
+	separator, err := sdcfg.GetDbSeparator("STATE_DB", ns)
+	if err != nil {
+		log.Errorf("MA: failed to get STATE_DB separator: %v", err)
+		return
+	}
+	client := redis.NewClient(&redis.Options{
+		Network:     "tcp",
+		Addr:        addr,
+		Password:    "",
+		DB:          db,
+		DialTimeout: 0,
+	})
+
+	maPendingMu.Lock()
+	if maClient != nil {
+		maClient.Close()
+	}
+	maClient, maSeparator = client, separator
+	maPendingMu.Unlock()
+
+	keys, err := client.Keys(masterArbitrationTable + separator + "*").Result()
+	if err != nil {
+		log.Errorf("MA: failed to read masters from STATE_DB: %v", err)
+		return
+	}
+	if len(keys) > 0 {
+		if err := client.Del(keys...).Err(); err != nil {
+			log.Errorf("MA: failed to remove stale masters from STATE_DB: %v", err)
+		}
+	}
+}
+
+// publishMasterEID records the master election ID of a role in STATE_DB. The write is done in
+// the background, so that Set does not wait for STATE_DB, and only the latest master of a role
+// is written.
+func publishMasterEID(role string, eid uint128) {
+	maPublisher.Do(func() { go publishMasterEIDs() })
+
+	maPendingMu.Lock()
+	maPending[role] = map[string]interface{}{
+		"election_id_high": eid.High,
+		"election_id_low":  eid.Low,
+		"last_update":      time.Now().UTC().Format(time.RFC3339),
+	}
+	maPendingMu.Unlock()
+
+	select {
+	case maPublish <- struct{}{}:
+	default:
+	}
+}
+
+// publishMasterEIDs writes the pending masters to STATE_DB whenever woken.
+func publishMasterEIDs() {
+	for range maPublish {
+		maPendingMu.Lock()
+		pending := maPending
+		maPending = map[string]map[string]interface{}{}
+		client, separator := maClient, maSeparator
+		maPendingMu.Unlock()
+
+		if client == nil {
+			log.Errorf("MA: STATE_DB is not connected, masters of %d roles are not published", len(pending))
+			continue
+		}
+		for role, fields := range pending {
+			if err := client.HMSet(masterArbitrationTable+separator+role, fields).Err(); err != nil {
+				log.Errorf("MA: failed to publish master of role %v to STATE_DB: %v", role, err)
+			}
+		}
+	}
+}
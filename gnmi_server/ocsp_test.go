@@ -0,0 +1,353 @@
+package gnmi
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sonic-net/sonic-gnmi/common_utils"
+	"golang.org/x/crypto/ocsp"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// testOcspResponder is a local OCSP responder answering with the status set per serial number,
+// signing its responses with the CA key or, when set, with the signer certificate.
+type testOcspResponder struct {
+	ca         *x509.Certificate
+	caKey      crypto.Signer
+	signer     *x509.Certificate
+	signerKey  crypto.Signer
+	status     map[int64]int
+	nextUpdate time.Duration
+	queries    int32
+}
+
+func (r *testOcspResponder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	atomic.AddInt32(&r.queries, 1)
+	body, _ := io.ReadAll(req.Body)
+	ocspReq, err := ocsp.ParseRequest(body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	r.respond(w, ocspReq.SerialNumber)
+}
+
+func (r *testOcspResponder) response(serial *big.Int) []byte {
+	template := ocsp.Response{
+		Status:       ocsp.Unknown,
+		SerialNumber: serial,
+		ThisUpdate:   time.Now().Add(-time.Minute),
+	}
+	if st, ok := r.status[serial.Int64()]; ok {
+		template.Status = st
+	}
+	if template.Status == ocsp.Revoked {
+		template.RevokedAt = time.Now().Add(-time.Hour)
+	}
+	if r.nextUpdate != 0 {
+		template.NextUpdate = time.Now().Add(r.nextUpdate)
+	}
+	if r.signer != nil {
+		template.Certificate = r.signer
+		der, _ := ocsp.CreateResponse(r.ca, r.signer, template, r.signerKey)
+		return der
+	}
+	der, _ := ocsp.CreateResponse(r.ca, r.ca, template, r.caKey)
+	return der
+}
+
+func (r *testOcspResponder) respond(w http.ResponseWriter, serial *big.Int) {
+	w.Header().Set("Content-Type", "application/ocsp-response")
+	w.Write(r.response(serial))
+}
+
+func newTestCert(t *testing.T, serial int64, template *x509.Certificate, parent *x509.Certificate, parentKey crypto.Signer) (*x509.Certificate, crypto.Signer) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template.SerialNumber = big.NewInt(serial)
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), parentKey)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return cert, key
+}
+
+func ocspCounters() [common_utils.COUNTER_SIZE]uint64 {
+	var counters [common_utils.COUNTER_SIZE]uint64
+	common_utils.GetMemCounters(&counters)
+	return counters
+}
+
+func TestVerifyCertOcsp(t *testing.T) {
+	common_utils.InitCounters()
+	defer ReleaseOcspCache()
+	defer SetOcspPolicy(GetOcspPolicy())
+
+	ca, caKey := newTestCert(t, 1, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "ca"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}, nil, nil)
+	responder := &testOcspResponder{ca: ca, caKey: caKey, status: map[int64]int{10: ocsp.Good, 11: ocsp.Revoked}, nextUpdate: time.Hour}
+	server := httptest.NewServer(responder)
+	defer server.Close()
+
+	client := func(serial int64, ocspServer string) tls.ConnectionState {
+		leaf, _ := newTestCert(t, serial, &x509.Certificate{
+			Subject:    pkix.Name{CommonName: "certname1"},
+			OCSPServer: []string{ocspServer},
+		}, ca, caKey)
+		return tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{leaf, ca}}}
+	}
+
+	tests := []struct {
+		desc      string
+		policy    string
+		state     tls.ConnectionState
+		wantErr   bool
+		wantCount common_utils.CounterType
+	}{
+		{"good certificate", OCSP_HARD_FAIL, client(10, server.URL), false, common_utils.OCSP_GOOD},
+		{"revoked certificate", OCSP_SOFT_FAIL, client(11, server.URL), true, common_utils.OCSP_REVOKED},
+		{"unknown certificate soft-fail", OCSP_SOFT_FAIL, client(12, server.URL), false, common_utils.OCSP_UNKNOWN},
+		{"unknown certificate hard-fail", OCSP_HARD_FAIL, client(13, server.URL), true, common_utils.OCSP_UNKNOWN},
+		{"responder down soft-fail", OCSP_SOFT_FAIL, client(14, "http://127.0.0.1:1/ocsp"), false, common_utils.OCSP_FAIL},
+		{"responder down hard-fail", OCSP_HARD_FAIL, client(15, "http://127.0.0.1:1/ocsp"), true, common_utils.OCSP_FAIL},
+		{"no issuer hard-fail", OCSP_HARD_FAIL, tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{ca}}}, true, common_utils.OCSP_FAIL},
+	}
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			if err := SetOcspPolicy(test.policy); err != nil {
+				t.Fatalf("SetOcspPolicy failed: %v", err)
+			}
+			before := ocspCounters()
+			err := VerifyCertOcsp(test.state)
+			if (err != nil) != test.wantErr {
+				t.Errorf("VerifyCertOcsp returned %v, want error %v", err, test.wantErr)
+			}
+			if after := ocspCounters(); after[test.wantCount] != before[test.wantCount]+1 {
+				t.Errorf("Counter %v not incremented", test.wantCount)
+			}
+		})
+	}
+
+	if err := SetOcspPolicy("never-fail"); err == nil {
+		t.Errorf("Expected invalid OCSP policy to fail")
+	}
+}
+
+func TestOcspResponseCache(t *testing.T) {
+	defer ReleaseOcspCache()
+	defer SetOcspPolicy(GetOcspPolicy())
+	SetOcspPolicy(OCSP_HARD_FAIL)
+
+	ca, caKey := newTestCert(t, 1, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "ca"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}, nil, nil)
+	responder := &testOcspResponder{ca: ca, caKey: caKey, status: map[int64]int{20: ocsp.Good, 21: ocsp.Good}, nextUpdate: time.Hour}
+	server := httptest.NewServer(responder)
+	defer server.Close()
+	leaf, _ := newTestCert(t, 20, &x509.Certificate{Subject: pkix.Name{CommonName: "certname1"}, OCSPServer: []string{server.URL}}, ca, caKey)
+	state := tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{leaf, ca}}}
+
+	// The response is cached until its nextUpdate
+	for i := 0; i < 3; i++ {
+		if err := VerifyCertOcsp(state); err != nil {
+			t.Fatalf("VerifyCertOcsp failed: %v", err)
+		}
+	}
+	if queries := atomic.LoadInt32(&responder.queries); queries != 1 {
+		t.Errorf("Expected 1 OCSP query with cached response, got %d", queries)
+	}
+
+	ocspCache.Lock()
+	ocspCache.entries[ocspCacheKey(leaf, ca)].expires = time.Now().Add(-time.Second)
+	ocspCache.Unlock()
+	if err := VerifyCertOcsp(state); err != nil {
+		t.Fatalf("VerifyCertOcsp failed: %v", err)
+	}
+	if queries := atomic.LoadInt32(&responder.queries); queries != 2 {
+		t.Errorf("Expected responder to be queried again after nextUpdate, got %d queries", queries)
+	}
+
+	// Responses without nextUpdate are cached for OcspResponseTTL
+	ReleaseOcspCache()
+	responder.nextUpdate = 0
+	VerifyCertOcsp(state)
+	VerifyCertOcsp(state)
+	if queries := atomic.LoadInt32(&responder.queries); queries != 3 {
+		t.Errorf("Expected responses without nextUpdate to be cached, got %d queries", queries)
+	}
+	ocspCache.Lock()
+	entry := ocspCache.entries[ocspCacheKey(leaf, ca)]
+	ocspCache.Unlock()
+	if ttl := time.Until(entry.expires); ttl <= 0 || ttl > OcspResponseTTL {
+		t.Errorf("Expected response without nextUpdate to expire within %v, got %v", OcspResponseTTL, ttl)
+	}
+	ReleaseOcspCache()
+	VerifyCertOcsp(state)
+	if queries := atomic.LoadInt32(&responder.queries); queries != 4 {
+		t.Errorf("Expected responder to be queried again, got %d queries", queries)
+	}
+
+	// A stapled response is ignored, the responder is queried and its revoked status wins
+	responder.status[21] = ocsp.Revoked
+	stapledLeaf, _ := newTestCert(t, 21, &x509.Certificate{Subject: pkix.Name{CommonName: "certname1"}, OCSPServer: []string{server.URL}}, ca, caKey)
+	stapled := tls.ConnectionState{
+		VerifiedChains: [][]*x509.Certificate{{stapledLeaf, ca}},
+		OCSPResponse:   (&testOcspResponder{ca: ca, caKey: caKey, status: map[int64]int{21: ocsp.Good}, nextUpdate: time.Hour}).response(stapledLeaf.SerialNumber),
+	}
+	ctx := peer.NewContext(context.Background(), &peer.Peer{AuthInfo: credentials.TLSInfo{State: stapled}})
+	if err := VerifyPeerCertOcsp(ctx); err == nil {
+		t.Errorf("Expected revoked certificate with stapled good response to fail")
+	}
+	if queries := atomic.LoadInt32(&responder.queries); queries != 5 {
+		t.Errorf("Expected responder to be queried despite the stapled response, got %d queries", queries)
+	}
+}
+
+func TestOcspCacheSize(t *testing.T) {
+	defer ReleaseOcspCache()
+	ReleaseOcspCache()
+
+	resp := func(nextUpdate time.Duration) *ocsp.Response {
+		return &ocsp.Response{Status: ocsp.Good, NextUpdate: time.Now().Add(nextUpdate)}
+	}
+	appendOcspCache("expired", resp(-time.Second))
+	appendOcspCache("first", resp(time.Minute))
+	for i := 2; i < ocspCacheSize; i++ {
+		appendOcspCache(fmt.Sprintf("key%d", i), resp(time.Hour))
+	}
+
+	// The expired response is removed first, then the response expiring first
+	appendOcspCache("new1", resp(time.Hour))
+	appendOcspCache("new2", resp(time.Hour))
+	ocspCache.Lock()
+	defer ocspCache.Unlock()
+	if len(ocspCache.entries) != ocspCacheSize {
+		t.Errorf("Expected %d cached responses, got %d", ocspCacheSize, len(ocspCache.entries))
+	}
+	for key, want := range map[string]bool{"expired": false, "first": false, "key2": true, "new1": true, "new2": true} {
+		if _, exist := ocspCache.entries[key]; exist != want {
+			t.Errorf("Cached response %v exists %v, want %v", key, exist, want)
+		}
+	}
+}
+
+func TestOcspFailureCache(t *testing.T) {
+	common_utils.InitCounters()
+	defer ReleaseOcspCache()
+	defer SetOcspPolicy(GetOcspPolicy())
+	SetOcspPolicy(OCSP_SOFT_FAIL)
+
+	ca, caKey := newTestCert(t, 1, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "ca"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}, nil, nil)
+	var queries int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&queries, 1)
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	leaf, _ := newTestCert(t, 40, &x509.Certificate{Subject: pkix.Name{CommonName: "certname1"}, OCSPServer: []string{server.URL}}, ca, caKey)
+	state := tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{leaf, ca}}}
+
+	// A failed query is not repeated until OcspFailureTTL has passed
+	before := ocspCounters()
+	for i := 0; i < 3; i++ {
+		if err := VerifyCertOcsp(state); err != nil {
+			t.Fatalf("VerifyCertOcsp failed in soft-fail mode: %v", err)
+		}
+	}
+	if queries := atomic.LoadInt32(&queries); queries != 1 {
+		t.Errorf("Expected 1 OCSP query with cached failure, got %d", queries)
+	}
+	if after := ocspCounters(); after[common_utils.OCSP_FAIL] != before[common_utils.OCSP_FAIL]+3 {
+		t.Errorf("Expected every verification to count as failed")
+	}
+	SetOcspPolicy(OCSP_HARD_FAIL)
+	if err := VerifyCertOcsp(state); err == nil {
+		t.Errorf("Expected cached failure to be rejected in hard-fail mode")
+	}
+
+	ocspCache.Lock()
+	ocspCache.entries[ocspCacheKey(leaf, ca)].expires = time.Now().Add(-time.Second)
+	ocspCache.Unlock()
+	VerifyCertOcsp(state)
+	if queries := atomic.LoadInt32(&queries); queries != 2 {
+		t.Errorf("Expected responder to be queried again after OcspFailureTTL, got %d queries", queries)
+	}
+}
+
+func TestOcspDelegatedResponder(t *testing.T) {
+	common_utils.InitCounters()
+	defer ReleaseOcspCache()
+	defer SetOcspPolicy(GetOcspPolicy())
+	SetOcspPolicy(OCSP_HARD_FAIL)
+
+	ca, caKey := newTestCert(t, 1, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "ca"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}, nil, nil)
+	responder := &testOcspResponder{ca: ca, caKey: caKey, status: map[int64]int{30: ocsp.Good, 31: ocsp.Good}, nextUpdate: time.Hour}
+	server := httptest.NewServer(responder)
+	defer server.Close()
+	state := func(serial int64) tls.ConnectionState {
+		leaf, _ := newTestCert(t, serial, &x509.Certificate{Subject: pkix.Name{CommonName: "certname1"}, OCSPServer: []string{server.URL}}, ca, caKey)
+		return tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{leaf, ca}}}
+	}
+
+	// A client certificate of the same CA can't vouch for a revoked certificate
+	responder.signer, responder.signerKey = newTestCert(t, 2, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "certname2"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca, caKey)
+	before := ocspCounters()
+	if err := VerifyCertOcsp(state(30)); err == nil {
+		t.Errorf("Expected response signed by a leaf certificate to be rejected")
+	}
+	if after := ocspCounters(); after[common_utils.OCSP_FAIL] != before[common_utils.OCSP_FAIL]+1 {
+		t.Errorf("Counter OCSP_FAIL not incremented")
+	}
+
+	// A responder delegated OCSP signing by the CA is trusted
+	responder.signer, responder.signerKey = newTestCert(t, 3, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "ocsp"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageOCSPSigning},
+	}, ca, caKey)
+	if err := VerifyCertOcsp(state(31)); err != nil {
+		t.Errorf("VerifyCertOcsp with delegated responder failed: %v", err)
+	}
+}
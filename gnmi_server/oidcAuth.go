@@ -0,0 +1,345 @@
+package gnmi
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/golang/glog"
+	"github.com/sonic-net/sonic-gnmi/common_utils"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// Claim holding the user name when OidcConfig.UsernameClaim is unset
+	oidcDefaultUsernameClaim = "sub"
+	// Claim holding the groups or roles when OidcConfig.RolesClaim is unset
+	oidcDefaultRolesClaim = "groups"
+)
+
+// OidcConfig describes the identity provider whose tokens are accepted by the oidc client_auth mode.
+type OidcConfig struct {
+	// Issuer the "iss" claim must match
+	Issuer string
+	// Audience the "aud" claim must contain, not checked when empty
+	Audience string
+	// JWKS file with the provider's public keys, reloaded when it changes
+	JwksFile string
+	// Claim holding the user name, "sub" when empty
+	UsernameClaim string
+	// Claim holding the user's groups or roles, "groups" when empty.
+	// Nested claims are given with dots, e.g. "realm_access.roles".
+	RolesClaim string
+	// Maps groups to roles. When set, groups without an entry grant no role;
+	// otherwise the groups are used as roles.
+	RoleMap map[string][]string
+
+	mu      sync.Mutex
+	modTime time.Time
+	keys    map[string]*oidcKey
+}
+
+// oidcKey is a public key of the identity provider.
+type oidcKey struct {
+	alg string
+	key interface{}
+}
+
+// jsonWebKey is a key of a JWKS file, as defined by RFC 7517.
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// ParseOidcRoleMap parses a role map of the form "group1=role1,role2;group2=role3".
+func ParseOidcRoleMap(spec string) (map[string][]string, error) {
+	roleMap := map[string][]string{}
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		group := strings.TrimSpace(parts[0])
+		if len(parts) != 2 || group == "" {
+			return nil, fmt.Errorf("invalid role map entry %q, expecting group=role[,role...]", entry)
+		}
+		for _, role := range strings.Split(parts[1], ",") {
+			if role = strings.TrimSpace(role); role != "" {
+				roleMap[group] = append(roleMap[group], role)
+			}
+		}
+		if len(roleMap[group]) == 0 {
+			return nil, fmt.Errorf("no roles given for group %v", group)
+		}
+	}
+	return roleMap, nil
+}
+
+func decodeJwkInt(field string, value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+	if err != nil || len(data) == 0 {
+		return nil, fmt.Errorf("invalid %q", field)
+	}
+	return new(big.Int).SetBytes(data), nil
+}
+
+// parseJwk returns the public key of a JWK. Only RSA and EC signing keys are supported,
+// so that a public key can never be used as an HMAC secret.
+func parseJwk(jwk *jsonWebKey) (*oidcKey, error) {
+	switch jwk.Kty {
+	case "RSA":
+		n, err := decodeJwkInt("n", jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeJwkInt("e", jwk.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("invalid RSA exponent")
+		}
+		return &oidcKey{alg: jwk.Alg, key: &rsa.PublicKey{N: n, E: int(e.Int64())}}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", jwk.Crv)
+		}
+		x, err := decodeJwkInt("x", jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeJwkInt("y", jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("point is not on curve %v", jwk.Crv)
+		}
+		return &oidcKey{alg: jwk.Alg, key: &ecdsa.PublicKey{Curve: curve, X: x, Y: y}}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", jwk.Kty)
+}
+
+// LoadJwks reads the JWKS file. Keys that are not signing keys or of an unsupported type are skipped,
+// but a file without any usable key is an error.
+func (c *OidcConfig) LoadJwks() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.loadJwksLocked()
+}
+
+func (c *OidcConfig) loadJwksLocked() error {
+	info, err := os.Stat(c.JwksFile)
+	if err != nil {
+		return err
+	}
+	data, err := ioutil.ReadFile(c.JwksFile)
+	if err != nil {
+		return err
+	}
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &jwks); err != nil {
+		return fmt.Errorf("invalid JWKS file %v: %v", c.JwksFile, err)
+	}
+
+	keys := map[string]*oidcKey{}
+	for i := range jwks.Keys {
+		jwk := &jwks.Keys[i]
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := parseJwk(jwk)
+		if err != nil {
+			glog.Warningf("Skipping key %q of JWKS file %v: %v", jwk.Kid, c.JwksFile, err)
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	if len(keys) == 0 {
+		return fmt.Errorf("no usable signing key in JWKS file %v", c.JwksFile)
+	}
+
+	c.keys = keys
+	c.modTime = info.ModTime()
+	glog.V(1).Infof("Loaded %d keys from JWKS file %v", len(keys), c.JwksFile)
+	return nil
+}
+
+// currentKeys returns the provider keys, reloading the JWKS file when it has changed.
+// The keys loaded last are kept if the changed file can't be loaded.
+func (c *OidcConfig) currentKeys() map[string]*oidcKey {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if info, err := os.Stat(c.JwksFile); err == nil && !info.ModTime().Equal(c.modTime) {
+		if err := c.loadJwksLocked(); err != nil {
+			glog.Errorf("Failed to reload JWKS file: %v", err)
+		}
+	}
+	return c.keys
+}
+
+// verifyKey returns the provider key a token is signed with.
+func (c *OidcConfig) verifyKey(token *jwt.Token) (interface{}, error) {
+	keys := c.currentKeys()
+	kid, _ := token.Header["kid"].(string)
+	key, ok := keys[kid]
+	if !ok {
+		if kid != "" || len(keys) != 1 {
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+		for _, k := range keys {
+			key = k
+		}
+	}
+	if key.alg != "" && key.alg != token.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method %v", token.Method.Alg())
+	}
+	switch key.key.(type) {
+	case *rsa.PublicKey:
+		switch token.Method.(type) {
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+			return key.key, nil
+		}
+	case *ecdsa.PublicKey:
+		if _, ok := token.Method.(*jwt.SigningMethodECDSA); ok {
+			return key.key, nil
+		}
+	}
+	return nil, fmt.Errorf("unexpected signing method %v", token.Method.Alg())
+}
+
+// lookupClaim returns the value of a claim, following dots into nested claims.
+func lookupClaim(claims jwt.MapClaims, name string) interface{} {
+	var value interface{} = map[string]interface{}(claims)
+	for _, part := range strings.Split(name, ".") {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = m[part]
+	}
+	return value
+}
+
+// parseOidcToken validates a token of the identity provider, and returns the user name and roles it grants.
+func (c *OidcConfig) parseOidcToken(tokenString string) (string, []string, error) {
+	claims := jwt.MapClaims{}
+	if _, err := jwt.ParseWithClaims(tokenString, claims, c.verifyKey); err != nil {
+		return "", nil, err
+	}
+	if !claims.VerifyExpiresAt(time.Now().Unix(), true) {
+		return "", nil, fmt.Errorf("token has no expiry")
+	}
+	if !claims.VerifyIssuer(c.Issuer, true) {
+		return "", nil, fmt.Errorf("unexpected issuer %v", claims["iss"])
+	}
+	if c.Audience != "" && !claims.VerifyAudience(c.Audience, true) {
+		return "", nil, fmt.Errorf("token is not issued for audience %v", c.Audience)
+	}
+
+	usernameClaim := c.UsernameClaim
+	if usernameClaim == "" {
+		usernameClaim = oidcDefaultUsernameClaim
+	}
+	username, _ := lookupClaim(claims, usernameClaim).(string)
+	if username == "" {
+		return "", nil, fmt.Errorf("token has no %q claim", usernameClaim)
+	}
+
+	rolesClaim := c.RolesClaim
+	if rolesClaim == "" {
+		rolesClaim = oidcDefaultRolesClaim
+	}
+	var groups []string
+	switch v := lookupClaim(claims, rolesClaim).(type) {
+	case string:
+		groups = strings.Fields(v)
+	case []interface{}:
+		for _, g := range v {
+			if s, ok := g.(string); ok {
+				groups = append(groups, s)
+			}
+		}
+	}
+
+	if len(c.RoleMap) == 0 {
+		return username, groups, nil
+	}
+	var roles []string
+	for _, g := range groups {
+		roles = append(roles, c.RoleMap[g]...)
+	}
+	return username, roles, nil
+}
+
+// bearerToken returns the token of an "authorization: Bearer" header, or of the access_token metadata.
+func bearerToken(md metadata.MD) string {
+	for _, v := range md["authorization"] {
+		if len(v) > 7 && strings.EqualFold(v[:7], "bearer ") {
+			return strings.TrimSpace(v[7:])
+		}
+	}
+	if v := md["access_token"]; len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+// OidcAuthenAndAuthor authenticates the caller with a token of the identity provider,
+// taking the roles of the user from the token's claims.
+func OidcAuthenAndAuthor(ctx context.Context, config *OidcConfig) (context.Context, error) {
+	rc, ctx := common_utils.GetContext(ctx)
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx, status.Errorf(codes.Unknown, "Invalid context")
+	}
+
+	tokenString := bearerToken(md)
+	if tokenString == "" {
+		return ctx, status.Errorf(codes.Unauthenticated, "No OIDC Token Provided")
+	}
+
+	username, roles, err := config.parseOidcToken(tokenString)
+	if err != nil {
+		glog.Infof("[%s] Invalid OIDC token; %v", rc.ID, err)
+		return ctx, status.Errorf(codes.Unauthenticated, "Invalid OIDC Token: %v", err)
+	}
+	if len(roles) == 0 {
+		return ctx, status.Errorf(codes.Unauthenticated, "OIDC Token of %v grants no role", username)
+	}
+
+	rc.Auth.User = username
+	rc.Auth.Roles = roles
+	return ctx, nil
+}
@@ -0,0 +1,253 @@
+package gnmi
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/sonic-net/sonic-gnmi/common_utils"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/metadata"
+)
+
+const oidcTestIssuer = "https://idp.example.com"
+
+func jwkInt(i *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(i.Bytes())
+}
+
+func writeJwksFile(t *testing.T, fileName string, keys ...map[string]string) {
+	data, _ := json.Marshal(map[string]interface{}{"keys": keys})
+	if err := ioutil.WriteFile(fileName, data, 0600); err != nil {
+		t.Fatalf("Failed to write JWKS file: %v", err)
+	}
+}
+
+func signOidcToken(t *testing.T, method jwt.SigningMethod, kid string, key interface{}, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	tokenString, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("Failed to sign token: %v", err)
+	}
+	return tokenString
+}
+
+func oidcCtx(tokenString string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+tokenString))
+}
+
+func TestOidcAuthenAndAuthor(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	jwksFile := filepath.Join(t.TempDir(), "jwks.json")
+	writeJwksFile(t, jwksFile,
+		map[string]string{"kty": "RSA", "kid": "rsa1", "use": "sig", "alg": "RS256",
+			"n": jwkInt(rsaKey.N), "e": jwkInt(big.NewInt(int64(rsaKey.E)))},
+		map[string]string{"kty": "EC", "kid": "ec1", "crv": "P-256",
+			"x": jwkInt(ecKey.X), "y": jwkInt(ecKey.Y)},
+		map[string]string{"kty": "oct", "kid": "secret", "k": "c2VjcmV0"})
+
+	config := &OidcConfig{Issuer: oidcTestIssuer, Audience: "sonic", JwksFile: jwksFile}
+	if err := config.LoadJwks(); err != nil {
+		t.Fatalf("LoadJwks failed: %v", err)
+	}
+
+	validClaims := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss":    oidcTestIssuer,
+			"aud":    []string{"sonic", "other"},
+			"sub":    "alice",
+			"exp":    time.Now().Add(time.Hour).Unix(),
+			"groups": []string{"gnmi_readwrite", "gnoi_readonly"},
+		}
+	}
+
+	tests := []struct {
+		desc  string
+		token func() string
+		user  string
+		roles []string
+	}{
+		{
+			desc:  "RS256 token",
+			token: func() string { return signOidcToken(t, jwt.SigningMethodRS256, "rsa1", rsaKey, validClaims()) },
+			user:  "alice",
+			roles: []string{"gnmi_readwrite", "gnoi_readonly"},
+		},
+		{
+			desc:  "ES256 token",
+			token: func() string { return signOidcToken(t, jwt.SigningMethodES256, "ec1", ecKey, validClaims()) },
+			user:  "alice",
+			roles: []string{"gnmi_readwrite", "gnoi_readonly"},
+		},
+		{
+			desc: "expired token",
+			token: func() string {
+				claims := validClaims()
+				claims["exp"] = time.Now().Add(-time.Minute).Unix()
+				return signOidcToken(t, jwt.SigningMethodRS256, "rsa1", rsaKey, claims)
+			},
+		},
+		{
+			desc: "token without expiry",
+			token: func() string {
+				claims := validClaims()
+				delete(claims, "exp")
+				return signOidcToken(t, jwt.SigningMethodRS256, "rsa1", rsaKey, claims)
+			},
+		},
+		{
+			desc: "token of other issuer",
+			token: func() string {
+				claims := validClaims()
+				claims["iss"] = "https://other.example.com"
+				return signOidcToken(t, jwt.SigningMethodRS256, "rsa1", rsaKey, claims)
+			},
+		},
+		{
+			desc: "token for other audience",
+			token: func() string {
+				claims := validClaims()
+				claims["aud"] = "other"
+				return signOidcToken(t, jwt.SigningMethodRS256, "rsa1", rsaKey, claims)
+			},
+		},
+		{
+			desc: "token of unknown key",
+			token: func() string {
+				otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+				return signOidcToken(t, jwt.SigningMethodRS256, "rsa2", otherKey, validClaims())
+			},
+		},
+		{
+			desc:  "token signed with the method of another key",
+			token: func() string { return signOidcToken(t, jwt.SigningMethodES256, "rsa1", ecKey, validClaims()) },
+		},
+		{
+			desc: "token signed with an unsupported key type",
+			token: func() string {
+				return signOidcToken(t, jwt.SigningMethodHS256, "secret", []byte("secret"), validClaims())
+			},
+		},
+		{
+			desc: "token without groups",
+			token: func() string {
+				claims := validClaims()
+				delete(claims, "groups")
+				return signOidcToken(t, jwt.SigningMethodRS256, "rsa1", rsaKey, claims)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			ctx, err := OidcAuthenAndAuthor(oidcCtx(test.token()), config)
+			if test.user == "" {
+				if err == nil {
+					t.Errorf("Expected token to be rejected")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected token to be accepted: %v", err)
+			}
+			rc, _ := common_utils.GetContext(ctx)
+			if rc.Auth.User != test.user || !reflect.DeepEqual(rc.Auth.Roles, test.roles) {
+				t.Errorf("Got user %v roles %v, want %v %v", rc.Auth.User, rc.Auth.Roles, test.user, test.roles)
+			}
+		})
+	}
+
+	// Groups are mapped to roles from a nested claim
+	config.RolesClaim = "realm_access.roles"
+	config.UsernameClaim = "preferred_username"
+	config.RoleMap, _ = ParseOidcRoleMap("netops=gnmi_readwrite,gnoi_readwrite; viewers=gnmi_readonly")
+	claims := validClaims()
+	claims["preferred_username"] = "bob"
+	claims["realm_access"] = map[string]interface{}{"roles": []string{"viewers", "unmapped"}}
+	ctx, err := OidcAuthenAndAuthor(oidcCtx(signOidcToken(t, jwt.SigningMethodRS256, "rsa1", rsaKey, claims)), config)
+	if err != nil {
+		t.Fatalf("Expected token to be accepted: %v", err)
+	}
+	rc, _ := common_utils.GetContext(ctx)
+	if rc.Auth.User != "bob" || !reflect.DeepEqual(rc.Auth.Roles, []string{"gnmi_readonly"}) {
+		t.Errorf("Got user %v roles %v", rc.Auth.User, rc.Auth.Roles)
+	}
+
+	// and the roles are checked against the target
+	cfg := &Config{UserAuth: AuthTypes{"password": false, "cert": false, "jwt": false, "oidc": true}, Oidc: config}
+	tokenCtx := oidcCtx(signOidcToken(t, jwt.SigningMethodRS256, "rsa1", rsaKey, claims))
+	if _, err := authenticate(cfg, tokenCtx, "gnmi", false); err != nil {
+		t.Errorf("authenticate with readonly role should pass: %v", err)
+	}
+	if _, err := authenticate(cfg, tokenCtx, "gnmi", true); err == nil {
+		t.Errorf("authenticate with readonly role should fail for write access")
+	}
+	if _, err := authenticate(cfg, metadata.NewIncomingContext(context.Background(), metadata.MD{}), "gnmi", false); err == nil {
+		t.Errorf("authenticate without token should fail")
+	}
+}
+
+func TestOidcJwksReload(t *testing.T) {
+	oldKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	newKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	jwk := func(kid string, key *ecdsa.PrivateKey) map[string]string {
+		return map[string]string{"kty": "EC", "kid": kid, "crv": "P-256", "x": jwkInt(key.X), "y": jwkInt(key.Y)}
+	}
+	jwksFile := filepath.Join(t.TempDir(), "jwks.json")
+	writeJwksFile(t, jwksFile, jwk("old", oldKey))
+
+	config := &OidcConfig{Issuer: oidcTestIssuer, JwksFile: jwksFile}
+	if err := config.LoadJwks(); err != nil {
+		t.Fatalf("LoadJwks failed: %v", err)
+	}
+	claims := jwt.MapClaims{"iss": oidcTestIssuer, "sub": "alice", "groups": "gnmi_readonly", "exp": time.Now().Add(time.Hour).Unix()}
+	oldToken := signOidcToken(t, jwt.SigningMethodES256, "", oldKey, claims)
+	newToken := signOidcToken(t, jwt.SigningMethodES256, "new", newKey, claims)
+
+	if _, err := OidcAuthenAndAuthor(oidcCtx(oldToken), config); err != nil {
+		t.Errorf("Token without kid rejected with a single key: %v", err)
+	}
+	if _, err := OidcAuthenAndAuthor(oidcCtx(newToken), config); err == nil {
+		t.Errorf("Token of key not yet in JWKS file accepted")
+	}
+
+	writeJwksFile(t, jwksFile, jwk("new", newKey))
+	future := time.Now().Add(time.Minute)
+	os.Chtimes(jwksFile, future, future)
+	if _, err := OidcAuthenAndAuthor(oidcCtx(newToken), config); err != nil {
+		t.Errorf("Token of key added to JWKS file rejected: %v", err)
+	}
+	if _, err := OidcAuthenAndAuthor(oidcCtx(oldToken), config); err == nil {
+		t.Errorf("Token of key removed from JWKS file accepted")
+	}
+
+	// An invalid file keeps the keys loaded last
+	ioutil.WriteFile(jwksFile, []byte("{}"), 0600)
+	future = future.Add(time.Minute)
+	os.Chtimes(jwksFile, future, future)
+	if _, err := OidcAuthenAndAuthor(oidcCtx(newToken), config); err != nil {
+		t.Errorf("Token rejected after invalid JWKS update: %v", err)
+	}
+	if err := config.LoadJwks(); err == nil {
+		t.Errorf("Expected JWKS file without keys to fail")
+	}
+
+	if _, err := ParseOidcRoleMap("netops"); err == nil {
+		t.Errorf("Expected role map entry without roles to fail")
+	}
+}
@@ -0,0 +1,196 @@
+package gnmi
+
+// qos_cli_test.go
+
+// Tests SHOW queue/counters, SHOW queue/watermark, SHOW pfc/counters and SHOW priority-group/watermark
+
+import (
+	"crypto/tls"
+	"testing"
+	"time"
+
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+
+	"github.com/agiledragon/gomonkey/v2"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+)
+
+func TestGetQosCounters(t *testing.T) {
+	s := createServer(t, ServerPort)
+	go runServer(t, s)
+	defer s.ForceStop()
+	defer ResetDataSetsAndMappings(t)
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: true}
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig))}
+
+	conn, err := grpc.Dial(TargetAddr, opts...)
+	if err != nil {
+		t.Fatalf("Dialing to %q failed: %v", TargetAddr, err)
+	}
+	defer conn.Close()
+
+	gClient := pb.NewGNMIClient(conn)
+	ctx, cancel := context.WithTimeout(context.Background(), QueryTimeout*time.Second)
+	defer cancel()
+
+	portsFileName := "../testdata/PORTS.txt"
+	portOidMappingFileName := "../testdata/PORT_COUNTERS_MAPPING.txt"
+	portCountersFileName := "../testdata/PORT_COUNTERS.txt"
+	portCountersTwoFileName := "../testdata/PORT_COUNTERS_TWO.txt"
+	queueCountersFileName := "../testdata/QUEUE_COUNTERS.txt"
+	queueCountersTwoFileName := "../testdata/QUEUE_COUNTERS_TWO.txt"
+	pgWatermarksFileName := "../testdata/PG_WATERMARKS.txt"
+
+	queueCountersAll := `{"Ethernet0":{"UC0":{"totalpacket":"1200","totalbytes":"153600","droppacket":"3","dropbytes":"384"},"MC8":{"totalpacket":"40","totalbytes":"5120","droppacket":"0","dropbytes":"0"}},"Ethernet40":{"UC0":{"totalpacket":"500","totalbytes":"64000","droppacket":"1","dropbytes":"128"}}}`
+	queueCountersDiff := `{"Ethernet0":{"UC0":{"totalpacket":"300","totalbytes":"38400","droppacket":"2","dropbytes":"256"},"MC8":{"totalpacket":"2","totalbytes":"256","droppacket":"0","dropbytes":"0"}}}`
+	queueWatermark := `{"Ethernet0":{"UC0":"2048","MC8":"0"}}`
+	pfcCounters := `{"Rx":{"Ethernet0":{"PFC0":"0","PFC1":"0","PFC2":"0","PFC3":"0","PFC4":"0","PFC5":"0","PFC6":"0","PFC7":"0"}},"Tx":{"Ethernet0":{"PFC0":"0","PFC1":"0","PFC2":"0","PFC3":"0","PFC4":"0","PFC5":"0","PFC6":"0","PFC7":"0"}}}`
+	pgWatermarkAll := `{"Shared":{"Ethernet0":{"PG0":"0","PG3":"4096"},"Ethernet40":{"PG3":"8192"}},"Headroom":{"Ethernet0":{"PG0":"0","PG3":"512"},"Ethernet40":{"PG3":"N/A"}}}`
+	pgWatermarkSelectPort := `{"Shared":{"Ethernet40":{"PG3":"8192"}},"Headroom":{"Ethernet40":{"PG3":"N/A"}}}`
+
+	ResetDataSetsAndMappings(t)
+
+	tests := []struct {
+		desc        string
+		pathTarget  string
+		textPbPath  string
+		wantRetCode codes.Code
+		wantRespVal interface{}
+		valTest     bool
+		mockSleep   bool
+		testInit    func()
+	}{
+		{
+			desc:       "query SHOW queue counters",
+			pathTarget: "SHOW",
+			textPbPath: `
+				elem: <name: "queue" >
+				elem: <name: "counters" >
+			`,
+			wantRetCode: codes.OK,
+			wantRespVal: []byte(queueCountersAll),
+			valTest:     true,
+			testInit: func() {
+				AddDataSet(t, ConfigDbNum, portsFileName)
+				AddDataSet(t, CountersDbNum, portOidMappingFileName)
+				AddDataSet(t, CountersDbNum, portCountersFileName)
+				AddDataSet(t, CountersDbNum, queueCountersFileName)
+				AddDataSet(t, CountersDbNum, pgWatermarksFileName)
+			},
+		},
+		{
+			desc:       "query SHOW queue counters unknown interface",
+			pathTarget: "SHOW",
+			textPbPath: `
+				elem: <name: "queue" >
+				elem: <name: "counters" key: { key: "interface" value: "Ethernet80" }>
+			`,
+			wantRetCode: codes.NotFound,
+		},
+		{
+			desc:       "query SHOW queue counters period option",
+			pathTarget: "SHOW",
+			textPbPath: `
+				elem: <name: "queue" >
+				elem: <name: "counters"
+				      key: { key: "interface" value: "Ethernet0" }
+				      key: { key: "period" value: "5" }>
+			`,
+			wantRetCode: codes.OK,
+			wantRespVal: []byte(queueCountersDiff),
+			valTest:     true,
+			mockSleep:   true,
+		},
+		{
+			desc:       "query SHOW queue watermark interface option",
+			pathTarget: "SHOW",
+			textPbPath: `
+				elem: <name: "queue" >
+				elem: <name: "watermark" key: { key: "interface" value: "Ethernet0" }>
+			`,
+			wantRetCode: codes.OK,
+			wantRespVal: []byte(queueWatermark),
+			valTest:     true,
+		},
+		{
+			desc:       "query SHOW pfc counters interface option",
+			pathTarget: "SHOW",
+			textPbPath: `
+				elem: <name: "pfc" >
+				elem: <name: "counters" key: { key: "interface" value: "Ethernet0" }>
+			`,
+			wantRetCode: codes.OK,
+			wantRespVal: []byte(pfcCounters),
+			valTest:     true,
+		},
+		{
+			desc:       "query SHOW pfc counters period option",
+			pathTarget: "SHOW",
+			textPbPath: `
+				elem: <name: "pfc" >
+				elem: <name: "counters"
+				      key: { key: "interface" value: "Ethernet0" }
+				      key: { key: "period" value: "5" }>
+			`,
+			wantRetCode: codes.OK,
+			wantRespVal: []byte(pfcCounters),
+			valTest:     true,
+			mockSleep:   true,
+		},
+		{
+			desc:       "query SHOW pfc counters period too long",
+			pathTarget: "SHOW",
+			textPbPath: `
+				elem: <name: "pfc" >
+				elem: <name: "counters" key: { key: "period" value: "301" }>
+			`,
+			wantRetCode: codes.NotFound,
+		},
+		{
+			desc:       "query SHOW priority-group watermark",
+			pathTarget: "SHOW",
+			textPbPath: `
+				elem: <name: "priority-group" >
+				elem: <name: "watermark" >
+			`,
+			wantRetCode: codes.OK,
+			wantRespVal: []byte(pgWatermarkAll),
+			valTest:     true,
+		},
+		{
+			desc:       "query SHOW priority-group watermark interface option",
+			pathTarget: "SHOW",
+			textPbPath: `
+				elem: <name: "priority-group" >
+				elem: <name: "watermark" key: { key: "interface" value: "Ethernet40" }>
+			`,
+			wantRetCode: codes.OK,
+			wantRespVal: []byte(pgWatermarkSelectPort),
+			valTest:     true,
+		},
+	}
+
+	for _, test := range tests {
+		if test.testInit != nil {
+			test.testInit()
+		}
+		var patches *gomonkey.Patches
+		if test.mockSleep {
+			patches = gomonkey.ApplyFunc(time.Sleep, func(d time.Duration) {
+				AddDataSet(t, CountersDbNum, portCountersTwoFileName)
+				AddDataSet(t, CountersDbNum, queueCountersTwoFileName)
+			})
+		}
+
+		t.Run(test.desc, func(t *testing.T) {
+			runTestGet(t, ctx, gClient, test.pathTarget, test.textPbPath, test.wantRetCode, test.wantRespVal, test.valTest)
+		})
+		if patches != nil {
+			patches.Reset()
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package gnmi
+
+// route_cli_test.go
+
+// Tests SHOW ip bgp summary, SHOW ip bgp neighbors, SHOW ip route and SHOW ipv6 route
+
+import (
+	"crypto/tls"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+
+	"github.com/agiledragon/gomonkey/v2"
+	sdcfg "github.com/sonic-net/sonic-gnmi/sonic_db_config"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+)
+
+func TestGetShowRouting(t *testing.T) {
+	s := createServer(t, ServerPort)
+	go runServer(t, s)
+	defer s.ForceStop()
+	defer ResetDataSetsAndMappings(t)
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: true}
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig))}
+
+	conn, err := grpc.Dial(TargetAddr, opts...)
+	if err != nil {
+		t.Fatalf("Dialing to %q failed: %v", TargetAddr, err)
+	}
+	defer conn.Close()
+
+	gClient := pb.NewGNMIClient(conn)
+	ctx, cancel := context.WithTimeout(context.Background(), QueryTimeout*time.Second)
+	defer cancel()
+
+	readFile := func(fileName string) []byte {
+		content, err := ioutil.ReadFile(fileName)
+		if err != nil {
+			t.Fatalf("read file %v err: %v", fileName, err)
+		}
+		return content
+	}
+
+	bgpNeighborFileName := "../testdata/BGP_NEIGHBOR_IPV4.txt"
+	ipv4BGPSummary := `{"ipv4Unicast":{"routerId":"10.1.0.32","as":64601,"vrfId":0,"tableVersion":6402,"ribCount":6402,"ribMemory":819456,"peerCount":2,"peerMemory":48144,"peerGroupCount":2,"peerGroupMemory":128,"peers":{"10.0.0.1":{"version":4,"remoteAs":64802,"msgRcvd":9191,"msgSent":9195,"tableVersion":6402,"inq":0,"outq":0,"peerUptime":"4d03h44m","state":"Established","pfxRcd":6400,"NeighborName":"ARISTA01T1"},"10.0.0.5":{"version":4,"remoteAs":64802,"msgRcvd":0,"msgSent":0,"tableVersion":0,"inq":0,"outq":0,"peerUptime":"never","state":"Active","pfxRcd":0,"NeighborName":"NotAvailable"}}}}`
+
+	patches := MockNSEnterVtysh(t)
+	defer patches.Reset()
+
+	tests := []struct {
+		desc        string
+		pathTarget  string
+		textPbPath  string
+		wantRetCode codes.Code
+		wantRespVal interface{}
+		valTest     bool
+		multiAsic   bool
+		testInit    func()
+	}{
+		{
+			desc:       "query SHOW ip bgp summary",
+			pathTarget: "SHOW",
+			textPbPath: `
+				elem: <name: "ip" >
+				elem: <name: "bgp" >
+				elem: <name: "summary" >
+			`,
+			wantRetCode: codes.OK,
+			wantRespVal: []byte(ipv4BGPSummary),
+			valTest:     true,
+			testInit: func() {
+				AddDataSet(t, ConfigDbNum, bgpNeighborFileName)
+			},
+		},
+		{
+			desc:       "query SHOW ip bgp neighbors",
+			pathTarget: "SHOW",
+			textPbPath: `
+				elem: <name: "ip" >
+				elem: <name: "bgp" >
+				elem: <name: "neighbors" >
+			`,
+			wantRetCode: codes.OK,
+			wantRespVal: readFile("../testdata/VTYSH_SHOW_IP_BGP_NEIGHBORS_JSON.txt"),
+			valTest:     true,
+		},
+		{
+			desc:       "query SHOW ip route",
+			pathTarget: "SHOW",
+			textPbPath: `
+				elem: <name: "ip" >
+				elem: <name: "route" >
+			`,
+			wantRetCode: codes.OK,
+			wantRespVal: readFile("../testdata/VTYSH_SHOW_IP_ROUTE_JSON.txt"),
+			valTest:     true,
+		},
+		{
+			desc:       "query SHOW ip route with vrf and prefix",
+			pathTarget: "SHOW",
+			textPbPath: `
+				elem: <name: "ip" >
+				elem: <name: "route" key: { key: "vrf" value: "Vrf1" } key: { key: "prefix" value: "10.1.0.0/24" } >
+			`,
+			wantRetCode: codes.OK,
+			wantRespVal: readFile("../testdata/VTYSH_SHOW_IP_ROUTE_VRF_VRF1_10_1_0_0_24_JSON.txt"),
+			valTest:     true,
+		},
+		{
+			desc:       "query SHOW ip route with unknown vrf",
+			pathTarget: "SHOW",
+			textPbPath: `
+				elem: <name: "ip" >
+				elem: <name: "route" key: { key: "vrf" value: "Vrf2" } >
+			`,
+			wantRetCode: codes.NotFound,
+		},
+		{
+			desc:       "query SHOW ip route with invalid vrf",
+			pathTarget: "SHOW",
+			textPbPath: `
+				elem: <name: "ip" >
+				elem: <name: "route" key: { key: "vrf" value: "Vrf1 json; bash" } >
+			`,
+			wantRetCode: codes.InvalidArgument,
+		},
+		{
+			desc:       "query SHOW ip route with IPv6 prefix",
+			pathTarget: "SHOW",
+			textPbPath: `
+				elem: <name: "ip" >
+				elem: <name: "route" key: { key: "prefix" value: "fc00::/64" } >
+			`,
+			wantRetCode: codes.InvalidArgument,
+		},
+		{
+			desc:       "query SHOW ip route with namespace on single-ASIC",
+			pathTarget: "SHOW",
+			textPbPath: `
+				elem: <name: "ip" >
+				elem: <name: "route" key: { key: "namespace" value: "asic0" } >
+			`,
+			wantRetCode: codes.InvalidArgument,
+		},
+		{
+			desc:       "query SHOW ip route with namespace on multi-ASIC",
+			pathTarget: "SHOW",
+			textPbPath: `
+				elem: <name: "ip" >
+				elem: <name: "route" key: { key: "namespace" value: "asic1" } >
+			`,
+			wantRetCode: codes.OK,
+			wantRespVal: readFile("../testdata/VTYSH_SHOW_IP_ROUTE_JSON_ASIC1.txt"),
+			valTest:     true,
+			multiAsic:   true,
+		},
+		{
+			desc:       "query SHOW ip route without namespace on multi-ASIC",
+			pathTarget: "SHOW",
+			textPbPath: `
+				elem: <name: "ip" >
+				elem: <name: "route" >
+			`,
+			wantRetCode: codes.InvalidArgument,
+			multiAsic:   true,
+		},
+		{
+			desc:       "query SHOW ipv6 route",
+			pathTarget: "SHOW",
+			textPbPath: `
+				elem: <name: "ipv6" >
+				elem: <name: "route" >
+			`,
+			wantRetCode: codes.OK,
+			wantRespVal: readFile("../testdata/VTYSH_SHOW_IPV6_ROUTE_JSON.txt"),
+			valTest:     true,
+		},
+		{
+			desc:       "query SHOW ipv6 route with invalid prefix",
+			pathTarget: "SHOW",
+			textPbPath: `
+				elem: <name: "ipv6" >
+				elem: <name: "route" key: { key: "prefix" value: "fc00::/129" } >
+			`,
+			wantRetCode: codes.InvalidArgument,
+		},
+	}
+
+	for _, test := range tests {
+		if test.testInit != nil {
+			test.testInit()
+		}
+		var nsPatches *gomonkey.Patches
+		if test.multiAsic {
+			nsPatches = gomonkey.ApplyFuncReturn(sdcfg.GetDbNonDefaultNamespaces, []string{"asic0", "asic1"}, nil)
+		}
+		t.Run(test.desc, func(t *testing.T) {
+			runTestGet(t, ctx, gClient, test.pathTarget, test.textPbPath, test.wantRetCode, test.wantRespVal, test.valTest)
+		})
+		if nsPatches != nil {
+			nsPatches.Reset()
+		}
+	}
+}
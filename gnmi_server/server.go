@@ -13,6 +13,7 @@ import (
 	operationalhandler "github.com/sonic-net/sonic-gnmi/pkg/server/operational-handler"
 	spb "github.com/sonic-net/sonic-gnmi/proto"
 	spb_gnoi "github.com/sonic-net/sonic-gnmi/proto/gnoi"
+	spb_checkpoint_gnoi "github.com/sonic-net/sonic-gnmi/proto/gnoi/checkpoint"
 	spb_jwt_gnoi "github.com/sonic-net/sonic-gnmi/proto/gnoi/jwt"
 	_ "github.com/sonic-net/sonic-gnmi/show_client"
 	sdc "github.com/sonic-net/sonic-gnmi/sonic_data_client"
@@ -28,6 +29,7 @@ import (
 
 	gnoi_file_pb "github.com/openconfig/gnoi/file"
 	gnoi_os_pb "github.com/openconfig/gnoi/os"
+	gnoi_checkpoint "github.com/sonic-net/sonic-gnmi/pkg/gnoi/checkpoint"
 	gnoi_debug "github.com/sonic-net/sonic-gnmi/pkg/gnoi/debug"
 	gnoi_debug_pb "github.com/sonic-net/sonic-gnmi/proto/gnoi/debug"
 	"golang.org/x/net/context"
@@ -51,6 +53,10 @@ type Server struct {
 	config  *Config
 	cMu     sync.Mutex
 	clients map[string]*Client
+	// listeners are the additional listeners of Config.Listeners
+	listeners []*listener
+	// idleConns closes the connections idle for longer than Config.IdleConnDuration
+	idleConns *idleConnTracker
 	// SaveStartupConfig points to a function that is called to save changes of
 	// configuration to a file. By default it points to an empty function -
 	// the configuration is not saved to a file.
@@ -58,7 +64,14 @@ type Server struct {
 	// ReqFromMaster point to a function that is called to verify if the request
 	// comes from a master controller.
 	ReqFromMaster func(req *gnmipb.SetRequest, masterEID *uint128) error
-	masterEID     uint128
+	// masterEID is the master election ID of the default role, and
+	// roleMasterEIDs those of the roles named in MasterArbitration extensions.
+	masterEID      uint128
+	roleMasterEIDs map[string]*uint128
+	// debugPolicy holds the gNOI Debug command policy, reloaded when its file changes.
+	debugPolicy *gnoi_debug.PolicyStore
+	// checkpoints tracks the named config checkpoints, and any pending commit-confirmed rollback.
+	checkpoints *gnoi_checkpoint.Manager
 	gnoi_system_pb.UnimplementedSystemServer
 	factory_reset.UnimplementedFactoryResetServer
 }
@@ -143,11 +156,17 @@ type ContainerzServer struct {
 // DebugServer is the server API for Debug service.
 type DebugServer struct {
 	*Server
-	readWhitelist  []string
-	writeWhitelist []string
+	policy *gnoi_debug.PolicyStore
 	gnoi_debug_pb.UnimplementedDebugServer
 }
 
+// CheckpointServer is the server API for SonicCheckpointService service.
+type CheckpointServer struct {
+	*Server
+	manager *gnoi_checkpoint.Manager
+	spb_checkpoint_gnoi.UnimplementedSonicCheckpointServiceServer
+}
+
 type AuthTypes map[string]bool
 
 // Config is a collection of values for Server
@@ -166,8 +185,17 @@ type Config struct {
 	ConfigTableName     string
 	Vrf                 string
 	EnableCrl           bool
+	EnableOcsp          bool
 	// Path to the directory where image is stored.
 	ImgDir string
+	// Reject Set requests which are not sent by the master of their role.
+	EnableMasterArbitration bool
+	// Paths each Master Arbitration role may write, all paths when unset.
+	RolePaths RolePaths
+	// Identity provider of the oidc client_auth mode
+	Oidc *OidcConfig
+	// Additional listeners, serving the same services with their own policy
+	Listeners []*ListenerConfig
 }
 
 // DBusOSBackend is a concrete implementation of OSBackend
@@ -236,7 +264,7 @@ func (i AuthTypes) Set(mode string) error {
 		}
 
 		if _, exist := i[m]; !exist {
-			return fmt.Errorf("Expecting one or more of 'cert', 'password' or 'jwt'")
+			return fmt.Errorf("Expecting one or more of 'cert', 'password', 'jwt' or 'oidc'")
 		}
 		i[m] = true
 	}
@@ -248,7 +276,7 @@ func (i AuthTypes) Unset(mode string) error {
 	for _, m := range modes {
 		m = strings.Trim(m, " ")
 		if _, exist := i[m]; !exist {
-			return fmt.Errorf("Expecting one or more of 'cert', 'password' or 'jwt'")
+			return fmt.Errorf("Expecting one or more of 'cert', 'password', 'jwt' or 'oidc'")
 		}
 		i[m] = false
 	}
@@ -262,20 +290,65 @@ func NewServer(config *Config, opts []grpc.ServerOption) (*Server, error) {
 	}
 	common_utils.InitCounters()
 
+	idleConns := newIdleConnTracker(config)
+	opts = append(append([]grpc.ServerOption{}, opts...), grpc.StatsHandler(idleConns))
 	s := grpc.NewServer(opts...)
 	reflection.Register(s)
 
 	srv := &Server{
 		s:                 s,
+		idleConns:         idleConns,
 		config:            config,
 		clients:           map[string]*Client{},
 		SaveStartupConfig: saveOnSetDisabled,
 		// ReqFromMaster point to a function that is called to verify if
 		// the request comes from a master controller.
-		ReqFromMaster: ReqFromMasterDisabledMA,
-		masterEID:     uint128{High: 0, Low: 0},
+		ReqFromMaster:  ReqFromMasterDisabledMA,
+		masterEID:      uint128{High: 0, Low: 0},
+		roleMasterEIDs: map[string]*uint128{},
+	}
+
+	if config.EnableMasterArbitration {
+		srv.ReqFromMaster = ReqFromMasterEnabledMA
+		prepareMasterEIDs()
+	}
+
+	srv.debugPolicy = gnoi_debug.NewPolicyStore()
+	if err := srv.debugPolicy.Watch(); err != nil {
+		log.Warningf("Debug command policy will not be reloaded on change: %v", err)
+	}
+
+	if srv.config.Port < 0 {
+		srv.config.Port = 0
+	}
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", srv.config.Port))
+	if err != nil {
+		idleConns.Stop()
+		return nil, fmt.Errorf("failed to open listener port %d: %v", srv.config.Port, err)
+	}
+	srv.lis = idleConns.listener(lis)
+	if srv.config.EnableTranslibWrite || srv.config.EnableNativeWrite {
+		// Resumes any rollback left pending by a previous server
+		srv.checkpoints = gnoi_checkpoint.NewManager(connectCheckpointService)
+	}
+
+	srv.registerServices(srv.s)
+	for _, lc := range srv.config.Listeners {
+		l, err := srv.newListener(lc)
+		if err != nil {
+			srv.closeListeners()
+			srv.lis.Close()
+			idleConns.Stop()
+			return nil, err
+		}
+		srv.listeners = append(srv.listeners, l)
 	}
+	log.V(1).Infof("Created Server on %s, read-only: %t", srv.Address(), !srv.config.EnableTranslibWrite)
+	return srv, nil
+}
 
+// registerServices registers the services of srv on the gRPC server s.
+func (srv *Server) registerServices(s *grpc.Server) {
 	fileSrv := &FileServer{Server: srv}
 
 	// Create an instance of the concrete OSBackend implementation
@@ -289,38 +362,32 @@ func NewServer(config *Config, opts []grpc.ServerOption) (*Server, error) {
 
 	containerzSrv := &ContainerzServer{server: srv}
 
-	readWhitelist, writeWhitelist := gnoi_debug.ConstructWhitelists()
 	debugSrv := &DebugServer{
-		Server:         srv,
-		readWhitelist:  readWhitelist,
-		writeWhitelist: writeWhitelist,
+		Server: srv,
+		policy: srv.debugPolicy,
 	}
 
-	var err error
-	if srv.config.Port < 0 {
-		srv.config.Port = 0
-	}
-	srv.lis, err = net.Listen("tcp", fmt.Sprintf(":%d", srv.config.Port))
-	if err != nil {
-		return nil, fmt.Errorf("failed to open listener port %d: %v", srv.config.Port, err)
-	}
-	gnmipb.RegisterGNMIServer(srv.s, srv)
-	factory_reset.RegisterFactoryResetServer(srv.s, srv)
-	spb_jwt_gnoi.RegisterSonicJwtServiceServer(srv.s, srv)
+	gnmipb.RegisterGNMIServer(s, srv)
+	factory_reset.RegisterFactoryResetServer(s, srv)
+	spb_jwt_gnoi.RegisterSonicJwtServiceServer(s, srv)
 	if srv.config.EnableTranslibWrite || srv.config.EnableNativeWrite {
-		gnoi_system_pb.RegisterSystemServer(srv.s, srv)
-		gnoi_file_pb.RegisterFileServer(srv.s, fileSrv)
-		gnoi_os_pb.RegisterOSServer(srv.s, osSrv)
-		gnoi_containerz_pb.RegisterContainerzServer(srv.s, containerzSrv)
-		gnoi_debug_pb.RegisterDebugServer(srv.s, debugSrv)
+		gnoi_system_pb.RegisterSystemServer(s, srv)
+		gnoi_file_pb.RegisterFileServer(s, fileSrv)
+		gnoi_os_pb.RegisterOSServer(s, osSrv)
+		gnoi_containerz_pb.RegisterContainerzServer(s, containerzSrv)
+		gnoi_debug_pb.RegisterDebugServer(s, debugSrv)
+
+		checkpointSrv := &CheckpointServer{
+			Server:  srv,
+			manager: srv.checkpoints,
+		}
+		spb_checkpoint_gnoi.RegisterSonicCheckpointServiceServer(s, checkpointSrv)
 	}
 	if srv.config.EnableTranslibWrite {
-		spb_gnoi.RegisterSonicServiceServer(srv.s, srv)
+		spb_gnoi.RegisterSonicServiceServer(s, srv)
 
 	}
-	spb_gnoi.RegisterDebugServer(srv.s, srv)
-	log.V(1).Infof("Created Server on %s, read-only: %t", srv.Address(), !srv.config.EnableTranslibWrite)
-	return srv, nil
+	spb_gnoi.RegisterDebugServer(s, srv)
 }
 
 // Serve will start the Server serving and block until closed.
@@ -329,6 +396,13 @@ func (srv *Server) Serve() error {
 	if s == nil {
 		return fmt.Errorf("Serve() failed: not initialized")
 	}
+	for _, l := range srv.listeners {
+		go func(l *listener) {
+			if err := l.s.Serve(l.lis); err != nil {
+				log.Errorf("Listener %s returned with err: %v", l.config.Name, err)
+			}
+		}(l)
+	}
 	return srv.s.Serve(srv.lis)
 }
 
@@ -339,6 +413,12 @@ func (srv *Server) ForceStop() {
 		return
 	}
 	s.Stop()
+	for _, l := range srv.listeners {
+		l.s.Stop()
+	}
+	srv.idleConns.Stop()
+	srv.debugPolicy.Close()
+	srv.checkpoints.Close()
 }
 
 func (srv *Server) Stop() {
@@ -348,6 +428,12 @@ func (srv *Server) Stop() {
 		return
 	}
 	s.GracefulStop()
+	for _, l := range srv.listeners {
+		l.s.GracefulStop()
+	}
+	srv.idleConns.Stop()
+	srv.debugPolicy.Close()
+	srv.checkpoints.Close()
 }
 
 // Address returns the port the Server is listening to.
@@ -367,65 +453,53 @@ func (srv *Server) Auth(ctx context.Context) (context.Context, error) {
 }
 
 func authenticate(config *Config, ctx context.Context, target string, writeAccess bool) (context.Context, error) {
-	var err error
 	success := false
 	rc, ctx := common_utils.GetContext(ctx)
-	if !config.UserAuth.Any() {
+	userAuth, err := listenerUserAuth(config, ctx, writeAccess)
+	if err != nil {
+		return ctx, err
+	}
+	if !userAuth.Any() {
 		//No Auth enabled
 		rc.Auth.AuthEnabled = false
 		return ctx, nil
 	}
 
 	rc.Auth.AuthEnabled = true
-	if config.UserAuth.Enabled("password") {
+	if userAuth.Enabled("password") {
 		ctx, err = BasicAuthenAndAuthor(ctx)
 		if err == nil {
 			success = true
 		}
 	}
-	if !success && config.UserAuth.Enabled("jwt") {
+	if !success && userAuth.Enabled("jwt") {
 		_, ctx, err = JwtAuthenAndAuthor(ctx)
 		if err == nil {
 			success = true
 		}
 	}
-	if !success && config.UserAuth.Enabled("cert") {
+	if !success && userAuth.Enabled("oidc") && config.Oidc != nil {
+		ctx, err = OidcAuthenAndAuthor(ctx, config.Oidc)
+		if err == nil {
+			success = true
+			// roles of the identity provider always apply
+			if err := checkRoleAccess(&rc.Auth, target, writeAccess); err != nil {
+				return ctx, err
+			}
+		}
+	}
+	if !success && userAuth.Enabled("cert") {
 		ctx, err = ClientCertAuthenAndAuthor(ctx, config.ConfigTableName, config.EnableCrl)
+		if err == nil && config.EnableOcsp {
+			err = VerifyPeerCertOcsp(ctx)
+		}
 		if err == nil {
 			success = true
 		}
 		// role must be readwrite to support write access
 		if success && config.ConfigTableName != "" {
-			match := false
-			target = strings.ToLower(target)
-			for _, role := range rc.Auth.Roles {
-				role = strings.TrimSpace(role)
-				if strings.HasPrefix(role, target) {
-					// Extract the postfix from the role
-					// e.g. role=gnmi_config_db_readwrite
-					// e.g. role=gnoi_readonly
-					postfix := strings.TrimPrefix(role, target)
-					postfix = strings.TrimPrefix(postfix, "_")
-					// Check if the role postfix indicates no access, and deny access if true.
-					if postfix == NoAccessMode {
-						return ctx, fmt.Errorf("%s does not have access, target %s, role %s", rc.Auth.User, target, role)
-					} else if postfix == ReadOnlyMode {
-						// ReadOnlyMode is allowed for read access
-						if writeAccess {
-							return ctx, fmt.Errorf("%s does not have access, target %s, role %s", rc.Auth.User, target, role)
-						} else {
-							match = true
-							break
-						}
-					} else if postfix == WriteAccessMode {
-						// WriteAccessMode is allowed for read/write access
-						match = true
-						break
-					}
-				}
-			}
-			if !match && writeAccess {
-				return ctx, fmt.Errorf("%s does not have write access, target %s", rc.Auth.User, target)
+			if err := checkRoleAccess(&rc.Auth, target, writeAccess); err != nil {
+				return ctx, err
 			}
 		}
 	}
@@ -440,6 +514,43 @@ func authenticate(config *Config, ctx context.Context, target string, writeAcces
 	return ctx, nil
 }
 
+// checkRoleAccess verifies the roles of the user grant access to target, e.g. role
+// gnmi_readonly allows read access and gnmi_readwrite read and write access to target gnmi.
+func checkRoleAccess(auth *common_utils.AuthInfo, target string, writeAccess bool) error {
+	match := false
+	target = strings.ToLower(target)
+	for _, role := range auth.Roles {
+		role = strings.TrimSpace(role)
+		if strings.HasPrefix(role, target) {
+			// Extract the postfix from the role
+			// e.g. role=gnmi_config_db_readwrite
+			// e.g. role=gnoi_readonly
+			postfix := strings.TrimPrefix(role, target)
+			postfix = strings.TrimPrefix(postfix, "_")
+			// Check if the role postfix indicates no access, and deny access if true.
+			if postfix == NoAccessMode {
+				return fmt.Errorf("%s does not have access, target %s, role %s", auth.User, target, role)
+			} else if postfix == ReadOnlyMode {
+				// ReadOnlyMode is allowed for read access
+				if writeAccess {
+					return fmt.Errorf("%s does not have access, target %s, role %s", auth.User, target, role)
+				} else {
+					match = true
+					break
+				}
+			} else if postfix == WriteAccessMode {
+				// WriteAccessMode is allowed for read/write access
+				match = true
+				break
+			}
+		}
+	}
+	if !match && writeAccess {
+		return fmt.Errorf("%s does not have write access, target %s", auth.User, target)
+	}
+	return nil
+}
+
 // Subscribe implements the gNMI Subscribe RPC.
 func (s *Server) Subscribe(stream gnmipb.GNMI_SubscribeServer) error {
 	ctx := stream.Context()
@@ -462,8 +573,9 @@ func (s *Server) Subscribe(stream gnmipb.GNMI_SubscribeServer) error {
 
 	c := NewClient(pr.Addr)
 
-	c.setLogLevel(s.config.LogLevel)
-	c.setConnectionManager(s.config.Threshold)
+	live := s.config.Live()
+	c.setLogLevel(live.LogLevel)
+	c.setConnectionManager(live.Threshold)
 
 	s.cMu.Lock()
 	if oc, ok := s.clients[c.String()]; ok {
@@ -591,7 +703,6 @@ func (s *Server) Get(ctx context.Context, req *gnmipb.GetRequest) (*gnmipb.GetRe
 		common_utils.IncCounter(common_utils.GNMI_GET_FAIL)
 		return nil, err
 	}
-	notifications := make([]*gnmipb.Notification, len(paths))
 	spbValues, err := dc.Get(nil)
 	if err != nil {
 		common_utils.IncCounter(common_utils.GNMI_GET_FAIL)
@@ -601,6 +712,9 @@ func (s *Server) Get(ctx context.Context, req *gnmipb.GetRequest) (*gnmipb.GetRe
 		return nil, status.Error(codes.NotFound, err.Error())
 	}
 
+	// A path may resolve to several values, e.g. a sonic-db path with a
+	// wildcard namespace, so there is one notification per value
+	notifications := make([]*gnmipb.Notification, len(spbValues))
 	for index, spbValue := range spbValues {
 		update := &gnmipb.Update{
 			Path: spbValue.GetPath(),
@@ -636,11 +750,6 @@ func SaveOnSetEnabled() error {
 func saveOnSetDisabled() error { return nil }
 
 func (s *Server) Set(ctx context.Context, req *gnmipb.SetRequest) (*gnmipb.SetResponse, error) {
-	e := s.ReqFromMaster(req, &s.masterEID)
-	if e != nil {
-		return nil, e
-	}
-
 	common_utils.IncCounter(common_utils.GNMI_SET)
 	if s.config.EnableTranslibWrite == false && s.config.EnableNativeWrite == false {
 		common_utils.IncCounter(common_utils.GNMI_SET_FAIL)
@@ -701,6 +810,18 @@ func (s *Server) Set(ctx context.Context, req *gnmipb.SetRequest) (*gnmipb.SetRe
 		common_utils.IncCounter(common_utils.GNMI_SET_FAIL)
 		return nil, err
 	}
+	// Only authenticated clients take part in Master Arbitration
+	masterEID, err := s.masterEIDForRole(masterArbitrationRole(req))
+	if err == nil {
+		err = s.ReqFromMaster(req, masterEID)
+	}
+	if err == nil {
+		err = s.checkRolePaths(req)
+	}
+	if err != nil {
+		common_utils.IncCounter(common_utils.GNMI_SET_FAIL)
+		return nil, err
+	}
 	/* DELETE */
 	for _, path := range req.GetDelete() {
 		log.V(2).Infof("Delete path: %v", path)
@@ -820,7 +941,7 @@ func (lh *uint128) Compare(rh *uint128) int {
 }
 
 // ReqFromMasterEnabledMA returns true if the request is sent by the master
-// controller.
+// controller of its role. masterEID is the tracked master of that role.
 func ReqFromMasterEnabledMA(req *gnmipb.SetRequest, masterEID *uint128) error {
 	// Read the election_id.
 	reqEID := uint128{High: 0, Low: 0}
@@ -838,28 +959,25 @@ func ReqFromMasterEnabledMA(req *gnmipb.SetRequest, masterEID *uint128) error {
 			return status.Errorf(codes.InvalidArgument, "MA: ElectionId missing")
 		}
 
-		if ma.Role != nil {
-			// Role will be implemented later.
-			return status.Errorf(codes.Unimplemented, "MA: Role is not implemented")
-		}
-
 		reqEID = uint128{High: ma.ElectionId.High, Low: ma.ElectionId.Low}
 		// Use the election ID that is in the last extension, so, no 'break' here.
 	}
 
+	maMu.Lock()
+	defer maMu.Unlock()
 	if !hasMaExt {
 		log.V(0).Infof("MA: No Master Arbitration in setRequest extension, masterEID %v is not updated", masterEID)
 		return nil
 	}
 
-	maMu.Lock()
-	defer maMu.Unlock()
 	switch masterEID.Compare(&reqEID) {
 	case 1: // This Election ID is smaller than the known Master Election ID.
 		return status.Errorf(codes.PermissionDenied, "Election ID is smaller than the current master. Rejected. Master EID: %v. Current EID: %v.", masterEID, reqEID)
 	case -1: // New Master Election ID received!
-		log.V(0).Infof("New master has been elected with %v\n", reqEID)
+		role := masterArbitrationRole(req)
+		log.V(0).Infof("New master has been elected for role %v with %v\n", role, reqEID)
 		*masterEID = reqEID
+		publishMasterEID(role, reqEID)
 	}
 	return nil
 }
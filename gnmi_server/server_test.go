@@ -146,6 +146,34 @@ func createServer(t *testing.T, port int64) *Server {
 	return s
 }
 
+func createMasterArbitrationServer(t *testing.T, port int64, rolePaths RolePaths) *Server {
+	t.Helper()
+	certificate, err := testcert.NewCert()
+	if err != nil {
+		t.Fatalf("could not load server key pair: %s", err)
+	}
+	tlsCfg := &tls.Config{
+		ClientAuth:   tls.RequestClientCert,
+		Certificates: []tls.Certificate{certificate},
+	}
+
+	opts := []grpc.ServerOption{grpc.Creds(credentials.NewTLS(tlsCfg))}
+	cfg := &Config{
+		Port:                    port,
+		EnableTranslibWrite:     true,
+		EnableNativeWrite:       true,
+		Threshold:               100,
+		ImgDir:                  "/tmp",
+		EnableMasterArbitration: true,
+		RolePaths:               rolePaths,
+	}
+	s, err := NewServer(cfg, opts)
+	if err != nil {
+		t.Errorf("Failed to create gNMI server: %v", err)
+	}
+	return s
+}
+
 func createReadServer(t *testing.T, port int64) *Server {
 	certificate, err := testcert.NewCert()
 	if err != nil {
@@ -5068,14 +5096,6 @@ func TestRecoverFromJSONSerializationPanic(t *testing.T) {
 }
 
 func TestGnmiSetBatch(t *testing.T) {
-	mockCode :=
-		`
-print('No Yang validation for test mode...')
-print('%s')
-`
-	mock1 := gomonkey.ApplyGlobalVar(&sdc.PyCodeForYang, mockCode)
-	defer mock1.Reset()
-
 	sdcfg.Init()
 	s := createServer(t, 8090)
 	go runServer(t, s)
@@ -5154,7 +5174,7 @@ func TestGNMINative(t *testing.T) {
 		return &dbus.Call{}
 	})
 	defer mock2.Reset()
-	mock3 := gomonkey.ApplyFunc(sdc.RunPyCode, func(text string) error { return nil })
+	mock3 := gomonkey.ApplyFunc(sdc.ValidateYangConfig, func(config map[string]interface{}) error { return nil })
 	defer mock3.Reset()
 
 	sdcfg.Init()
@@ -5245,6 +5265,8 @@ func TestGNMINativeMultiNamespace(t *testing.T) {
 		return &dbus.Call{}
 	})
 	defer mock2.Reset()
+	mock3 := gomonkey.ApplyFunc(sdc.ValidateYangConfig, func(config map[string]interface{}) error { return nil })
+	defer mock3.Reset()
 	sdcfg.Init()
 	err := test_utils.SetupMultiNamespace()
 	if err != nil {
@@ -5530,6 +5552,195 @@ func TestMasterArbitration(t *testing.T) {
 	})
 }
 
+func TestMasterArbitrationRoles(t *testing.T) {
+	ns, _ := sdcfg.GetDbDefaultNamespace()
+	stateDb := getRedisClientN(t, StateDbNum, ns)
+	defer stateDb.Close()
+	// A master published by a previous server is removed by the new one
+	stateDb.HSet("GNMI_MASTER_ARBITRATION|intf", "election_id_low", "9")
+
+	// STATE_DB is left alone when Master Arbitration is disabled
+	createServer(t, 8088)
+	if n, _ := stateDb.Exists("GNMI_MASTER_ARBITRATION|intf").Result(); n != 1 {
+		t.Fatalf("Master was removed from STATE_DB with Master Arbitration disabled")
+	}
+
+	rolePaths, err := ParseRolePaths("acl=/acl")
+	if err != nil {
+		t.Fatalf("ParseRolePaths failed: %v", err)
+	}
+	s := createMasterArbitrationServer(t, 8088, rolePaths)
+	if n, _ := stateDb.Exists("GNMI_MASTER_ARBITRATION|intf").Result(); n != 0 {
+		t.Fatalf("Stale master was not removed from STATE_DB")
+	}
+	go runServer(t, s)
+	defer s.Stop()
+
+	prepareDbTranslib(t)
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: true}
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig))}
+
+	targetAddr := "127.0.0.1:8088"
+	conn, err := grpc.Dial(targetAddr, opts...)
+	if err != nil {
+		t.Fatalf("Dialing to %q failed: %v", targetAddr, err)
+	}
+	defer conn.Close()
+
+	gClient := pb.NewGNMIClient(conn)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	maExt := func(role string, low uint64) *ext_pb.Extension {
+		return &ext_pb.Extension{
+			Ext: &ext_pb.Extension_MasterArbitration{
+				MasterArbitration: &ext_pb.MasterArbitration{
+					Role:       &ext_pb.Role{Id: role},
+					ElectionId: &ext_pb.Uint128{High: 0, Low: low},
+				},
+			},
+		}
+	}
+	mtuReq := func(ext *ext_pb.Extension) *pb.SetRequest {
+		return &pb.SetRequest{
+			Prefix: &pb.Path{Elem: []*pb.PathElem{{Name: "interfaces"}}},
+			Update: []*pb.Update{
+				newPbUpdate("interface[name=Ethernet0]/config/mtu", `{"mtu": 9104}`),
+			},
+			Extension: []*ext_pb.Extension{ext},
+		}
+	}
+
+	// Each role elects its own master, independent of the default role.
+	t.Run("MasterArbitrationPerRoleElection", func(t *testing.T) {
+		if _, err := gClient.Set(ctx, mtuReq(maExt("intf", 5))); err != nil {
+			t.Fatal("Did not expected an error: " + err.Error())
+		}
+		if _, err := gClient.Set(ctx, mtuReq(maExt("bgp", 1))); err != nil {
+			t.Fatal("Did not expected an error: " + err.Error())
+		}
+		want := uint128{High: 0, Low: 5}
+		if s.roleMasterEIDs["intf"].Compare(&want) != 0 {
+			t.Fatalf("Master EID update failed. Want %v, got %v", want, s.roleMasterEIDs["intf"])
+		}
+		want = uint128{High: 0, Low: 1}
+		if s.roleMasterEIDs["bgp"].Compare(&want) != 0 {
+			t.Fatalf("Master EID update failed. Want %v, got %v", want, s.roleMasterEIDs["bgp"])
+		}
+		if s.masterEID.Compare(&uint128{}) != 0 {
+			t.Fatalf("Default role master EID changed to %v", s.masterEID)
+		}
+		// The master is published in the background
+		var fields map[string]string
+		for i := 0; i < 50; i++ {
+			fields, err = stateDb.HGetAll("GNMI_MASTER_ARBITRATION|intf").Result()
+			if err != nil {
+				t.Fatalf("Failed to read master from STATE_DB: %v", err)
+			}
+			if fields["election_id_low"] == "5" {
+				break
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+		if fields["election_id_high"] != "0" || fields["election_id_low"] != "5" {
+			t.Fatalf("Wrong master in STATE_DB: %v", fields)
+		}
+	})
+	// A smaller election ID is rejected within its own role only.
+	t.Run("MasterArbitrationPerRoleStaleMaster", func(t *testing.T) {
+		_, err := gClient.Set(ctx, mtuReq(maExt("intf", 2)))
+		if ret, ok := status.FromError(err); !ok || ret.Code() != codes.PermissionDenied {
+			t.Fatalf("Expected PermissionDenied. Got %v", err)
+		}
+		if _, err := gClient.Set(ctx, mtuReq(maExt("bgp", 2))); err != nil {
+			t.Fatal("Did not expected an error: " + err.Error())
+		}
+	})
+	// A role restricted to other paths is not allowed to write.
+	t.Run("MasterArbitrationRolePaths", func(t *testing.T) {
+		_, err := gClient.Set(ctx, mtuReq(maExt("acl", 1)))
+		if ret, ok := status.FromError(err); !ok || ret.Code() != codes.PermissionDenied {
+			t.Fatalf("Expected PermissionDenied. Got %v", err)
+		}
+	})
+}
+
+func TestMasterEIDForRole(t *testing.T) {
+	rolePaths, err := ParseRolePaths("acl=/acl")
+	if err != nil {
+		t.Fatalf("ParseRolePaths failed: %v", err)
+	}
+	s := &Server{config: &Config{RolePaths: rolePaths}, roleMasterEIDs: map[string]*uint128{}}
+
+	if eid, err := s.masterEIDForRole(masterArbitrationDefaultRole); err != nil || eid != &s.masterEID {
+		t.Fatalf("Expected the default role master EID, got %v, %v", eid, err)
+	}
+	for i := 0; i < maxMasterArbitrationRoles; i++ {
+		if _, err := s.masterEIDForRole(fmt.Sprintf("role%d", i)); err != nil {
+			t.Fatalf("Did not expect an error: %v", err)
+		}
+	}
+	// The same role is tracked once
+	first, _ := s.masterEIDForRole("role0")
+	if second, err := s.masterEIDForRole("role0"); err != nil || first != second {
+		t.Fatalf("Expected role0 to be tracked once, got %v, %v", second, err)
+	}
+	_, err = s.masterEIDForRole("role64")
+	if ret, ok := status.FromError(err); !ok || ret.Code() != codes.ResourceExhausted {
+		t.Fatalf("Expected ResourceExhausted. Got %v", err)
+	}
+	// Roles with role paths are always tracked
+	if _, err := s.masterEIDForRole("acl"); err != nil {
+		t.Fatalf("Did not expect an error: %v", err)
+	}
+	if len(s.roleMasterEIDs) != maxMasterArbitrationRoles+1 {
+		t.Fatalf("Expected %d tracked roles, got %d", maxMasterArbitrationRoles+1, len(s.roleMasterEIDs))
+	}
+}
+
+func TestParseRolePaths(t *testing.T) {
+	rolePaths, err := ParseRolePaths("acl=sonic-db:CONFIG_DB/localhost/ACL_TABLE, sonic-db:CONFIG_DB/localhost/ACL_RULE;intf=/interfaces/interface[name=Ethernet0]")
+	if err != nil {
+		t.Fatalf("ParseRolePaths failed: %v", err)
+	}
+	if len(rolePaths["acl"]) != 2 || len(rolePaths["intf"]) != 1 {
+		t.Fatalf("Unexpected role paths: %v", rolePaths)
+	}
+
+	if rolePaths["acl"][0].GetOrigin() != "sonic-db" || rolePaths["intf"][0].GetOrigin() != "" {
+		t.Fatalf("Unexpected role path origins: %v", rolePaths)
+	}
+
+	sonicDbPrefix := &pb.Path{Origin: "sonic-db"}
+	aclPath, _ := xpath.ToGNMIPath("CONFIG_DB/localhost/ACL_RULE/rule1")
+	if !pathAllowed(sonicDbPrefix, aclPath, rolePaths["acl"]) {
+		t.Errorf("Expected %v to be allowed for role acl", aclPath)
+	}
+	if pathAllowed(&pb.Path{Origin: "openconfig"}, aclPath, rolePaths["acl"]) {
+		t.Errorf("Expected %v to be denied for role acl in another origin", aclPath)
+	}
+	portPath, _ := xpath.ToGNMIPath("CONFIG_DB/localhost/PORT/Ethernet0")
+	if pathAllowed(sonicDbPrefix, portPath, rolePaths["acl"]) {
+		t.Errorf("Expected %v to be denied for role acl", portPath)
+	}
+	prefix := &pb.Path{Elem: []*pb.PathElem{{Name: "interfaces"}}}
+	mtuPath, _ := xpath.ToGNMIPath("interface[name=Ethernet0]/config/mtu")
+	if !pathAllowed(prefix, mtuPath, rolePaths["intf"]) {
+		t.Errorf("Expected %v to be allowed for role intf", mtuPath)
+	}
+	mtuPath, _ = xpath.ToGNMIPath("interface[name=Ethernet4]/config/mtu")
+	if pathAllowed(prefix, mtuPath, rolePaths["intf"]) {
+		t.Errorf("Expected %v to be denied for role intf", mtuPath)
+	}
+
+	for _, spec := range []string{"acl", "=/acl", "acl=", "acl=/acl[name"} {
+		if _, err := ParseRolePaths(spec); err == nil {
+			t.Errorf("Expected ParseRolePaths(%q) to fail", spec)
+		}
+	}
+}
+
 func TestSaveOnSet(t *testing.T) {
 	// Fail client creation
 	fakeDBC := gomonkey.ApplyFuncReturn(ssc.NewDbusClient, nil, fmt.Errorf("Fail Create"))
@@ -5613,6 +5824,7 @@ func TestClientCertAuthenAndAuthor(t *testing.T) {
 	ctx, cancel = CreateAuthorizationCtx()
 	configDb.Flushdb()
 	gnmiTable.Hset("certname1", "role", "gnmi_readwrite")
+	resetCertIdentityTables()
 	ctx, err = ClientCertAuthenAndAuthor(ctx, "GNMI_CLIENT_CERT", false)
 	if err != nil {
 		t.Errorf("CommonNameMatch with correct cert name should success: %v", err)
@@ -5625,6 +5837,7 @@ func TestClientCertAuthenAndAuthor(t *testing.T) {
 	configDb.Flushdb()
 	gnmiTable.Hset("certname1", "role", "gnmi_readwrite")
 	gnmiTable.Hset("certname2", "role", "gnmi_readonly")
+	resetCertIdentityTables()
 	ctx, err = ClientCertAuthenAndAuthor(ctx, "GNMI_CLIENT_CERT", false)
 	if err != nil {
 		t.Errorf("CommonNameMatch with correct cert name should success: %v", err)
@@ -5636,6 +5849,7 @@ func TestClientCertAuthenAndAuthor(t *testing.T) {
 	ctx, cancel = CreateAuthorizationCtx()
 	configDb.Flushdb()
 	gnmiTable.Hset("certname2", "role", "gnmi_readonly")
+	resetCertIdentityTables()
 	ctx, err = ClientCertAuthenAndAuthor(ctx, "GNMI_CLIENT_CERT", false)
 	if err == nil {
 		t.Errorf("CommonNameMatch with invalid cert name should fail: %v", err)
@@ -5678,6 +5892,7 @@ func TestClientCertAuthenAndAuthorMultiRole(t *testing.T) {
 	ctx, cancel = CreateAuthorizationCtx()
 	configDb.Flushdb()
 	gnmiTable.Hset("certname1", "role@", "gnmi_readwrite")
+	resetCertIdentityTables()
 	ctx, err = ClientCertAuthenAndAuthor(ctx, "GNMI_CLIENT_CERT", false)
 	if err != nil {
 		t.Errorf("CommonNameMatch with correct cert name should success: %v", err)
@@ -5690,6 +5905,7 @@ func TestClientCertAuthenAndAuthorMultiRole(t *testing.T) {
 	configDb.Flushdb()
 	gnmiTable.Hset("certname1", "role@", "gnmi_readwrite")
 	gnmiTable.Hset("certname2", "role@", "gnmi_readonly")
+	resetCertIdentityTables()
 	ctx, err = ClientCertAuthenAndAuthor(ctx, "GNMI_CLIENT_CERT", false)
 	if err != nil {
 		t.Errorf("CommonNameMatch with correct cert name should success: %v", err)
@@ -5701,6 +5917,7 @@ func TestClientCertAuthenAndAuthorMultiRole(t *testing.T) {
 	ctx, cancel = CreateAuthorizationCtx()
 	configDb.Flushdb()
 	gnmiTable.Hset("certname2", "role@", "gnmi_readonly")
+	resetCertIdentityTables()
 	ctx, err = ClientCertAuthenAndAuthor(ctx, "GNMI_CLIENT_CERT", false)
 	if err == nil {
 		t.Errorf("CommonNameMatch with invalid cert name should fail: %v", err)
@@ -5733,6 +5950,7 @@ func TestAuthenticate(t *testing.T) {
 	configDb.Flushdb()
 
 	gnmiTable.Hset("certname1", "role@", "sonic_linux,gnmi_noaccess,linux_sonic")
+	resetCertIdentityTables()
 	// Call authenticate to verify the user's role. This should fail if the role is "gnmi_noaccess".
 	_, err = authenticate(cfg, ctx, "gnmi", true)
 	if err == nil {
@@ -5745,6 +5963,7 @@ func TestAuthenticate(t *testing.T) {
 	}
 
 	gnmiTable.Hset("certname1", "role@", "sonic_linux,gnmi_readonly,linux_sonic")
+	resetCertIdentityTables()
 	// Call authenticate to verify the user's role. This should fail if the role is "gnmi_readonly".
 	_, err = authenticate(cfg, ctx, "gnmi", true)
 	if err == nil {
@@ -5757,6 +5976,7 @@ func TestAuthenticate(t *testing.T) {
 	}
 
 	gnmiTable.Hset("certname1", "role@", "sonic_linux,gnmi_readwrite,linux_sonic")
+	resetCertIdentityTables()
 	// Call authenticate to verify the user's role. This should pass if the role is "gnmi_readwrite".
 	_, err = authenticate(cfg, ctx, "gnmi", true)
 	if err != nil {
@@ -5769,6 +5989,7 @@ func TestAuthenticate(t *testing.T) {
 	}
 
 	gnmiTable.Hset("certname1", "role@", "sonic_linux,linux_sonic")
+	resetCertIdentityTables()
 	// Call authenticate to verify the user's role. This should faile if the role is empty.
 	_, err = authenticate(cfg, ctx, "gnmi", true)
 	if err == nil {
@@ -0,0 +1,92 @@
+package gnmi
+
+// vlan_cli_test.go
+
+// Tests SHOW vlan brief
+
+import (
+	"crypto/tls"
+	"testing"
+	"time"
+
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+)
+
+func TestGetShowVlanBrief(t *testing.T) {
+	s := createServer(t, ServerPort)
+	go runServer(t, s)
+	defer s.ForceStop()
+	defer ResetDataSetsAndMappings(t)
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: true}
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig))}
+
+	conn, err := grpc.Dial(TargetAddr, opts...)
+	if err != nil {
+		t.Fatalf("Dialing to %q failed: %v", TargetAddr, err)
+	}
+	defer conn.Close()
+
+	gClient := pb.NewGNMIClient(conn)
+	ctx, cancel := context.WithTimeout(context.Background(), QueryTimeout*time.Second)
+	defer cancel()
+
+	emptyResp := `[]`
+	vlanBrief := `[
+		{"VLAN ID": "1000", "IP Address": ["192.168.0.1/21", "fc02:1000::1/64"], "Ports": ["Ethernet40", "Ethernet80"], "Port Tagging": ["untagged", "untagged"], "Proxy ARP": "enabled", "DHCP Helper Address": ["192.0.0.1", "192.0.0.2"]},
+		{"VLAN ID": "2000", "IP Address": [], "Ports": ["Ethernet40"], "Port Tagging": ["tagged"], "Proxy ARP": "disabled", "DHCP Helper Address": []}
+	]`
+
+	vlanConfigFileName := "../testdata/VLAN_CONFIG.txt"
+
+	tests := []struct {
+		desc        string
+		pathTarget  string
+		textPbPath  string
+		wantRetCode codes.Code
+		wantRespVal interface{}
+		valTest     bool
+		testInit    func()
+	}{
+		{
+			desc:       "query SHOW vlan brief - no data",
+			pathTarget: "SHOW",
+			textPbPath: `
+				elem: <name: "vlan" >
+				elem: <name: "brief" >
+			`,
+			wantRetCode: codes.OK,
+			wantRespVal: []byte(emptyResp),
+			valTest:     true,
+		},
+		{
+			desc:       "query SHOW vlan brief",
+			pathTarget: "SHOW",
+			textPbPath: `
+				elem: <name: "vlan" >
+				elem: <name: "brief" >
+			`,
+			wantRetCode: codes.OK,
+			wantRespVal: []byte(vlanBrief),
+			valTest:     true,
+			testInit: func() {
+				FlushDataSet(t, ConfigDbNum)
+				AddDataSet(t, ConfigDbNum, vlanConfigFileName)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		if test.testInit != nil {
+			test.testInit()
+		}
+		t.Run(test.desc, func(t *testing.T) {
+			runTestGet(t, ctx, gClient, test.pathTarget, test.textPbPath, test.wantRetCode, test.wantRespVal, test.valTest)
+		})
+	}
+}
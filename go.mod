@@ -21,7 +21,9 @@ require (
 	github.com/msteinert/pam v0.0.0-20201130170657-e61372126161
 	github.com/openconfig/gnmi v0.0.0-20200617225440-d2b4e6a45802
 	github.com/openconfig/gnoi v0.3.0
+	github.com/openconfig/goyang v0.0.0-20200309174518-a00bece872fc
 	github.com/openconfig/ygot v0.7.1
+	github.com/sonic-net/sonic-gnmi/pkg/certidentity v0.0.0
 	github.com/stretchr/testify v1.9.0
 	golang.org/x/crypto v0.24.0
 	golang.org/x/net v0.26.0
@@ -45,7 +47,6 @@ require (
 	github.com/maruel/natural v1.1.1 // indirect
 	github.com/onsi/ginkgo v1.10.3 // indirect
 	github.com/onsi/gomega v1.7.1 // indirect
-	github.com/openconfig/goyang v0.0.0-20200309174518-a00bece872fc // indirect
 	github.com/philopon/go-toposort v0.0.0-20170620085441-9be86dbd762f // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/yuin/gopher-lua v1.1.1 // indirect
@@ -61,6 +62,7 @@ require (
 
 replace (
 	github.com/Azure/sonic-mgmt-common => ../sonic-mgmt-common
+	github.com/sonic-net/sonic-gnmi/pkg/certidentity => ./pkg/certidentity
 	golang.org/x/crypto => golang.org/x/crypto v0.24.0
 )
 
@@ -0,0 +1,3 @@
+module github.com/sonic-net/sonic-gnmi/pkg/certidentity
+
+go 1.19
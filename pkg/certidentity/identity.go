@@ -0,0 +1,195 @@
+// Package certidentity maps client certificates to the entries of a client cert table.
+//
+// An identity is a value taken from a part of the certificate: its common name, or one of
+// its DNS, URI or email SANs. A table entry matches identities taken from one source only,
+// by comparing its key to the identity value exactly, as a prefix, a glob or a regex.
+// The package has no dependencies so that the gNMI server and the standalone server share it.
+package certidentity
+
+import (
+	"crypto/x509"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Sources select the parts of a client certificate its identities can be taken from.
+const (
+	SourceCN    = "cn"
+	SourceDNS   = "dns"
+	SourceURI   = "uri"
+	SourceEmail = "email"
+)
+
+// Match types define how the key of a table entry matches an identity.
+// They are listed in order of precedence: an exact entry wins over a prefix entry, and so on.
+const (
+	MatchExact  = "exact"
+	MatchPrefix = "prefix"
+	MatchGlob   = "glob"
+	MatchRegex  = "regex"
+)
+
+var (
+	sourceLabels = map[string]string{
+		SourceCN:    "CN",
+		SourceDNS:   "DNS",
+		SourceURI:   "URI",
+		SourceEmail: "email",
+	}
+	sourceNames = map[string]string{
+		SourceCN:    "common name",
+		SourceDNS:   "DNS SAN",
+		SourceURI:   "URI SAN",
+		SourceEmail: "email SAN",
+	}
+	matchRank = map[string]int{MatchExact: 0, MatchPrefix: 1, MatchGlob: 2, MatchRegex: 3}
+)
+
+// ValidateSources checks that sources is a non-empty list of known sources.
+func ValidateSources(sources []string) error {
+	if len(sources) == 0 {
+		return fmt.Errorf("no client certificate identity source given")
+	}
+	for _, source := range sources {
+		if _, ok := sourceLabels[source]; !ok {
+			return fmt.Errorf("invalid client certificate identity source %q, expecting cn, dns, uri or email", source)
+		}
+	}
+	return nil
+}
+
+// SourceName returns a readable name of a source, e.g. "URI SAN".
+func SourceName(source string) string {
+	return sourceNames[source]
+}
+
+// Identity is an identity of a client certificate and the source it was taken from.
+type Identity struct {
+	Source string
+	Value  string
+}
+
+func (i Identity) String() string {
+	return sourceLabels[i.Source] + " " + i.Value
+}
+
+// FromCertificate returns the identities of a certificate, ordered by source precedence.
+func FromCertificate(cert *x509.Certificate, sources []string) []Identity {
+	var identities []Identity
+	for _, source := range sources {
+		var values []string
+		switch source {
+		case SourceCN:
+			if cert.Subject.CommonName != "" {
+				values = []string{cert.Subject.CommonName}
+			}
+		case SourceDNS:
+			values = cert.DNSNames
+		case SourceURI:
+			for _, uri := range cert.URIs {
+				values = append(values, uri.String())
+			}
+		case SourceEmail:
+			values = cert.EmailAddresses
+		}
+		for _, value := range values {
+			identities = append(identities, Identity{Source: source, Value: value})
+		}
+	}
+	return identities
+}
+
+// Entry is an entry of a client cert table.
+type Entry struct {
+	Key    string   // Table key, compared to identity values
+	Match  string   // How the key matches identity values
+	Source string   // Source of the identities the entry matches
+	Roles  []string // Roles granted to a matching client
+	re     *regexp.Regexp
+}
+
+// NewEntry returns a table entry, checking its match type, source and pattern.
+// An empty match is exact, and an empty source is the common name, like entries
+// written before either field existed.
+func NewEntry(key, match, source string, roles []string) (*Entry, error) {
+	if match == "" {
+		match = MatchExact
+	}
+	if source == "" {
+		source = SourceCN
+	}
+	if _, ok := sourceLabels[source]; !ok {
+		return nil, fmt.Errorf("invalid source %q, expecting cn, dns, uri or email", source)
+	}
+	entry := &Entry{Key: key, Match: match, Source: source, Roles: roles}
+	switch match {
+	case MatchExact, MatchPrefix:
+	case MatchGlob:
+		if _, err := path.Match(key, ""); err != nil {
+			return nil, fmt.Errorf("invalid glob %q: %w", key, err)
+		}
+	case MatchRegex:
+		re, err := regexp.Compile("^(?:" + key + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex %q: %w", key, err)
+		}
+		entry.re = re
+	default:
+		return nil, fmt.Errorf("invalid match %q, expecting exact, prefix, glob or regex", match)
+	}
+	return entry, nil
+}
+
+// Matches reports whether the entry matches an identity: it must be taken from the
+// entry's source, and its value must match the key.
+func (e *Entry) Matches(identity Identity) bool {
+	if identity.Source != e.Source {
+		return false
+	}
+	switch e.Match {
+	case MatchExact:
+		return identity.Value == e.Key
+	case MatchPrefix:
+		return strings.HasPrefix(identity.Value, e.Key)
+	case MatchGlob:
+		ok, _ := path.Match(e.Key, identity.Value)
+		return ok
+	case MatchRegex:
+		return e.re.MatchString(identity.Value)
+	}
+	return false
+}
+
+// Sort orders entries by precedence: by match type, then longer prefixes and globs
+// first, then by key and source.
+func Sort(entries []*Entry) {
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if matchRank[a.Match] != matchRank[b.Match] {
+			return matchRank[a.Match] < matchRank[b.Match]
+		}
+		if a.Match != MatchRegex && len(a.Key) != len(b.Key) {
+			return len(a.Key) > len(b.Key)
+		}
+		if a.Key != b.Key {
+			return a.Key < b.Key
+		}
+		return a.Source < b.Source
+	})
+}
+
+// Match returns the first identity matching an entry, and the entry with the highest
+// precedence it matches. Entries must be sorted by Sort.
+func Match(identities []Identity, entries []*Entry) (Identity, *Entry) {
+	for _, identity := range identities {
+		for _, entry := range entries {
+			if entry.Matches(identity) {
+				return identity, entry
+			}
+		}
+	}
+	return Identity{}, nil
+}
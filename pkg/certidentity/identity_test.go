@@ -0,0 +1,181 @@
+package certidentity
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestFromCertificate(t *testing.T) {
+	spiffe, _ := url.Parse("spiffe://example.com/ns/sonic/sa/gnmi-client")
+	cert := &x509.Certificate{
+		Subject:        pkix.Name{CommonName: "host1.example.com"},
+		DNSNames:       []string{"host1.example.com", "gnmi.example.com"},
+		URIs:           []*url.URL{spiffe},
+		EmailAddresses: []string{"ops@example.com"},
+	}
+
+	tests := []struct {
+		sources []string
+		want    []Identity
+	}{
+		{[]string{"cn"}, []Identity{{SourceCN, "host1.example.com"}}},
+		{[]string{"uri", "cn"}, []Identity{{SourceURI, "spiffe://example.com/ns/sonic/sa/gnmi-client"}, {SourceCN, "host1.example.com"}}},
+		{[]string{"dns"}, []Identity{{SourceDNS, "host1.example.com"}, {SourceDNS, "gnmi.example.com"}}},
+		{[]string{"email", "dns"}, []Identity{{SourceEmail, "ops@example.com"}, {SourceDNS, "host1.example.com"}, {SourceDNS, "gnmi.example.com"}}},
+	}
+	for _, test := range tests {
+		if got := FromCertificate(cert, test.sources); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("FromCertificate(%v) = %v, want %v", test.sources, got, test.want)
+		}
+	}
+	if got := FromCertificate(&x509.Certificate{}, []string{"cn", "uri"}); len(got) != 0 {
+		t.Errorf("Expected no identity, got %v", got)
+	}
+	if got := (Identity{SourceURI, "spiffe://x"}).String(); got != "URI spiffe://x" {
+		t.Errorf("Identity.String() = %q", got)
+	}
+}
+
+func TestValidateSources(t *testing.T) {
+	if err := ValidateSources([]string{"uri", "cn"}); err != nil {
+		t.Errorf("ValidateSources failed: %v", err)
+	}
+	if err := ValidateSources([]string{"uri", "serial"}); err == nil {
+		t.Errorf("Expected invalid source to fail")
+	}
+	if err := ValidateSources(nil); err == nil {
+		t.Errorf("Expected empty sources to fail")
+	}
+}
+
+func TestMatch(t *testing.T) {
+	table := []struct {
+		key, match, source, role string
+	}{
+		{"host1.example.com", "", "", "gnmi_readwrite"},
+		{"host", "prefix", "cn", "gnmi_readonly"},
+		{"host1.", "prefix", "", "gnmi_config_db_readwrite"},
+		{"*.example.com", "glob", "dns", "gnmi_noaccess"},
+		{"spiffe://example.com/ns/sonic/sa/*", "glob", "uri", "gnmi_readwrite"},
+		{`spiffe://example\.com/ns/[a-z]+/sa/.*`, "regex", "uri", "gnmi_readonly"},
+		{`spiffe://example\.com/ns/sonic/sa/.*`, "regex", "uri", "gnoi_readonly"},
+		{`ops-[0-9]+@example\.com`, "regex", "email", "gnmi_readonly"},
+		{"router[", "glob", "dns", "gnmi_readonly"},
+		{"switch(", "regex", "cn", "gnmi_readonly"},
+		{"unknown", "fuzzy", "cn", "gnmi_readonly"},
+		{"host2.example.com", "", "serial", "gnmi_readonly"},
+	}
+	var entries []*Entry
+	for _, e := range table {
+		entry, err := NewEntry(e.key, e.match, e.source, []string{e.role})
+		if err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) != 8 {
+		t.Fatalf("Expected the 4 invalid entries to be rejected, got %d entries", len(entries))
+	}
+	Sort(entries)
+
+	tests := []struct {
+		desc       string
+		identities []Identity
+		identity   Identity
+		key        string
+		role       string
+	}{
+		{
+			desc:       "exact match wins",
+			identities: []Identity{{SourceCN, "host1.example.com"}},
+			identity:   Identity{SourceCN, "host1.example.com"},
+			key:        "host1.example.com",
+			role:       "gnmi_readwrite",
+		},
+		{
+			desc:       "longest prefix wins",
+			identities: []Identity{{SourceCN, "host1.other.com"}},
+			identity:   Identity{SourceCN, "host1.other.com"},
+			key:        "host1.",
+			role:       "gnmi_config_db_readwrite",
+		},
+		{
+			desc:       "entries default to the common name",
+			identities: []Identity{{SourceDNS, "host1.example.com"}},
+			identity:   Identity{SourceDNS, "host1.example.com"},
+			key:        "*.example.com",
+			role:       "gnmi_noaccess",
+		},
+		{
+			desc:       "glob",
+			identities: []Identity{{SourceDNS, "router1.example.com"}},
+			identity:   Identity{SourceDNS, "router1.example.com"},
+			key:        "*.example.com",
+			role:       "gnmi_noaccess",
+		},
+		{
+			desc:       "glob wins over regex",
+			identities: []Identity{{SourceURI, "spiffe://example.com/ns/sonic/sa/gnmi-client"}},
+			identity:   Identity{SourceURI, "spiffe://example.com/ns/sonic/sa/gnmi-client"},
+			key:        "spiffe://example.com/ns/sonic/sa/*",
+			role:       "gnmi_readwrite",
+		},
+		{
+			desc:       "regex entries are ordered by key",
+			identities: []Identity{{SourceURI, "spiffe://example.com/ns/sonic/sa/a/b"}},
+			identity:   Identity{SourceURI, "spiffe://example.com/ns/sonic/sa/a/b"},
+			key:        `spiffe://example\.com/ns/[a-z]+/sa/.*`,
+			role:       "gnmi_readonly",
+		},
+		{
+			desc:       "first identity with a match wins",
+			identities: []Identity{{SourceURI, "spiffe://other.com/x"}, {SourceCN, "host1.example.com"}},
+			identity:   Identity{SourceCN, "host1.example.com"},
+			key:        "host1.example.com",
+			role:       "gnmi_readwrite",
+		},
+		{
+			desc:       "regex email",
+			identities: []Identity{{SourceEmail, "ops-12@example.com"}},
+			identity:   Identity{SourceEmail, "ops-12@example.com"},
+			key:        `ops-[0-9]+@example\.com`,
+			role:       "gnmi_readonly",
+		},
+		{
+			desc:       "regex must match the whole identity",
+			identities: []Identity{{SourceURI, "xspiffe://example.com/ns/sonic/sa/gnmi"}},
+		},
+		{
+			desc:       "common name does not match a URI entry",
+			identities: []Identity{{SourceCN, "spiffe://example.com/ns/sonic/sa/gnmi-client"}},
+		},
+		{
+			desc:       "DNS SAN does not match a common name entry",
+			identities: []Identity{{SourceDNS, "host1.other.com"}},
+		},
+		{
+			desc:       "email SAN does not match a URI entry",
+			identities: []Identity{{SourceEmail, "spiffe://example.com/ns/sonic/sa/gnmi-client"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			identity, entry := Match(test.identities, entries)
+			if test.key == "" {
+				if entry != nil {
+					t.Errorf("Expected no match, got %v", entry.Key)
+				}
+				return
+			}
+			if entry == nil {
+				t.Fatalf("Expected %v to match %v", test.identities, test.key)
+			}
+			if identity != test.identity || entry.Key != test.key || !reflect.DeepEqual(entry.Roles, []string{test.role}) {
+				t.Errorf("Got identity %v entry %v roles %v, want %v %v %v", identity, entry.Key, entry.Roles, test.identity, test.key, test.role)
+			}
+		})
+	}
+}
@@ -0,0 +1,406 @@
+// Package checkpoint implements the SONiC gNOI checkpoint service: persistent, named snapshots of
+// the running CONFIG_DB which can be listed, diffed against the running config, rolled back to and
+// deleted, along with commit-confirmed rollback.
+//
+// Snapshots are taken and restored through the host service, so this package only depends on the
+// small Service interface, which sonic_service_client.Service satisfies.
+package checkpoint
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/golang/glog"
+	cpb "github.com/sonic-net/sonic-gnmi/proto/gnoi/checkpoint"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// Suffix appended by the host service to the name passed to CreateCheckPoint.
+	fileSuffix = ".cp.json"
+
+	// File recording the pending commit-confirmed rollback, so it survives a restart.
+	pendingFile = ".pending.json"
+
+	// Largest accepted confirm timeout, in seconds.
+	maxConfirmTimeout = 24 * 60 * 60
+)
+
+var (
+	CHECKPOINT_DIR = "/etc/sonic/checkpoints"
+
+	// Persisted config, saved after every rollback so that a reboot does not undo it.
+	CONFIG_DB_PATH = "/etc/sonic/config_db.json"
+
+	// Checkpoint names are used as file names, so only a conservative character set is accepted.
+	// Names starting with '.' are reserved for the transient checkpoints used by Diff.
+	namePattern = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]{0,63}$`)
+)
+
+// Service is the subset of the host service API used to manage checkpoints.
+type Service interface {
+	Close() error
+	ConfigReplace(config string) error
+	ConfigSave(fileName string) error
+	CreateCheckPoint(cpName string) error
+	DeleteCheckPoint(cpName string) error
+}
+
+// pendingRollback is a checkpoint which is rolled back to, unless confirmed before the deadline.
+type pendingRollback struct {
+	Name     string    `json:"name"`
+	Deadline time.Time `json:"deadline"`
+
+	timer *time.Timer
+}
+
+// Manager implements the checkpoint RPCs. A single Manager should be shared by all requests,
+// since it tracks the pending commit-confirmed rollback.
+type Manager struct {
+	connect func() (Service, error)
+
+	mu      sync.Mutex
+	pending *pendingRollback
+}
+
+// NewManager constructs a Manager which uses connect to reach the host service.
+// A commit-confirmed rollback left pending by a previous Manager is resumed, and performed
+// immediately if its deadline has already passed.
+func NewManager(connect func() (Service, error)) *Manager {
+	m := &Manager{connect: connect}
+
+	data, err := os.ReadFile(filepath.Join(CHECKPOINT_DIR, pendingFile))
+	if os.IsNotExist(err) {
+		return m
+	}
+	var pending pendingRollback
+	if err == nil {
+		err = json.Unmarshal(data, &pending)
+	}
+	if err != nil {
+		log.Errorf("Checkpoint: ignoring unreadable pending rollback: %v", err)
+		return m
+	}
+
+	log.Infof("Checkpoint: resuming rollback to %q at %v", pending.Name, pending.Deadline)
+	m.mu.Lock()
+	m.arm(&pending)
+	m.mu.Unlock()
+	return m
+}
+
+// Close stops the timer of any pending rollback. The rollback stays recorded on disk, and is
+// resumed by the next Manager.
+func (m *Manager) Close() {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.pending != nil {
+		m.pending.timer.Stop()
+		m.pending = nil
+	}
+}
+
+// Create saves the running config as a new checkpoint. If a confirm timeout is requested, the
+// running config is rolled back to the checkpoint unless Confirm is called before it expires.
+// Only one rollback may be pending at a time.
+func (m *Manager) Create(ctx context.Context, req *cpb.CreateCheckpointRequest) (*cpb.CreateCheckpointResponse, error) {
+	name := req.GetName()
+	if err := validateName(name); err != nil {
+		return nil, err
+	}
+	if req.GetConfirmTimeout() > maxConfirmTimeout {
+		return nil, status.Errorf(codes.InvalidArgument, "confirm_timeout cannot exceed %d seconds", maxConfirmTimeout)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if req.GetConfirmTimeout() > 0 && m.pending != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "rollback to checkpoint %q is already pending", m.pending.Name)
+	}
+	if _, err := os.Stat(checkpointFile(name)); err == nil {
+		return nil, status.Errorf(codes.AlreadyExists, "checkpoint %q already exists", name)
+	}
+	if err := os.MkdirAll(CHECKPOINT_DIR, 0755); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create checkpoint directory: %v", err)
+	}
+
+	if err := m.withService(func(sc Service) error {
+		return sc.CreateCheckPoint(checkpointName(name))
+	}); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create checkpoint %q: %v", name, err)
+	}
+
+	info, err := os.Stat(checkpointFile(name))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "checkpoint %q was not written: %v", name, err)
+	}
+	checkpoint := &cpb.Checkpoint{Name: name, Created: info.ModTime().UnixNano()}
+
+	if timeout := req.GetConfirmTimeout(); timeout > 0 {
+		pending := &pendingRollback{Name: name, Deadline: time.Now().Add(time.Duration(timeout) * time.Second)}
+		if err := savePending(pending); err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to record pending rollback: %v", err)
+		}
+		m.arm(pending)
+		checkpoint.ConfirmDeadline = pending.Deadline.UnixNano()
+		log.Infof("Checkpoint: rolling back to %q at %v unless confirmed", name, pending.Deadline)
+	}
+
+	return &cpb.CreateCheckpointResponse{Checkpoint: checkpoint}, nil
+}
+
+// List returns the saved checkpoints, ordered by name.
+func (m *Manager) List(ctx context.Context, req *cpb.ListCheckpointsRequest) (*cpb.ListCheckpointsResponse, error) {
+	entries, err := os.ReadDir(CHECKPOINT_DIR)
+	if os.IsNotExist(err) {
+		return &cpb.ListCheckpointsResponse{}, nil
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to read checkpoint directory: %v", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	resp := &cpb.ListCheckpointsResponse{}
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), fileSuffix)
+		if entry.IsDir() || name == entry.Name() || !namePattern.MatchString(name) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			// Deleted since the directory was read
+			continue
+		}
+
+		checkpoint := &cpb.Checkpoint{Name: name, Created: info.ModTime().UnixNano()}
+		if m.pending != nil && m.pending.Name == name {
+			checkpoint.ConfirmDeadline = m.pending.Deadline.UnixNano()
+		}
+		resp.Checkpoints = append(resp.Checkpoints, checkpoint)
+	}
+
+	return resp, nil
+}
+
+// Diff returns the JSON patch which transforms the checkpoint into the running config. The running
+// config is captured in a transient checkpoint, which is deleted before returning.
+func (m *Manager) Diff(ctx context.Context, req *cpb.DiffCheckpointRequest) (*cpb.DiffCheckpointResponse, error) {
+	name := req.GetName()
+	if err := validateName(name); err != nil {
+		return nil, err
+	}
+	saved, err := loadCheckpoint(name)
+	if err != nil {
+		return nil, err
+	}
+
+	suffix := make([]byte, 6)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to generate random suffix: %v", err)
+	}
+	transient := ".running-" + hex.EncodeToString(suffix)
+
+	var running map[string]interface{}
+	err = m.withService(func(sc Service) error {
+		if err := sc.CreateCheckPoint(checkpointName(transient)); err != nil {
+			return err
+		}
+		defer sc.DeleteCheckPoint(checkpointName(transient))
+
+		var readErr error
+		running, readErr = readConfig(checkpointFile(transient))
+		return readErr
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to read running config: %v", err)
+	}
+
+	patch, err := json.Marshal(Diff(saved, running))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to marshal patch: %v", err)
+	}
+	return &cpb.DiffCheckpointResponse{Patch: string(patch)}, nil
+}
+
+// Rollback replaces the running config with the checkpoint, and saves it. Rolling back to the
+// checkpoint of a pending rollback performs that rollback now.
+func (m *Manager) Rollback(ctx context.Context, req *cpb.RollbackCheckpointRequest) (*cpb.RollbackCheckpointResponse, error) {
+	name := req.GetName()
+	if err := validateName(name); err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(checkpointFile(name)); os.IsNotExist(err) {
+		return nil, status.Errorf(codes.NotFound, "checkpoint %q does not exist", name)
+	}
+
+	m.mu.Lock()
+	if m.pending != nil && m.pending.Name == name {
+		m.disarm()
+	}
+	m.mu.Unlock()
+
+	if err := m.rollback(name); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to roll back to checkpoint %q: %v", name, err)
+	}
+
+	return &cpb.RollbackCheckpointResponse{}, nil
+}
+
+// Delete removes the checkpoint. The checkpoint of a pending rollback cannot be deleted.
+func (m *Manager) Delete(ctx context.Context, req *cpb.DeleteCheckpointRequest) (*cpb.DeleteCheckpointResponse, error) {
+	name := req.GetName()
+	if err := validateName(name); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.pending != nil && m.pending.Name == name {
+		return nil, status.Errorf(codes.FailedPrecondition, "checkpoint %q has a pending rollback, confirm it first", name)
+	}
+	if _, err := os.Stat(checkpointFile(name)); os.IsNotExist(err) {
+		return nil, status.Errorf(codes.NotFound, "checkpoint %q does not exist", name)
+	}
+
+	if err := m.withService(func(sc Service) error {
+		return sc.DeleteCheckPoint(checkpointName(name))
+	}); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to delete checkpoint %q: %v", name, err)
+	}
+
+	return &cpb.DeleteCheckpointResponse{}, nil
+}
+
+// Confirm cancels the pending rollback to the checkpoint. The checkpoint itself is kept.
+func (m *Manager) Confirm(ctx context.Context, req *cpb.ConfirmCheckpointRequest) (*cpb.ConfirmCheckpointResponse, error) {
+	name := req.GetName()
+	if err := validateName(name); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.pending == nil || m.pending.Name != name {
+		return nil, status.Errorf(codes.FailedPrecondition, "no rollback to checkpoint %q is pending", name)
+	}
+
+	m.disarm()
+	log.Infof("Checkpoint: confirmed, cancelled rollback to %q", name)
+	return &cpb.ConfirmCheckpointResponse{}, nil
+}
+
+// Helper which starts the timer of a pending rollback. Must be called with mu held.
+func (m *Manager) arm(pending *pendingRollback) {
+	m.pending = pending
+	pending.timer = time.AfterFunc(time.Until(pending.Deadline), func() {
+		m.mu.Lock()
+		if m.pending != pending {
+			// Confirmed, or replaced, while the timer fired
+			m.mu.Unlock()
+			return
+		}
+		m.disarm()
+		m.mu.Unlock()
+
+		log.Warningf("Checkpoint: not confirmed by %v, rolling back to %q", pending.Deadline, pending.Name)
+		if err := m.rollback(pending.Name); err != nil {
+			log.Errorf("Checkpoint: failed to roll back to %q: %v", pending.Name, err)
+		}
+	})
+}
+
+// Helper which cancels the pending rollback. Must be called with mu held.
+func (m *Manager) disarm() {
+	m.pending.timer.Stop()
+	m.pending = nil
+	if err := os.Remove(filepath.Join(CHECKPOINT_DIR, pendingFile)); err != nil && !os.IsNotExist(err) {
+		log.Errorf("Checkpoint: failed to remove pending rollback record: %v", err)
+	}
+}
+
+// Helper which replaces the running config with the checkpoint, and saves it.
+func (m *Manager) rollback(name string) error {
+	config, err := os.ReadFile(checkpointFile(name))
+	if err != nil {
+		return err
+	}
+
+	return m.withService(func(sc Service) error {
+		if err := sc.ConfigReplace(string(config)); err != nil {
+			return err
+		}
+		return sc.ConfigSave(CONFIG_DB_PATH)
+	})
+}
+
+// Helper which runs fn with a connection to the host service.
+func (m *Manager) withService(fn func(sc Service) error) error {
+	sc, err := m.connect()
+	if err != nil {
+		return err
+	}
+	defer sc.Close()
+	return fn(sc)
+}
+
+func validateName(name string) error {
+	if !namePattern.MatchString(name) {
+		return status.Errorf(codes.InvalidArgument,
+			"invalid checkpoint name %q, expected up to 64 letters, digits, '_', '.' or '-'", name)
+	}
+	return nil
+}
+
+// Helper which returns the name passed to the host service, which appends fileSuffix.
+func checkpointName(name string) string {
+	return filepath.Join(CHECKPOINT_DIR, name)
+}
+
+func checkpointFile(name string) string {
+	return checkpointName(name) + fileSuffix
+}
+
+func loadCheckpoint(name string) (map[string]interface{}, error) {
+	config, err := readConfig(checkpointFile(name))
+	if os.IsNotExist(err) {
+		return nil, status.Errorf(codes.NotFound, "checkpoint %q does not exist", name)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to read checkpoint %q: %v", name, err)
+	}
+	return config, nil
+}
+
+func readConfig(fileName string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	var config map[string]interface{}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("invalid config in '%s': %v", fileName, err)
+	}
+	return config, nil
+}
+
+func savePending(pending *pendingRollback) error {
+	data, err := json.Marshal(pending)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(CHECKPOINT_DIR, pendingFile), data, 0644)
+}
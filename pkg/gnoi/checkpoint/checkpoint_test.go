@@ -0,0 +1,307 @@
+package checkpoint
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	cpb "github.com/sonic-net/sonic-gnmi/proto/gnoi/checkpoint"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeService emulates the host service, snapshotting and replacing an in-memory running config.
+type fakeService struct {
+	mu       sync.Mutex
+	running  string
+	saved    []string
+	replaced int
+	err      error
+}
+
+func (f *fakeService) Close() error { return nil }
+
+func (f *fakeService) ConfigReplace(config string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	f.running = config
+	f.replaced++
+	return nil
+}
+
+func (f *fakeService) ConfigSave(fileName string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.saved = append(f.saved, fileName)
+	return nil
+}
+
+func (f *fakeService) CreateCheckPoint(cpName string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	return os.WriteFile(cpName+fileSuffix, []byte(f.running), 0644)
+}
+
+func (f *fakeService) DeleteCheckPoint(cpName string) error {
+	return os.Remove(cpName + fileSuffix)
+}
+
+func (f *fakeService) setRunning(config string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.running = config
+}
+
+func (f *fakeService) replacements() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.replaced
+}
+
+// Helper which points the checkpoint directory at a temporary directory, and constructs a
+// Manager backed by a fake host service.
+func setupManager(t *testing.T) (*Manager, *fakeService) {
+	t.Helper()
+
+	originalDir := CHECKPOINT_DIR
+	t.Cleanup(func() { CHECKPOINT_DIR = originalDir })
+	CHECKPOINT_DIR = filepath.Join(t.TempDir(), "checkpoints")
+
+	fake := &fakeService{running: `{"PORT": {"Ethernet0": {"mtu": "9100"}}}`}
+	m := NewManager(func() (Service, error) { return fake, nil })
+	t.Cleanup(m.Close)
+	return m, fake
+}
+
+func checkCode(t *testing.T, err error, code codes.Code) {
+	t.Helper()
+	if status.Code(err) != code {
+		t.Fatalf("expected %v, got: %v", code, err)
+	}
+}
+
+func TestCreateListDelete(t *testing.T) {
+	m, _ := setupManager(t)
+	ctx := context.Background()
+
+	// No directory yet
+	resp, err := m.List(ctx, &cpb.ListCheckpointsRequest{})
+	if err != nil || len(resp.GetCheckpoints()) != 0 {
+		t.Fatalf("expected no checkpoints, got %v, %v", resp, err)
+	}
+
+	for _, name := range []string{"second", "first"} {
+		created, err := m.Create(ctx, &cpb.CreateCheckpointRequest{Name: name})
+		if err != nil {
+			t.Fatalf("Create(%q) failed: %v", name, err)
+		}
+		if created.GetCheckpoint().GetName() != name || created.GetCheckpoint().GetCreated() == 0 {
+			t.Errorf("unexpected checkpoint: %v", created.GetCheckpoint())
+		}
+		if created.GetCheckpoint().GetConfirmDeadline() != 0 {
+			t.Errorf("unexpected confirm deadline without timeout")
+		}
+	}
+
+	_, err = m.Create(ctx, &cpb.CreateCheckpointRequest{Name: "first"})
+	checkCode(t, err, codes.AlreadyExists)
+
+	resp, err = m.List(ctx, &cpb.ListCheckpointsRequest{})
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(resp.GetCheckpoints()) != 2 || resp.GetCheckpoints()[0].GetName() != "first" || resp.GetCheckpoints()[1].GetName() != "second" {
+		t.Fatalf("unexpected checkpoints: %v", resp.GetCheckpoints())
+	}
+
+	if _, err := m.Delete(ctx, &cpb.DeleteCheckpointRequest{Name: "first"}); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	_, err = m.Delete(ctx, &cpb.DeleteCheckpointRequest{Name: "first"})
+	checkCode(t, err, codes.NotFound)
+
+	resp, _ = m.List(ctx, &cpb.ListCheckpointsRequest{})
+	if len(resp.GetCheckpoints()) != 1 || resp.GetCheckpoints()[0].GetName() != "second" {
+		t.Fatalf("unexpected checkpoints after delete: %v", resp.GetCheckpoints())
+	}
+}
+
+func TestInvalidRequests(t *testing.T) {
+	m, fake := setupManager(t)
+	ctx := context.Background()
+
+	for _, name := range []string{"", ".hidden", "../config", "a/b", "has space"} {
+		_, err := m.Create(ctx, &cpb.CreateCheckpointRequest{Name: name})
+		checkCode(t, err, codes.InvalidArgument)
+		_, err = m.Rollback(ctx, &cpb.RollbackCheckpointRequest{Name: name})
+		checkCode(t, err, codes.InvalidArgument)
+	}
+
+	_, err := m.Create(ctx, &cpb.CreateCheckpointRequest{Name: "cp", ConfirmTimeout: maxConfirmTimeout + 1})
+	checkCode(t, err, codes.InvalidArgument)
+
+	_, err = m.Diff(ctx, &cpb.DiffCheckpointRequest{Name: "missing"})
+	checkCode(t, err, codes.NotFound)
+	_, err = m.Rollback(ctx, &cpb.RollbackCheckpointRequest{Name: "missing"})
+	checkCode(t, err, codes.NotFound)
+	_, err = m.Confirm(ctx, &cpb.ConfirmCheckpointRequest{Name: "missing"})
+	checkCode(t, err, codes.FailedPrecondition)
+
+	fake.err = errors.New("host service unavailable")
+	_, err = m.Create(ctx, &cpb.CreateCheckpointRequest{Name: "cp"})
+	checkCode(t, err, codes.Internal)
+}
+
+func TestDiffRunningConfig(t *testing.T) {
+	m, fake := setupManager(t)
+	ctx := context.Background()
+
+	if _, err := m.Create(ctx, &cpb.CreateCheckpointRequest{Name: "base"}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	fake.setRunning(`{"PORT": {"Ethernet0": {"mtu": "1500"}}, "VLAN": {"Vlan10": {"vlanid": "10"}}}`)
+
+	resp, err := m.Diff(ctx, &cpb.DiffCheckpointRequest{Name: "base"})
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+
+	var patch []PatchOperation
+	if err := json.Unmarshal([]byte(resp.GetPatch()), &patch); err != nil {
+		t.Fatalf("invalid patch %q: %v", resp.GetPatch(), err)
+	}
+	if len(patch) != 2 ||
+		patch[0].Op != "replace" || patch[0].Path != "/PORT/Ethernet0/mtu" || patch[0].Value != "1500" ||
+		patch[1].Op != "add" || patch[1].Path != "/VLAN" {
+		t.Errorf("unexpected patch: %s", resp.GetPatch())
+	}
+
+	// The transient checkpoint of the running config is removed
+	entries, _ := os.ReadDir(CHECKPOINT_DIR)
+	if len(entries) != 1 {
+		t.Errorf("expected only the saved checkpoint, found %d files", len(entries))
+	}
+}
+
+func TestRollback(t *testing.T) {
+	m, fake := setupManager(t)
+	ctx := context.Background()
+
+	if _, err := m.Create(ctx, &cpb.CreateCheckpointRequest{Name: "base"}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	original := fake.running
+	fake.setRunning(`{}`)
+
+	if _, err := m.Rollback(ctx, &cpb.RollbackCheckpointRequest{Name: "base"}); err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+	if fake.running != original {
+		t.Errorf("running config was not restored, got %s", fake.running)
+	}
+	if len(fake.saved) != 1 || fake.saved[0] != CONFIG_DB_PATH {
+		t.Errorf("restored config was not saved: %v", fake.saved)
+	}
+
+	fake.err = errors.New("replace failed")
+	_, err := m.Rollback(ctx, &cpb.RollbackCheckpointRequest{Name: "base"})
+	checkCode(t, err, codes.Internal)
+}
+
+func TestConfirm(t *testing.T) {
+	m, fake := setupManager(t)
+	ctx := context.Background()
+
+	created, err := m.Create(ctx, &cpb.CreateCheckpointRequest{Name: "base", ConfirmTimeout: 60})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if created.GetCheckpoint().GetConfirmDeadline() == 0 {
+		t.Fatal("expected a confirm deadline")
+	}
+
+	// Only one rollback may be pending, and its checkpoint cannot be deleted
+	_, err = m.Create(ctx, &cpb.CreateCheckpointRequest{Name: "other", ConfirmTimeout: 60})
+	checkCode(t, err, codes.FailedPrecondition)
+	_, err = m.Delete(ctx, &cpb.DeleteCheckpointRequest{Name: "base"})
+	checkCode(t, err, codes.FailedPrecondition)
+
+	list, _ := m.List(ctx, &cpb.ListCheckpointsRequest{})
+	if list.GetCheckpoints()[0].GetConfirmDeadline() != created.GetCheckpoint().GetConfirmDeadline() {
+		t.Errorf("List does not report the confirm deadline: %v", list.GetCheckpoints())
+	}
+
+	if _, err := m.Confirm(ctx, &cpb.ConfirmCheckpointRequest{Name: "base"}); err != nil {
+		t.Fatalf("Confirm failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(CHECKPOINT_DIR, pendingFile)); !os.IsNotExist(err) {
+		t.Errorf("pending rollback record was not removed")
+	}
+	_, err = m.Confirm(ctx, &cpb.ConfirmCheckpointRequest{Name: "base"})
+	checkCode(t, err, codes.FailedPrecondition)
+
+	if _, err := m.Delete(ctx, &cpb.DeleteCheckpointRequest{Name: "base"}); err != nil {
+		t.Fatalf("Delete after confirm failed: %v", err)
+	}
+	if fake.replacements() != 0 {
+		t.Errorf("confirmed checkpoint was rolled back")
+	}
+}
+
+func TestAutoRollback(t *testing.T) {
+	m, fake := setupManager(t)
+	ctx := context.Background()
+
+	if _, err := m.Create(ctx, &cpb.CreateCheckpointRequest{Name: "base", ConfirmTimeout: 1}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	fake.setRunning(`{}`)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for fake.replacements() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("running config was not rolled back")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	_, err := m.Confirm(ctx, &cpb.ConfirmCheckpointRequest{Name: "base"})
+	checkCode(t, err, codes.FailedPrecondition)
+}
+
+func TestResumePendingRollback(t *testing.T) {
+	m, fake := setupManager(t)
+	ctx := context.Background()
+
+	if _, err := m.Create(ctx, &cpb.CreateCheckpointRequest{Name: "base", ConfirmTimeout: 60}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	m.Close()
+
+	// An expired deadline recorded by a previous Manager is rolled back straight away
+	expired := &pendingRollback{Name: "base", Deadline: time.Now().Add(-time.Second)}
+	if err := savePending(expired); err != nil {
+		t.Fatalf("Failed to record pending rollback: %v", err)
+	}
+	resumed := NewManager(func() (Service, error) { return fake, nil })
+	defer resumed.Close()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for fake.replacements() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("resumed rollback was not performed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
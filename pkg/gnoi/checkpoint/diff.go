@@ -0,0 +1,63 @@
+package checkpoint
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// PatchOperation is a single RFC 6902 JSON patch operation.
+type PatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// Diff returns the JSON patch which transforms from into to.
+//
+// Objects are compared recursively, and any other value (including arrays) is replaced as a whole.
+// Operations are ordered by path, so the patch for a given pair of configs is stable.
+func Diff(from, to map[string]interface{}) []PatchOperation {
+	return diffObjects("", from, to, nil)
+}
+
+func diffObjects(prefix string, from, to map[string]interface{}, patch []PatchOperation) []PatchOperation {
+	keys := make([]string, 0, len(from)+len(to))
+	for key := range from {
+		keys = append(keys, key)
+	}
+	for key := range to {
+		if _, ok := from[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		path := prefix + "/" + escapePointer(key)
+		oldValue, inFrom := from[key]
+		newValue, inTo := to[key]
+
+		switch {
+		case !inTo:
+			patch = append(patch, PatchOperation{Op: "remove", Path: path})
+		case !inFrom:
+			patch = append(patch, PatchOperation{Op: "add", Path: path, Value: newValue})
+		default:
+			oldObject, oldIsObject := oldValue.(map[string]interface{})
+			newObject, newIsObject := newValue.(map[string]interface{})
+			if oldIsObject && newIsObject {
+				patch = diffObjects(path, oldObject, newObject, patch)
+			} else if !reflect.DeepEqual(oldValue, newValue) {
+				patch = append(patch, PatchOperation{Op: "replace", Path: path, Value: newValue})
+			}
+		}
+	}
+
+	return patch
+}
+
+// Helper which escapes a key for use as a JSON pointer reference token, per RFC 6901.
+func escapePointer(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
@@ -0,0 +1,66 @@
+package checkpoint
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	testCases := []struct {
+		name  string
+		from  string
+		to    string
+		patch string
+	}{
+		{
+			name:  "identical",
+			from:  `{"PORT": {"Ethernet0": {"mtu": "9100"}}}`,
+			to:    `{"PORT": {"Ethernet0": {"mtu": "9100"}}}`,
+			patch: `null`,
+		},
+		{
+			name:  "nested changes are ordered by path",
+			from:  `{"PORT": {"Ethernet0": {"mtu": "9100", "speed": "100000"}}, "VLAN": {"Vlan10": {}}}`,
+			to:    `{"PORT": {"Ethernet0": {"mtu": "1500", "fec": "rs"}}, "ACL_TABLE": {}}`,
+			patch: `[{"op":"add","path":"/ACL_TABLE","value":{}},{"op":"add","path":"/PORT/Ethernet0/fec","value":"rs"},{"op":"replace","path":"/PORT/Ethernet0/mtu","value":"1500"},{"op":"remove","path":"/PORT/Ethernet0/speed"},{"op":"remove","path":"/VLAN"}]`,
+		},
+		{
+			name:  "arrays are replaced whole",
+			from:  `{"ACL_TABLE": {"DATAACL": {"ports": ["Ethernet0"]}}}`,
+			to:    `{"ACL_TABLE": {"DATAACL": {"ports": ["Ethernet0", "Ethernet4"]}}}`,
+			patch: `[{"op":"replace","path":"/ACL_TABLE/DATAACL/ports","value":["Ethernet0","Ethernet4"]}]`,
+		},
+		{
+			name:  "keys are escaped",
+			from:  `{"ROUTE": {}}`,
+			to:    `{"ROUTE": {"10.0.0.0/24": {"nexthop": "~1"}}}`,
+			patch: `[{"op":"add","path":"/ROUTE/10.0.0.0~124","value":{"nexthop":"~1"}}]`,
+		},
+		{
+			name:  "empty values are kept",
+			from:  `{"FEATURE": {"lldp": {"state": "enabled"}}}`,
+			to:    `{"FEATURE": {"lldp": {"state": ""}}}`,
+			patch: `[{"op":"replace","path":"/FEATURE/lldp/state","value":""}]`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var from, to map[string]interface{}
+			if err := json.Unmarshal([]byte(tc.from), &from); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal([]byte(tc.to), &to); err != nil {
+				t.Fatal(err)
+			}
+
+			patch, err := json.Marshal(Diff(from, to))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(patch) != tc.patch {
+				t.Errorf("expected patch %s, got %s", tc.patch, patch)
+			}
+		})
+	}
+}
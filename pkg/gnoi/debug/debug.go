@@ -9,6 +9,7 @@ import (
 	debug_pb "github.com/sonic-net/sonic-gnmi/proto/gnoi/debug"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"mvdan.cc/sh/v3/syntax"
 )
 
 var (
@@ -22,6 +23,11 @@ var (
 // It validates the request, then runs the command on the host, streaming responses
 // back to the client.
 //
+// MODE_CLI accepts a single command or pipeline, with the policies of ValidateCommand.
+// MODE_SHELL additionally accepts '&&' chains, with the policies of ValidateShellCommand.
+// In both modes every command must be permitted by the caller's rules, and execution is identical.
+// The timeout and byte limit of the request are capped by the limits of the matched rules.
+//
 // Responses are streamed to the client in the following order:
 //   - Request: 1, beginning of execution
 //   - Data ([]byte): 0 - many, during execution
@@ -32,7 +38,7 @@ var (
 func HandleCommandRequest(
 	req *debug_pb.DebugRequest,
 	stream debug_pb.Debug_DebugServer,
-	whitelist []string,
+	rules RuleSet,
 ) error {
 	ctx := stream.Context()
 
@@ -46,59 +52,72 @@ func HandleCommandRequest(
 		return status.Error(codes.InvalidArgument, "command cannot be nil")
 	}
 
-	err := ValidateCommand(string(command), whitelist)
+	var operators []syntax.BinCmdOperator
+	switch req.GetMode() {
+	case debug_pb.DebugRequest_MODE_CLI:
+		operators = cliOperators
+	case debug_pb.DebugRequest_MODE_SHELL:
+		operators = shellOperators
+	case debug_pb.DebugRequest_MODE_UNSPECIFIED:
+		return status.Error(codes.InvalidArgument, "mode cannot be UNSPECIFIED")
+	default:
+		return status.Errorf(codes.InvalidArgument, "unsupported mode %v", req.GetMode())
+	}
+
+	commands, err := parseCommand(string(command), operators)
+	if err != nil {
+		return status.Errorf(codes.PermissionDenied, "command failed validation: %v", err)
+	}
+	limits, err := rules.Authorize(commands)
 	if err != nil {
 		return status.Errorf(codes.PermissionDenied, "command failed validation: %v", err)
 	}
 
-	// Optional args
+	// Optional args, capped by the matched rules
 	byteLimit := req.GetByteLimit()
+	if limits.ByteLimit > 0 && (byteLimit <= 0 || limits.ByteLimit < byteLimit) {
+		byteLimit = limits.ByteLimit
+	}
 	roleAccount := req.GetRoleAccount()
-	timeout := req.GetTimeout()
+	timeout := time.Duration(req.GetTimeout())
+	if limits.MaxRuntime > 0 && (timeout <= 0 || limits.MaxRuntime < timeout) {
+		timeout = limits.MaxRuntime
+	}
 	if timeout > 0 {
-		timeoutCtx, cancel := context.WithTimeout(ctx, time.Duration(timeout))
+		timeoutCtx, cancel := context.WithTimeout(ctx, timeout)
 		defer cancel()
 		ctx = timeoutCtx
 	}
 
-	mode := req.GetMode()
 	var wg sync.WaitGroup
 	outCh := make(chan string, 100)
 	errCh := make(chan string, 100)
 
-	switch mode {
-	case debug_pb.DebugRequest_MODE_CLI:
-		// 1. Send request, indicating start of execution
-		err := sendReqInResponse(stream, req)
-		if err != nil {
-			return status.Errorf(codes.FailedPrecondition, "Failed to run command '%s': '%v'", command, err)
-		}
-
-		// 2. Send stdout/stderr
-		wg.Add(2)
-		go func() {
-			defer wg.Done()
-			streamDataInChannel(ctx, stream, outCh)
-		}()
-		go func() {
-			defer wg.Done()
-			streamDataInChannel(ctx, stream, errCh)
-		}()
-
-		exitCode, err := runCommand(ctx, outCh, errCh, roleAccount, byteLimit, string(command))
-		if err != nil {
-			return status.Errorf(codes.FailedPrecondition, "Failed to run command '%s': '%v'", command, err)
-		}
-		wg.Wait()
-
-		// 3. Send status (with exit code), indicating completion
-		sendStatusInResponse(stream, exitCode)
+	// 1. Send request, indicating start of execution
+	err = sendReqInResponse(stream, req)
+	if err != nil {
+		return status.Errorf(codes.FailedPrecondition, "Failed to run command '%s': '%v'", command, err)
+	}
 
-	case debug_pb.DebugRequest_MODE_SHELL:
-		return status.Error(codes.Unimplemented, "mode SHELL is currently unimplemented")
-	case debug_pb.DebugRequest_MODE_UNSPECIFIED:
-		return status.Error(codes.InvalidArgument, "mode cannot be UNSPECIFIED")
+	// 2. Send stdout/stderr
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		streamDataInChannel(ctx, stream, outCh)
+	}()
+	go func() {
+		defer wg.Done()
+		streamDataInChannel(ctx, stream, errCh)
+	}()
+
+	exitCode, err := runCommand(ctx, outCh, errCh, roleAccount, byteLimit, string(command))
+	if err != nil {
+		return status.Errorf(codes.FailedPrecondition, "Failed to run command '%s': '%v'", command, err)
 	}
+	wg.Wait()
+
+	// 3. Send status (with exit code), indicating completion
+	sendStatusInResponse(stream, exitCode)
 
 	return nil
 }
@@ -85,13 +85,54 @@ func TestHandleCommandRequest(t *testing.T) {
 			errType:   codes.InvalidArgument,
 		},
 		{
-			name: "Error on unimplemented SHELL mode",
+			name: "SHELL mode runs a chained pipeline",
 			req: &debug_pb.DebugRequest{
-				Command: []byte("ls"),
+				Command: []byte("show version | any && ls"),
+				Mode:    debug_pb.DebugRequest_MODE_SHELL,
+			},
+			runCmdFn: func(ctx context.Context, outCh chan<- string, errCh chan<- string, roleAccount string, byteLimit int64, cmd string) (int, error) {
+				defer func() {
+					close(outCh)
+					close(errCh)
+				}()
+
+				if cmd != "show version | any && ls" {
+					return 1, nil
+				}
+				outCh <- "SONiC Version: 202305"
+				return 0, nil
+			},
+			expectedCode: 0,
+			expectedData: []string{
+				"SONiC Version: 202305",
+			},
+		},
+		{
+			name: "Error on SHELL mode with redirect",
+			req: &debug_pb.DebugRequest{
+				Command: []byte("show version > /tmp/out"),
 				Mode:    debug_pb.DebugRequest_MODE_SHELL,
 			},
 			expectErr: true,
-			errType:   codes.Unimplemented,
+			errType:   codes.PermissionDenied,
+		},
+		{
+			name: "Error on SHELL mode with non-whitelisted chained command",
+			req: &debug_pb.DebugRequest{
+				Command: []byte("ls && rm -rf /"),
+				Mode:    debug_pb.DebugRequest_MODE_SHELL,
+			},
+			expectErr: true,
+			errType:   codes.PermissionDenied,
+		},
+		{
+			name: "Error on CLI mode with chained command",
+			req: &debug_pb.DebugRequest{
+				Command: []byte("ls && ls"),
+				Mode:    debug_pb.DebugRequest_MODE_CLI,
+			},
+			expectErr: true,
+			errType:   codes.PermissionDenied,
 		},
 		{
 			name: "Error on UNSPECIFIED mode",
@@ -181,7 +222,7 @@ func TestHandleCommandRequest(t *testing.T) {
 			return -1, ctx.Err() // Return context error
 		}
 
-		err := HandleCommandRequest(req, stream, testWhitelist)
+		err := HandleCommandRequest(req, stream, WhitelistRules(testWhitelist))
 		if err == nil {
 			t.Fatal("expected an error but got nil")
 		}
@@ -193,6 +234,38 @@ func TestHandleCommandRequest(t *testing.T) {
 		}
 	})
 
+	t.Run("Rule limits cap the request", func(t *testing.T) {
+		req := &debug_pb.DebugRequest{
+			Command:   []byte("show version"),
+			Mode:      debug_pb.DebugRequest_MODE_CLI,
+			ByteLimit: 1 << 20,
+		}
+		stream := &mockDebugServerStream{ctx: context.Background()}
+		rules := WhitelistRules(nil)
+		rules.add("limited", []Rule{{Command: "show", MaxRuntime: time.Minute, ByteLimit: 1024}})
+		var capturedByteLimit int64
+		var capturedDeadline bool
+
+		runCommand = func(ctx context.Context, outCh chan<- string, errCh chan<- string, roleAccount string, byteLimit int64, cmd string) (int, error) {
+			close(outCh)
+			close(errCh)
+			capturedByteLimit = byteLimit
+			_, capturedDeadline = ctx.Deadline()
+			return 0, nil
+		}
+		defer func() { runCommand = originalRunCommand }()
+
+		if err := HandleCommandRequest(req, stream, rules); err != nil {
+			t.Fatalf("did not expect an error but got: %v", err)
+		}
+		if capturedByteLimit != 1024 {
+			t.Errorf("expected byte limit 1024, got %d", capturedByteLimit)
+		}
+		if !capturedDeadline {
+			t.Error("expected the rule's max runtime to set a deadline")
+		}
+	})
+
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			if tc.runCmdFn != nil {
@@ -201,7 +274,7 @@ func TestHandleCommandRequest(t *testing.T) {
 			defer func() { runCommand = originalRunCommand }()
 
 			stream := &mockDebugServerStream{ctx: context.Background()}
-			err := HandleCommandRequest(tc.req, stream, testWhitelist)
+			err := HandleCommandRequest(tc.req, stream, WhitelistRules(testWhitelist))
 
 			if tc.expectErr {
 				if err == nil {
@@ -0,0 +1,431 @@
+package debug
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/golang/glog"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// Roles used for callers who hold no role with a dedicated policy, based on their access level.
+	ReadOnlyRole  = "readonly"
+	ReadWriteRole = "readwrite"
+
+	// When used as the final token of an argument pattern, matches any remaining arguments.
+	anyArgs = "**"
+)
+
+var (
+	POLICY_FILE_PATH = "/etc/sonic/command_policy.yaml"
+)
+
+// Rule permits a single command, optionally restricted to a set of argument patterns.
+//
+// Each pattern is a space separated list of tokens, matched positionally against the command's
+// arguments using path.Match syntax, except that '*' and '?' also match '/' so that 'cat *' permits
+// 'cat /var/log/syslog'. A wildcard never matches a '..' path element. A final token of '**'
+// matches any remaining arguments, and an empty pattern matches a command with no arguments.
+// A rule without patterns permits any arguments.
+//
+// MaxRuntime and ByteLimit, when non-zero, cap the values requested by the client.
+type Rule struct {
+	Command    string        `yaml:"command"`
+	Args       []string      `yaml:"args"`
+	MaxRuntime time.Duration `yaml:"max_runtime"`
+	ByteLimit  int64         `yaml:"byte_limit"`
+
+	role string
+}
+
+// PolicyFile is the format of the YAML file present at `/etc/sonic/command_policy.yaml`, e.g.
+//
+//	roles:
+//	  readonly:
+//	    - command: show
+//	      max_runtime: 30s
+//	    - command: ip
+//	      args: ["link show **", "route show **"]
+//	  gnoi_debug_oncall:
+//	    - command: ip
+//	      args: ["link set * down", "link set * up"]
+//	      byte_limit: 4096
+type PolicyFile struct {
+	Roles map[string][]Rule `yaml:"roles"`
+}
+
+// Policy holds the rules permitted for each role.
+type Policy struct {
+	roles map[string][]Rule
+}
+
+// NewPolicy validates the provided rules, and constructs a policy from them.
+func NewPolicy(roles map[string][]Rule) (*Policy, error) {
+	policy := &Policy{roles: make(map[string][]Rule, len(roles))}
+	for role, rules := range roles {
+		bound := make([]Rule, 0, len(rules))
+		for i, rule := range rules {
+			if err := rule.validate(); err != nil {
+				return nil, fmt.Errorf("role %q rule %d: %v", role, i, err)
+			}
+			rule.role = role
+			bound = append(bound, rule)
+		}
+		policy.roles[role] = bound
+	}
+
+	return policy, nil
+}
+
+// RulesFor returns the rules permitted for a caller holding the provided roles.
+//
+// If any of the roles has a dedicated policy, the caller is permitted the union of those policies.
+// Otherwise the caller falls back to the 'readonly' policy, along with the 'readwrite' policy
+// if they have write access. The 'readonly' and 'readwrite' policies are only ever chosen by access
+// level, so a role which happens to share their name is not granted them.
+func (p *Policy) RulesFor(roles []string, writeAccess bool) RuleSet {
+	var rs RuleSet
+	for _, role := range roles {
+		if role == ReadOnlyRole || role == ReadWriteRole {
+			continue
+		}
+		if rules, ok := p.roles[role]; ok {
+			rs.add(role, rules)
+		}
+	}
+	if len(rs.roles) > 0 {
+		return rs
+	}
+
+	rs.add(ReadOnlyRole, p.roles[ReadOnlyRole])
+	if writeAccess {
+		rs.add(ReadWriteRole, p.roles[ReadWriteRole])
+	}
+	return rs
+}
+
+// RuleSet is the collection of rules which apply to a single caller.
+type RuleSet struct {
+	roles []string
+	rules []Rule
+}
+
+// Limits are the tightest execution limits of the rules matched by a command.
+// Zero values mean no limit.
+type Limits struct {
+	MaxRuntime time.Duration
+	ByteLimit  int64
+}
+
+// WhitelistRules constructs a rule set permitting each whitelisted command, with any arguments.
+func WhitelistRules(whitelist []string) RuleSet {
+	var rs RuleSet
+	rs.add("whitelist", rulesFromWhitelist(whitelist))
+	return rs
+}
+
+func (rs *RuleSet) add(role string, rules []Rule) {
+	rs.roles = append(rs.roles, role)
+	for _, rule := range rules {
+		rule.role = role
+		rs.rules = append(rs.rules, rule)
+	}
+}
+
+// Authorize checks every command (as returned by parseCommand) against the rule set.
+// The first matching rule for each command is used.
+//
+// Returns the tightest limits of the matched rules, or ErrRejected explaining which command failed.
+func (rs RuleSet) Authorize(commands [][]string) (Limits, error) {
+	var limits Limits
+	for i, argv := range commands {
+		rule, err := rs.match(argv)
+		if err != nil {
+			return Limits{}, fmt.Errorf("%w: command %d: %v", ErrRejected, i+1, err)
+		}
+
+		if rule.MaxRuntime > 0 && (limits.MaxRuntime == 0 || rule.MaxRuntime < limits.MaxRuntime) {
+			limits.MaxRuntime = rule.MaxRuntime
+		}
+		if rule.ByteLimit > 0 && (limits.ByteLimit == 0 || rule.ByteLimit < limits.ByteLimit) {
+			limits.ByteLimit = rule.ByteLimit
+		}
+	}
+
+	return limits, nil
+}
+
+// Helper which finds the first rule permitting argv.
+// Returns an error describing the rules which were considered, if none match.
+func (rs RuleSet) match(argv []string) (Rule, error) {
+	cmdName, args := argv[0], argv[1:]
+
+	var considered []string
+	for _, rule := range rs.rules {
+		if rule.Command != cmdName {
+			continue
+		}
+		if rule.matchArgs(args) {
+			return rule, nil
+		}
+		considered = append(considered, fmt.Sprintf("role %q allows %q", rule.role, rule.Args))
+	}
+
+	if len(considered) == 0 {
+		return Rule{}, fmt.Errorf("command %q is not permitted for roles %q", cmdName, rs.roles)
+	}
+	return Rule{}, fmt.Errorf("arguments %q of command %q do not match any rule (%s)",
+		strings.Join(args, " "), cmdName, strings.Join(considered, "; "))
+}
+
+// Helper which checks the rule is well formed.
+func (r *Rule) validate() error {
+	if r.Command == "" {
+		return errors.New("command cannot be empty")
+	}
+	if r.MaxRuntime < 0 {
+		return fmt.Errorf("max_runtime cannot be negative, got %v", r.MaxRuntime)
+	}
+	if r.ByteLimit < 0 {
+		return fmt.Errorf("byte_limit cannot be negative, got %d", r.ByteLimit)
+	}
+
+	for _, pattern := range r.Args {
+		tokens := strings.Fields(pattern)
+		for i, token := range tokens {
+			if token == anyArgs {
+				if i != len(tokens)-1 {
+					return fmt.Errorf("pattern %q: '%s' must be the final token", pattern, anyArgs)
+				}
+				continue
+			}
+			if _, err := path.Match(token, ""); err != nil {
+				return fmt.Errorf("pattern %q: %v", pattern, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Helper which checks whether args match any of the rule's argument patterns.
+func (r *Rule) matchArgs(args []string) bool {
+	if r.Args == nil {
+		return true
+	}
+
+	for _, pattern := range r.Args {
+		if matchPattern(strings.Fields(pattern), args) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Helper which positionally matches args against the pattern tokens.
+func matchPattern(tokens, args []string) bool {
+	for i, token := range tokens {
+		if token == anyArgs && i == len(tokens)-1 {
+			return true
+		}
+		if i >= len(args) {
+			return false
+		}
+		if !matchToken(token, args[i]) {
+			return false
+		}
+	}
+
+	return len(args) == len(tokens)
+}
+
+// Helper which matches a single argument against a pattern token. '/' is swapped for NUL, which
+// cannot appear in an argument, so that path.Match lets '*' and '?' span it.
+func matchToken(token, arg string) bool {
+	if hasDotDot(arg) && !hasDotDot(token) {
+		return false
+	}
+
+	const sep = "\x00"
+	ok, _ := path.Match(strings.ReplaceAll(token, "/", sep), strings.ReplaceAll(arg, "/", sep))
+	return ok
+}
+
+// Helper which checks whether s has a '..' path element.
+func hasDotDot(s string) bool {
+	for _, elem := range strings.Split(s, "/") {
+		if elem == ".." {
+			return true
+		}
+	}
+	return false
+}
+
+// Helper which constructs rules permitting each command in the whitelist, with any arguments.
+func rulesFromWhitelist(whitelist []string) []Rule {
+	rules := make([]Rule, 0, len(whitelist))
+	for _, cmd := range whitelist {
+		rules = append(rules, Rule{Command: cmd})
+	}
+
+	return rules
+}
+
+// Helper which constructs the 'readonly' and 'readwrite' policies from flat whitelists.
+func policyFromWhitelists(read, write []string) *Policy {
+	policy, _ := NewPolicy(map[string][]Rule{
+		ReadOnlyRole:  rulesFromWhitelist(read),
+		ReadWriteRole: rulesFromWhitelist(write),
+	})
+	return policy
+}
+
+// LoadPolicy constructs a policy from the YAML file present at `/etc/sonic/command_policy.yaml`.
+// If there is no such file, falls back to the flat whitelists read by ConstructWhitelists.
+//
+// Returns an error if the policy file exists, but cannot be read or is invalid.
+func LoadPolicy() (*Policy, error) {
+	policyBytes, err := os.ReadFile(POLICY_FILE_PATH)
+	if os.IsNotExist(err) {
+		glog.V(2).Infof("No policy found at path '%s', using whitelists", POLICY_FILE_PATH)
+		return policyFromWhitelists(ConstructWhitelists()), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read policy at '%s': %v", POLICY_FILE_PATH, err)
+	}
+
+	var policyFile PolicyFile
+	if err := yaml.Unmarshal(policyBytes, &policyFile); err != nil {
+		return nil, fmt.Errorf("could not unmarshal policy at '%s': %v", POLICY_FILE_PATH, err)
+	}
+	if len(policyFile.Roles) == 0 {
+		return nil, fmt.Errorf("policy at '%s' does not define any roles", POLICY_FILE_PATH)
+	}
+
+	policy, err := NewPolicy(policyFile.Roles)
+	if err != nil {
+		return nil, fmt.Errorf("invalid policy at '%s': %v", POLICY_FILE_PATH, err)
+	}
+	return policy, nil
+}
+
+// PolicyStore holds the current policy, reloading it when the policy or whitelist files change.
+type PolicyStore struct {
+	mu      sync.RWMutex
+	policy  *Policy
+	watcher *fsnotify.Watcher
+	done    chan struct{}
+}
+
+// NewPolicyStore loads the current policy. If the policy file is invalid, the configured whitelists
+// are used until a valid policy is reloaded.
+func NewPolicyStore() *PolicyStore {
+	policy, err := LoadPolicy()
+	if err != nil {
+		glog.Errorf("%v, using whitelists until the policy is fixed", err)
+		policy = policyFromWhitelists(ConstructWhitelists())
+	}
+
+	return &PolicyStore{policy: policy}
+}
+
+// Policy returns the current policy.
+func (s *PolicyStore) Policy() *Policy {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.policy
+}
+
+// Reload replaces the current policy with the one on disk.
+// If the policy on disk is invalid, the current policy is kept.
+func (s *PolicyStore) Reload() error {
+	policy, err := LoadPolicy()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.policy = policy
+	s.mu.Unlock()
+	return nil
+}
+
+// Watch starts reloading the policy whenever the policy or whitelist files are written, created,
+// renamed or removed. Directories are watched, rather than the files, so that files which are
+// replaced or do not yet exist are still picked up.
+func (s *PolicyStore) Watch() error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	files := map[string]bool{
+		filepath.Clean(POLICY_FILE_PATH):    true,
+		filepath.Clean(WHITELIST_FILE_PATH): true,
+	}
+	dirs := make(map[string]bool)
+	for file := range files {
+		dir := filepath.Dir(file)
+		if dirs[dir] {
+			continue
+		}
+		if err := watcher.Add(dir); err != nil {
+			watcher.Close()
+			return fmt.Errorf("could not watch '%s': %v", dir, err)
+		}
+		dirs[dir] = true
+	}
+
+	s.watcher = watcher
+	s.done = make(chan struct{})
+	go s.watch(files)
+	return nil
+}
+
+// Close stops watching for changes.
+func (s *PolicyStore) Close() {
+	if s == nil || s.watcher == nil {
+		return
+	}
+	s.watcher.Close()
+	<-s.done
+	s.watcher = nil
+}
+
+func (s *PolicyStore) watch(files map[string]bool) {
+	defer close(s.done)
+	for {
+		select {
+		case event, ok := <-s.watcher.Events:
+			if !ok {
+				return
+			}
+			if !files[filepath.Clean(event.Name)] {
+				continue
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename|fsnotify.Remove) == 0 {
+				continue
+			}
+
+			if err := s.Reload(); err != nil {
+				glog.Errorf("Failed to reload debug policy after %v, keeping current policy: %v", event, err)
+				continue
+			}
+			glog.Infof("Reloaded debug policy after %v", event)
+		case err, ok := <-s.watcher.Errors:
+			if !ok {
+				return
+			}
+			glog.Errorf("Error watching debug policy: %v", err)
+		}
+	}
+}
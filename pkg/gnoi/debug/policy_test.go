@@ -0,0 +1,305 @@
+package debug
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testPolicy = `
+roles:
+  readonly:
+    - command: show
+      max_runtime: 30s
+    - command: ip
+      args: ["link show **", "route show"]
+      byte_limit: 4096
+    - command: cat
+      args: ["/var/log/*"]
+  readwrite:
+    - command: ip
+      args: ["link set * down"]
+  oncall:
+    - command: ip
+      max_runtime: 10s
+      byte_limit: 1024
+`
+
+// Helper which points the policy and whitelist paths at a temporary directory,
+// optionally writing the provided policy.
+func setupPolicyFile(t *testing.T, content string) string {
+	t.Helper()
+
+	originalPolicy, originalWhitelist := POLICY_FILE_PATH, WHITELIST_FILE_PATH
+	t.Cleanup(func() {
+		POLICY_FILE_PATH = originalPolicy
+		WHITELIST_FILE_PATH = originalWhitelist
+	})
+
+	tempDir := t.TempDir()
+	POLICY_FILE_PATH = filepath.Join(tempDir, "command_policy.yaml")
+	WHITELIST_FILE_PATH = filepath.Join(tempDir, "command_whitelist.yaml")
+	if content != "" {
+		if err := os.WriteFile(POLICY_FILE_PATH, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write policy file: %v", err)
+		}
+	}
+
+	return tempDir
+}
+
+func TestRuleSetAuthorize(t *testing.T) {
+	setupPolicyFile(t, testPolicy)
+	policy, err := LoadPolicy()
+	if err != nil {
+		t.Fatalf("Failed to load policy: %v", err)
+	}
+
+	testCases := []struct {
+		name        string
+		roles       []string
+		writeAccess bool
+		input       string
+		allow       bool
+		limits      Limits
+		errMsg      string
+	}{
+		{
+			name:   "read user runs show",
+			input:  "show version",
+			allow:  true,
+			limits: Limits{MaxRuntime: 30 * time.Second},
+		},
+		{
+			name:   "read user runs permitted ip subcommand",
+			input:  "ip link show dev Ethernet0",
+			allow:  true,
+			limits: Limits{ByteLimit: 4096},
+		},
+		{
+			name:   "read user runs exact pattern",
+			input:  "ip route show",
+			allow:  true,
+			limits: Limits{ByteLimit: 4096},
+		},
+		{
+			name:   "read user cannot add arguments to exact pattern",
+			input:  "ip route show table all",
+			errMsg: `arguments "route show table all" of command "ip" do not match any rule`,
+		},
+		{
+			name:   "read user cannot set link down",
+			input:  "ip link set Ethernet0 down",
+			errMsg: `role "readonly" allows ["link show **" "route show"]`,
+		},
+		{
+			name:        "write user can set link down",
+			writeAccess: true,
+			input:       "ip link set Ethernet0 down",
+			allow:       true,
+		},
+		{
+			name:        "write user cannot set link up",
+			writeAccess: true,
+			input:       "ip link set Ethernet0 up",
+			errMsg:      `role "readwrite" allows ["link set * down"]`,
+		},
+		{
+			name:        "role named readwrite has no write access",
+			roles:       []string{ReadWriteRole},
+			writeAccess: false,
+			input:       "ip link set Ethernet0 down",
+			errMsg:      `command "ip" do not match any rule (role "readonly"`,
+		},
+		{
+			name:  "wildcard spans path separators",
+			input: "cat /var/log/swss/sairedis.rec",
+			allow: true,
+		},
+		{
+			name:   "wildcard does not match parent directory",
+			input:  "cat /var/log/../../etc/shadow",
+			errMsg: `arguments "/var/log/../../etc/shadow" of command "cat" do not match any rule`,
+		},
+		{
+			name:   "command without rule",
+			input:  "reboot",
+			errMsg: `command "reboot" is not permitted for roles ["readonly"]`,
+		},
+		{
+			name:   "pipeline takes tightest limits",
+			input:  "show version | ip link show",
+			allow:  true,
+			limits: Limits{MaxRuntime: 30 * time.Second, ByteLimit: 4096},
+		},
+		{
+			name:   "pipeline reports failing command",
+			input:  "show version | reboot",
+			errMsg: "command 2:",
+		},
+		{
+			name:   "dedicated role replaces access level policy",
+			roles:  []string{"gnoi_readonly", "oncall"},
+			input:  "ip link set Ethernet0 up",
+			allow:  true,
+			limits: Limits{MaxRuntime: 10 * time.Second, ByteLimit: 1024},
+		},
+		{
+			name:   "dedicated role does not inherit readonly",
+			roles:  []string{"oncall"},
+			input:  "show version",
+			errMsg: `command "show" is not permitted for roles ["oncall"]`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			commands, err := parseCommand(tc.input, shellOperators)
+			if err != nil {
+				t.Fatalf("Failed to parse command: %v", err)
+			}
+
+			limits, err := policy.RulesFor(tc.roles, tc.writeAccess).Authorize(commands)
+			if tc.allow {
+				if err != nil {
+					t.Fatalf("expected allow, got error: %v", err)
+				}
+				if limits != tc.limits {
+					t.Errorf("expected limits %+v, got %+v", tc.limits, limits)
+				}
+				return
+			}
+
+			if !errors.Is(err, ErrRejected) {
+				t.Fatalf("expected rejection error, got: %v", err)
+			}
+			if !strings.Contains(err.Error(), tc.errMsg) {
+				t.Errorf("expected error to contain %q, got: %v", tc.errMsg, err)
+			}
+		})
+	}
+}
+
+func TestNewPolicyInvalidRules(t *testing.T) {
+	testCases := []struct {
+		name string
+		rule Rule
+	}{
+		{name: "empty command", rule: Rule{}},
+		{name: "negative runtime", rule: Rule{Command: "ls", MaxRuntime: -time.Second}},
+		{name: "negative byte limit", rule: Rule{Command: "ls", ByteLimit: -1}},
+		{name: "wildcard args not final", rule: Rule{Command: "ls", Args: []string{"** foo"}}},
+		{name: "malformed pattern", rule: Rule{Command: "ls", Args: []string{"[a"}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewPolicy(map[string][]Rule{ReadOnlyRole: {tc.rule}})
+			if err == nil {
+				t.Fatal("expected an error but got nil")
+			}
+		})
+	}
+}
+
+func TestLoadPolicy(t *testing.T) {
+	t.Run("Falls back to whitelists", func(t *testing.T) {
+		tempDir := setupPolicyFile(t, "")
+		whitelist := "read_whitelist: [cmd1]\nwrite_whitelist: [cmd2]\n"
+		if err := os.WriteFile(filepath.Join(tempDir, "command_whitelist.yaml"), []byte(whitelist), 0644); err != nil {
+			t.Fatalf("Failed to write whitelist file: %v", err)
+		}
+
+		policy, err := LoadPolicy()
+		if err != nil {
+			t.Fatalf("Failed to load policy: %v", err)
+		}
+
+		if _, err := policy.RulesFor(nil, false).Authorize([][]string{{"cmd1", "any", "args"}}); err != nil {
+			t.Errorf("expected read command to be permitted: %v", err)
+		}
+		if _, err := policy.RulesFor(nil, false).Authorize([][]string{{"cmd2"}}); err == nil {
+			t.Error("expected write command to be rejected for read user")
+		}
+		if _, err := policy.RulesFor(nil, true).Authorize([][]string{{"cmd2"}}); err != nil {
+			t.Errorf("expected write command to be permitted: %v", err)
+		}
+	})
+
+	for name, content := range map[string]string{
+		"Malformed YAML": "roles: [",
+		"No roles":       "roles: {}",
+		"Invalid rule":   "roles:\n  readonly:\n    - args: [foo]\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			setupPolicyFile(t, content)
+			if _, err := LoadPolicy(); err == nil {
+				t.Fatal("expected an error but got nil")
+			}
+		})
+	}
+}
+
+func TestNewPolicyStoreInvalidPolicy(t *testing.T) {
+	tempDir := setupPolicyFile(t, "roles: [")
+	whitelist := "read_whitelist: [cmd1]\nwrite_whitelist: [cmd2]\n"
+	if err := os.WriteFile(filepath.Join(tempDir, "command_whitelist.yaml"), []byte(whitelist), 0644); err != nil {
+		t.Fatalf("Failed to write whitelist file: %v", err)
+	}
+
+	// The configured whitelist is used, not the defaults
+	rules := NewPolicyStore().Policy().RulesFor(nil, false)
+	if _, err := rules.Authorize([][]string{{"cmd1"}}); err != nil {
+		t.Errorf("expected whitelisted command to be permitted: %v", err)
+	}
+	if _, err := rules.Authorize([][]string{{"ls"}}); err == nil {
+		t.Error("expected command from the default whitelist to be rejected")
+	}
+}
+
+func TestPolicyStoreReload(t *testing.T) {
+	setupPolicyFile(t, "roles:\n  readonly:\n    - command: ls\n")
+
+	store := NewPolicyStore()
+	if err := store.Watch(); err != nil {
+		t.Fatalf("Failed to watch policy: %v", err)
+	}
+	defer store.Close()
+
+	permitted := func(cmd string) bool {
+		_, err := store.Policy().RulesFor(nil, false).Authorize([][]string{{cmd}})
+		return err == nil
+	}
+	if !permitted("ls") || permitted("cat") {
+		t.Fatal("initial policy was not loaded")
+	}
+
+	// Invalid policies are ignored
+	if err := os.WriteFile(POLICY_FILE_PATH, []byte("roles: ["), 0644); err != nil {
+		t.Fatalf("Failed to write policy file: %v", err)
+	}
+	if err := store.Reload(); err == nil {
+		t.Error("expected reload of invalid policy to fail")
+	}
+	if !permitted("ls") {
+		t.Error("invalid policy replaced the current policy")
+	}
+
+	// Valid policies are picked up without an explicit reload
+	if err := os.WriteFile(POLICY_FILE_PATH, []byte("roles:\n  readonly:\n    - command: cat\n"), 0644); err != nil {
+		t.Fatalf("Failed to write policy file: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for !permitted("cat") {
+		if time.Now().After(deadline) {
+			t.Fatal("policy was not reloaded after the file changed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if permitted("ls") {
+		t.Error("reloaded policy still permits removed command")
+	}
+}
@@ -12,6 +12,13 @@ const dangerousCharSet = "~$`&;<>(){}[]*?"
 
 var ErrRejected = errors.New("command rejected by policy")
 
+var (
+	// Operators permitted between commands in MODE_CLI: pipelines only.
+	cliOperators = []syntax.BinCmdOperator{syntax.Pipe}
+	// Operators permitted between commands in MODE_SHELL: pipelines and && chains.
+	shellOperators = []syntax.BinCmdOperator{syntax.Pipe, syntax.AndStmt}
+)
+
 // ValidateCommand parses the input shell text and, if allowed by policy (i.e. the rules within this fn).
 // Policies:
 //   - Must not contain potentially dangerous characters: $`&;<>(){}[]*?
@@ -35,9 +42,44 @@ var ErrRejected = errors.New("command rejected by policy")
 //   - Binary pipe expression (with left and right recursed upon)
 //
 // Returns nil for valid command. Otherwise returns ErrRejected.
-func ValidateCommand(input string, whitelist []string) (err error) {
+func ValidateCommand(input string, whitelist []string) error {
+	return validateCommand(input, whitelist, cliOperators)
+}
+
+// ValidateShellCommand applies the same policies as ValidateCommand, but additionally
+// permits commands to be chained with '&&', e.g. `show version && show ip bgp summary | head`.
+// Every command in the chain, on either side of each pipe, must be whitelisted.
+//
+// Returns nil for valid command. Otherwise returns ErrRejected.
+func ValidateShellCommand(input string, whitelist []string) error {
+	return validateCommand(input, whitelist, shellOperators)
+}
+
+// Helper which validates input against the policies, permitting only the given binary operators
+// between commands, then checks every command name against the whitelist.
+func validateCommand(input string, whitelist []string, operators []syntax.BinCmdOperator) error {
+	commands, err := parseCommand(input, operators)
+	if err != nil {
+		return err
+	}
+
+	for _, argv := range commands {
+		if !sliceContains(whitelist, argv[0]) {
+			return fmt.Errorf("%w: command %q is not whitelisted", ErrRejected, argv[0])
+		}
+	}
+
+	return nil
+}
+
+// Helper which parses input and validates it against the policies described on ValidateCommand,
+// permitting only the given binary operators between commands.
+//
+// Returns the argv of every command found, in order of appearance, or ErrRejected.
+func parseCommand(input string, operators []syntax.BinCmdOperator) (commands [][]string, err error) {
 	defer func() {
 		if r := recover(); r != nil {
+			commands = nil
 			err = fmt.Errorf("%w: recover error: %v", ErrRejected, r)
 		}
 	}()
@@ -46,51 +88,51 @@ func ValidateCommand(input string, whitelist []string) (err error) {
 	p := syntax.NewParser(syntax.Variant(syntax.LangBash))
 	ast, err := p.Parse(strings.NewReader(input), input)
 	if err != nil {
-		return fmt.Errorf("%w: parse error: %v", ErrRejected, err)
+		return nil, fmt.Errorf("%w: parse error: %v", ErrRejected, err)
 	}
 
 	// Reject any remaining dangerous nodes
-	unsafe := walkForDangerousNodeTypes(ast)
+	unsafe := walkForDangerousNodeTypes(ast, operators)
 	if unsafe {
-		return fmt.Errorf("%w: `%s` contains unsafe statements", ErrRejected, input)
+		return nil, fmt.Errorf("%w: `%s` contains unsafe statements", ErrRejected, input)
 	}
 
 	// Must be contain at least one statement
 	if len(ast.Stmts) == 0 {
-		return fmt.Errorf("%w: no statements found within parsed command: %s", ErrRejected, input)
+		return nil, fmt.Errorf("%w: no statements found within parsed command: %s", ErrRejected, input)
 	}
 
 	for _, statement := range ast.Stmts {
-		err := validateStatement(statement, whitelist)
+		commands, err = validateStatement(statement, operators, commands)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
 
-	return nil
+	return commands, nil
 }
 
-// Helper which validates a provided statement against statement-specific policies, along with the whitelist.
-// Recurses on any found pipelines, to validate the commands on either side.
+// Helper which validates a provided statement against statement-specific policies.
+// Recurses on any found pipelines (or permitted chains), to validate the commands on either side.
 //
-// Returns nil on success, or the specific validation error, if any.
-func validateStatement(statement *syntax.Stmt, whitelist []string) error {
+// Returns commands with the argv of each validated command appended, or the specific validation error, if any.
+func validateStatement(statement *syntax.Stmt, operators []syntax.BinCmdOperator, commands [][]string) ([][]string, error) {
 	// Disallow negation, background, coprocessing, semicolons, and redirects.
 	if statement.Negated {
-		return fmt.Errorf("%w: negation '!' not allowed", ErrRejected)
+		return nil, fmt.Errorf("%w: negation '!' not allowed", ErrRejected)
 	}
 	if statement.Background {
-		return fmt.Errorf("%w: background '&' not allowed", ErrRejected)
+		return nil, fmt.Errorf("%w: background '&' not allowed", ErrRejected)
 	}
 	if statement.Coprocess {
-		return fmt.Errorf("%w: coprocess '|&' not allowed", ErrRejected)
+		return nil, fmt.Errorf("%w: coprocess '|&' not allowed", ErrRejected)
 	}
 	// Semicolon valid -> multiple commands. Deny.
 	if statement.Semicolon.IsValid() {
-		return fmt.Errorf("%w: multiple/terminated commands not allowed", ErrRejected)
+		return nil, fmt.Errorf("%w: multiple/terminated commands not allowed", ErrRejected)
 	}
 	if len(statement.Redirs) > 0 {
-		return fmt.Errorf("%w: redirects not allowed", ErrRejected)
+		return nil, fmt.Errorf("%w: redirects not allowed", ErrRejected)
 	}
 
 	// Command must be a simple call expression or pipeline (no binary/case/...).
@@ -99,53 +141,50 @@ func validateStatement(statement *syntax.Stmt, whitelist []string) error {
 		call := statement.Cmd.(*syntax.CallExpr)
 		// Disallow assignments in the call (e.g. FOO=bar echo ...)
 		if len(call.Assigns) > 0 {
-			return fmt.Errorf("%w: inline assignments not allowed", ErrRejected)
+			return nil, fmt.Errorf("%w: inline assignments not allowed", ErrRejected)
 		}
 
 		// There must be at least one argument (the command name).
 		if len(call.Args) == 0 {
-			return fmt.Errorf("%w: empty call", ErrRejected)
+			return nil, fmt.Errorf("%w: empty call", ErrRejected)
 		}
 
 		// Every Word must be a literal (no param/cmd/arith/brace/glob/...).
+		argv := make([]string, 0, len(call.Args))
 		for i, w := range call.Args {
 			lit := w.Lit()
 			if lit == "" {
-				return fmt.Errorf("%w: word %d is not a plain literal (contains expansions/substs/globs)", ErrRejected, i)
+				return nil, fmt.Errorf("%w: word %d is not a plain literal (contains expansions/substs/globs)", ErrRejected, i)
 			}
+			argv = append(argv, lit)
 		}
 
-		// The first argv element is the command name. Look it up in whitelist.
-		cmdName := call.Args[0].Lit()
-		if !sliceContains(whitelist, cmdName) {
-			return fmt.Errorf("%w: command %q is not whitelisted", ErrRejected, cmdName)
-		}
+		// The first argv element is the command name, checked by the caller.
+		commands = append(commands, argv)
 	case *syntax.BinaryCmd:
 		binCmd := statement.Cmd.(*syntax.BinaryCmd)
-		// Only allow pipeline
-		if binCmd.Op != syntax.Pipe {
-			return fmt.Errorf("%w: only simple commands and pipelines allowed (no subshells, control structures, etc)", ErrRejected)
+		// Only allow pipeline (and chains, where permitted)
+		if !operatorAllowed(binCmd.Op, operators) {
+			return nil, fmt.Errorf("%w: operator '%s' not allowed", ErrRejected, binCmd.Op)
 		}
 
 		// Validate statements on both sides of the operator
-		errX := validateStatement(binCmd.X, whitelist)
-		if errX != nil {
-			return errX
-		}
-		errY := validateStatement(binCmd.Y, whitelist)
-		if errY != nil {
-			return errY
+		commands, err := validateStatement(binCmd.X, operators, commands)
+		if err != nil {
+			return nil, err
 		}
+		return validateStatement(binCmd.Y, operators, commands)
 	default:
-		return fmt.Errorf("%w: only simple commands and pipelines allowed (no subshells, control structures, etc)", ErrRejected)
+		return nil, fmt.Errorf("%w: only simple commands and pipelines allowed (no subshells, control structures, etc)", ErrRejected)
 	}
 
-	return nil
+	return commands, nil
 }
 
 // Helper which walks a given AST from the provided node, checking for potentially dangerous node types.
-// Returns false if any are found.
-func walkForDangerousNodeTypes(node syntax.Node) bool {
+// Binary commands are only considered safe if their operator is one of the provided operators.
+// Returns true if any are found.
+func walkForDangerousNodeTypes(node syntax.Node, operators []syntax.BinCmdOperator) bool {
 	var unsafe bool
 
 	syntax.Walk(node, func(node syntax.Node) bool {
@@ -154,7 +193,7 @@ func walkForDangerousNodeTypes(node syntax.Node) bool {
 			unsafe = true
 			return false
 		case *syntax.BinaryCmd:
-			if curr.Op == syntax.Pipe {
+			if operatorAllowed(curr.Op, operators) {
 				return true
 			}
 
@@ -182,6 +221,17 @@ func walkForDangerousNodeTypes(node syntax.Node) bool {
 	return unsafe
 }
 
+// Helper which returns whether op is one of the permitted operators.
+func operatorAllowed(op syntax.BinCmdOperator, operators []syntax.BinCmdOperator) bool {
+	for _, allowed := range operators {
+		if op == allowed {
+			return true
+		}
+	}
+
+	return false
+}
+
 // Helper which ports the slices.Contains functionality for string slices to this version of Go.
 // Returns whether the string exists within the provided slice.
 func sliceContains(slice []string, str string) bool {
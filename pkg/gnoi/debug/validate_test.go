@@ -223,6 +223,114 @@ func TestValidateAndExtract(t *testing.T) {
 	}
 }
 
+func TestValidateShellCommand(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		allow bool
+	}{
+		{
+			name:  "simple command",
+			input: "echo hello world",
+			allow: true,
+		},
+		{
+			name:  "pipeline",
+			input: "cat /etc/hosts | grep localhost",
+			allow: true,
+		},
+		{
+			name:  "logical AND",
+			input: "echo a && echo b",
+			allow: true,
+		},
+		{
+			name:  "chained pipelines",
+			input: "ls /tmp | grep foo && cat /etc/hosts | grep bar",
+			allow: true,
+		},
+		{
+			name:  "chain with non-whitelisted command",
+			input: "ls && rm -rf /",
+			allow: false,
+		},
+		{
+			name:  "pipeline with non-whitelisted command after chain",
+			input: "ls && cat foo | sh",
+			allow: false,
+		},
+		{
+			name:  "logical OR",
+			input: "echo a || echo b",
+			allow: false,
+		},
+		{
+			name:  "pipe stderr",
+			input: "ls |& grep foo",
+			allow: false,
+		},
+		{
+			name:  "output redirect",
+			input: "ls && cat file1 > out",
+			allow: false,
+		},
+		{
+			name:  "append redirect in pipeline",
+			input: "ls | grep foo >> out",
+			allow: false,
+		},
+		{
+			name:  "command substitution",
+			input: "echo a && echo $(uname)",
+			allow: false,
+		},
+		{
+			name:  "process substitution",
+			input: "ls && cat <(ls /)",
+			allow: false,
+		},
+		{
+			name:  "multiple statements",
+			input: "echo a; echo b",
+			allow: false,
+		},
+		{
+			name:  "subshell in chain",
+			input: "ls && (ls /)",
+			allow: false,
+		},
+		{
+			name:  "background in chain",
+			input: "ls && sleep 1 &",
+			allow: false,
+		},
+		{
+			name:  "empty chain operand",
+			input: "ls &&",
+			allow: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateShellCommand(tt.input, exampleWhitelist)
+
+			if tt.allow {
+				if err != nil {
+					t.Fatalf("expected allow, got error: %v", err)
+				}
+			} else {
+				if err == nil {
+					t.Fatalf("expected rejection, but command was allowed: %s", tt.input)
+				}
+				if !errors.Is(err, ErrRejected) {
+					t.Errorf("expected rejection error, got: %v", err)
+				}
+			}
+		})
+	}
+}
+
 // Variant of the above table tests, built to handle fuzzing. By default, just runs for the provided seed values.
 // Can optionally run fuzzing as follows: `go test ./pkg/gnoi/debug -fuzz=FuzzValidateAndExtract -fuzztime=20s`
 func FuzzValidateAndExtract(f *testing.F) {
@@ -34,8 +34,30 @@ const (
 
 	// Maximum file size allowed (4GB - typical maximum firmware size)
 	maxFileSize = 4 * 1024 * 1024 * 1024 // 4GB in bytes
+
+	// Permissions used when a Put request does not specify any
+	defaultPutPermissions = 0644
 )
 
+// Options adapts the handlers to servers that see the device filesystem
+// somewhere other than the /mnt/host container mount.
+type Options struct {
+	// RootFS is the directory the device filesystem is mounted at.
+	// An empty RootFS means the device filesystem is the local one.
+	RootFS string
+
+	// OctalPermissions decodes Put permissions as octal digits carried in a
+	// decimal number (640 for 0640), as the gNOI File service specifies.
+	// Otherwise the permissions are used as the file mode bits.
+	OctalPermissions bool
+}
+
+// containerOptions returns the options used by the handlers without an
+// Options argument: the device filesystem is at /mnt/host when it exists.
+func containerOptions() Options {
+	return Options{RootFS: containerRoot()}
+}
+
 // HandleTransferToRemote implements the complete logic for the TransferToRemote RPC.
 // It validates the request, checks for DPU metadata, and routes accordingly.
 //
@@ -74,7 +96,18 @@ func HandleTransferToRemote(
 	}
 
 	// No DPU headers, handle normally for local device
-	return handleTransferToRemoteLocal(ctx, req)
+	return handleTransferToRemoteLocal(ctx, req, containerOptions())
+}
+
+// HandleTransferToRemoteWithOptions implements TransferToRemote for a server
+// whose device filesystem is described by opts. It always downloads to the
+// local device; DPU routing is only done by HandleTransferToRemote.
+func HandleTransferToRemoteWithOptions(
+	ctx context.Context,
+	req *gnoi_file_pb.TransferToRemoteRequest,
+	opts Options,
+) (*gnoi_file_pb.TransferToRemoteResponse, error) {
+	return handleTransferToRemoteLocal(ctx, req, opts)
 }
 
 // handleTransferToRemoteLocal implements the local device logic for TransferToRemote RPC.
@@ -82,6 +115,7 @@ func HandleTransferToRemote(
 func handleTransferToRemoteLocal(
 	ctx context.Context,
 	req *gnoi_file_pb.TransferToRemoteRequest,
+	opts Options,
 ) (*gnoi_file_pb.TransferToRemoteResponse, error) {
 	// Validate request
 	if req == nil {
@@ -98,11 +132,18 @@ func handleTransferToRemoteLocal(
 		return nil, status.Error(codes.InvalidArgument, "local_path cannot be empty")
 	}
 
-	// Validate path is in allowed directories for security
-	if err := validatePath(localPath); err != nil {
+	// Validate path is in allowed directories for security, and find it under
+	// the device filesystem root
+	translatedPath, err := resolvePath(opts.RootFS, localPath)
+	if err != nil {
 		return nil, status.Errorf(codes.InvalidArgument, "invalid local_path: %v", err)
 	}
 
+	// The download opens the path and would follow a symlink out of the allowed directories
+	if info, err := os.Lstat(translatedPath); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid local_path: %s is a symlink", localPath)
+	}
+
 	// Validate protocol - only HTTP supported initially
 	protocol := remoteDownload.GetProtocol()
 	if protocol != common.RemoteDownload_HTTP {
@@ -115,10 +156,6 @@ func handleTransferToRemoteLocal(
 		return nil, status.Error(codes.InvalidArgument, "remote download path (URL) cannot be empty")
 	}
 
-	// Container path translation: prepend /mnt/host to access host filesystem
-	// Only apply if /mnt/host exists (running in container) and path doesn't already have it
-	translatedPath := translatePathForContainer(localPath)
-
 	// Create context with timeout for download operation
 	downloadCtx, cancel := context.WithTimeout(ctx, downloadTimeout)
 	defer cancel()
@@ -154,16 +191,66 @@ func handleTransferToRemoteLocal(
 //   - Running in container: "/mnt/host/tmp/firmware.bin"
 //   - Running on host: "/tmp/firmware.bin"
 func translatePathForContainer(path string) string {
-	// Clean the path first
-	cleanPath := filepath.Clean(path)
+	// Clean the path first, and prepend the container mount, if any
+	return containerRoot() + filepath.Clean(path)
+}
 
-	// Check if /mnt/host exists (indicates we're running in a container)
+// containerRoot returns /mnt/host if it exists, which indicates we're running
+// in a container, and "" otherwise.
+func containerRoot() string {
 	if _, err := os.Stat("/mnt/host"); err == nil {
-		return "/mnt/host" + cleanPath
+		return "/mnt/host"
+	}
+	return ""
+}
+
+// resolvePath validates a device path that is about to be written or removed,
+// and returns where it is found under rootFS.
+//
+// validatePath only checks the path as text, so a symlink such as /tmp/etc -> /etc
+// would let /tmp/etc/passwd through. The existing part of the parent directory is
+// therefore resolved with filepath.EvalSymlinks, and the result must still be in
+// an allowed directory of the device filesystem. The final component is not
+// followed: Put renames over it and Remove deletes the link itself.
+func resolvePath(rootFS, path string) (string, error) {
+	if err := validatePath(path); err != nil {
+		return "", err
+	}
+
+	root := "/"
+	if rootFS != "" {
+		var err error
+		if root, err = filepath.EvalSymlinks(rootFS); err != nil {
+			return "", fmt.Errorf("cannot resolve root %s: %v", rootFS, err)
+		}
+	}
+
+	// Directories that don't exist yet can't be symlinks, so resolve the deepest
+	// existing ancestor and keep the rest as is. The loop ends at root at the latest.
+	cleanPath := filepath.Clean(path)
+	dir, rest := filepath.Join(root, filepath.Dir(cleanPath)), filepath.Base(cleanPath)
+	for {
+		resolved, err := filepath.EvalSymlinks(dir)
+		if err == nil {
+			dir = resolved
+			break
+		}
+		if !os.IsNotExist(err) {
+			return "", fmt.Errorf("cannot resolve %s: %v", path, err)
+		}
+		rest = filepath.Join(filepath.Base(dir), rest)
+		dir = filepath.Dir(dir)
 	}
 
-	// Not in container, return original path
-	return cleanPath
+	resolvedPath := filepath.Join(dir, rest)
+	rel, err := filepath.Rel(root, resolvedPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, "../") {
+		return "", fmt.Errorf("%s resolves outside the device filesystem", path)
+	}
+	if err := validatePath("/" + rel); err != nil {
+		return "", fmt.Errorf("%s resolves to /%s: %v", path, rel, err)
+	}
+	return resolvedPath, nil
 }
 
 // validatePath checks if the requested path is within allowed directories.
@@ -256,6 +343,17 @@ func HandlePut(stream gnoi_file_pb.File_PutServer) error {
 		}
 	}
 
+	return handlePut(stream, containerOptions())
+}
+
+// HandlePutWithOptions implements the Put RPC for a server whose device
+// filesystem is described by opts.
+func HandlePutWithOptions(stream gnoi_file_pb.File_PutServer, opts Options) error {
+	return handlePut(stream, opts)
+}
+
+// handlePut receives the file for HandlePut and HandlePutWithOptions.
+func handlePut(stream gnoi_file_pb.File_PutServer, opts Options) error {
 	// Step 1: Receive the first message (must be Open)
 	firstReq, err := stream.Recv()
 	if err != nil {
@@ -272,39 +370,42 @@ func HandlePut(stream gnoi_file_pb.File_PutServer) error {
 		return status.Error(codes.InvalidArgument, "remote_file cannot be empty")
 	}
 
-	permissions := openMsg.GetPermissions()
-	if permissions == 0 {
-		// Default to 0644 if not specified
-		permissions = 0644
+	mode := os.FileMode(defaultPutPermissions)
+	if permissions := openMsg.GetPermissions(); permissions != 0 {
+		mode = os.FileMode(permissions)
+		if opts.OctalPermissions {
+			if mode, err = fileModeFromOctal(permissions); err != nil {
+				return status.Errorf(codes.InvalidArgument, "invalid permissions: %v", err)
+			}
+		}
 	}
 
-	// Step 2: Validate path is in allowed directories
-	if err := validatePath(remotePath); err != nil {
+	// Step 2: Validate path is in allowed directories, and find it under the
+	// device filesystem root
+	translatedPath, err := resolvePath(opts.RootFS, remotePath)
+	if err != nil {
 		return status.Errorf(codes.InvalidArgument, "invalid remote_file: %v", err)
 	}
 
-	// Step 3: Container path translation
-	translatedPath := translatePathForContainer(remotePath)
-
-	// Step 4: Create temp file for atomic write
-	tempPath := translatedPath + ".tmp"
-	f, err := os.OpenFile(tempPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	// Step 3: Create a unique temp file in the target directory for atomic write,
+	// so the rename stays on one file system and the name can't be pre-created
+	f, err := os.CreateTemp(filepath.Dir(translatedPath), "."+filepath.Base(translatedPath)+".*.tmp")
 	if err != nil {
 		return status.Errorf(codes.Internal, "failed to create temp file: %v", err)
 	}
+	tempPath := f.Name()
+	renamed := false
 	defer func() {
-		if closeErr := f.Close(); closeErr != nil {
-			log.Errorf("Failed to close temp file %s: %v", tempPath, closeErr)
-		}
-		// Only remove if file still exists (indicates failure path)
-		if _, err := os.Stat(tempPath); err == nil {
+		f.Close()
+		// Only remove the temp file on the failure path
+		if !renamed {
 			if rmErr := os.Remove(tempPath); rmErr != nil {
 				log.Errorf("Failed to cleanup temp file %s: %v", tempPath, rmErr)
 			}
 		}
 	}()
 
-	// Step 5: Receive chunks and write to temp file
+	// Step 4: Receive chunks and write to temp file
 	hasher := md5.New() // nosemgrep: go.lang.security.audit.crypto.use_of_weak_crypto.use-of-md5
 	for {
 		req, err := stream.Recv()
@@ -326,7 +427,7 @@ func HandlePut(stream gnoi_file_pb.File_PutServer) error {
 			// Update hash
 			hasher.Write(contents)
 		} else if hashMsg := req.GetHash(); hashMsg != nil {
-			// Step 6: Verify hash
+			// Step 5: Verify hash
 			calculatedHash := hasher.Sum(nil)
 			receivedHash := hashMsg.GetHash()
 
@@ -341,25 +442,44 @@ func HandlePut(stream gnoi_file_pb.File_PutServer) error {
 		}
 	}
 
-	// Step 7: Close the temp file before renaming
+	// Step 6: Close the temp file before renaming
 	if err := f.Close(); err != nil {
 		return status.Errorf(codes.Internal, "failed to close temp file: %v", err)
 	}
 
-	// Step 8: Set permissions on temp file
-	if err := os.Chmod(tempPath, os.FileMode(permissions)); err != nil {
+	// Step 7: Set permissions on temp file
+	if err := os.Chmod(tempPath, mode); err != nil {
 		return status.Errorf(codes.Internal, "failed to set permissions: %v", err)
 	}
 
-	// Step 9: Atomic rename to final path
+	// Step 8: Atomic rename to final path
 	if err := os.Rename(tempPath, translatedPath); err != nil {
 		return status.Errorf(codes.Internal, "failed to rename file: %v", err)
 	}
+	renamed = true
 
-	// Step 10: Send success response
+	// Step 9: Send success response
 	return stream.SendAndClose(&gnoi_file_pb.PutResponse{})
 }
 
+// fileModeFromOctal converts gNOI permissions (octal digits carried as a
+// decimal number) to a file mode. Only the permission bits are honoured.
+func fileModeFromOctal(permissions uint32) (os.FileMode, error) {
+	var mode, place uint32 = 0, 1
+	for p := permissions; p > 0; p /= 10 {
+		digit := p % 10
+		if digit > 7 {
+			return 0, fmt.Errorf("permissions %d are not in octal notation", permissions)
+		}
+		mode += digit * place
+		place *= 8
+	}
+	if mode > 0777 {
+		return 0, fmt.Errorf("permissions %d exceed 777", permissions)
+	}
+	return os.FileMode(mode), nil
+}
+
 // HandleTransferToRemoteForDPU handles TransferToRemote when DPU headers are present.
 // It downloads the file to NPU first, then uploads it to the specified DPU using File.Put.
 //
@@ -658,7 +778,19 @@ func HandleTransferToRemoteForDPUStreaming(
 	}, nil
 }
 
+// HandleFileRemove implements the Remove RPC. Only files in /tmp/ or /var/tmp/
+// can be removed.
 func HandleFileRemove(ctx context.Context, req *gnoi_file_pb.RemoveRequest) (*gnoi_file_pb.RemoveResponse, error) {
+	return HandleFileRemoveWithOptions(ctx, req, containerOptions())
+}
+
+// HandleFileRemoveWithOptions implements the Remove RPC for a server whose
+// device filesystem is described by opts.
+func HandleFileRemoveWithOptions(
+	ctx context.Context,
+	req *gnoi_file_pb.RemoveRequest,
+	opts Options,
+) (*gnoi_file_pb.RemoveResponse, error) {
 	log.Infof("HandleFileRemove called with request: %+v", req)
 
 	if req == nil {
@@ -672,16 +804,16 @@ func HandleFileRemove(ctx context.Context, req *gnoi_file_pb.RemoveRequest) (*gn
 		return nil, status.Error(codes.InvalidArgument, "Invalid request: remote_file field is empty.")
 	}
 
-	if err := validatePath(remoteFile); err != nil {
+	// Map the device path to the container path if needed, so files created on
+	// the DUT in /tmp/ are visible, without following symlinks out of /tmp/
+	translatedPath, err := resolvePath(opts.RootFS, remoteFile)
+	if err != nil {
 		log.Errorf("Denied: %v", err)
 		return nil, status.Error(codes.PermissionDenied, "only files in /tmp/ or /var/tmp/ can be removed")
 	}
-
-	// NEW: map host path to container path if needed.
-	// translatePathForContainer will prepend /mnt/host inside the gnmi container
-	// when appropriate, so files created on the DUT in /tmp/ are visible.
-	localPath := remoteFile
-	translatedPath := translatePathForContainer(localPath)
+	if info, err := os.Lstat(translatedPath); err == nil && info.IsDir() {
+		return nil, status.Errorf(codes.InvalidArgument, "%s is a directory", remoteFile)
+	}
 	log.Infof("HandleFileRemove removing file: remote=%s translated=%s", remoteFile, translatedPath)
 
 	// Attempt remove and map errors to gRPC status codes for testable behavior.
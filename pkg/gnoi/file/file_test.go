@@ -829,6 +829,127 @@ func TestHandlePut_DefaultPermissions(t *testing.T) {
 	os.Remove(path)
 }
 
+func TestHandlePutWithOptions_RootFS(t *testing.T) {
+	rootFS := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(rootFS, "tmp"), 0755); err != nil {
+		t.Fatalf("Failed to create rootfs: %v", err)
+	}
+
+	content := []byte("rooted put")
+	hash := md5.Sum(content)
+	stream := newMockPutStream()
+	stream.addOpenRequest("/tmp/rooted.txt", 640)
+	stream.addContentRequest(content)
+	stream.addHashRequest(hash[:])
+
+	if err := HandlePutWithOptions(stream, Options{RootFS: rootFS, OctalPermissions: true}); err != nil {
+		t.Fatalf("HandlePutWithOptions() error = %v", err)
+	}
+
+	info, err := os.Stat(filepath.Join(rootFS, "tmp", "rooted.txt"))
+	if err != nil {
+		t.Fatalf("File was not written under rootFS: %v", err)
+	}
+	if info.Mode().Perm() != 0640 {
+		t.Errorf("File permissions = %o, want %o", info.Mode().Perm(), 0640)
+	}
+}
+
+func TestHandlePutWithOptions_InvalidOctalPermissions(t *testing.T) {
+	stream := newMockPutStream()
+	stream.addOpenRequest("/tmp/bad_perms.txt", 648)
+
+	err := HandlePutWithOptions(stream, Options{RootFS: t.TempDir(), OctalPermissions: true})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument error, got %v", err)
+	}
+}
+
+func TestFileModeFromOctal(t *testing.T) {
+	mode, err := fileModeFromOctal(640)
+	if err != nil || mode != 0640 {
+		t.Errorf("fileModeFromOctal(640) = %o, %v, want 640", mode, err)
+	}
+	if _, err := fileModeFromOctal(648); err == nil {
+		t.Errorf("fileModeFromOctal(648) returned nil error for non-octal digits")
+	}
+	if _, err := fileModeFromOctal(4755); err == nil {
+		t.Errorf("fileModeFromOctal(4755) returned nil error for special bits")
+	}
+}
+
+func TestSymlinkEscape(t *testing.T) {
+	// /tmp/etc in the device filesystem links to /etc, which must stay untouched
+	rootFS := t.TempDir()
+	for _, dir := range []string{"tmp", "etc"} {
+		if err := os.MkdirAll(filepath.Join(rootFS, dir), 0755); err != nil {
+			t.Fatalf("Failed to create rootfs: %v", err)
+		}
+	}
+	protected := filepath.Join(rootFS, "etc", "passwd")
+	if err := os.WriteFile(protected, []byte("root"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(rootFS, "etc"), filepath.Join(rootFS, "tmp", "etc")); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+	if err := os.Symlink(protected, filepath.Join(rootFS, "tmp", "passwd")); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+	opts := Options{RootFS: rootFS}
+
+	t.Run("Put", func(t *testing.T) {
+		content := []byte("escaped")
+		hash := md5.Sum(content)
+		stream := newMockPutStream()
+		stream.addOpenRequest("/tmp/etc/passwd", 0644)
+		stream.addContentRequest(content)
+		stream.addHashRequest(hash[:])
+
+		if err := HandlePutWithOptions(stream, opts); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("Expected InvalidArgument error, got %v", err)
+		}
+	})
+
+	t.Run("TransferToRemote", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("escaped"))
+		}))
+		defer server.Close()
+
+		for _, path := range []string{"/tmp/etc/passwd", "/tmp/passwd"} {
+			_, err := HandleTransferToRemoteWithOptions(context.Background(), &gnoi_file_pb.TransferToRemoteRequest{
+				LocalPath: path,
+				RemoteDownload: &common.RemoteDownload{
+					Path:     server.URL,
+					Protocol: common.RemoteDownload_HTTP,
+				},
+			}, opts)
+			if status.Code(err) != codes.InvalidArgument {
+				t.Errorf("TransferToRemote(%q): expected InvalidArgument error, got %v", path, err)
+			}
+		}
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		_, err := HandleFileRemoveWithOptions(context.Background(),
+			&gnoi_file_pb.RemoveRequest{RemoteFile: "/tmp/etc/passwd"}, opts)
+		if status.Code(err) != codes.PermissionDenied {
+			t.Errorf("Expected PermissionDenied error, got %v", err)
+		}
+
+		// Removing a link in /tmp/ removes the link, not its target
+		if _, err := HandleFileRemoveWithOptions(context.Background(),
+			&gnoi_file_pb.RemoveRequest{RemoteFile: "/tmp/passwd"}, opts); err != nil {
+			t.Errorf("Remove of a symlink failed: %v", err)
+		}
+	})
+
+	if content, err := os.ReadFile(protected); err != nil || string(content) != "root" {
+		t.Errorf("Protected file was modified: %q, %v", content, err)
+	}
+}
+
 func TestHandlePut_StreamError(t *testing.T) {
 	// Test stream receive error
 	stream := newMockPutStream()
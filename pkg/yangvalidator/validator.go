@@ -0,0 +1,824 @@
+// Package yangvalidator validates CONFIG_DB content against the SONiC YANG models, in process.
+// It is a native replacement for loading the data through the sonic_yang Python library.
+//
+// Every table, key and field is checked against its model: unknown fields, missing mandatory
+// leaves, and values which do not satisfy their type (ranges, lengths, patterns, enumerations,
+// identities, unions) are reported. Leafrefs must refer to data present in the config, and their
+// values are checked against the type of the leaf they refer to. Tables without a model are ignored,
+// as they are by sonic_yang.
+//
+// Constructs the validator does not evaluate, such as 'must' and 'when' statements, relative
+// leafrefs and leafrefs with predicates, and patterns Go cannot compile, are skipped with a warning
+// when the models are loaded, so that data which sonic_yang accepts is not rejected. Models which
+// cannot be parsed are skipped in the same way, along with the models which depend on them.
+package yangvalidator
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	log "github.com/golang/glog"
+	"github.com/openconfig/goyang/pkg/yang"
+)
+
+var (
+	YANG_MODELS_DIR = "/usr/local/yang-models"
+)
+
+const (
+	// Separator of the key components of a CONFIG_DB entry.
+	keySeparator = "|"
+
+	// Suffix of leaf-list fields in their Redis form, whose values are comma separated.
+	leafListSuffix = "@"
+
+	// Placeholder field of entries which have no other fields.
+	nullField = "NULL"
+
+	// Largest number of errors included in the message of a ValidationError.
+	maxReportedErrors = 10
+)
+
+// LeafError describes why the value at Path, e.g. '/PORT/Ethernet0/mtu', is invalid.
+type LeafError struct {
+	Path    string
+	Message string
+}
+
+func (e LeafError) String() string {
+	return e.Path + ": " + e.Message
+}
+
+// ValidationError is returned when a config does not conform to the YANG models.
+type ValidationError struct {
+	// Errors are ordered by path.
+	Errors []LeafError
+}
+
+func (e *ValidationError) Error() string {
+	var msgs []string
+	for i, leafErr := range e.Errors {
+		if i == maxReportedErrors {
+			msgs = append(msgs, fmt.Sprintf("and %d more", len(e.Errors)-i))
+			break
+		}
+		msgs = append(msgs, leafErr.String())
+	}
+	return "YANG validation failed: " + strings.Join(msgs, "; ")
+}
+
+// unsupportedError is returned when the validator cannot decide whether a value is valid.
+type unsupportedError struct {
+	msg string
+}
+
+func (e *unsupportedError) Error() string {
+	return e.msg
+}
+
+func unsupportedf(format string, args ...interface{}) error {
+	return &unsupportedError{msg: fmt.Sprintf(format, args...)}
+}
+
+// leafref is the target of a leafref path, e.g. '/port:sonic-port/port:PORT/port:PORT_LIST/port:name'.
+type leafref struct {
+	table string
+	// The list holding the leaf, or nil if the leaf belongs to the container entry named entry.
+	list  *yang.Entry
+	entry string
+	leaf  *yang.Entry
+	// Set instead when the path cannot be resolved.
+	err error
+}
+
+// Validator holds the schema of each CONFIG_DB table. It is safe for concurrent use.
+type Validator struct {
+	tables   map[string]*yang.Entry
+	patterns map[string]*regexp.Regexp
+	// Leafrefs by path.
+	leafrefs map[string]*leafref
+}
+
+// loadResult is the outcome of loading the models in a directory.
+type loadResult struct {
+	v   *Validator
+	err error
+}
+
+var (
+	cacheMu sync.Mutex
+	cache   = map[string]loadResult{}
+)
+
+// Load returns a Validator for the models in YANG_MODELS_DIR. The models are parsed on first use,
+// and the Validator, or the error loading it, is shared by later calls.
+func Load() (*Validator, error) {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+
+	if result, ok := cache[YANG_MODELS_DIR]; ok {
+		return result.v, result.err
+	}
+	v, err := NewValidator(YANG_MODELS_DIR)
+	cache[YANG_MODELS_DIR] = loadResult{v: v, err: err}
+	return v, err
+}
+
+// NewValidator parses every '.yang' file in dir, and constructs a Validator from them. Models which
+// cannot be parsed or processed are skipped with a warning. Returns an error if no table could be
+// loaded.
+func NewValidator(dir string) (*Validator, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.yang"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no YANG models found in '%s'", dir)
+	}
+
+	ms := yang.NewModules()
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if err := ms.Parse(string(data), file); err != nil {
+			log.Warningf("Skipping YANG model '%s', which cannot be parsed: %v", file, err)
+		}
+	}
+	if errs := ms.Process(); len(errs) > 0 {
+		log.Warningf("Skipping the YANG models in '%s' which cannot be processed: %v", dir, errs)
+	}
+
+	v := &Validator{
+		tables:   make(map[string]*yang.Entry),
+		patterns: make(map[string]*regexp.Regexp),
+		leafrefs: make(map[string]*leafref),
+	}
+	names := make([]string, 0, len(ms.Modules))
+	for name := range ms.Modules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// SONiC models hold each table in a container, within a top level container named after the module
+	var must, when int
+	for _, name := range names {
+		if missing := missingImport(ms, ms.Modules[name], map[string]bool{}); missing != "" {
+			log.Warningf("Skipping YANG model %s, which imports %s, which is not loaded", name, missing)
+			continue
+		}
+		module := yang.ToEntry(ms.Modules[name])
+		if len(module.Errors) > 0 {
+			log.Warningf("Skipping YANG model %s: %v", name, module.Errors)
+			continue
+		}
+		for _, top := range module.Dir {
+			if !top.IsContainer() {
+				continue
+			}
+			for tableName, table := range top.Dir {
+				if !table.IsContainer() {
+					continue
+				}
+				if _, ok := v.tables[tableName]; ok {
+					log.Warningf("Table %s is defined by multiple YANG models, using the first", tableName)
+					continue
+				}
+				v.tables[tableName] = table
+				v.compilePatterns(table)
+				countStatements(table, &must, &when)
+			}
+		}
+	}
+	if len(v.tables) == 0 {
+		return nil, fmt.Errorf("no tables could be loaded from the YANG models in '%s'", dir)
+	}
+	for _, tableName := range childNames(&yang.Entry{Dir: v.tables}) {
+		v.resolveLeafrefs(tableName, v.tables[tableName])
+	}
+	if must > 0 || when > 0 {
+		log.Warningf("%d 'must' and %d 'when' statements of the YANG models in '%s' are not evaluated", must, when, dir)
+	}
+
+	log.V(2).Infof("Loaded YANG models for %d tables from '%s'", len(v.tables), dir)
+	return v, nil
+}
+
+// Helper which returns the name of the first module imported by m, directly or indirectly, which
+// is not loaded in ms. Returns "" if every import is loaded.
+func missingImport(ms *yang.Modules, m *yang.Module, seen map[string]bool) string {
+	if seen[m.Name] {
+		return ""
+	}
+	seen[m.Name] = true
+	for _, imp := range m.Import {
+		imported, ok := ms.Modules[imp.Name]
+		if !ok {
+			return imp.Name
+		}
+		if missing := missingImport(ms, imported, seen); missing != "" {
+			return missing
+		}
+	}
+	return ""
+}
+
+// Helper which compiles every pattern used by leaves within e. YANG patterns are XSD regular
+// expressions, which are implicitly anchored. Patterns which Go cannot compile are stored as nil,
+// and values are not checked against them.
+func (v *Validator) compilePatterns(e *yang.Entry) {
+	var compileType func(t *yang.YangType)
+	compileType = func(t *yang.YangType) {
+		if t == nil {
+			return
+		}
+		for _, pattern := range t.Pattern {
+			if _, ok := v.patterns[pattern]; ok {
+				continue
+			}
+			re, err := regexp.Compile("^(?:" + pattern + ")$")
+			if err != nil {
+				log.Warningf("YANG pattern %q cannot be compiled, values are not checked against it: %v", pattern, err)
+			}
+			v.patterns[pattern] = re
+		}
+		for _, member := range t.Type {
+			compileType(member)
+		}
+	}
+
+	compileType(e.Type)
+	for _, child := range e.Dir {
+		v.compilePatterns(child)
+	}
+}
+
+// Helper which resolves the leafrefs used by leaves within e, which belongs to table.
+func (v *Validator) resolveLeafrefs(table string, e *yang.Entry) {
+	var resolveType func(t *yang.YangType)
+	resolveType = func(t *yang.YangType) {
+		if t == nil {
+			return
+		}
+		if t.Kind == yang.Yleafref {
+			ref, ok := v.leafrefs[t.Path]
+			if !ok {
+				ref = v.resolveLeafref(t.Path)
+				v.leafrefs[t.Path] = ref
+				if ref.err != nil {
+					log.Warningf("YANG leafref of table %s is not checked: %v", table, ref.err)
+				}
+			}
+		}
+		for _, member := range t.Type {
+			resolveType(member)
+		}
+	}
+
+	resolveType(e.Type)
+	for _, child := range e.Dir {
+		v.resolveLeafrefs(table, child)
+	}
+}
+
+// Helper which finds the leaf an absolute leafref path refers to. The path must name a leaf of a
+// list, or of a container entry, of a table. Other paths, relative ones, and ones with predicates
+// are not supported.
+func (v *Validator) resolveLeafref(path string) *leafref {
+	if !strings.HasPrefix(path, "/") {
+		return &leafref{err: unsupportedf("relative leafref path %q is not supported", path)}
+	}
+	if strings.Contains(path, "[") {
+		return &leafref{err: unsupportedf("leafref path %q with predicates is not supported", path)}
+	}
+	var elems []string
+	for _, elem := range strings.Split(strings.TrimPrefix(path, "/"), "/") {
+		if i := strings.Index(elem, ":"); i >= 0 {
+			elem = elem[i+1:]
+		}
+		elems = append(elems, elem)
+	}
+	if len(elems) != 4 {
+		return &leafref{err: unsupportedf("leafref path %q is not supported", path)}
+	}
+	tableName, parentName, leafName := elems[1], elems[2], elems[3]
+
+	schema, ok := v.tables[tableName]
+	if !ok {
+		return &leafref{err: unsupportedf("leafref path %q refers to a table without a YANG model", path)}
+	}
+	parent, ok := schema.Dir[parentName]
+	if !ok || !(parent.IsList() || parent.IsContainer()) {
+		return &leafref{err: unsupportedf("leafref path %q does not refer to a leaf", path)}
+	}
+	leaves := make(map[string]*yang.Entry)
+	collectLeaves(parent, leaves)
+	leaf, ok := leaves[leafName]
+	if !ok {
+		return &leafref{err: unsupportedf("leafref path %q does not refer to a leaf", path)}
+	}
+
+	ref := &leafref{table: tableName, leaf: leaf}
+	if parent.IsList() {
+		ref.list = parent
+	} else {
+		ref.entry = parentName
+	}
+	return ref
+}
+
+// Validate checks the config, a map of table name to key to field to value, as read from
+// config_db.json. Returns a *ValidationError listing every invalid value.
+func (v *Validator) Validate(config map[string]interface{}) error {
+	c := &validation{Validator: v, config: config}
+
+	for _, tableName := range sortedKeys(config) {
+		schema, ok := v.tables[tableName]
+		if !ok {
+			log.V(2).Infof("Table %s has no YANG model, skipping validation", tableName)
+			continue
+		}
+		path := "/" + tableName
+		entries, ok := config[tableName].(map[string]interface{})
+		if !ok {
+			c.errorf(path, "expected an object of entries")
+			continue
+		}
+		c.validateTable(path, schema, entries)
+	}
+
+	if len(c.errors) == 0 {
+		return nil
+	}
+	sort.SliceStable(c.errors, func(i, j int) bool { return c.errors[i].Path < c.errors[j].Path })
+	return &ValidationError{Errors: c.errors}
+}
+
+// validation holds the state of a single call to Validate.
+type validation struct {
+	*Validator
+	config map[string]interface{}
+	errors []LeafError
+}
+
+func (c *validation) errorf(path, format string, args ...interface{}) {
+	c.errors = append(c.errors, LeafError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// Helper which counts the 'must' and 'when' statements of e and its descendants, which are not
+// evaluated.
+func countStatements(e *yang.Entry, must, when *int) {
+	switch n := e.Node.(type) {
+	case *yang.Container:
+		*must += len(n.Must)
+	case *yang.List:
+		*must += len(n.Must)
+	case *yang.Leaf:
+		*must += len(n.Must)
+	case *yang.LeafList:
+		*must += len(n.Must)
+	}
+	if _, ok := e.GetWhenXPath(); ok {
+		*when++
+	}
+	for _, uses := range e.Uses {
+		if uses.Uses.When != nil {
+			*when++
+		}
+	}
+	for _, augment := range e.Augmented {
+		if _, ok := augment.GetWhenXPath(); ok {
+			*when++
+		}
+	}
+	for _, child := range e.Dir {
+		countStatements(child, must, when)
+	}
+}
+
+// Helper which validates the entries of a table. Tables either hold lists, in which case each
+// entry is matched to the list with as many keys as the entry has key components, or containers,
+// in which case each entry is named after a container.
+func (c *validation) validateTable(path string, schema *yang.Entry, entries map[string]interface{}) {
+	var lists []*yang.Entry
+	for _, child := range sortedChildren(schema) {
+		if child.IsList() {
+			lists = append(lists, child)
+		}
+	}
+
+	for _, key := range sortedKeys(entries) {
+		entryPath := path + "/" + key
+		fields, ok := entries[key].(map[string]interface{})
+		if !ok {
+			c.errorf(entryPath, "expected an object of fields")
+			continue
+		}
+
+		if len(lists) == 0 {
+			container, ok := schema.Dir[key]
+			if !ok || !container.IsContainer() {
+				c.errorf(entryPath, "unknown entry, expected one of %v", childNames(schema))
+				continue
+			}
+			c.validateFields(entryPath, container, nil, fields)
+			continue
+		}
+
+		components := strings.Split(key, keySeparator)
+		list := c.matchList(lists, components)
+		if list == nil {
+			c.errorf(entryPath, "key has %d components, which does not match any list of the table", len(components))
+			continue
+		}
+
+		keyLeaves := strings.Fields(list.Key)
+		keyValues := make(map[string]bool, len(keyLeaves))
+		for i, keyLeaf := range keyLeaves {
+			keyValues[keyLeaf] = true
+			if leaf, ok := list.Dir[keyLeaf]; ok {
+				c.validateValue(entryPath, leaf.Type, components[i])
+			}
+		}
+		c.validateFields(entryPath, list, keyValues, fields)
+	}
+}
+
+// Helper which validates the fields of an entry, skipping the key leaves.
+func (c *validation) validateFields(path string, schema *yang.Entry, keys map[string]bool, fields map[string]interface{}) {
+	leaves := make(map[string]*yang.Entry)
+	collectLeaves(schema, leaves)
+	nested := nestedList(schema)
+
+	for _, name := range sortedKeys(fields) {
+		if name == nullField {
+			continue
+		}
+		value := fields[name]
+		fieldName := strings.TrimSuffix(name, leafListSuffix)
+		fieldPath := path + "/" + fieldName
+
+		leaf, ok := leaves[fieldName]
+		if (!ok || keys[fieldName]) && nested != nil {
+			c.validateNestedEntry(fieldPath, nested, fieldName, value)
+			continue
+		}
+		if !ok || keys[fieldName] {
+			c.errorf(fieldPath, "unknown field")
+			continue
+		}
+
+		if leaf.IsLeafList() {
+			for _, item := range leafListValues(name, value) {
+				c.validateValue(fieldPath, leaf.Type, item)
+			}
+			continue
+		}
+		if _, isList := value.([]interface{}); isList {
+			c.errorf(fieldPath, "expected a single value")
+			continue
+		}
+		c.validateValue(fieldPath, leaf.Type, fmt.Sprint(value))
+	}
+
+	for _, name := range childNames(&yang.Entry{Dir: leaves}) {
+		leaf := leaves[name]
+		if keys[name] || !isMandatory(leaf) {
+			continue
+		}
+		if _, ok := fields[name]; !ok {
+			c.errorf(path+"/"+name, "mandatory field is missing")
+		}
+	}
+}
+
+// Helper which validates an entry of a list nested in a list. Such entries are stored as a field
+// of the outer entry, named after the key of the entry and holding its only other leaf, e.g. the
+// DSCP to TC mappings in 'DSCP_TO_TC_MAP|AZURE': {"0": "1", "8": "0"}.
+func (c *validation) validateNestedEntry(path string, list *yang.Entry, key string, value interface{}) {
+	keyLeaves := strings.Fields(list.Key)
+	leaves := make(map[string]*yang.Entry)
+	collectLeaves(list, leaves)
+	if len(keyLeaves) != 1 || len(leaves) != 2 || leaves[keyLeaves[0]] == nil {
+		log.V(2).Infof("%s: entries of list %s are not checked", path, list.Name)
+		return
+	}
+
+	c.validateValue(path, leaves[keyLeaves[0]].Type, key)
+	for name, leaf := range leaves {
+		if name != keyLeaves[0] {
+			c.validateValue(path, leaf.Type, fmt.Sprint(value))
+		}
+	}
+}
+
+// Helper which validates a single value of a leaf, or leaf-list.
+func (c *validation) validateValue(path string, t *yang.YangType, value string) {
+	err := c.checkType(t, value)
+	var unsupported *unsupportedError
+	switch {
+	case errors.As(err, &unsupported):
+		log.V(2).Infof("%s: %q is not checked: %v", path, value, err)
+	case err != nil:
+		c.errorf(path, "%q is not valid: %v", value, err)
+	}
+}
+
+// Helper which checks the value against the type. Leafrefs are checked against the type of the
+// leaf they refer to, and must refer to data present in the config unless they do not require an
+// instance. Returns an *unsupportedError when the type cannot be checked.
+func (c *validation) checkType(t *yang.YangType, value string) error {
+	if t == nil {
+		return unsupportedf("type could not be resolved")
+	}
+	switch t.Kind {
+	case yang.Ystring:
+		if len(t.Length) > 0 {
+			length := yang.FromInt(int64(len([]rune(value))))
+			if !inRange(t.Length, length) {
+				return fmt.Errorf("length must be within %v", t.Length)
+			}
+		}
+		for _, pattern := range t.Pattern {
+			re := c.patterns[pattern]
+			if re == nil {
+				continue
+			}
+			if !re.MatchString(value) {
+				return fmt.Errorf("does not match pattern %q", pattern)
+			}
+		}
+	case yang.Yint8, yang.Yint16, yang.Yint32, yang.Yint64,
+		yang.Yuint8, yang.Yuint16, yang.Yuint32, yang.Yuint64, yang.Ydecimal64:
+		n, err := parseNumber(t, value)
+		if err != nil {
+			return err
+		}
+		if len(t.Range) > 0 && !inRange(t.Range, n) {
+			return fmt.Errorf("must be within %v", t.Range)
+		}
+	case yang.Ybool:
+		if value != "true" && value != "false" {
+			return fmt.Errorf("must be 'true' or 'false'")
+		}
+	case yang.Yenum:
+		if t.Enum != nil && !t.Enum.IsDefined(value) {
+			return fmt.Errorf("must be one of %v", t.Enum.Names())
+		}
+	case yang.Ybits:
+		for _, bit := range strings.Fields(value) {
+			if t.Bit != nil && !t.Bit.IsDefined(bit) {
+				return fmt.Errorf("bit %q must be one of %v", bit, t.Bit.Names())
+			}
+		}
+	case yang.Yidentityref:
+		name := value
+		if i := strings.Index(name, ":"); i >= 0 {
+			name = name[i+1:]
+		}
+		if t.IdentityBase != nil && !t.IdentityBase.IsDefined(name) {
+			return fmt.Errorf("is not derived from identity %q", t.IdentityBase.Name)
+		}
+	case yang.Ybinary:
+		if _, err := base64.StdEncoding.DecodeString(value); err != nil {
+			return fmt.Errorf("must be base64 encoded")
+		}
+	case yang.Yempty:
+		if value != "" {
+			return fmt.Errorf("must be empty")
+		}
+	case yang.Yleafref:
+		ref, ok := c.leafrefs[t.Path]
+		if !ok {
+			return unsupportedf("leafref path %q is not supported", t.Path)
+		}
+		if ref.err != nil {
+			return ref.err
+		}
+		if err := c.checkType(ref.leaf.Type, value); err != nil {
+			return err
+		}
+		if !t.OptionalInstance {
+			return c.checkLeafref(ref, value)
+		}
+	case yang.Yunion:
+		var errs []string
+		var unsupported error
+		for _, member := range t.Type {
+			err := c.checkType(member, value)
+			if err == nil {
+				return nil
+			}
+			var unsupportedErr *unsupportedError
+			if errors.As(err, &unsupportedErr) && unsupported == nil {
+				unsupported = err
+			}
+			errs = append(errs, err.Error())
+		}
+		// The value may be valid for the member which cannot be checked
+		if unsupported != nil {
+			return unsupported
+		}
+		return fmt.Errorf("does not match any member of the union (%s)", strings.Join(errs, "; "))
+	default:
+		return unsupportedf("type %s is not supported", t.Kind)
+	}
+
+	return nil
+}
+
+// Helper which checks that the target of a leafref holds the value in the config.
+func (c *validation) checkLeafref(ref *leafref, value string) error {
+	tableName, leafName := ref.table, ref.leaf.Name
+	entries, _ := c.config[tableName].(map[string]interface{})
+
+	if ref.list == nil {
+		fields, _ := entries[ref.entry].(map[string]interface{})
+		if field, ok := fields[leafName]; ok && fmt.Sprint(field) == value {
+			return nil
+		}
+		return fmt.Errorf("%s %s does not have %s %q", tableName, ref.entry, leafName, value)
+	}
+
+	keyLeaves := strings.Fields(ref.list.Key)
+	keyIndex := -1
+	for i, keyLeaf := range keyLeaves {
+		if keyLeaf == leafName {
+			keyIndex = i
+		}
+	}
+
+	for key, entry := range entries {
+		components := strings.Split(key, keySeparator)
+		if len(components) != len(keyLeaves) {
+			continue
+		}
+		if keyIndex >= 0 {
+			if components[keyIndex] == value {
+				return nil
+			}
+			continue
+		}
+		fields, _ := entry.(map[string]interface{})
+		if field, ok := fields[leafName]; ok && fmt.Sprint(field) == value {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("no %s entry has %s %q", tableName, leafName, value)
+}
+
+// Helper which finds the list for a key. Of the lists with as many keys as the key has
+// components, the first whose key leaves accept the components is preferred.
+func (c *validation) matchList(lists []*yang.Entry, components []string) *yang.Entry {
+	var first *yang.Entry
+	for _, list := range lists {
+		keyLeaves := strings.Fields(list.Key)
+		if len(keyLeaves) != len(components) {
+			continue
+		}
+		if first == nil {
+			first = list
+		}
+
+		matches := true
+		for i, keyLeaf := range keyLeaves {
+			if leaf, ok := list.Dir[keyLeaf]; ok && c.checkType(leaf.Type, components[i]) != nil {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return list
+		}
+	}
+	return first
+}
+
+// Helper which finds a list within the list e, whose entries are fields of the entries of e.
+// Returns nil if e is not a list, or has no such list.
+func nestedList(e *yang.Entry) *yang.Entry {
+	if !e.IsList() {
+		return nil
+	}
+	for _, child := range sortedChildren(e) {
+		if child.IsList() {
+			return child
+		}
+	}
+	return nil
+}
+
+// Helper which collects the leaves and leaf-lists of an entry, including those within choices.
+func collectLeaves(e *yang.Entry, leaves map[string]*yang.Entry) {
+	for name, child := range e.Dir {
+		switch {
+		case child.IsChoice() || child.IsCase():
+			collectLeaves(child, leaves)
+		case child.IsLeaf() || child.IsLeafList():
+			leaves[name] = child
+		}
+	}
+}
+
+// Helper which reports whether a leaf is mandatory. goyang does not copy the statement of leaves
+// into their entry.
+func isMandatory(e *yang.Entry) bool {
+	if leaf, ok := e.Node.(*yang.Leaf); ok && leaf.Mandatory != nil {
+		return leaf.Mandatory.Name == "true"
+	}
+	return e.Mandatory.Value()
+}
+
+// Helper which returns the values of a leaf-list field. Fields are JSON arrays in config_db.json,
+// or comma separated values in their Redis form, named with a trailing '@'.
+func leafListValues(name string, value interface{}) []string {
+	switch v := value.(type) {
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			values = append(values, fmt.Sprint(item))
+		}
+		return values
+	default:
+		s := fmt.Sprint(v)
+		if strings.HasSuffix(name, leafListSuffix) {
+			if s == "" {
+				return nil
+			}
+			return strings.Split(s, ",")
+		}
+		return []string{s}
+	}
+}
+
+// Helper which parses value as a number of the type's kind.
+func parseNumber(t *yang.YangType, value string) (yang.Number, error) {
+	if t.Kind == yang.Ydecimal64 {
+		return yang.DecimalValueFromString(value, t.FractionDigits)
+	}
+
+	bits := map[yang.TypeKind]int{
+		yang.Yint8: 8, yang.Yint16: 16, yang.Yint32: 32, yang.Yint64: 64,
+		yang.Yuint8: 8, yang.Yuint16: 16, yang.Yuint32: 32, yang.Yuint64: 64,
+	}[t.Kind]
+	switch t.Kind {
+	case yang.Yuint8, yang.Yuint16, yang.Yuint32, yang.Yuint64:
+		u, err := strconv.ParseUint(value, 10, bits)
+		if err != nil {
+			return yang.Number{}, fmt.Errorf("must be a %d bit unsigned integer", bits)
+		}
+		return yang.FromUint(u), nil
+	default:
+		i, err := strconv.ParseInt(value, 10, bits)
+		if err != nil {
+			return yang.Number{}, fmt.Errorf("must be a %d bit integer", bits)
+		}
+		return yang.FromInt(i), nil
+	}
+}
+
+// Helper which checks whether n is within any of the ranges.
+func inRange(ranges yang.YangRange, n yang.Number) bool {
+	for _, r := range ranges {
+		if !n.Less(r.Min) && !r.Max.Less(n) {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func childNames(e *yang.Entry) []string {
+	names := make([]string, 0, len(e.Dir))
+	for name := range e.Dir {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sortedChildren(e *yang.Entry) []*yang.Entry {
+	children := make([]*yang.Entry, 0, len(e.Dir))
+	for _, name := range childNames(e) {
+		children = append(children, e.Dir[name])
+	}
+	return children
+}
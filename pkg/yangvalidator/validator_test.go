@@ -0,0 +1,392 @@
+package yangvalidator
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const validConfig = `{
+	"PORT": {
+		"Ethernet0": {"lanes": "0,1,2,3", "mtu": "9100", "admin_status": "up", "fec": "rs", "speed": "100000", "link_training": "true"},
+		"Ethernet4": {"lanes": "4,5,6,7", "speed": "auto", "fec": "sonic-types:fc"}
+	},
+	"VLAN": {
+		"Vlan10": {"vlanid": "10", "dhcp_servers": ["10.0.0.1", "10.0.0.2"]},
+		"Vlan20": {"vlanid": "20", "dhcp_servers@": "10.0.0.1,10.0.0.2"}
+	},
+	"VLAN_MEMBER": {
+		"Vlan10|Ethernet0": {"tagging_mode": "untagged"}
+	},
+	"PORTCHANNEL": {
+		"PortChannel01": {"admin_status": "up", "min_links": "1"}
+	},
+	"PORTCHANNEL_MEMBER": {
+		"PortChannel01|Ethernet0": {"NULL": "NULL"}
+	},
+	"DSCP_TO_TC_MAP": {
+		"AZURE": {"0": "1", "8": "0", "63": "15"}
+	},
+	"DEVICE_METADATA": {
+		"localhost": {"hostname": "sonic", "bgp_asn": "65100"}
+	},
+	"TABLE_WITHOUT_MODEL": {
+		"anything": {"goes": "here"}
+	}
+}`
+
+func loadTestValidator(t *testing.T) *Validator {
+	t.Helper()
+	v, err := NewValidator("testdata")
+	if err != nil {
+		t.Fatalf("Failed to load test models: %v", err)
+	}
+	return v
+}
+
+func parseConfig(t *testing.T, text string) map[string]interface{} {
+	t.Helper()
+	var config map[string]interface{}
+	if err := json.Unmarshal([]byte(text), &config); err != nil {
+		t.Fatalf("Invalid test config: %v", err)
+	}
+	return config
+}
+
+func TestValidateValidConfig(t *testing.T) {
+	v := loadTestValidator(t)
+	if err := v.Validate(parseConfig(t, validConfig)); err != nil {
+		t.Fatalf("expected valid config, got: %v", err)
+	}
+}
+
+func TestValidateInvalidConfig(t *testing.T) {
+	v := loadTestValidator(t)
+
+	testCases := []struct {
+		name  string
+		table string
+		value string
+		paths []string
+		msg   string
+	}{
+		{
+			name:  "value out of range",
+			table: "PORT",
+			value: `{"Ethernet0": {"lanes": "0", "mtu": "10000"}}`,
+			paths: []string{"/PORT/Ethernet0/mtu"},
+			msg:   "must be within 68..9216",
+		},
+		{
+			name:  "not a number",
+			table: "PORT",
+			value: `{"Ethernet0": {"lanes": "0", "mtu": "jumbo"}}`,
+			paths: []string{"/PORT/Ethernet0/mtu"},
+			msg:   "must be a 16 bit unsigned integer",
+		},
+		{
+			name:  "key does not match pattern",
+			table: "PORT",
+			value: `{"Eth0": {"lanes": "0"}, "Ethernet0": {"lanes": "0"}}`,
+			paths: []string{"/PORT/Eth0"},
+			msg:   "does not match pattern",
+		},
+		{
+			name:  "invalid enumeration",
+			table: "PORT",
+			value: `{"Ethernet0": {"lanes": "0", "admin_status": "sideways"}}`,
+			paths: []string{"/PORT/Ethernet0/admin_status"},
+			msg:   "must be one of [down up]",
+		},
+		{
+			name:  "invalid identity",
+			table: "PORT",
+			value: `{"Ethernet0": {"lanes": "0", "fec": "none"}}`,
+			paths: []string{"/PORT/Ethernet0/fec"},
+			msg:   `is not derived from identity "fec_mode"`,
+		},
+		{
+			name:  "no union member matches",
+			table: "PORT",
+			value: `{"Ethernet0": {"lanes": "0", "speed": "fast"}}`,
+			paths: []string{"/PORT/Ethernet0/speed"},
+			msg:   "does not match any member of the union",
+		},
+		{
+			name:  "invalid boolean within choice",
+			table: "PORT",
+			value: `{"Ethernet0": {"lanes": "0", "link_training": "yes"}}`,
+			paths: []string{"/PORT/Ethernet0/link_training"},
+			msg:   "must be 'true' or 'false'",
+		},
+		{
+			name:  "unknown field",
+			table: "PORT",
+			value: `{"Ethernet0": {"lanes": "0", "colour": "blue"}}`,
+			paths: []string{"/PORT/Ethernet0/colour"},
+			msg:   "unknown field",
+		},
+		{
+			name:  "mandatory field missing",
+			table: "PORT",
+			value: `{"Ethernet0": {"mtu": "9100"}}`,
+			paths: []string{"/PORT/Ethernet0/lanes"},
+			msg:   "mandatory field is missing",
+		},
+		{
+			name:  "invalid leaf-list item",
+			table: "VLAN",
+			value: `{"Vlan10": {"dhcp_servers@": "10.0.0.1,dhcp.example.com"}}`,
+			paths: []string{"/VLAN/Vlan10/dhcp_servers"},
+			msg:   `"dhcp.example.com" is not valid`,
+		},
+		{
+			name:  "leafref without instance",
+			table: "VLAN_MEMBER",
+			value: `{"Vlan10|Ethernet8": {"tagging_mode": "tagged"}}`,
+			paths: []string{"/VLAN_MEMBER/Vlan10|Ethernet8"},
+			msg:   `no PORT entry has name "Ethernet8"`,
+		},
+		{
+			name:  "leafref value does not match the type of its target",
+			table: "VLAN_MEMBER",
+			value: `{"Vlan10|Eth0": {"tagging_mode": "tagged"}}`,
+			paths: []string{"/VLAN_MEMBER/Vlan10|Eth0"},
+			msg:   `"Eth0" is not valid: does not match pattern`,
+		},
+		{
+			name:  "leafref of a container entry without instance",
+			table: "DEVICE_METADATA",
+			value: `{"localhost": {"hostname": "sonic", "mgmt_vlan": "Vlan30"}}`,
+			paths: []string{"/DEVICE_METADATA/localhost/mgmt_vlan"},
+			msg:   `no VLAN entry has name "Vlan30"`,
+		},
+		{
+			name:  "portchannel member of an unknown port",
+			table: "PORTCHANNEL_MEMBER",
+			value: `{"PortChannel01|Ethernet8": {"NULL": "NULL"}}`,
+			paths: []string{"/PORTCHANNEL_MEMBER/PortChannel01|Ethernet8"},
+			msg:   `no PORT entry has name "Ethernet8"`,
+		},
+		{
+			name:  "invalid key of a nested list",
+			table: "DSCP_TO_TC_MAP",
+			value: `{"AZURE": {"0": "1", "64": "1"}}`,
+			paths: []string{"/DSCP_TO_TC_MAP/AZURE/64"},
+			msg:   `"64" is not valid: does not match pattern`,
+		},
+		{
+			name:  "invalid value of a nested list",
+			table: "DSCP_TO_TC_MAP",
+			value: `{"AZURE": {"0": "16"}}`,
+			paths: []string{"/DSCP_TO_TC_MAP/AZURE/0"},
+			msg:   `"16" is not valid: does not match pattern`,
+		},
+		{
+			name:  "wrong number of key components",
+			table: "VLAN_MEMBER",
+			value: `{"Vlan10": {"tagging_mode": "tagged"}}`,
+			paths: []string{"/VLAN_MEMBER/Vlan10"},
+			msg:   "key has 1 components",
+		},
+		{
+			name:  "unknown container entry",
+			table: "DEVICE_METADATA",
+			value: `{"remotehost": {"hostname": "sonic"}}`,
+			paths: []string{"/DEVICE_METADATA/remotehost"},
+			msg:   "unknown entry, expected one of [localhost]",
+		},
+		{
+			name:  "multiple errors are reported by path",
+			table: "PORT",
+			value: `{"Ethernet4": {"mtu": "1"}, "Ethernet0": {"lanes": "0", "mtu": "1"}}`,
+			msg:   "must be within 68..9216",
+			paths: []string{"/PORT/Ethernet0/mtu", "/PORT/Ethernet4/lanes", "/PORT/Ethernet4/mtu"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := parseConfig(t, validConfig)
+			config[tc.table] = parseConfig(t, tc.value)
+
+			err := v.Validate(config)
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("expected a ValidationError, got: %v", err)
+			}
+
+			var paths []string
+			for _, leafErr := range validationErr.Errors {
+				paths = append(paths, leafErr.Path)
+			}
+			if !reflect.DeepEqual(paths, tc.paths) {
+				t.Errorf("expected errors at %v, got: %v", tc.paths, err)
+			}
+			if !strings.Contains(err.Error(), tc.msg) {
+				t.Errorf("expected error to contain %q, got: %v", tc.msg, err)
+			}
+		})
+	}
+}
+
+func TestValidateUnsupportedConstructs(t *testing.T) {
+	v := loadTestValidator(t)
+
+	// A relative leafref, and leaves with 'when' and 'must' statements, are accepted unchecked
+	config := parseConfig(t, validConfig)
+	config["DEVICE_METADATA"] = parseConfig(t, `{"localhost": {
+		"hostname": "sonic", "bgp_asn": "65100", "default_hostname": "other", "deployment_id": "1", "region": "west"
+	}}`)
+	if err := v.Validate(config); err != nil {
+		t.Errorf("expected unsupported constructs to be skipped, got: %v", err)
+	}
+
+	// goyang rejects models with patterns Go cannot parse, so clear one to stand in for it
+	v.patterns["Ethernet([0-9]{1,3})"] = nil
+	config = parseConfig(t, validConfig)
+	config["PORT"] = parseConfig(t, `{"Ethernet0": {"lanes": "0"}, "Eth4": {"lanes": "4"}}`)
+	config["VLAN_MEMBER"] = parseConfig(t, `{"Vlan10|Eth4": {"tagging_mode": "untagged"}}`)
+	delete(config, "PORTCHANNEL_MEMBER")
+	if err := v.Validate(config); err != nil {
+		t.Errorf("expected values of the pattern to be accepted, got: %v", err)
+	}
+}
+
+// TestValidateInstalledModels loads the sonic-yang-models package, as installed on a SONiC device
+// and in the build environment, and checks that a config using tables whose models rely on 'must'
+// and 'when' statements and nested lists is accepted.
+func TestValidateInstalledModels(t *testing.T) {
+	if _, err := os.Stat(YANG_MODELS_DIR); err != nil {
+		t.Skipf("sonic-yang-models are not installed at '%s'", YANG_MODELS_DIR)
+	}
+	v, err := NewValidator(YANG_MODELS_DIR)
+	if err != nil {
+		t.Fatalf("Failed to load models: %v", err)
+	}
+	for _, table := range []string{"PORT", "ACL_TABLE", "ACL_RULE", "BUFFER_POOL", "BUFFER_PROFILE", "DSCP_TO_TC_MAP"} {
+		if _, ok := v.tables[table]; !ok {
+			t.Errorf("no model loaded for table %s", table)
+		}
+	}
+
+	const config = `{
+		"PORT": {
+			"Ethernet0": {"alias": "etp1", "lanes": "0,1,2,3", "speed": "100000", "mtu": "9100", "admin_status": "up"},
+			"Ethernet4": {"alias": "etp2", "lanes": "4,5,6,7", "speed": "100000", "mtu": "9100", "admin_status": "up"}
+		},
+		"ACL_TABLE": {
+			"DATAACL": {"policy_desc": "DATAACL", "type": "L3", "stage": "ingress", "ports": ["Ethernet0", "Ethernet4"]}
+		},
+		"ACL_RULE": {
+			"DATAACL|RULE_1": {"PRIORITY": "9999", "PACKET_ACTION": "DROP", "SRC_IP": "10.0.0.2/32"}
+		},
+		"BUFFER_POOL": {
+			"ingress_lossless_pool": {"mode": "dynamic", "size": "12766208", "type": "ingress"}
+		},
+		"BUFFER_PROFILE": {
+			"ingress_lossy_profile": {"pool": "ingress_lossless_pool", "size": "0", "dynamic_th": "3"}
+		},
+		"DSCP_TO_TC_MAP": {
+			"AZURE": {"0": "1", "8": "0", "46": "5"}
+		}
+	}`
+	if err := v.Validate(parseConfig(t, config)); err != nil {
+		t.Fatalf("expected valid config, got: %v", err)
+	}
+}
+
+func TestValidationErrorMessage(t *testing.T) {
+	err := &ValidationError{}
+	for i := 0; i < maxReportedErrors+2; i++ {
+		err.Errors = append(err.Errors, LeafError{Path: "/PORT/Ethernet0/mtu", Message: "invalid"})
+	}
+
+	msg := err.Error()
+	if !strings.HasPrefix(msg, "YANG validation failed: /PORT/Ethernet0/mtu: invalid") {
+		t.Errorf("unexpected message: %s", msg)
+	}
+	if !strings.HasSuffix(msg, "and 2 more") {
+		t.Errorf("expected remaining errors to be summarised, got: %s", msg)
+	}
+}
+
+func TestNewValidatorErrors(t *testing.T) {
+	if _, err := NewValidator(t.TempDir()); err == nil {
+		t.Error("expected an error for a directory without models")
+	}
+
+	original := YANG_MODELS_DIR
+	defer func() { YANG_MODELS_DIR = original }()
+	YANG_MODELS_DIR = "testdata"
+
+	first, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load models: %v", err)
+	}
+	second, _ := Load()
+	if first != second {
+		t.Error("expected the validator to be cached")
+	}
+
+	// Failures are cached too, rather than parsing the models again
+	YANG_MODELS_DIR = t.TempDir()
+	if _, err := Load(); err == nil {
+		t.Fatal("expected an error for a directory without models")
+	}
+	if err := os.WriteFile(filepath.Join(YANG_MODELS_DIR, "sonic-types.yang"), []byte("module sonic-types {}"), 0644); err != nil {
+		t.Fatalf("Failed to write model: %v", err)
+	}
+	if _, err := Load(); err == nil {
+		t.Error("expected the load failure to be cached")
+	}
+}
+
+func TestNewValidatorSkipsBrokenModels(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"sonic-types.yang", "sonic-port.yang", "sonic-vlan.yang"} {
+		data, err := os.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			t.Fatalf("Failed to read model: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			t.Fatalf("Failed to write model: %v", err)
+		}
+	}
+	broken := map[string]string{
+		// A syntax error
+		"sonic-broken.yang": "module sonic-broken {",
+		// An import of a model which is not present
+		"sonic-acl.yang": `module sonic-acl {
+			namespace "http://github.com/sonic-net/sonic-acl";
+			prefix acl;
+			import sonic-missing { prefix missing; }
+			container sonic-acl { container ACL_TABLE { leaf name { type missing:name; } } }
+		}`,
+	}
+	for name, data := range broken {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatalf("Failed to write model: %v", err)
+		}
+	}
+
+	v, err := NewValidator(dir)
+	if err != nil {
+		t.Fatalf("expected the broken models to be skipped, got: %v", err)
+	}
+	if _, ok := v.tables["ACL_TABLE"]; ok {
+		t.Error("expected the model with a missing import to be skipped")
+	}
+	config := parseConfig(t, `{
+		"PORT": {"Ethernet0": {"lanes": "0", "mtu": "1"}},
+		"ACL_TABLE": {"DATAACL": {"anything": "goes"}}
+	}`)
+	err = v.Validate(config)
+	if err == nil || !strings.Contains(err.Error(), "/PORT/Ethernet0/mtu") {
+		t.Errorf("expected the loaded models to be validated, got: %v", err)
+	}
+}
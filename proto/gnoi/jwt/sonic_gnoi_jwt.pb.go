@@ -278,40 +278,140 @@ func (m *RefreshResponse) GetToken() *JwtToken {
 	return nil
 }
 
+// Revokes a single token when access_token is set, otherwise all tokens
+// issued so far to username. An empty request revokes the caller's own token.
+type RevokeRequest struct {
+	Username             string   `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	AccessToken          string   `protobuf:"bytes,2,opt,name=access_token,json=accessToken,proto3" json:"access_token,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RevokeRequest) Reset()         { *m = RevokeRequest{} }
+func (m *RevokeRequest) String() string { return proto.CompactTextString(m) }
+func (*RevokeRequest) ProtoMessage()    {}
+func (*RevokeRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a2a81dd5b5518377, []int{5}
+}
+func (m *RevokeRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *RevokeRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_RevokeRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *RevokeRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RevokeRequest.Merge(m, src)
+}
+func (m *RevokeRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *RevokeRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_RevokeRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RevokeRequest proto.InternalMessageInfo
+
+func (m *RevokeRequest) GetUsername() string {
+	if m != nil {
+		return m.Username
+	}
+	return ""
+}
+
+func (m *RevokeRequest) GetAccessToken() string {
+	if m != nil {
+		return m.AccessToken
+	}
+	return ""
+}
+
+type RevokeResponse struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RevokeResponse) Reset()         { *m = RevokeResponse{} }
+func (m *RevokeResponse) String() string { return proto.CompactTextString(m) }
+func (*RevokeResponse) ProtoMessage()    {}
+func (*RevokeResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a2a81dd5b5518377, []int{6}
+}
+func (m *RevokeResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *RevokeResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_RevokeResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *RevokeResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RevokeResponse.Merge(m, src)
+}
+func (m *RevokeResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *RevokeResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_RevokeResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RevokeResponse proto.InternalMessageInfo
+
 func init() {
 	proto.RegisterType((*JwtToken)(nil), "gnoi.sonic_jwt.JwtToken")
 	proto.RegisterType((*AuthenticateRequest)(nil), "gnoi.sonic_jwt.AuthenticateRequest")
 	proto.RegisterType((*AuthenticateResponse)(nil), "gnoi.sonic_jwt.AuthenticateResponse")
 	proto.RegisterType((*RefreshRequest)(nil), "gnoi.sonic_jwt.RefreshRequest")
 	proto.RegisterType((*RefreshResponse)(nil), "gnoi.sonic_jwt.RefreshResponse")
+	proto.RegisterType((*RevokeRequest)(nil), "gnoi.sonic_jwt.RevokeRequest")
+	proto.RegisterType((*RevokeResponse)(nil), "gnoi.sonic_jwt.RevokeResponse")
 }
 
 func init() { proto.RegisterFile("sonic_gnoi_jwt.proto", fileDescriptor_a2a81dd5b5518377) }
 
 var fileDescriptor_a2a81dd5b5518377 = []byte{
-	// 343 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x52, 0xcd, 0x4e, 0xc2, 0x40,
-	0x10, 0xa6, 0xe2, 0x0f, 0x0c, 0x04, 0xc8, 0xca, 0xa1, 0x69, 0x62, 0xc5, 0xea, 0x81, 0x8b, 0x25,
-	0xc1, 0x27, 0xc0, 0x83, 0x89, 0x44, 0x2f, 0x85, 0x9b, 0x87, 0x5a, 0xea, 0x50, 0x16, 0xc3, 0x6e,
-	0xed, 0x6e, 0xad, 0xbe, 0x89, 0x0f, 0xe3, 0x03, 0x78, 0xf4, 0x11, 0x0c, 0xbe, 0x88, 0xe9, 0xb6,
-	0x34, 0x42, 0xd4, 0x83, 0xb7, 0xfd, 0xe6, 0xfb, 0xe6, 0x9b, 0x6f, 0x26, 0x0b, 0x6d, 0xc1, 0x19,
-	0xf5, 0xdd, 0x80, 0x71, 0xea, 0xce, 0x13, 0x69, 0x87, 0x11, 0x97, 0x9c, 0x34, 0x52, 0x6c, 0x67,
-	0xd4, 0x3c, 0x91, 0xc6, 0x69, 0x40, 0xe5, 0x2c, 0x9e, 0xd8, 0x3e, 0x5f, 0xf4, 0x02, 0x1e, 0xf0,
-	0x9e, 0x92, 0x4d, 0xe2, 0xa9, 0x42, 0x0a, 0xa8, 0x57, 0xd6, 0x6e, 0xdd, 0x42, 0x65, 0x98, 0xc8,
-	0x31, 0xbf, 0x47, 0x46, 0x8e, 0xa0, 0xee, 0xf9, 0x3e, 0x0a, 0xe1, 0xca, 0x14, 0xeb, 0x5a, 0x47,
-	0xeb, 0x56, 0x9d, 0x5a, 0x56, 0xcb, 0x24, 0x04, 0xb6, 0xe5, 0x73, 0x88, 0xfa, 0x96, 0xa2, 0xd4,
-	0x9b, 0x1c, 0x00, 0xe0, 0x53, 0x48, 0x23, 0x14, 0x2e, 0x65, 0x7a, 0xb9, 0xa3, 0x75, 0xcb, 0x4e,
-	0x35, 0xaf, 0x5c, 0x32, 0xeb, 0x1a, 0xf6, 0x07, 0xb1, 0x9c, 0x21, 0x93, 0xd4, 0xf7, 0x24, 0x3a,
-	0xf8, 0x10, 0xa3, 0x90, 0xc4, 0x80, 0x4a, 0x2c, 0x30, 0x62, 0xde, 0x02, 0xf3, 0x41, 0x05, 0x4e,
-	0xb9, 0xd0, 0x13, 0x22, 0xe1, 0xd1, 0x5d, 0x3e, 0xa9, 0xc0, 0xd6, 0x05, 0xb4, 0xd7, 0xed, 0x44,
-	0xc8, 0x99, 0x40, 0x62, 0xc3, 0xce, 0xb8, 0x48, 0x5d, 0xeb, 0xeb, 0xf6, 0xfa, 0x5d, 0xec, 0xd5,
-	0x96, 0x4e, 0x26, 0xb3, 0x5a, 0xd0, 0x70, 0x70, 0x1a, 0xa1, 0x98, 0xe5, 0x89, 0xac, 0x01, 0x34,
-	0x8b, 0xca, 0xff, 0x4c, 0xfb, 0xaf, 0x1a, 0x34, 0x47, 0x29, 0x3b, 0x4c, 0xe4, 0x08, 0xa3, 0x47,
-	0xea, 0x23, 0xb9, 0x81, 0xfa, 0xf7, 0xc0, 0xe4, 0x78, 0xd3, 0xe4, 0x87, 0xeb, 0x18, 0x27, 0x7f,
-	0x8b, 0xb2, 0x78, 0x56, 0x89, 0x5c, 0xc1, 0x5e, 0x9e, 0x99, 0x98, 0x9b, 0x2d, 0xeb, 0xeb, 0x19,
-	0x87, 0xbf, 0xf2, 0x2b, 0xb7, 0xf3, 0xd6, 0xdb, 0xd2, 0xd4, 0xde, 0x97, 0xa6, 0xf6, 0xb1, 0x34,
-	0xb5, 0x97, 0x4f, 0xb3, 0x34, 0xd9, 0x55, 0xbf, 0xe4, 0xec, 0x2b, 0x00, 0x00, 0xff, 0xff, 0xdb,
-	0xda, 0x4f, 0x99, 0x7c, 0x02, 0x00, 0x00,
+	// 382 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x52, 0x4d, 0x6e, 0xda, 0x40,
+	0x18, 0xc5, 0xd0, 0x52, 0xf8, 0xa0, 0x80, 0xa6, 0x2c, 0x2c, 0x4b, 0xb8, 0xd4, 0xed, 0x82, 0x4d,
+	0x8d, 0x44, 0x4f, 0x40, 0x17, 0x95, 0x40, 0x6d, 0x17, 0x86, 0x5d, 0x17, 0xae, 0x71, 0x3e, 0x8c,
+	0x41, 0xcc, 0x38, 0x9e, 0x31, 0x4e, 0x4e, 0x90, 0x2b, 0xe4, 0x48, 0x59, 0xe6, 0x08, 0x11, 0xb9,
+	0x48, 0x64, 0x8f, 0xb1, 0xf8, 0x09, 0x51, 0x94, 0x9d, 0xdf, 0xf7, 0xde, 0xbc, 0xef, 0x7d, 0x4f,
+	0x86, 0x36, 0x67, 0xd4, 0x77, 0x6d, 0x8f, 0x32, 0xdf, 0x5e, 0xc6, 0xc2, 0x0c, 0x42, 0x26, 0x18,
+	0x69, 0x24, 0xd8, 0x94, 0xd4, 0x32, 0x16, 0xda, 0x77, 0xcf, 0x17, 0x8b, 0x68, 0x66, 0xba, 0x6c,
+	0xdd, 0xf7, 0x98, 0xc7, 0xfa, 0xa9, 0x6c, 0x16, 0xcd, 0x53, 0x94, 0x82, 0xf4, 0x4b, 0x3e, 0x37,
+	0xfe, 0x43, 0x65, 0x1c, 0x8b, 0x29, 0x5b, 0x21, 0x25, 0x5f, 0xa0, 0xee, 0xb8, 0x2e, 0x72, 0x6e,
+	0x8b, 0x04, 0xab, 0x4a, 0x57, 0xe9, 0x55, 0xad, 0x9a, 0x9c, 0x49, 0x09, 0x81, 0x77, 0xe2, 0x3a,
+	0x40, 0xb5, 0x98, 0x52, 0xe9, 0x37, 0xe9, 0x00, 0xe0, 0x55, 0xe0, 0x87, 0xc8, 0x6d, 0x9f, 0xaa,
+	0xa5, 0xae, 0xd2, 0x2b, 0x59, 0xd5, 0x6c, 0x32, 0xa2, 0xc6, 0x1f, 0xf8, 0x34, 0x8c, 0xc4, 0x02,
+	0xa9, 0xf0, 0x5d, 0x47, 0xa0, 0x85, 0x97, 0x11, 0x72, 0x41, 0x34, 0xa8, 0x44, 0x1c, 0x43, 0xea,
+	0xac, 0x31, 0x5b, 0x94, 0xe3, 0x84, 0x0b, 0x1c, 0xce, 0x63, 0x16, 0x5e, 0x64, 0x9b, 0x72, 0x6c,
+	0xfc, 0x82, 0xf6, 0xa1, 0x1d, 0x0f, 0x18, 0xe5, 0x48, 0x4c, 0x78, 0x3f, 0xcd, 0x53, 0xd7, 0x06,
+	0xaa, 0x79, 0xd8, 0x8b, 0xb9, 0xbb, 0xd2, 0x92, 0x32, 0xa3, 0x05, 0x0d, 0x0b, 0xe7, 0x21, 0xf2,
+	0x45, 0x96, 0xc8, 0x18, 0x42, 0x33, 0x9f, 0xbc, 0xd1, 0xf4, 0x2f, 0x7c, 0xb4, 0x70, 0xc3, 0x56,
+	0xaf, 0xba, 0xf2, 0xb8, 0xee, 0xe2, 0x49, 0xdd, 0x32, 0xa4, 0xf4, 0x93, 0x89, 0x06, 0x37, 0x45,
+	0x68, 0x4e, 0x92, 0xfd, 0xe3, 0x58, 0x4c, 0x30, 0xdc, 0xf8, 0x2e, 0x92, 0x7f, 0x50, 0xdf, 0xaf,
+	0x84, 0x7c, 0x3d, 0x8e, 0xf9, 0x4c, 0xff, 0xda, 0xb7, 0x97, 0x45, 0x72, 0x9d, 0x51, 0x20, 0xbf,
+	0xe1, 0x43, 0xd6, 0x0a, 0xd1, 0x8f, 0x9f, 0x1c, 0x16, 0xa8, 0x7d, 0x3e, 0xcb, 0xe7, 0x6e, 0x23,
+	0x28, 0xcb, 0x83, 0x48, 0xe7, 0x54, 0xbc, 0x57, 0x9c, 0xa6, 0x9f, 0xa3, 0x77, 0x56, 0x3f, 0x5b,
+	0x77, 0x5b, 0x5d, 0xb9, 0xdf, 0xea, 0xca, 0xc3, 0x56, 0x57, 0x6e, 0x1f, 0xf5, 0xc2, 0xac, 0x9c,
+	0xfe, 0xd2, 0x3f, 0x9e, 0x06, 0x00, 0x4a, 0xa9, 0xc7, 0xc3, 0x29, 0x03, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -328,6 +428,7 @@ const _ = grpc.SupportPackageIsVersion4
 type SonicJwtServiceClient interface {
 	Authenticate(ctx context.Context, in *AuthenticateRequest, opts ...grpc.CallOption) (*AuthenticateResponse, error)
 	Refresh(ctx context.Context, in *RefreshRequest, opts ...grpc.CallOption) (*RefreshResponse, error)
+	Revoke(ctx context.Context, in *RevokeRequest, opts ...grpc.CallOption) (*RevokeResponse, error)
 }
 
 type sonicJwtServiceClient struct {
@@ -356,10 +457,20 @@ func (c *sonicJwtServiceClient) Refresh(ctx context.Context, in *RefreshRequest,
 	return out, nil
 }
 
+func (c *sonicJwtServiceClient) Revoke(ctx context.Context, in *RevokeRequest, opts ...grpc.CallOption) (*RevokeResponse, error) {
+	out := new(RevokeResponse)
+	err := c.cc.Invoke(ctx, "/gnoi.sonic_jwt.SonicJwtService/Revoke", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // SonicJwtServiceServer is the server API for SonicJwtService service.
 type SonicJwtServiceServer interface {
 	Authenticate(context.Context, *AuthenticateRequest) (*AuthenticateResponse, error)
 	Refresh(context.Context, *RefreshRequest) (*RefreshResponse, error)
+	Revoke(context.Context, *RevokeRequest) (*RevokeResponse, error)
 }
 
 // UnimplementedSonicJwtServiceServer can be embedded to have forward compatible implementations.
@@ -372,6 +483,9 @@ func (*UnimplementedSonicJwtServiceServer) Authenticate(ctx context.Context, req
 func (*UnimplementedSonicJwtServiceServer) Refresh(ctx context.Context, req *RefreshRequest) (*RefreshResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Refresh not implemented")
 }
+func (*UnimplementedSonicJwtServiceServer) Revoke(ctx context.Context, req *RevokeRequest) (*RevokeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Revoke not implemented")
+}
 
 func RegisterSonicJwtServiceServer(s *grpc.Server, srv SonicJwtServiceServer) {
 	s.RegisterService(&_SonicJwtService_serviceDesc, srv)
@@ -413,6 +527,24 @@ func _SonicJwtService_Refresh_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _SonicJwtService_Revoke_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevokeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SonicJwtServiceServer).Revoke(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gnoi.sonic_jwt.SonicJwtService/Revoke",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SonicJwtServiceServer).Revoke(ctx, req.(*RevokeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _SonicJwtService_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "gnoi.sonic_jwt.SonicJwtService",
 	HandlerType: (*SonicJwtServiceServer)(nil),
@@ -425,6 +557,10 @@ var _SonicJwtService_serviceDesc = grpc.ServiceDesc{
 			MethodName: "Refresh",
 			Handler:    _SonicJwtService_Refresh_Handler,
 		},
+		{
+			MethodName: "Revoke",
+			Handler:    _SonicJwtService_Revoke_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "sonic_gnoi_jwt.proto",
@@ -622,6 +758,74 @@ func (m *RefreshResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
+func (m *RevokeRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *RevokeRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *RevokeRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if len(m.AccessToken) > 0 {
+		i -= len(m.AccessToken)
+		copy(dAtA[i:], m.AccessToken)
+		i = encodeVarintSonicGnoiJwt(dAtA, i, uint64(len(m.AccessToken)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.Username) > 0 {
+		i -= len(m.Username)
+		copy(dAtA[i:], m.Username)
+		i = encodeVarintSonicGnoiJwt(dAtA, i, uint64(len(m.Username)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *RevokeResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *RevokeResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *RevokeResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	return len(dAtA) - i, nil
+}
+
 func encodeVarintSonicGnoiJwt(dAtA []byte, offset int, v uint64) int {
 	offset -= sovSonicGnoiJwt(v)
 	base := offset
@@ -720,6 +924,38 @@ func (m *RefreshResponse) Size() (n int) {
 	return n
 }
 
+func (m *RevokeRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Username)
+	if l > 0 {
+		n += 1 + l + sovSonicGnoiJwt(uint64(l))
+	}
+	l = len(m.AccessToken)
+	if l > 0 {
+		n += 1 + l + sovSonicGnoiJwt(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *RevokeResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
 func sovSonicGnoiJwt(x uint64) (n int) {
 	return (math_bits.Len64(x|1) + 6) / 7
 }
@@ -1215,6 +1451,178 @@ func (m *RefreshResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *RevokeRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowSonicGnoiJwt
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: RevokeRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: RevokeRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Username", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSonicGnoiJwt
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSonicGnoiJwt
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSonicGnoiJwt
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Username = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field AccessToken", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSonicGnoiJwt
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSonicGnoiJwt
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSonicGnoiJwt
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.AccessToken = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipSonicGnoiJwt(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthSonicGnoiJwt
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthSonicGnoiJwt
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *RevokeResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowSonicGnoiJwt
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: RevokeResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: RevokeResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		default:
+			iNdEx = preIndex
+			skippy, err := skipSonicGnoiJwt(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthSonicGnoiJwt
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthSonicGnoiJwt
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func skipSonicGnoiJwt(dAtA []byte) (n int, err error) {
 	l := len(dAtA)
 	iNdEx := 0
@@ -0,0 +1,71 @@
+package show_client
+
+import (
+	"encoding/json"
+
+	log "github.com/golang/glog"
+	sdc "github.com/sonic-net/sonic-gnmi/sonic_data_client"
+)
+
+type IPv4BGPSummaryResponse struct {
+	IPv4Unicast BGPUnicastSummary `json:"ipv4Unicast"`
+}
+
+var (
+	vtyshBGPIPv4SummaryCommand   = "show ip bgp summary json"
+	vtyshBGPIPv4NeighborsCommand = "show ip bgp neighbors json"
+)
+
+// Helper which sets the name of each peer from CONFIG_DB/BGP_NEIGHBOR
+func setBGPNeighborNames(peers map[string]Peer) error {
+	queries := [][]string{
+		{"CONFIG_DB", "BGP_NEIGHBOR"},
+	}
+
+	bgpNeighborTableOutput, err := GetMapFromQueries(queries)
+	if err != nil {
+		log.Errorf("Unable to pull data for queries %v, got err %v", queries, err)
+		return err
+	}
+
+	for ip, peer := range peers {
+		// If unable to find name in CONFIG_DB/BGP_NEIGHBOR using show command default of NotAvailable
+		peer.NeighborName = GetFieldValueString(bgpNeighborTableOutput, ip, "NotAvailable", "name")
+		peers[ip] = peer
+	}
+	return nil
+}
+
+func getIPv4BGPSummary(options sdc.OptionMap) ([]byte, error) {
+	vtyshOutput, err := GetDataFromVtysh(vtyshBGPIPv4SummaryCommand, options)
+	if err != nil {
+		log.Errorf("Unable to succesfully execute command %v, get err %v", vtyshBGPIPv4SummaryCommand, err)
+		return nil, err
+	}
+	var vtyshResponse IPv4BGPSummaryResponse
+	if err := json.Unmarshal([]byte(vtyshOutput), &vtyshResponse); err != nil {
+		log.Errorf("Unable to create response from vtysh output %v", err)
+		return nil, err
+	}
+
+	if err := setBGPNeighborNames(vtyshResponse.IPv4Unicast.Peers); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(vtyshResponse)
+}
+
+func getIPv4BGPNeighbors(options sdc.OptionMap) ([]byte, error) {
+	vtyshOutput, err := GetDataFromVtysh(vtyshBGPIPv4NeighborsCommand, options)
+	if err != nil {
+		log.Errorf("Unable to succesfully execute command %v, get err %v", vtyshBGPIPv4NeighborsCommand, err)
+		return nil, err
+	}
+	// Neighbors are reported as FRR formats them, keyed by neighbor address
+	var neighbors map[string]interface{}
+	if err := json.Unmarshal([]byte(vtyshOutput), &neighbors); err != nil {
+		log.Errorf("Unable to create response from vtysh output %v", err)
+		return nil, err
+	}
+	return json.Marshal(neighbors)
+}
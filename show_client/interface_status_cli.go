@@ -0,0 +1,135 @@
+package show_client
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	log "github.com/golang/glog"
+	sdc "github.com/sonic-net/sonic-gnmi/sonic_data_client"
+)
+
+// Speed is stored in Mbps, and displayed as e.g. 100G, 2.5G or 100M
+func formatPortSpeed(speed string) string {
+	mbps, err := strconv.Atoi(speed)
+	if err != nil {
+		return defaultMissingCounterValue
+	}
+	switch {
+	case mbps < 1000:
+		return fmt.Sprintf("%dM", mbps)
+	case mbps%1000 >= 100:
+		return fmt.Sprintf("%.1fG", float64(mbps)/1000)
+	default:
+		return fmt.Sprintf("%dG", mbps/1000)
+	}
+}
+
+// The Vlan column is the PortChannel a port belongs to, "trunk" if it is a tagged member of any
+// VLAN, "untagged" if it is only an untagged member, and "routed" otherwise.
+func getPortVlanModes() (map[string]string, error) {
+	queries := [][]string{
+		{"CONFIG_DB", "VLAN_MEMBER"},
+	}
+	vlanMembers, err := GetMapFromQueries(queries)
+	if err != nil {
+		log.Errorf("Unable to pull data for queries %v, got err %v", queries, err)
+		return nil, err
+	}
+
+	modes := make(map[string]string)
+	for key := range vlanMembers {
+		parts := strings.SplitN(key, "|", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		port := parts[1]
+		if GetFieldValueString(vlanMembers, key, "", "tagging_mode") == "tagged" {
+			modes[port] = "trunk"
+		} else if _, ok := modes[port]; !ok {
+			modes[port] = "untagged"
+		}
+	}
+
+	queries = [][]string{
+		{"CONFIG_DB", "PORTCHANNEL_MEMBER"},
+	}
+	portChannelMembers, err := GetMapFromQueries(queries)
+	if err != nil {
+		log.Errorf("Unable to pull data for queries %v, got err %v", queries, err)
+		return nil, err
+	}
+	for key := range portChannelMembers {
+		parts := strings.SplitN(key, "|", 2)
+		if len(parts) == 2 {
+			modes[parts[1]] = parts[0]
+		}
+	}
+	return modes, nil
+}
+
+func getInterfacesStatus(options sdc.OptionMap) ([]byte, error) {
+	queries := [][]string{
+		{"APPL_DB", AppDBPortTable},
+	}
+	portTable, err := GetMapFromQueries(queries)
+	if err != nil {
+		log.Errorf("Unable to pull data for queries %v, got err %v", queries, err)
+		return nil, err
+	}
+
+	queries = [][]string{
+		{"STATE_DB", "TRANSCEIVER_INFO"},
+	}
+	transceiverInfo, err := GetMapFromQueries(queries)
+	if err != nil {
+		log.Errorf("Unable to pull data for queries %v, got err %v", queries, err)
+		return nil, err
+	}
+
+	vlanModes, err := getPortVlanModes()
+	if err != nil {
+		return nil, err
+	}
+
+	var ports []string
+	if intf, ok := options["interface"].String(); ok {
+		if _, found := portTable[intf]; !found {
+			return nil, fmt.Errorf("interface %s not found", intf)
+		}
+		ports = []string{intf}
+	} else {
+		for port := range portTable {
+			// Skip the port initialization markers kept in the same table
+			if port == "PortConfigDone" || port == "PortInitDone" {
+				continue
+			}
+			ports = append(ports, port)
+		}
+		ports = natsortInterfaces(ports)
+	}
+
+	portStatus := make([]map[string]string, 0, len(ports))
+	for _, port := range ports {
+		vlan, ok := vlanModes[port]
+		if !ok {
+			vlan = "routed"
+		}
+		portStatus = append(portStatus, map[string]string{
+			"Interface": port,
+			"Lanes":     GetFieldValueString(portTable, port, defaultMissingCounterValue, "lanes"),
+			"Speed":     formatPortSpeed(GetFieldValueString(portTable, port, defaultMissingCounterValue, "speed")),
+			"MTU":       GetFieldValueString(portTable, port, defaultMissingCounterValue, "mtu"),
+			"FEC":       GetFieldValueString(portTable, port, defaultMissingCounterValue, "fec"),
+			"Alias":     GetFieldValueString(portTable, port, defaultMissingCounterValue, "alias"),
+			"Vlan":      vlan,
+			"Oper":      GetFieldValueString(portTable, port, "down", "oper_status"),
+			"Admin":     GetFieldValueString(portTable, port, "down", "admin_status"),
+			"Type":      GetFieldValueString(transceiverInfo, port, defaultMissingCounterValue, "type"),
+			"Asym PFC":  GetFieldValueString(portTable, port, defaultMissingCounterValue, "pfc_asym"),
+		})
+	}
+
+	return json.Marshal(portStatus)
+}
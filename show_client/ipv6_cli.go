@@ -7,10 +7,10 @@ import (
 )
 
 type IPv6BGPSummaryResponse struct {
-	IPv6Unicast IPv6Unicast `json:"ipv6Unicast"`
+	IPv6Unicast BGPUnicastSummary `json:"ipv6Unicast"`
 }
 
-type IPv6Unicast struct {
+type BGPUnicastSummary struct {
 	RouterID        string          `json:"routerId"`
 	LocalAS         int             `json:"as"`
 	VRFId           int             `json:"vrfId"`
@@ -55,34 +55,10 @@ func getIPv6BGPSummary(options sdc.OptionMap) ([]byte, error) {
 		return nil, err
 	}
 
-	// Fetch neighbor name from CONFIG DB
-	queries := [][]string{
-		{"CONFIG_DB", "BGP_NEIGHBOR"},
-	}
-
-	bgpNeighborTableOutput, err := GetMapFromQueries(queries)
-	if err != nil {
-		log.Errorf("Unable to pull data for queries %v, got err %v", queries, err)
+	if err := setBGPNeighborNames(vtyshResponse.IPv6Unicast.Peers); err != nil {
 		return nil, err
 	}
 
-	// Modify vtysh data to use neighbor name from CONFIG DB
-	for ip, peer := range vtyshResponse.IPv6Unicast.Peers {
-		// If unable to find name in CONFIG_DB/BGP_NEIGHBOR using show command default of NotAvailable
-		neighborName := "NotAvailable"
-		if neighbor, found := bgpNeighborTableOutput[ip]; found {
-			if entry, ok := neighbor.(map[string]interface{}); ok {
-				if name, exists := entry["name"]; exists {
-					if nameVal, ok := name.(string); ok {
-						neighborName = nameVal
-					}
-				}
-			}
-		}
-		peer.NeighborName = neighborName
-		vtyshResponse.IPv6Unicast.Peers[ip] = peer
-	}
-
 	ipv6BGPSummaryJSON, err := json.Marshal(vtyshResponse)
 	if err != nil {
 		log.Errorf("Unable to create json data from modified vtysh response %v, got err %v", vtyshResponse, err)
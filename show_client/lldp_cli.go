@@ -0,0 +1,60 @@
+package show_client
+
+import (
+	"encoding/json"
+
+	log "github.com/golang/glog"
+	sdc "github.com/sonic-net/sonic-gnmi/sonic_data_client"
+)
+
+const AppDBLldpEntryTable = "LLDP_ENTRY_TABLE"
+
+// LLDP_ENTRY_TABLE field for each neighbor attribute reported
+var lldpNeighborFields = map[string]string{
+	"ChassisID":        "lldp_rem_chassis_id",
+	"SysName":          "lldp_rem_sys_name",
+	"SysDescr":         "lldp_rem_sys_desc",
+	"MgmtIP":           "lldp_rem_man_addr",
+	"Capability":       "lldp_rem_sys_cap_enabled",
+	"PortID":           "lldp_rem_port_id",
+	"PortIDSubtype":    "lldp_rem_port_id_subtype",
+	"PortDescr":        "lldp_rem_port_desc",
+	"ChassisIDSubtype": "lldp_rem_chassis_id_subtype",
+}
+
+func getLldpNeighbors(options sdc.OptionMap) ([]byte, error) {
+	queries := [][]string{
+		{"APPL_DB", AppDBLldpEntryTable},
+	}
+	intf, filtered := options["interface"].String()
+	if filtered {
+		queries = [][]string{
+			{"APPL_DB", AppDBLldpEntryTable, intf},
+		}
+	}
+
+	data, err := GetMapFromQueries(queries)
+	if err != nil {
+		log.Errorf("Unable to pull data for queries %v, got err %v", queries, err)
+		return nil, err
+	}
+	// A single entry is returned as its fields, rather than keyed by interface
+	if filtered {
+		data = map[string]interface{}{intf: data}
+	}
+
+	neighbors := make(map[string]map[string]string, len(data))
+	for port := range data {
+		neighbor := make(map[string]string, len(lldpNeighborFields))
+		for name, field := range lldpNeighborFields {
+			if value := GetFieldValueString(data, port, "", field); value != "" {
+				neighbor[name] = value
+			}
+		}
+		if len(neighbor) > 0 {
+			neighbors[port] = neighbor
+		}
+	}
+
+	return json.Marshal(neighbors)
+}
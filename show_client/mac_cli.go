@@ -0,0 +1,156 @@
+package show_client
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+
+	log "github.com/golang/glog"
+	sdc "github.com/sonic-net/sonic-gnmi/sonic_data_client"
+)
+
+// ASIC_STATE is keyed by object type and object, so querying an object type returns entries keyed
+// by <object type>:<object>
+const (
+	asicDBFdbEntryType   = "SAI_OBJECT_TYPE_FDB_ENTRY"
+	asicDBBridgePortType = "SAI_OBJECT_TYPE_BRIDGE_PORT"
+	asicDBVlanType       = "SAI_OBJECT_TYPE_VLAN"
+)
+
+// FDB entries are keyed by a JSON object identifying the entry
+type fdbEntryKey struct {
+	BvId string `json:"bvid"`
+	Mac  string `json:"mac"`
+	Vlan string `json:"vlan"`
+}
+
+type macEntry struct {
+	vlan int
+	mac  string
+	port string
+	kind string
+}
+
+// Helper which returns the reverse of a name to OID map in COUNTERS_DB
+func getOidToNameMap(table string) (map[string]string, error) {
+	queries := [][]string{
+		{"COUNTERS_DB", table},
+	}
+	nameMap, err := GetMapFromQueries(queries)
+	if err != nil {
+		log.Errorf("Unable to pull data for queries %v, got err %v", queries, err)
+		return nil, err
+	}
+	oidMap := make(map[string]string, len(nameMap))
+	for name, oid := range nameMap {
+		if oidStr, ok := oid.(string); ok {
+			oidMap[oidStr] = name
+		}
+	}
+	return oidMap, nil
+}
+
+func getMacTable(options sdc.OptionMap) ([]byte, error) {
+	queries := [][]string{
+		{"ASIC_DB", "ASIC_STATE:" + asicDBFdbEntryType},
+	}
+	fdbEntries, err := GetMapFromQueries(queries)
+	if err != nil {
+		log.Errorf("Unable to pull data for queries %v, got err %v", queries, err)
+		return nil, err
+	}
+
+	queries = [][]string{
+		{"ASIC_DB", "ASIC_STATE:" + asicDBBridgePortType},
+	}
+	bridgePorts, err := GetMapFromQueries(queries)
+	if err != nil {
+		log.Errorf("Unable to pull data for queries %v, got err %v", queries, err)
+		return nil, err
+	}
+
+	queries = [][]string{
+		{"ASIC_DB", "ASIC_STATE:" + asicDBVlanType},
+	}
+	asicVlans, err := GetMapFromQueries(queries)
+	if err != nil {
+		log.Errorf("Unable to pull data for queries %v, got err %v", queries, err)
+		return nil, err
+	}
+
+	portNames, err := getOidToNameMap("COUNTERS_PORT_NAME_MAP")
+	if err != nil {
+		return nil, err
+	}
+	lagNames, err := getOidToNameMap("COUNTERS_LAG_NAME_MAP")
+	if err != nil {
+		return nil, err
+	}
+	for oid, name := range lagNames {
+		portNames[oid] = name
+	}
+
+	vlanFilter, filterVlan := options["vlan"].Int()
+	portFilter, filterPort := options["port"].String()
+
+	var entries []macEntry
+	for key := range fdbEntries {
+		var entryKey fdbEntryKey
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(key, asicDBFdbEntryType+":")), &entryKey); err != nil {
+			log.V(2).Infof("Skipping FDB entry with invalid key %v: %v", key, err)
+			continue
+		}
+
+		bridgePort := GetFieldValueString(fdbEntries, key, "", "SAI_FDB_ENTRY_ATTR_BRIDGE_PORT_ID")
+		portOid := GetFieldValueString(bridgePorts, asicDBBridgePortType+":"+bridgePort, "", "SAI_BRIDGE_PORT_ATTR_PORT_ID")
+		port, ok := portNames[portOid]
+		if !ok {
+			continue
+		}
+
+		vlanId := entryKey.Vlan
+		if entryKey.BvId != "" {
+			vlanId = GetFieldValueString(asicVlans, asicDBVlanType+":"+entryKey.BvId, "", "SAI_VLAN_ATTR_VLAN_ID")
+		}
+		vlan, err := strconv.Atoi(vlanId)
+		if err != nil {
+			continue
+		}
+
+		if filterVlan && vlan != vlanFilter {
+			continue
+		}
+		if filterPort && port != portFilter {
+			continue
+		}
+
+		kind := GetFieldValueString(fdbEntries, key, "", "SAI_FDB_ENTRY_ATTR_TYPE")
+		entries = append(entries, macEntry{
+			vlan: vlan,
+			mac:  entryKey.Mac,
+			port: port,
+			kind: strings.ToLower(strings.TrimPrefix(kind, "SAI_FDB_ENTRY_TYPE_")),
+		})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].vlan != entries[j].vlan {
+			return entries[i].vlan < entries[j].vlan
+		}
+		return entries[i].mac < entries[j].mac
+	})
+
+	macTable := make([]map[string]string, 0, len(entries))
+	for i, entry := range entries {
+		macTable = append(macTable, map[string]string{
+			"No.":        strconv.Itoa(i + 1),
+			"Vlan":       strconv.Itoa(entry.vlan),
+			"MacAddress": entry.mac,
+			"Port":       entry.port,
+			"Type":       entry.kind,
+		})
+	}
+
+	return json.Marshal(macTable)
+}
@@ -0,0 +1,54 @@
+package show_client
+
+import (
+	"fmt"
+
+	log "github.com/golang/glog"
+	sdc "github.com/sonic-net/sonic-gnmi/sonic_data_client"
+)
+
+const pfcPriorityCount = 8
+
+func getPfcCountersSnapshot(intf string) (counterSnapshot, error) {
+	queries := [][]string{
+		{"COUNTERS_DB", "COUNTERS", "Ethernet*"},
+	}
+	aliasCountersOutput, err := GetMapFromQueries(queries)
+	if err != nil {
+		log.Errorf("Unable to pull data for queries %v, got err %v", queries, err)
+		return nil, err
+	}
+	portCounters := RemapAliasToPortName(aliasCountersOutput)
+
+	if intf != "" {
+		counters, found := portCounters[intf]
+		if !found {
+			return nil, fmt.Errorf("interface %s not found", intf)
+		}
+		portCounters = map[string]interface{}{intf: counters}
+	}
+
+	snapshot := counterSnapshot{
+		"Rx": make(map[string]map[string]string, len(portCounters)),
+		"Tx": make(map[string]map[string]string, len(portCounters)),
+	}
+	for port := range portCounters {
+		rx := make(map[string]string, pfcPriorityCount)
+		tx := make(map[string]string, pfcPriorityCount)
+		for priority := 0; priority < pfcPriorityCount; priority++ {
+			name := fmt.Sprintf("PFC%d", priority)
+			rx[name] = GetFieldValueString(portCounters, port, defaultMissingCounterValue, fmt.Sprintf("SAI_PORT_STAT_PFC_%d_RX_PKTS", priority))
+			tx[name] = GetFieldValueString(portCounters, port, defaultMissingCounterValue, fmt.Sprintf("SAI_PORT_STAT_PFC_%d_TX_PKTS", priority))
+		}
+		snapshot["Rx"][port] = rx
+		snapshot["Tx"][port] = tx
+	}
+	return snapshot, nil
+}
+
+func getPfcCounters(options sdc.OptionMap) ([]byte, error) {
+	intf, _ := options["interface"].String()
+	return getCountersOverPeriod(options, func() (counterSnapshot, error) {
+		return getPfcCountersSnapshot(intf)
+	})
+}
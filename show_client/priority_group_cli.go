@@ -0,0 +1,52 @@
+package show_client
+
+import (
+	"encoding/json"
+	"fmt"
+
+	log "github.com/golang/glog"
+	sdc "github.com/sonic-net/sonic-gnmi/sonic_data_client"
+)
+
+// PERIODIC_WATERMARKS field for each priority group watermark reported
+var priorityGroupWatermarkFields = map[string]string{
+	"Shared":   "SAI_INGRESS_PRIORITY_GROUP_STAT_SHARED_WATERMARK_BYTES",
+	"Headroom": "SAI_INGRESS_PRIORITY_GROUP_STAT_XOFF_ROOM_WATERMARK_BYTES",
+}
+
+func getPriorityGroupWatermark(options sdc.OptionMap) ([]byte, error) {
+	queries := [][]string{
+		{"COUNTERS_DB", "PERIODIC_WATERMARKS", "Ethernet*", "PriorityGroups"},
+	}
+	pgData, err := GetMapFromQueries(queries)
+	if err != nil {
+		log.Errorf("Unable to pull data for queries %v, got err %v", queries, err)
+		return nil, err
+	}
+	aliasMap := sdc.AliasToPortNameMap()
+	intf, filtered := options["interface"].String()
+
+	watermarks := make(map[string]map[string]map[string]string, len(priorityGroupWatermarkFields))
+	for name := range priorityGroupWatermarkFields {
+		watermarks[name] = make(map[string]map[string]string)
+	}
+	found := false
+	for key := range pgData {
+		port, index, ok := splitPortIndexKey(key, aliasMap)
+		if !ok || (filtered && port != intf) {
+			continue
+		}
+		found = true
+		for name, field := range priorityGroupWatermarkFields {
+			if _, ok := watermarks[name][port]; !ok {
+				watermarks[name][port] = make(map[string]string)
+			}
+			watermarks[name][port]["PG"+index] = GetFieldValueString(pgData, key, defaultMissingCounterValue, field)
+		}
+	}
+
+	if filtered && !found {
+		return nil, fmt.Errorf("no priority groups found for interface %s", intf)
+	}
+	return json.Marshal(watermarks)
+}
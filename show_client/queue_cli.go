@@ -0,0 +1,157 @@
+package show_client
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	log "github.com/golang/glog"
+	sdc "github.com/sonic-net/sonic-gnmi/sonic_data_client"
+)
+
+// COUNTERS field for each queue statistic reported
+var queueCounterFields = map[string]string{
+	"totalpacket": "SAI_QUEUE_STAT_PACKETS",
+	"totalbytes":  "SAI_QUEUE_STAT_BYTES",
+	"droppacket":  "SAI_QUEUE_STAT_DROPPED_PACKETS",
+	"dropbytes":   "SAI_QUEUE_STAT_DROPPED_BYTES",
+}
+
+// Merged into the queue stats from PERIODIC_WATERMARKS by the virtual path
+const queueWatermarkField = "SAI_QUEUE_STAT_SHARED_WATERMARK_BYTES"
+
+// Queues are labelled by type and index as in queuestat, e.g. UC0 or MC8
+var queueTypePrefixes = map[string]string{
+	"SAI_QUEUE_TYPE_UNICAST":   "UC",
+	"SAI_QUEUE_TYPE_MULTICAST": "MC",
+	"SAI_QUEUE_TYPE_ALL":       "ALL",
+}
+
+// Helper which returns the label of each queue, keyed by <port>:<index>
+func getQueueLabels() (map[string]string, error) {
+	queries := [][]string{
+		{"COUNTERS_DB", "COUNTERS_QUEUE_NAME_MAP"},
+	}
+	queueNames, err := GetMapFromQueries(queries)
+	if err != nil {
+		log.Errorf("Unable to pull data for queries %v, got err %v", queries, err)
+		return nil, err
+	}
+
+	queries = [][]string{
+		{"COUNTERS_DB", "COUNTERS_QUEUE_TYPE_MAP"},
+	}
+	queueTypes, err := GetMapFromQueries(queries)
+	if err != nil {
+		log.Errorf("Unable to pull data for queries %v, got err %v", queries, err)
+		return nil, err
+	}
+
+	labels := make(map[string]string, len(queueNames))
+	for queue, oid := range queueNames {
+		_, index, ok := splitPortIndexKey(queue, nil)
+		if !ok {
+			continue
+		}
+		queueType, _ := queueTypes[fmt.Sprint(oid)].(string)
+		labels[queue] = queueTypePrefixes[queueType] + index
+	}
+	return labels, nil
+}
+
+// Helper which returns the stats of each queue keyed by port and queue label, optionally only for
+// a single interface
+func getQueueStats(intf string) (map[string]map[string]interface{}, error) {
+	queries := [][]string{
+		{"COUNTERS_DB", "COUNTERS", "Ethernet*", "Queues"},
+	}
+	queueData, err := GetMapFromQueries(queries)
+	if err != nil {
+		log.Errorf("Unable to pull data for queries %v, got err %v", queries, err)
+		return nil, err
+	}
+
+	labels, err := getQueueLabels()
+	if err != nil {
+		return nil, err
+	}
+	aliasMap := sdc.AliasToPortNameMap()
+
+	stats := make(map[string]map[string]interface{})
+	for key, value := range queueData {
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		// The periodic watermark of a queue is returned as a separate <port>:<index>:periodic entry
+		port, index, ok := splitPortIndexKey(strings.TrimSuffix(key, ":periodic"), aliasMap)
+		if !ok || (intf != "" && port != intf) {
+			continue
+		}
+		label, ok := labels[port+":"+index]
+		if !ok {
+			label = index
+		}
+
+		if _, ok := stats[port]; !ok {
+			stats[port] = make(map[string]interface{})
+		}
+		queueStats, ok := stats[port][label].(map[string]interface{})
+		if !ok {
+			queueStats = make(map[string]interface{}, len(fields))
+			stats[port][label] = queueStats
+		}
+		for field, fieldValue := range fields {
+			queueStats[field] = fieldValue
+		}
+	}
+
+	if intf != "" && len(stats) == 0 {
+		return nil, fmt.Errorf("no queues found for interface %s", intf)
+	}
+	return stats, nil
+}
+
+func getQueueCountersSnapshot(intf string) (counterSnapshot, error) {
+	stats, err := getQueueStats(intf)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := make(counterSnapshot, len(stats))
+	for port, queues := range stats {
+		snapshot[port] = make(map[string]map[string]string, len(queues))
+		for label := range queues {
+			counters := make(map[string]string, len(queueCounterFields))
+			for name, field := range queueCounterFields {
+				counters[name] = GetFieldValueString(queues, label, defaultMissingCounterValue, field)
+			}
+			snapshot[port][label] = counters
+		}
+	}
+	return snapshot, nil
+}
+
+func getQueueCounters(options sdc.OptionMap) ([]byte, error) {
+	intf, _ := options["interface"].String()
+	return getCountersOverPeriod(options, func() (counterSnapshot, error) {
+		return getQueueCountersSnapshot(intf)
+	})
+}
+
+func getQueueWatermark(options sdc.OptionMap) ([]byte, error) {
+	intf, _ := options["interface"].String()
+	stats, err := getQueueStats(intf)
+	if err != nil {
+		return nil, err
+	}
+
+	watermarks := make(map[string]map[string]string, len(stats))
+	for port, queues := range stats {
+		watermarks[port] = make(map[string]string, len(queues))
+		for label := range queues {
+			watermarks[port][label] = GetFieldValueString(queues, label, defaultMissingCounterValue, queueWatermarkField)
+		}
+	}
+	return json.Marshal(watermarks)
+}
@@ -0,0 +1,72 @@
+package show_client
+
+import (
+	"encoding/json"
+	"net"
+	"regexp"
+	"strings"
+
+	log "github.com/golang/glog"
+	sdc "github.com/sonic-net/sonic-gnmi/sonic_data_client"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// VRF names are passed to vtysh, so only allow the characters SONiC VRF names use
+var vrfNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,32}$`)
+
+// Helper which builds the vtysh command for the ip or ipv6 route table, with the vrf and prefix options.
+func getRouteCommand(family string, options sdc.OptionMap) (string, error) {
+	args := []string{"show", family, "route"}
+
+	if vrf, ok := options["vrf"].String(); ok {
+		if !vrfNamePattern.MatchString(vrf) {
+			return "", status.Errorf(codes.InvalidArgument, "vrf %v is not a valid VRF name", vrf)
+		}
+		args = append(args, "vrf", vrf)
+	}
+
+	if prefix, ok := options["prefix"].String(); ok {
+		ip := net.ParseIP(prefix)
+		if ip == nil {
+			var err error
+			if ip, _, err = net.ParseCIDR(prefix); err != nil {
+				return "", status.Errorf(codes.InvalidArgument, "prefix %v is not a valid address or prefix", prefix)
+			}
+		}
+		if (ip.To4() != nil) != (family == "ip") {
+			return "", status.Errorf(codes.InvalidArgument, "prefix %v is not an %v address or prefix", prefix, family)
+		}
+		args = append(args, prefix)
+	}
+
+	return strings.Join(append(args, "json"), " "), nil
+}
+
+func getRoutes(family string, options sdc.OptionMap) ([]byte, error) {
+	command, err := getRouteCommand(family, options)
+	if err != nil {
+		return nil, err
+	}
+
+	vtyshOutput, err := GetDataFromVtysh(command, options)
+	if err != nil {
+		log.Errorf("Unable to succesfully execute command %v, get err %v", command, err)
+		return nil, err
+	}
+	// Routes are reported as FRR formats them, keyed by prefix
+	var routes map[string]interface{}
+	if err := json.Unmarshal([]byte(vtyshOutput), &routes); err != nil {
+		log.Errorf("Unable to create response from vtysh output %v", err)
+		return nil, err
+	}
+	return json.Marshal(routes)
+}
+
+func getIPRoute(options sdc.OptionMap) ([]byte, error) {
+	return getRoutes("ip", options)
+}
+
+func getIPv6Route(options sdc.OptionMap) ([]byte, error) {
+	return getRoutes("ipv6", options)
+}
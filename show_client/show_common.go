@@ -1,16 +1,22 @@
 package show_client
 
 import (
+	"encoding/json"
 	"fmt"
 	"os/exec"
 	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	log "github.com/golang/glog"
 	"github.com/google/shlex"
 	natural "github.com/maruel/natural"
 	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
 	sdc "github.com/sonic-net/sonic-gnmi/sonic_data_client"
+	sdcfg "github.com/sonic-net/sonic-gnmi/sonic_db_config"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 const AppDBPortTable = "PORT_TABLE"
@@ -52,6 +58,39 @@ func GetDataFromHostCommand(command string) (string, error) {
 	return string(output), nil
 }
 
+// GetDataFromVtysh runs an FRR command through vtysh on the host.
+// Multi-ASIC devices run FRR per ASIC, so the namespace option selects which instance to query,
+// and is required there.
+func GetDataFromVtysh(command string, options sdc.OptionMap) (string, error) {
+	namespaces, err := sdcfg.GetDbNonDefaultNamespaces()
+	if err != nil {
+		return "", err
+	}
+
+	vtysh := "vtysh"
+	namespace, ok := options["namespace"].String()
+	if len(namespaces) == 0 {
+		if ok && namespace != sdcfg.SONIC_DEFAULT_NAMESPACE {
+			return "", status.Errorf(codes.InvalidArgument, "namespace %v is not valid on a single-ASIC device", namespace)
+		}
+	} else {
+		if !ok {
+			return "", status.Errorf(codes.InvalidArgument, "option namespace is required on a multi-ASIC device, expected one of %v", namespaces)
+		}
+		found := false
+		for _, ns := range namespaces {
+			found = found || ns == namespace
+		}
+		if !found {
+			return "", status.Errorf(codes.InvalidArgument, "namespace %v is not valid, expected one of %v", namespace, namespaces)
+		}
+		// Namespaces are named asic<N>, and vtysh takes the ASIC number
+		vtysh += " -n " + strings.TrimPrefix(namespace, "asic")
+	}
+
+	return GetDataFromHostCommand(fmt.Sprintf("%s -c \"%s\"", vtysh, command))
+}
+
 func GetDataFromFile(fileName string) ([]byte, error) {
 	fileContent, err := sdc.ImplIoutilReadFile(fileName)
 	if err != nil {
@@ -197,3 +236,68 @@ func natsortInterfaces(interfaces []string) []string {
 	sort.Sort(natural.StringSlice(interfaces))
 	return interfaces
 }
+
+// Queue and priority group keys are <port>:<index>, where port may be a vendor alias
+func splitPortIndexKey(key string, aliasMap map[string]string) (string, string, bool) {
+	sep := strings.LastIndex(key, ":")
+	if sep < 0 {
+		return "", "", false
+	}
+	port, index := key[:sep], key[sep+1:]
+	if portName, ok := aliasMap[port]; ok {
+		port = portName
+	}
+	return port, index, true
+}
+
+// Counters grouped by two levels of keys, e.g. port and queue
+type counterSnapshot map[string]map[string]map[string]string
+
+// Counters missing from the old snapshot are treated as starting from zero
+func calculateDiffCounterSnapshot(oldSnapshot counterSnapshot, newSnapshot counterSnapshot) counterSnapshot {
+	diffSnapshot := make(counterSnapshot, len(newSnapshot))
+	for outer, groups := range newSnapshot {
+		diffSnapshot[outer] = make(map[string]map[string]string, len(groups))
+		for inner, counters := range groups {
+			diffCounters := make(map[string]string, len(counters))
+			for name, newValue := range counters {
+				oldValue, ok := oldSnapshot[outer][inner][name]
+				if !ok {
+					oldValue = "0"
+				}
+				diffCounters[name] = calculateDiffCounters(oldValue, newValue, defaultMissingCounterValue)
+			}
+			diffSnapshot[outer][inner] = diffCounters
+		}
+	}
+	return diffSnapshot
+}
+
+// Helper which returns a counter snapshot, or with the period option the difference between
+// snapshots taken period seconds apart
+func getCountersOverPeriod(options sdc.OptionMap, getSnapshot func() (counterSnapshot, error)) ([]byte, error) {
+	period, takeDiffSnapshot := options["period"].Int()
+	if period > maxShowCommandPeriod {
+		return nil, fmt.Errorf("period value must be <= %v", maxShowCommandPeriod)
+	}
+
+	oldSnapshot, err := getSnapshot()
+	if err != nil {
+		log.Errorf("Unable to get counter snapshot due to err: %v", err)
+		return nil, err
+	}
+
+	if !takeDiffSnapshot {
+		return json.Marshal(oldSnapshot)
+	}
+
+	time.Sleep(time.Duration(period) * time.Second)
+
+	newSnapshot, err := getSnapshot()
+	if err != nil {
+		log.Errorf("Unable to get new counter snapshot due to err: %v", err)
+		return nil, err
+	}
+
+	return json.Marshal(calculateDiffCounterSnapshot(oldSnapshot, newSnapshot))
+}
@@ -15,6 +15,10 @@ const (
 	showCmdOptionPeriodDesc        = "[period=INTEGER] Display statistics over a specified period (in seconds)"
 	showCmdOptionJsonDesc          = "[json=true] No-op since response is in json format"
 	showCmdOptionDpuDesc           = "[dpu=TEXT] Filter by DPU module name"
+	showCmdOptionVlanDesc          = "[vlan=INTEGER] Filter by VLAN ID"
+	showCmdOptionAsicNamespaceDesc = "[namespace=TEXT] ASIC namespace to query, required on multi-ASIC devices"
+	showCmdOptionVrfDesc           = "[vrf=TEXT] Show routes in a VRF, or all VRFs with vrf=all"
+	showCmdOptionPrefixDesc        = "[prefix=TEXT] Show routes for an address or prefix"
 )
 
 var (
@@ -83,4 +87,28 @@ var (
 		showCmdOptionDpuDesc,
 		sdc.StringValue,
 	)
+
+	showCmdOptionVlan = sdc.NewShowCmdOption(
+		"vlan",
+		showCmdOptionVlanDesc,
+		sdc.IntValue,
+	)
+
+	showCmdOptionAsicNamespace = sdc.NewShowCmdOption(
+		"namespace",
+		showCmdOptionAsicNamespaceDesc,
+		sdc.StringValue,
+	)
+
+	showCmdOptionVrf = sdc.NewShowCmdOption(
+		"vrf",
+		showCmdOptionVrfDesc,
+		sdc.StringValue,
+	)
+
+	showCmdOptionPrefix = sdc.NewShowCmdOption(
+		"prefix",
+		showCmdOptionPrefixDesc,
+		sdc.StringValue,
+	)
 )
@@ -85,6 +85,32 @@ func init() {
 		getWatermarkTelemetryInterval,
 		nil,
 	)
+	sdc.RegisterCliPath(
+		[]string{"SHOW", "queue", "counters"},
+		getQueueCounters,
+		nil,
+		showCmdOptionInterface,
+		showCmdOptionPeriod,
+	)
+	sdc.RegisterCliPath(
+		[]string{"SHOW", "queue", "watermark"},
+		getQueueWatermark,
+		nil,
+		showCmdOptionInterface,
+	)
+	sdc.RegisterCliPath(
+		[]string{"SHOW", "pfc", "counters"},
+		getPfcCounters,
+		nil,
+		showCmdOptionInterface,
+		showCmdOptionPeriod,
+	)
+	sdc.RegisterCliPath(
+		[]string{"SHOW", "priority-group", "watermark"},
+		getPriorityGroupWatermark,
+		nil,
+		showCmdOptionInterface,
+	)
 	sdc.RegisterCliPath(
 		[]string{"SHOW", "interface", "transceiver", "error-status"},
 		getTransceiverErrorStatus,
@@ -94,4 +120,56 @@ func init() {
 		sdc.UnimplementedOption(showCmdOptionFetchFromHW),
 		showCmdOptionInterface,
 	)
+	sdc.RegisterCliPath(
+		[]string{"SHOW", "interfaces", "status"},
+		getInterfacesStatus,
+		nil,
+		showCmdOptionInterface,
+	)
+	sdc.RegisterCliPath(
+		[]string{"SHOW", "vlan", "brief"},
+		getVlanBrief,
+		nil,
+	)
+	sdc.RegisterCliPath(
+		[]string{"SHOW", "lldp", "neighbors"},
+		getLldpNeighbors,
+		nil,
+		showCmdOptionInterface,
+	)
+	sdc.RegisterCliPath(
+		[]string{"SHOW", "mac"},
+		getMacTable,
+		nil,
+		showCmdOptionVlan,
+		showCmdOptionPort,
+	)
+	sdc.RegisterCliPath(
+		[]string{"SHOW", "ip", "bgp", "summary"},
+		getIPv4BGPSummary,
+		nil,
+		showCmdOptionAsicNamespace,
+	)
+	sdc.RegisterCliPath(
+		[]string{"SHOW", "ip", "bgp", "neighbors"},
+		getIPv4BGPNeighbors,
+		nil,
+		showCmdOptionAsicNamespace,
+	)
+	sdc.RegisterCliPath(
+		[]string{"SHOW", "ip", "route"},
+		getIPRoute,
+		nil,
+		showCmdOptionAsicNamespace,
+		showCmdOptionVrf,
+		showCmdOptionPrefix,
+	)
+	sdc.RegisterCliPath(
+		[]string{"SHOW", "ipv6", "route"},
+		getIPv6Route,
+		nil,
+		showCmdOptionAsicNamespace,
+		showCmdOptionVrf,
+		showCmdOptionPrefix,
+	)
 }
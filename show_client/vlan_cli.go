@@ -0,0 +1,100 @@
+package show_client
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+
+	log "github.com/golang/glog"
+	sdc "github.com/sonic-net/sonic-gnmi/sonic_data_client"
+)
+
+type vlanBrief struct {
+	VlanId      string   `json:"VLAN ID"`
+	IPAddresses []string `json:"IP Address"`
+	Ports       []string `json:"Ports"`
+	PortTagging []string `json:"Port Tagging"`
+	ProxyArp    string   `json:"Proxy ARP"`
+	DhcpHelpers []string `json:"DHCP Helper Address"`
+}
+
+func getVlanBrief(options sdc.OptionMap) ([]byte, error) {
+	queries := [][]string{
+		{"CONFIG_DB", "VLAN"},
+	}
+	vlans, err := GetMapFromQueries(queries)
+	if err != nil {
+		log.Errorf("Unable to pull data for queries %v, got err %v", queries, err)
+		return nil, err
+	}
+
+	queries = [][]string{
+		{"CONFIG_DB", "VLAN_MEMBER"},
+	}
+	vlanMembers, err := GetMapFromQueries(queries)
+	if err != nil {
+		log.Errorf("Unable to pull data for queries %v, got err %v", queries, err)
+		return nil, err
+	}
+
+	queries = [][]string{
+		{"CONFIG_DB", "VLAN_INTERFACE"},
+	}
+	vlanInterfaces, err := GetMapFromQueries(queries)
+	if err != nil {
+		log.Errorf("Unable to pull data for queries %v, got err %v", queries, err)
+		return nil, err
+	}
+
+	briefs := make(map[string]*vlanBrief, len(vlans))
+	for vlan := range vlans {
+		brief := &vlanBrief{
+			VlanId:      GetFieldValueString(vlans, vlan, strings.TrimPrefix(vlan, "Vlan"), "vlanid"),
+			IPAddresses: []string{},
+			Ports:       []string{},
+			PortTagging: []string{},
+			ProxyArp:    GetFieldValueString(vlanInterfaces, vlan, "disabled", "proxy_arp"),
+			DhcpHelpers: []string{},
+		}
+		if servers := GetFieldValueString(vlans, vlan, "", "dhcp_servers@"); servers != "" {
+			brief.DhcpHelpers = strings.Split(servers, ",")
+		}
+		briefs[vlan] = brief
+	}
+
+	// VLAN_INTERFACE is keyed by Vlan|prefix for each address, and by Vlan for interface settings
+	for key := range vlanInterfaces {
+		parts := strings.SplitN(key, "|", 2)
+		if brief, ok := briefs[parts[0]]; ok && len(parts) == 2 {
+			brief.IPAddresses = append(brief.IPAddresses, parts[1])
+		}
+	}
+	for _, brief := range briefs {
+		sort.Strings(brief.IPAddresses)
+	}
+
+	var members []string
+	for key := range vlanMembers {
+		members = append(members, key)
+	}
+	for _, key := range natsortInterfaces(members) {
+		parts := strings.SplitN(key, "|", 2)
+		if brief, ok := briefs[parts[0]]; ok && len(parts) == 2 {
+			brief.Ports = append(brief.Ports, parts[1])
+			brief.PortTagging = append(brief.PortTagging, GetFieldValueString(vlanMembers, key, defaultMissingCounterValue, "tagging_mode"))
+		}
+	}
+
+	result := make([]*vlanBrief, 0, len(briefs))
+	for _, brief := range briefs {
+		result = append(result, brief)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		left, _ := strconv.Atoi(result[i].VlanId)
+		right, _ := strconv.Atoi(result[j].VlanId)
+		return left < right
+	})
+
+	return json.Marshal(result)
+}
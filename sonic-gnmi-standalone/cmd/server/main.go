@@ -42,6 +42,7 @@ import (
 	"context"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 
 	"github.com/golang/glog"
@@ -72,7 +73,8 @@ func main() {
 		glog.V(1).Infof("Using SONiC ConfigDB certificates: redis=%s, db=%d, table=%s",
 			config.Global.RedisAddr, config.Global.RedisDB, config.Global.ConfigTableName)
 		builder = builder.WithSONiCCertificates(config.Global.RedisAddr, config.Global.RedisDB).
-			WithConfigTableName(config.Global.ConfigTableName)
+			WithConfigTableName(config.Global.ConfigTableName).
+			WithClientIdentitySources(strings.Split(config.Global.ClientIdentity, ","))
 	}
 
 	// Configure TLS based on command-line flags
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sonic-net/sonic-gnmi/sonic-gnmi-standalone/pkg/workflow"
+	"github.com/sonic-net/sonic-gnmi/sonic-gnmi-standalone/pkg/workflow/fleet"
+)
+
+var fleetCmd = &cobra.Command{
+	Use:   "fleet [workflow.yaml]",
+	Short: "Apply a workflow to every device in an inventory",
+	Long: `Apply a workflow to every device listed in a YAML or CSV inventory file.
+A canary batch runs first; if any canary fails, the rollout stops. The remaining
+devices run in parallel until done or until failed devices exceed the failure
+budget. Each device gets its own log and state file, and a JSON summary report
+is written at the end.`,
+	Args: cobra.ExactArgs(1),
+	Example: `  # Roll out to an inventory, 10 devices at a time after 2 canaries
+  upgrade-agent fleet workflow.yaml --inventory devices.yaml --concurrency 10 --canary 2
+
+  # Halt once more than 5% of the fleet has failed
+  upgrade-agent fleet workflow.yaml --inventory devices.csv --max-failure-percent 5
+
+  # Example inventory (devices.yaml):
+  # defaults:
+  #   tls: true
+  #   username: admin
+  #   passwordEnv: SONIC_PASSWORD
+  # devices:
+  #   - name: leaf1
+  #     address: 10.0.0.1:50055
+  #   - name: leaf2
+  #     address: 10.0.0.2:50055
+  #     variables:
+  #       version: "202505"`,
+	RunE:         runFleet,
+	SilenceUsage: true, // Don't print usage on errors
+}
+
+const (
+	// DefaultFleetLogDir is the default directory for per-device logs and state.
+	DefaultFleetLogDir = "upgrade-agent-logs"
+
+	// DefaultReportFileName is the summary report file name inside the log directory.
+	DefaultReportFileName = "report.json"
+)
+
+// Fleet command flags.
+var (
+	inventoryFile     string
+	concurrency       int
+	canaryCount       int
+	maxFailurePercent float64
+	logDir            string
+	reportFile        string
+)
+
+func init() {
+	fleetCmd.Flags().StringVar(&inventoryFile, "inventory", "", "Inventory file (YAML or CSV)")
+	fleetCmd.Flags().IntVar(&concurrency, "concurrency", fleet.DefaultConcurrency, "Devices upgraded in parallel")
+	fleetCmd.Flags().IntVar(&canaryCount, "canary", fleet.DefaultCanaryCount, "Devices upgraded first; any failure halts the rollout")
+	fleetCmd.Flags().Float64Var(&maxFailurePercent, "max-failure-percent", fleet.DefaultMaxFailurePercent,
+		"Halt the rollout once failed devices exceed this percentage of the fleet")
+	fleetCmd.Flags().StringVar(&logDir, "log-dir", DefaultFleetLogDir, "Directory for per-device logs and state files")
+	fleetCmd.Flags().StringVar(&reportFile, "report", "",
+		"Summary report file (default: <log-dir>/"+DefaultReportFileName+")")
+	fleetCmd.Flags().BoolVar(&resume, "resume", false, "Resume each device from its state file in the log directory")
+	fleetCmd.MarkFlagRequired("inventory")
+}
+
+// runFleet loads a workflow and an inventory and rolls the workflow out to every device.
+func runFleet(cmd *cobra.Command, args []string) error {
+	workflowFile := args[0]
+
+	wf, err := workflow.LoadWorkflowFromFile(workflowFile)
+	if err != nil {
+		return fmt.Errorf("failed to load workflow from '%s': %w", workflowFile, err)
+	}
+
+	inventory, err := fleet.LoadInventory(inventoryFile)
+	if err != nil {
+		return fmt.Errorf("failed to load inventory: %w", err)
+	}
+
+	vars, err := parseSetVars(setVars)
+	if err != nil {
+		return err
+	}
+
+	if reportFile == "" {
+		reportFile = filepath.Join(logDir, DefaultReportFileName)
+	}
+
+	runner := fleet.NewRunner(newStepRegistry(), fleet.Options{
+		Concurrency:       concurrency,
+		CanaryCount:       canaryCount,
+		MaxFailurePercent: maxFailurePercent,
+		LogDir:            logDir,
+		RequestTimeout:    timeout,
+		Resume:            resume,
+		DefaultTLS:        tls,
+		Variables:         vars,
+	})
+
+	fmt.Printf("Executing workflow from %s\n", workflowFile)
+	fmt.Printf("  Inventory: %s (%d devices)\n", inventoryFile, len(inventory.Devices))
+	fmt.Printf("  Request timeout: %v\n", timeout)
+	fmt.Printf("  Logs: %s\n", logDir)
+	fmt.Println()
+
+	// Stop on SIGINT/SIGTERM, leaving the device runs interrupted so that they can be resumed
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	report, err := runner.Run(ctx, wf, inventory)
+	if err != nil {
+		return fmt.Errorf("fleet rollout failed: %w", err)
+	}
+
+	if err := report.Save(reportFile); err != nil {
+		return err
+	}
+	fmt.Printf("Report written to %s\n", reportFile)
+
+	if report.Failed > 0 || report.Halted {
+		return fmt.Errorf("fleet rollout incomplete: %d succeeded, %d failed, %d skipped",
+			report.Succeeded, report.Failed, report.Skipped)
+	}
+	return nil
+}
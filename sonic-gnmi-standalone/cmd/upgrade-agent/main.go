@@ -6,16 +6,26 @@
 // Example usage:
 //
 //	upgrade-agent apply workflow.yaml --server device:50055
+//	upgrade-agent fleet workflow.yaml --inventory devices.yaml --concurrency 10
+//	upgrade-agent plan workflow.yaml --set version=SONiC-OS-202505.01
 //
-// The tool currently supports download steps via gNOI System.SetPackage RPC with
-// features like MD5 validation, multi-step workflows, and both secure (TLS) and
-// insecure connections.
+// The tool supports these step types:
+//   - download: gNOI System.SetPackage with MD5 validation
+//   - reboot: gNOI System.Reboot, then polls System.RebootStatus
+//   - wait-for-reachable: retries a gRPC connection until the device answers
+//   - verify-os: gNOI OS.Verify against an expected version
+//   - health-gate: gNMI Get results compared against thresholds
+//
+// Both secure (TLS) and insecure connections are supported.
 package main
 
 import (
 	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -44,6 +54,12 @@ Server connection is specified via command-line flags.`,
   # With TLS enabled
   upgrade-agent apply workflow.yaml --server device:50055 --tls
   
+  # Continue an interrupted run from its last completed step
+  upgrade-agent apply workflow.yaml --server device:50055 --resume
+  
+  # Set template variables used as {{ .version }} in step params
+  upgrade-agent apply workflow.yaml --server device:50055 --set version=202505
+  
   # Example UpgradeWorkflow:
   # apiVersion: sonic.net/v1
   # kind: UpgradeWorkflow
@@ -58,18 +74,30 @@ Server connection is specified via command-line flags.`,
   #         filename: "/tmp/sonic.bin"
   #         md5: "d41d8cd98f00b204e9800998ecf8427e"
   #         version: "1.0.0"
-  #         activate: false`,
+  #         activate: false
+  #       rollback:                  # optional, runs if this or a later step fails
+  #         type: download
+  #         params: {...}
+  #   onFailure:                     # optional, runs after rollback
+  #     - name: notify
+  #       type: download
+  #       params: {...}`,
 	RunE:         runApply,
 	SilenceUsage: true, // Don't print usage on errors
 }
 
 const (
-	// DefaultTimeout is the default timeout for upgrade operations.
+	// DefaultTimeout is the default timeout of each RPC issued by a step.
 	// 5 minutes allows time for large package downloads over slow connections.
+	// Steps that wait for the device, like wait-for-reachable, use their own timeouts.
 	DefaultTimeout = 5 * time.Minute
 
 	// DefaultTLSEnabled is the default TLS setting for gRPC connections.
 	DefaultTLSEnabled = false
+
+	// DefaultStateFileSuffix is appended to the workflow file path to form the
+	// default state file used for --resume.
+	DefaultStateFileSuffix = ".state.json"
 )
 
 // Global flags.
@@ -77,17 +105,33 @@ var (
 	timeout time.Duration
 	server  string
 	tls     bool
+	setVars []string
+)
+
+// Apply command flags.
+var (
+	resume    bool
+	stateFile string
 )
 
 func init() {
 	// Global flags (shared by all commands)
-	rootCmd.PersistentFlags().DurationVar(&timeout, "timeout", DefaultTimeout, "Request timeout")
-	rootCmd.PersistentFlags().StringVar(&server, "server", "", "Server address (host:port)")
+	rootCmd.PersistentFlags().DurationVar(&timeout, "timeout", DefaultTimeout, "Timeout of each request made by a step")
 	rootCmd.PersistentFlags().BoolVar(&tls, "tls", false, "Enable TLS")
-	rootCmd.MarkPersistentFlagRequired("server")
+	rootCmd.PersistentFlags().StringArrayVar(&setVars, "set", nil,
+		"Set a workflow template variable (key=value, repeatable)")
+
+	// Apply command flags
+	applyCmd.Flags().StringVar(&server, "server", "", "Server address (host:port)")
+	applyCmd.MarkFlagRequired("server")
+	applyCmd.Flags().BoolVar(&resume, "resume", false, "Resume from the last completed step of a previous run")
+	applyCmd.Flags().StringVar(&stateFile, "state-file", "",
+		"Path of the execution state file (default: <workflow.yaml>"+DefaultStateFileSuffix+")")
 
 	// Add commands to root
 	rootCmd.AddCommand(applyCmd)
+	rootCmd.AddCommand(fleetCmd)
+	rootCmd.AddCommand(planCmd)
 }
 
 func main() {
@@ -106,16 +150,23 @@ func runApply(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to load workflow from '%s': %w", workflowFile, err)
 	}
 
-	// Create step registry and register available step types
-	registry := workflow.NewRegistry()
-	registry.Register(steps.DownloadStepType, steps.NewDownloadStep)
+	vars, err := parseSetVars(setVars)
+	if err != nil {
+		return err
+	}
+
+	// Create workflow execution engine that records progress for --resume
+	if stateFile == "" {
+		stateFile = workflowFile + DefaultStateFileSuffix
+	}
+	engine := workflow.NewEngine(newStepRegistry()).WithStateFile(stateFile).WithVariables(vars)
 
-	// Create workflow execution engine
-	engine := workflow.NewEngine(registry)
+	// Stop on SIGINT/SIGTERM, leaving the run interrupted so that it can be resumed
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	// Create context with timeout for all operations
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
+	// Bound each request, not the whole workflow, since steps may wait for longer
+	ctx = workflow.WithRequestTimeout(ctx, timeout)
 
 	// Prepare client configuration for steps
 	clientConfig := map[string]interface{}{
@@ -126,13 +177,44 @@ func runApply(cmd *cobra.Command, args []string) error {
 	// Display execution details
 	fmt.Printf("Executing workflow from %s\n", workflowFile)
 	fmt.Printf("  Server: %s (TLS: %v)\n", server, tls)
-	fmt.Printf("  Timeout: %v\n", timeout)
+	fmt.Printf("  Request timeout: %v\n", timeout)
+	fmt.Printf("  State file: %s\n", stateFile)
 	fmt.Println()
 
-	// Execute the workflow
+	// Execute the workflow, or continue it from the saved state
+	if resume {
+		if err := engine.Resume(ctx, wf, clientConfig); err != nil {
+			return fmt.Errorf("workflow execution failed: %w", err)
+		}
+		return nil
+	}
 	if err := engine.Execute(ctx, wf, clientConfig); err != nil {
 		return fmt.Errorf("workflow execution failed: %w", err)
 	}
 
 	return nil
 }
+
+// newStepRegistry creates a step registry with all available step types registered.
+func newStepRegistry() *workflow.DefaultStepRegistry {
+	registry := workflow.NewRegistry()
+	registry.Register(steps.DownloadStepType, steps.NewDownloadStep)
+	registry.Register(steps.RebootStepType, steps.NewRebootStep)
+	registry.Register(steps.WaitForReachableStepType, steps.NewWaitForReachableStep)
+	registry.Register(steps.VerifyOSStepType, steps.NewVerifyOSStep)
+	registry.Register(steps.HealthGateStepType, steps.NewHealthGateStep)
+	return registry
+}
+
+// parseSetVars parses repeated --set key=value flags into a variable map.
+func parseSetVars(values []string) (map[string]string, error) {
+	vars := make(map[string]string, len(values))
+	for _, value := range values {
+		kv := strings.SplitN(value, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("invalid --set value '%s', expected key=value", value)
+		}
+		vars[kv[0]] = kv[1]
+	}
+	return vars, nil
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sonic-net/sonic-gnmi/sonic-gnmi-standalone/pkg/workflow"
+	"github.com/sonic-net/sonic-gnmi/sonic-gnmi-standalone/pkg/workflow/fleet"
+)
+
+var planCmd = &cobra.Command{
+	Use:   "plan [workflow.yaml]",
+	Short: "Validate a workflow and show the actions it would perform",
+	Long: `Resolve the templates and when conditions of a workflow, validate every step
+and print the exact actions that apply would perform. No connection is made to
+any device.
+
+Variables come from the workflow's spec.variables, then the inventory entry of the
+device being planned, then --set flags. Environment variables are available in
+templates as {{ env "NAME" }}.`,
+	Args: cobra.ExactArgs(1),
+	Example: `  # Plan with variables from the command line
+  upgrade-agent plan workflow.yaml --set version=SONiC-OS-202505.01
+
+  # Plan every device of an inventory, or just one of them
+  upgrade-agent plan workflow.yaml --inventory devices.yaml
+  upgrade-agent plan workflow.yaml --inventory devices.yaml --device leaf1
+
+  # Example templated step:
+  # - name: verify-image
+  #   type: verify-os
+  #   when: 'ne .skip_verify "true"'
+  #   params:
+  #     version: "{{ .version }}"`,
+	RunE:         runPlan,
+	SilenceUsage: true, // Don't print usage on errors
+}
+
+// Plan command flags.
+var (
+	planInventoryFile string
+	planDevice        string
+)
+
+func init() {
+	planCmd.Flags().StringVar(&planInventoryFile, "inventory", "", "Inventory file (YAML or CSV) providing device variables")
+	planCmd.Flags().StringVar(&planDevice, "device", "", "Only plan this inventory device")
+}
+
+// runPlan loads a workflow and prints its resolved plan for each target.
+func runPlan(cmd *cobra.Command, args []string) error {
+	workflowFile := args[0]
+
+	wf, err := workflow.LoadWorkflowFromFile(workflowFile)
+	if err != nil {
+		return fmt.Errorf("failed to load workflow from '%s': %w", workflowFile, err)
+	}
+
+	vars, err := parseSetVars(setVars)
+	if err != nil {
+		return err
+	}
+
+	registry := newStepRegistry()
+	if planInventoryFile == "" {
+		if planDevice != "" {
+			return fmt.Errorf("--device requires --inventory")
+		}
+		return printPlan(workflow.NewEngine(registry).WithVariables(vars), wf)
+	}
+
+	inventory, err := fleet.LoadInventory(planInventoryFile)
+	if err != nil {
+		return fmt.Errorf("failed to load inventory: %w", err)
+	}
+
+	planned, failed := 0, 0
+	for i := range inventory.Devices {
+		device := &inventory.Devices[i]
+		if planDevice != "" && device.Name != planDevice {
+			continue
+		}
+		planned++
+
+		fmt.Printf("=== Device %s (%s) ===\n", device.Name, device.Address)
+		engine := workflow.NewEngine(registry).WithVariables(device.TemplateVariables(vars))
+		if err := printPlan(engine, wf); err != nil {
+			fmt.Printf("%v\n\n", err)
+			failed++
+		}
+	}
+
+	if planned == 0 {
+		return fmt.Errorf("device '%s' not found in inventory", planDevice)
+	}
+	if failed > 0 {
+		return fmt.Errorf("plan failed for %d of %d devices", failed, planned)
+	}
+	return nil
+}
+
+// printPlan plans the workflow with engine and prints the result.
+func printPlan(engine *workflow.Engine, wf *workflow.Workflow) error {
+	plan, err := engine.Plan(wf)
+	plan.Print(os.Stdout)
+	if err != nil {
+		return fmt.Errorf("workflow %s is invalid: %w", wf.Metadata.Name, err)
+	}
+	fmt.Println("Plan is valid.")
+	return nil
+}
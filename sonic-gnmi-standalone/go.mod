@@ -1,16 +1,19 @@
 module github.com/sonic-net/sonic-gnmi/sonic-gnmi-standalone
 
-go 1.19
+go 1.21
 
 require (
 	github.com/alicebob/miniredis/v2 v2.35.0
 	github.com/fsnotify/fsnotify v1.9.0
-	github.com/golang/glog v1.0.0
-	github.com/openconfig/gnoi v0.2.0
+	github.com/golang/glog v1.2.0
+	github.com/openconfig/gnmi v0.0.0-20200617225440-d2b4e6a45802
+	github.com/openconfig/gnoi v0.3.0
 	github.com/redis/go-redis/v9 v9.12.1
+	github.com/sonic-net/sonic-gnmi v0.0.0
+	github.com/sonic-net/sonic-gnmi/pkg/certidentity v0.0.0
 	github.com/spf13/cobra v1.9.1
 	github.com/stretchr/testify v1.10.0
-	google.golang.org/grpc v1.50.1
+	google.golang.org/grpc v1.64.1
 	gopkg.in/yaml.v3 v3.0.1
 )
 
@@ -18,14 +21,18 @@ require (
 	github.com/cespare/xxhash/v2 v2.3.0 // indirect
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
-	github.com/golang/protobuf v1.5.2 // indirect
+	github.com/golang/protobuf v1.5.4 // indirect
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/spf13/pflag v1.0.6 // indirect
 	github.com/yuin/gopher-lua v1.1.1 // indirect
-	golang.org/x/net v0.7.0 // indirect
-	golang.org/x/sys v0.13.0 // indirect
-	golang.org/x/text v0.7.0 // indirect
-	google.golang.org/genproto v0.0.0-20221118155620-16455021b5e6 // indirect
-	google.golang.org/protobuf v1.28.1 // indirect
+	golang.org/x/net v0.26.0 // indirect
+	golang.org/x/sys v0.26.0 // indirect
+	golang.org/x/text v0.16.0 // indirect
+	google.golang.org/genproto v0.0.0-20230410155749-daa745c078e1 // indirect
+	google.golang.org/protobuf v1.34.1 // indirect
 )
+
+replace github.com/sonic-net/sonic-gnmi => ../
+
+replace github.com/sonic-net/sonic-gnmi/pkg/certidentity => ../pkg/certidentity
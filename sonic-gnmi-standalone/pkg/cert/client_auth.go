@@ -10,15 +10,18 @@ import (
 
 	"github.com/golang/glog"
 	"github.com/redis/go-redis/v9"
+	"github.com/sonic-net/sonic-gnmi/pkg/certidentity"
 )
 
 // ClientAuthManager manages client certificate authorization using ConfigDB.
 type ClientAuthManager struct {
-	redisAddr     string
-	redisDB       int
-	configTable   string
-	clientCNRoles map[string]string // CN -> roles mapping
-	mu            sync.RWMutex
+	redisAddr       string
+	redisDB         int
+	configTable     string
+	identitySources []string              // Certificate parts identities are taken from, in order of precedence
+	clientCNRoles   map[string]string     // Table key -> roles mapping
+	entries         []*certidentity.Entry // Table entries in order of precedence
+	mu              sync.RWMutex
 }
 
 // NewClientAuthManager creates a new client certificate authorization manager.
@@ -27,13 +30,26 @@ func NewClientAuthManager(redisAddr string, redisDB int, configTable string) *Cl
 		configTable = "GNMI_CLIENT_CERT"
 	}
 	return &ClientAuthManager{
-		redisAddr:     redisAddr,
-		redisDB:       redisDB,
-		configTable:   configTable,
-		clientCNRoles: make(map[string]string),
+		redisAddr:       redisAddr,
+		redisDB:         redisDB,
+		configTable:     configTable,
+		identitySources: []string{certidentity.SourceCN},
+		clientCNRoles:   make(map[string]string),
 	}
 }
 
+// SetIdentitySources sets the certificate parts identities are taken from, e.g. "uri", "cn".
+// When several are set, the first identity matching a table entry is used.
+func (cam *ClientAuthManager) SetIdentitySources(sources []string) error {
+	if err := certidentity.ValidateSources(sources); err != nil {
+		return err
+	}
+	cam.mu.Lock()
+	defer cam.mu.Unlock()
+	cam.identitySources = sources
+	return nil
+}
+
 // LoadClientCertConfig loads client certificate authorization from ConfigDB.
 func (cam *ClientAuthManager) LoadClientCertConfig() error {
 	glog.V(1).Infof("Loading client certificate config from table: %s", cam.configTable)
@@ -57,6 +73,7 @@ func (cam *ClientAuthManager) LoadClientCertConfig() error {
 
 	// Clear existing entries
 	cam.clientCNRoles = make(map[string]string)
+	cam.entries = nil
 
 	for iter.Next(ctx) {
 		key := iter.Val()
@@ -67,16 +84,28 @@ func (cam *ClientAuthManager) LoadClientCertConfig() error {
 		}
 		cn := parts[1]
 
-		// Get the role for this CN
-		role, err := client.HGet(ctx, key, "role@").Result()
+		// Get the role for this CN, and which identities the CN matches
+		fields, err := client.HGetAll(ctx, key).Result()
 		if err != nil {
 			glog.V(2).Infof("Failed to get role for CN %s: %v", cn, err)
 			continue
 		}
+		role, ok := fields["role@"]
+		if !ok {
+			glog.V(2).Infof("Failed to get role for CN %s: no role@ field", cn)
+			continue
+		}
+		entry, err := certidentity.NewEntry(cn, fields["match"], fields["source"], []string{role})
+		if err != nil {
+			glog.Warningf("Skipping client CN %s: %v", cn, err)
+			continue
+		}
 
 		cam.clientCNRoles[cn] = role
-		glog.V(2).Infof("Loaded client CN: %s with role: %s", cn, role)
+		cam.entries = append(cam.entries, entry)
+		glog.V(2).Infof("Loaded client CN: %s with role: %s, match: %s, source: %s", cn, role, entry.Match, entry.Source)
 	}
+	certidentity.Sort(cam.entries)
 
 	if err := iter.Err(); err != nil {
 		return fmt.Errorf("failed to scan client certificates: %w", err)
@@ -98,23 +127,31 @@ func (cam *ClientAuthManager) VerifyClientCertificate(rawCerts [][]byte, verifie
 		return fmt.Errorf("failed to parse client certificate: %w", err)
 	}
 
-	// Get the common name from the certificate
-	cn := cert.Subject.CommonName
-	if cn == "" {
-		return fmt.Errorf("client certificate has no common name")
-	}
-
-	// Check if the CN is authorized
 	cam.mu.RLock()
-	role, authorized := cam.clientCNRoles[cn]
-	cam.mu.RUnlock()
+	defer cam.mu.RUnlock()
 
-	if !authorized {
-		glog.V(1).Infof("Unauthorized client CN: %s", cn)
-		return fmt.Errorf("client CN %s is not authorized", cn)
+	// Get the identities from the certificate
+	identities := certidentity.FromCertificate(cert, cam.identitySources)
+	if len(identities) == 0 {
+		names := make([]string, 0, len(cam.identitySources))
+		for _, source := range cam.identitySources {
+			names = append(names, certidentity.SourceName(source))
+		}
+		return fmt.Errorf("client certificate has no %s", strings.Join(names, " or "))
 	}
 
-	glog.V(2).Infof("Authorized client CN: %s with role: %s", cn, role)
+	// Check if an identity is authorized
+	identity, entry := certidentity.Match(identities, cam.entries)
+	if entry == nil {
+		desc := make([]string, 0, len(identities))
+		for _, i := range identities {
+			desc = append(desc, i.String())
+		}
+		glog.V(1).Infof("Unauthorized client %s", strings.Join(desc, ", "))
+		return fmt.Errorf("client %s is not authorized", strings.Join(desc, ", "))
+	}
+
+	glog.V(2).Infof("Authorized client %s by %s entry %s with role: %s", identity, entry.Match, entry.Key, entry.Roles[0])
 	return nil
 }
 
@@ -132,7 +169,26 @@ func (cam *ClientAuthManager) GetAuthorizedCNs() []string {
 
 // AddClientCN adds a client CN with its role (for testing).
 func (cam *ClientAuthManager) AddClientCN(cn, role string) {
+	cam.AddClientEntry(cn, certidentity.MatchExact, certidentity.SourceCN, role)
+}
+
+// AddClientEntry adds a table entry whose key matches identities from source by match (for testing).
+func (cam *ClientAuthManager) AddClientEntry(key, match, source, role string) error {
+	entry, err := certidentity.NewEntry(key, match, source, []string{role})
+	if err != nil {
+		return err
+	}
+
 	cam.mu.Lock()
 	defer cam.mu.Unlock()
-	cam.clientCNRoles[cn] = role
+	cam.clientCNRoles[key] = role
+	entries := []*certidentity.Entry{entry}
+	for _, e := range cam.entries {
+		if e.Key != key {
+			entries = append(entries, e)
+		}
+	}
+	certidentity.Sort(entries)
+	cam.entries = entries
+	return nil
 }
@@ -9,11 +9,13 @@ import (
 	"fmt"
 	"math/big"
 	"net"
+	"net/url"
 	"testing"
 	"time"
 
 	"github.com/alicebob/miniredis/v2"
 	"github.com/redis/go-redis/v9"
+	"github.com/sonic-net/sonic-gnmi/pkg/certidentity"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -277,6 +279,107 @@ func TestAddClientCN(t *testing.T) {
 	})
 }
 
+func TestVerifyClientCertificateIdentityMatching(t *testing.T) {
+	// Start miniredis for testing
+	mr := miniredis.RunT(t)
+	defer mr.Close()
+
+	client := redis.NewClient(&redis.Options{
+		Addr: mr.Addr(),
+		DB:   4,
+	})
+	defer client.Close()
+
+	entries := map[string]map[string]string{
+		"GNMI_CLIENT_CERT|host1.example.com":                     {"role@": "gnmi_readwrite"},
+		"GNMI_CLIENT_CERT|rack1-":                                {"role@": "gnmi_readonly", "match": "prefix"},
+		"GNMI_CLIENT_CERT|spiffe://example.com/ns/sonic/sa/*":    {"role@": "gnmi_readwrite", "match": "glob", "source": "uri"},
+		"GNMI_CLIENT_CERT|ops-[0-9]+@example\\.com":              {"role@": "gnmi_readonly", "match": "regex", "source": "email"},
+		"GNMI_CLIENT_CERT|broken(":                               {"role@": "gnmi_readonly", "match": "regex"},
+		"GNMI_CLIENT_CERT|spiffe://example.com/ns/other/sa/gnmi": {"role@": "gnmi_readonly", "match": "unknown"},
+	}
+	for key, fields := range entries {
+		for field, value := range fields {
+			require.NoError(t, client.HSet(ctx, key, field, value).Err())
+		}
+	}
+
+	manager := NewClientAuthManager(mr.Addr(), 4, "GNMI_CLIENT_CERT")
+	require.NoError(t, manager.SetIdentitySources([]string{certidentity.SourceURI, certidentity.SourceEmail, certidentity.SourceCN}))
+	require.NoError(t, manager.LoadClientCertConfig())
+	assert.Len(t, manager.GetAuthorizedCNs(), 4, "entries with invalid patterns are skipped")
+
+	spiffe, _ := url.Parse("spiffe://example.com/ns/sonic/sa/gnmi-client")
+	other, _ := url.Parse("spiffe://example.com/ns/other/sa/gnmi")
+
+	tests := []struct {
+		name       string
+		cn         string
+		uris       []*url.URL
+		emails     []string
+		authorized bool
+	}{
+		{"exact_cn", "host1.example.com", nil, nil, true},
+		{"prefix_cn", "rack1-host7", nil, nil, true},
+		{"glob_uri_san", "rotated-cn-42", []*url.URL{spiffe}, nil, true},
+		{"regex_email_san", "", nil, []string{"ops-12@example.com"}, true},
+		{"regex_must_match_whole_identity", "", nil, []string{"ops-12@example.com.evil"}, false},
+		{"cn_does_not_match_uri_entry", "spiffe://example.com/ns/sonic/sa/gnmi-client", nil, nil, false},
+		{"entry_with_invalid_match_type_skipped", "", []*url.URL{other}, nil, false},
+		{"no_identity_matches", "host2.example.com", []*url.URL{other}, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cert := generateTestCertificateWithSANs(t, tt.cn, tt.uris, tt.emails)
+			err := manager.VerifyClientCertificate([][]byte{cert.Raw}, nil)
+			if tt.authorized {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), "is not authorized")
+			}
+		})
+	}
+
+	t.Run("no_identity_in_configured_sources", func(t *testing.T) {
+		require.NoError(t, manager.SetIdentitySources([]string{certidentity.SourceURI}))
+		cert := generateTestCertificate(t, "host1.example.com")
+		err := manager.VerifyClientCertificate([][]byte{cert.Raw}, nil)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "client certificate has no URI SAN")
+	})
+
+	t.Run("invalid_identity_source", func(t *testing.T) {
+		assert.Error(t, manager.SetIdentitySources([]string{"serial"}))
+		assert.Error(t, manager.SetIdentitySources(nil))
+	})
+}
+
+// generateTestCertificateWithSANs creates a test X.509 certificate with specified CN, URI and email SANs.
+func generateTestCertificateWithSANs(t *testing.T, commonName string, uris []*url.URL, emails []string) *x509.Certificate {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	template := x509.Certificate{
+		SerialNumber:   big.NewInt(1),
+		Subject:        pkix.Name{CommonName: commonName},
+		NotBefore:      time.Now(),
+		NotAfter:       time.Now().Add(365 * 24 * time.Hour),
+		KeyUsage:       x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:    []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		URIs:           uris,
+		EmailAddresses: emails,
+	}
+
+	certBytes, err := x509.CreateCertificate(rand.Reader, &template, &template, &privateKey.PublicKey, privateKey)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(certBytes)
+	require.NoError(t, err)
+
+	return cert
+}
+
 // generateTestCertificate creates a test X.509 certificate with specified CN.
 func generateTestCertificate(t *testing.T, commonName string) *x509.Certificate {
 	// Generate private key
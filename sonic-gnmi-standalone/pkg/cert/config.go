@@ -4,6 +4,8 @@ import (
 	"crypto/tls"
 	"fmt"
 	"time"
+
+	"github.com/sonic-net/sonic-gnmi/pkg/certidentity"
 )
 
 // CertConfig holds configuration for certificate management and verification.
@@ -31,6 +33,7 @@ type CertConfig struct {
 	RedisAddr          string        // Redis server address (default: "localhost:6379")
 	RedisDB            int           // Redis database number for ConfigDB (default: 4)
 	ConfigTableName    string        // ConfigDB table name for client certs (default: "GNMI_CLIENT_CERT")
+	IdentitySources    []string      // Client certificate parts identities are taken from (default: "cn")
 	SONiCConfigTimeout time.Duration // Timeout for SONiC config loading (default: 30s)
 }
 
@@ -72,12 +75,19 @@ func NewDefaultConfig() *CertConfig {
 		RedisAddr:          "localhost:6379",
 		RedisDB:            4, // ConfigDB is database 4 in SONiC
 		ConfigTableName:    "GNMI_CLIENT_CERT",
+		IdentitySources:    []string{certidentity.SourceCN},
 		SONiCConfigTimeout: 30 * time.Second,
 	}
 }
 
 // Validate checks the certificate configuration for consistency and completeness.
 func (c *CertConfig) Validate() error {
+	if len(c.IdentitySources) > 0 {
+		if err := certidentity.ValidateSources(c.IdentitySources); err != nil {
+			return err
+		}
+	}
+
 	if c.UseSONiCConfig {
 		// SONiC config mode - no file paths needed
 		return nil
@@ -81,6 +81,11 @@ func NewCertificateManager(config *CertConfig) CertificateManager {
 			config.RedisDB,
 			config.ConfigTableName,
 		)
+		if len(config.IdentitySources) > 0 {
+			if err := cm.clientAuthMgr.SetIdentitySources(config.IdentitySources); err != nil {
+				glog.Errorf("Invalid client certificate identity sources, using CN: %v", err)
+			}
+		}
 	}
 
 	return cm
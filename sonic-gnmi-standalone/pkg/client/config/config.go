@@ -2,8 +2,16 @@
 package config
 
 import (
+	"context"
 	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
 	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
 )
 
 // Config contains basic configuration for gRPC clients.
@@ -19,6 +27,11 @@ type Config struct {
 
 	// Timeout for RPC requests (important for long SetPackage operations)
 	Timeout time.Duration
+
+	// Username and Password are sent as per-RPC metadata for password
+	// authentication (optional)
+	Username string
+	Password string
 }
 
 // DefaultConfig returns a Config with basic defaults.
@@ -35,3 +48,71 @@ func New(address string) *Config {
 	config.Address = address
 	return config
 }
+
+// DialOptions returns the gRPC dial options matching this configuration.
+func (c *Config) DialOptions() []grpc.DialOption {
+	var opts []grpc.DialOption
+	if c.TLS {
+		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(c.TLSConfig)))
+	} else {
+		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	}
+
+	if c.Username != "" {
+		opts = append(opts, grpc.WithPerRPCCredentials(&passwordCredentials{
+			username:   c.Username,
+			password:   c.Password,
+			requireTLS: c.TLS,
+		}))
+	}
+	return opts
+}
+
+// LoadTLSConfig builds a client TLS configuration from PEM files.
+// caFile verifies the server certificate; certFile and keyFile provide a client
+// certificate for mutual TLS. Any of them may be empty.
+func LoadTLSConfig(caFile, certFile, keyFile string) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if caFile != "" {
+		caPEM, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA certificate: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no valid certificates found in %s", caFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// passwordCredentials attaches username and password metadata to every RPC.
+type passwordCredentials struct {
+	username   string
+	password   string
+	requireTLS bool
+}
+
+// GetRequestMetadata implements credentials.PerRPCCredentials.
+func (p *passwordCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{
+		"username": p.username,
+		"password": p.password,
+	}, nil
+}
+
+// RequireTransportSecurity implements credentials.PerRPCCredentials.
+func (p *passwordCredentials) RequireTransportSecurity() bool {
+	return p.requireTLS
+}
@@ -0,0 +1,219 @@
+// Package gnmi provides a gNMI client.
+package gnmi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"google.golang.org/grpc"
+
+	"github.com/sonic-net/sonic-gnmi/sonic-gnmi-standalone/pkg/client/config"
+)
+
+// Client provides access to gNMI service methods.
+type Client struct {
+	conn   *grpc.ClientConn
+	client gnmi.GNMIClient
+}
+
+// NewClient creates a new gNMI Client with the given configuration.
+func NewClient(cfg *config.Config) (*Client, error) {
+	conn, err := grpc.Dial(cfg.Address, cfg.DialOptions()...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", cfg.Address, err)
+	}
+
+	return &Client{
+		conn:   conn,
+		client: gnmi.NewGNMIClient(conn),
+	}, nil
+}
+
+// Close closes the underlying gRPC connection.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Get retrieves a single path and returns its decoded value.
+//
+// The target is set as the prefix target (e.g. "COUNTERS_DB" for SONiC DB paths)
+// and may be empty. JSON values are decoded into maps, slices and scalars.
+// When the response carries several updates, the result is a map keyed by
+// each update's path.
+func (c *Client) Get(ctx context.Context, target, path string) (interface{}, error) {
+	gnmiPath, err := ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &gnmi.GetRequest{
+		Prefix:   &gnmi.Path{Target: target},
+		Path:     []*gnmi.Path{gnmiPath},
+		Encoding: gnmi.Encoding_JSON_IETF,
+	}
+
+	resp, err := c.client.Get(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("Get %s failed: %w", path, err)
+	}
+
+	var updates []*gnmi.Update
+	for _, notification := range resp.GetNotification() {
+		updates = append(updates, notification.GetUpdate()...)
+	}
+
+	switch len(updates) {
+	case 0:
+		return nil, fmt.Errorf("Get %s returned no data", path)
+	case 1:
+		return DecodeValue(updates[0].GetVal())
+	}
+
+	values := make(map[string]interface{}, len(updates))
+	for _, update := range updates {
+		value, err := DecodeValue(update.GetVal())
+		if err != nil {
+			return nil, err
+		}
+		values[PathToString(update.GetPath())] = value
+	}
+	return values, nil
+}
+
+// DecodeValue converts a gNMI TypedValue into a plain Go value.
+func DecodeValue(val *gnmi.TypedValue) (interface{}, error) {
+	switch v := val.GetValue().(type) {
+	case *gnmi.TypedValue_JsonIetfVal:
+		return decodeJSON(v.JsonIetfVal)
+	case *gnmi.TypedValue_JsonVal:
+		return decodeJSON(v.JsonVal)
+	case *gnmi.TypedValue_StringVal:
+		return v.StringVal, nil
+	case *gnmi.TypedValue_IntVal:
+		return v.IntVal, nil
+	case *gnmi.TypedValue_UintVal:
+		return v.UintVal, nil
+	case *gnmi.TypedValue_BoolVal:
+		return v.BoolVal, nil
+	case *gnmi.TypedValue_FloatVal:
+		return float64(v.FloatVal), nil
+	case *gnmi.TypedValue_AsciiVal:
+		return v.AsciiVal, nil
+	case *gnmi.TypedValue_BytesVal:
+		return v.BytesVal, nil
+	default:
+		return nil, fmt.Errorf("unsupported value type %T", v)
+	}
+}
+
+// decodeJSON unmarshals a JSON value, keeping numbers as json.Number.
+func decodeJSON(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("failed to decode JSON value: %w", err)
+	}
+	return value, nil
+}
+
+// ParsePath converts a string path such as "/interfaces/interface[name=Ethernet0]/state"
+// into a gNMI Path. Slashes inside key values must be escaped with a backslash.
+func ParsePath(path string) (*gnmi.Path, error) {
+	gnmiPath := &gnmi.Path{}
+
+	for _, element := range splitPath(path) {
+		name := element
+		keys := map[string]string{}
+
+		if idx := strings.Index(element, "["); idx >= 0 {
+			if !strings.HasSuffix(element, "]") {
+				return nil, fmt.Errorf("invalid path element '%s' in path '%s'", element, path)
+			}
+			name = element[:idx]
+			for _, key := range strings.Split(element[idx+1:len(element)-1], "][") {
+				kv := strings.SplitN(key, "=", 2)
+				if len(kv) != 2 || kv[0] == "" {
+					return nil, fmt.Errorf("invalid key '%s' in path '%s'", key, path)
+				}
+				keys[kv[0]] = kv[1]
+			}
+		}
+
+		if name == "" {
+			return nil, fmt.Errorf("empty element name in path '%s'", path)
+		}
+
+		elem := &gnmi.PathElem{Name: name}
+		if len(keys) > 0 {
+			elem.Key = keys
+		}
+		gnmiPath.Elem = append(gnmiPath.Elem, elem)
+	}
+
+	return gnmiPath, nil
+}
+
+// splitPath splits a path on unescaped slashes outside of key brackets.
+func splitPath(path string) []string {
+	var elements []string
+	var current strings.Builder
+	inKey := false
+
+	for i := 0; i < len(path); i++ {
+		ch := path[i]
+		switch {
+		case ch == '\\' && i+1 < len(path):
+			i++
+			current.WriteByte(path[i])
+			continue
+		case ch == '[':
+			inKey = true
+		case ch == ']':
+			inKey = false
+		case ch == '/' && !inKey:
+			if current.Len() > 0 {
+				elements = append(elements, current.String())
+				current.Reset()
+			}
+			continue
+		}
+		current.WriteByte(ch)
+	}
+	if current.Len() > 0 {
+		elements = append(elements, current.String())
+	}
+
+	return elements
+}
+
+// PathToString renders a gNMI Path in the same format accepted by ParsePath.
+func PathToString(path *gnmi.Path) string {
+	var b strings.Builder
+	for _, elem := range path.GetElem() {
+		b.WriteString("/")
+		b.WriteString(elem.GetName())
+		for _, key := range sortedKeys(elem.GetKey()) {
+			fmt.Fprintf(&b, "[%s=%s]", key, elem.GetKey()[key])
+		}
+	}
+	if b.Len() == 0 {
+		return "/"
+	}
+	return b.String()
+}
+
+// sortedKeys returns the keys of m in sorted order for stable output.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
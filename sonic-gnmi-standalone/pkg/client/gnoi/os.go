@@ -0,0 +1,63 @@
+package gnoi
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc"
+
+	"github.com/openconfig/gnoi/os"
+
+	"github.com/sonic-net/sonic-gnmi/sonic-gnmi-standalone/pkg/client/config"
+)
+
+// VerifyResult contains the result of an OS Verify operation.
+type VerifyResult struct {
+	// Version is the OS version currently running
+	Version string
+
+	// ActivationFailMessage is set when the activated OS failed to boot
+	// and the device fell back to the previous version
+	ActivationFailMessage string
+}
+
+// OSClient provides access to gNOI OS service methods.
+type OSClient struct {
+	conn   *grpc.ClientConn
+	client os.OSClient
+}
+
+// NewOSClient creates a new OSClient with the given configuration.
+func NewOSClient(cfg *config.Config) (*OSClient, error) {
+	conn, err := grpc.Dial(cfg.Address, cfg.DialOptions()...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", cfg.Address, err)
+	}
+
+	return &OSClient{
+		conn:   conn,
+		client: os.NewOSClient(conn),
+	}, nil
+}
+
+// Close closes the underlying gRPC connection.
+func (c *OSClient) Close() error {
+	return c.conn.Close()
+}
+
+// Verify returns the OS version currently running on the device.
+func (c *OSClient) Verify(ctx context.Context) (*VerifyResult, error) {
+	resp, err := c.client.Verify(ctx, &os.VerifyRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("Verify failed: %w", err)
+	}
+
+	return &VerifyResult{
+		Version:               resp.GetVersion(),
+		ActivationFailMessage: resp.GetActivationFailMessage(),
+	}, nil
+}
+
+// Future OS service methods to be implemented:
+// - Install(ctx, *InstallParams) error
+// - Activate(ctx, *ActivateParams) error
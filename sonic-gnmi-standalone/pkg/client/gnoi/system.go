@@ -5,10 +5,9 @@ import (
 	"context"
 	"encoding/hex"
 	"fmt"
+	"time"
 
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials"
-	"google.golang.org/grpc/credentials/insecure"
 
 	"github.com/openconfig/gnoi/common"
 	"github.com/openconfig/gnoi/system"
@@ -35,6 +34,33 @@ type SetPackageParams struct {
 	Activate bool
 }
 
+// RebootParams contains the parameters for a Reboot operation.
+type RebootParams struct {
+	// Method is the reboot method (COLD, WARM, NSF, ...)
+	Method system.RebootMethod
+
+	// Delay is how long the device waits before rebooting (optional)
+	Delay time.Duration
+
+	// Message is an informational reason for the reboot (optional)
+	Message string
+
+	// Force reboots even if sanity checks fail (optional)
+	Force bool
+}
+
+// RebootStatusResult contains the result of a RebootStatus query.
+type RebootStatusResult struct {
+	// Active indicates whether a reboot is pending or in progress
+	Active bool
+
+	// Wait is the time left until the reboot
+	Wait time.Duration
+
+	// Reason is the reason given for the reboot
+	Reason string
+}
+
 // SystemClient provides access to gNOI System service methods.
 type SystemClient struct {
 	conn   *grpc.ClientConn
@@ -43,21 +69,8 @@ type SystemClient struct {
 
 // NewSystemClient creates a new SystemClient with the given configuration.
 func NewSystemClient(cfg *config.Config) (*SystemClient, error) {
-	// Set up connection options
-	var opts []grpc.DialOption
-
-	if cfg.TLS {
-		if cfg.TLSConfig != nil {
-			opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(cfg.TLSConfig)))
-		} else {
-			opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(nil)))
-		}
-	} else {
-		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
-	}
-
 	// Connect to server
-	conn, err := grpc.Dial(cfg.Address, opts...)
+	conn, err := grpc.Dial(cfg.Address, cfg.DialOptions()...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to %s: %w", cfg.Address, err)
 	}
@@ -131,9 +144,38 @@ func (c *SystemClient) SetPackage(ctx context.Context, params *SetPackageParams)
 	return nil
 }
 
+// Reboot requests a reboot of the target device.
+func (c *SystemClient) Reboot(ctx context.Context, params *RebootParams) error {
+	req := &system.RebootRequest{
+		Method:  params.Method,
+		Delay:   uint64(params.Delay.Nanoseconds()),
+		Message: params.Message,
+		Force:   params.Force,
+	}
+
+	if _, err := c.client.Reboot(ctx, req); err != nil {
+		return fmt.Errorf("Reboot failed: %w", err)
+	}
+	return nil
+}
+
+// RebootStatus returns the status of a pending or in-progress reboot.
+// The returned error preserves the gRPC status so callers can detect an
+// unreachable device with status.Code.
+func (c *SystemClient) RebootStatus(ctx context.Context) (*RebootStatusResult, error) {
+	resp, err := c.client.RebootStatus(ctx, &system.RebootStatusRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("RebootStatus failed: %w", err)
+	}
+
+	return &RebootStatusResult{
+		Active: resp.GetActive(),
+		Wait:   time.Duration(resp.GetWait()),
+		Reason: resp.GetReason(),
+	}, nil
+}
+
 // Future System service methods to be implemented:
-// - Reboot(ctx, *RebootParams) error
-// - RebootStatus(ctx, *RebootStatusParams) (*RebootStatusResult, error)
 // - CancelReboot(ctx, *CancelRebootParams) error
 // - KillProcess(ctx, *KillProcessParams) error
 // - Ping(ctx, *PingParams) (stream *PingResult, error)
@@ -69,6 +69,7 @@ type certConfig struct {
 	redisDB         int
 	requireClient   bool
 	configTableName string
+	identitySources []string
 }
 
 // NewServerBuilder creates a new ServerBuilder instance with default configuration.
@@ -173,6 +174,15 @@ func (b *ServerBuilder) WithConfigTableName(tableName string) *ServerBuilder {
 	return b
 }
 
+// WithClientIdentitySources sets the client certificate parts ("cn", "dns", "uri", "email")
+// identities are matched against the ConfigDB table with, in order of precedence.
+func (b *ServerBuilder) WithClientIdentitySources(sources []string) *ServerBuilder {
+	if b.certConfig != nil {
+		b.certConfig.identitySources = sources
+	}
+	return b
+}
+
 // EnableGNOISystem enables the gNOI System service, which provides system-level
 // operations including package management, reboot, and system information.
 func (b *ServerBuilder) EnableGNOISystem() *ServerBuilder {
@@ -282,6 +292,9 @@ func (b *ServerBuilder) createCertificateManager() (cert.CertificateManager, err
 		certConfig.CAFile = b.certConfig.caFile
 		certConfig.RequireClientCert = b.certConfig.requireClient
 		certConfig.ConfigTableName = b.certConfig.configTableName
+		if len(b.certConfig.identitySources) > 0 {
+			certConfig.IdentitySources = b.certConfig.identitySources
+		}
 		certConfig.RedisAddr = b.certConfig.redisAddr // For client auth manager
 		certConfig.RedisDB = b.certConfig.redisDB     // For client auth manager
 		certConfig.EnableMonitoring = false           // Disable monitoring in builder pattern for now
@@ -301,6 +314,9 @@ func (b *ServerBuilder) createCertificateManager() (cert.CertificateManager, err
 		certConfig.RedisDB = b.certConfig.redisDB
 		certConfig.RequireClientCert = b.certConfig.requireClient
 		certConfig.ConfigTableName = b.certConfig.configTableName
+		if len(b.certConfig.identitySources) > 0 {
+			certConfig.IdentitySources = b.certConfig.identitySources
+		}
 		certConfig.EnableMonitoring = false // Disable monitoring in builder pattern for now
 
 		certMgr := cert.NewCertificateManager(certConfig)
@@ -328,14 +344,13 @@ func (b *ServerBuilder) registerServices(srv *Server, rootFS string) {
 
 	// Register gNOI File service
 	if b.services["gnoi.file"] {
-		fileServer := &snfile.FileServer{}
+		fileServer := snfile.NewServer(rootFS)
 		file.RegisterFileServer(srv.grpcServer, fileServer)
 		glog.Info("Registered gNOI File service")
 		serviceCount++
 	}
 
 	// Future services will be implemented:
-	// - gNOI File service
 	// - gNOI Containerz service
 	// - gNMI service
 
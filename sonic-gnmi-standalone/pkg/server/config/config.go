@@ -22,6 +22,7 @@ type Config struct {
 	RedisDB              int    // Redis database number for ConfigDB
 	EnableCertMonitoring bool   // Enable certificate file monitoring
 	ConfigTableName      string // ConfigDB table name for client certificates
+	ClientIdentity       string // Client certificate parts identities are taken from, comma separated
 }
 
 var Global *Config
@@ -43,6 +44,7 @@ func Initialize() {
 	redisDB := flag.Int("redis-db", 4, "Redis database number for ConfigDB (default: 4)")
 	enableCertMonitoring := flag.Bool("cert-monitoring", true, "Enable certificate file monitoring for automatic reload")
 	configTableName := flag.String("config-table-name", "GNMI_CLIENT_CERT", "ConfigDB table name for client certificates")
+	clientIdentity := flag.String("client-identity", "cn", "Client certificate parts identities are taken from, in order of precedence (cn,dns,uri,email)")
 
 	flag.Parse()
 
@@ -83,5 +85,6 @@ func Initialize() {
 		RedisDB:              *redisDB,
 		EnableCertMonitoring: *enableCertMonitoring,
 		ConfigTableName:      *configTableName,
+		ClientIdentity:       *clientIdentity,
 	}
 }
@@ -1,51 +1,197 @@
+// Package file implements the gNOI File service for SONiC.
+// It allows clients to read, write, inspect and remove files on the device,
+// and to have the device download a file from a remote server.
+//
+// All paths in requests are device paths. When the server runs in a container
+// they are translated to the host filesystem using the configured rootFS,
+// in the same way as the System service. Put, TransferToRemote and Remove are
+// served by the shared handlers in pkg/gnoi/file, which restrict writes and
+// removals to /tmp/ and /var/tmp/.
 package file
 
 import (
 	"context"
+	"crypto/md5"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/golang/glog"
 	"github.com/openconfig/gnoi/file"
-	"google.golang.org/grpc"
+	"github.com/openconfig/gnoi/types"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
-	"os"
+
+	sharedfile "github.com/sonic-net/sonic-gnmi/pkg/gnoi/file"
 )
 
+// getChunkSize is the size of each Contents message sent by Get.
+const getChunkSize = 64 * 1024
+
 // FileServer implements the gNOI File service.
 type FileServer struct {
 	file.UnimplementedFileServer
+	rootFS string // Root filesystem path for containerized deployments
 }
 
-// Remove deletes the specified file from the filesystem.
-func (s *FileServer) Remove(ctx context.Context, req *file.RemoveRequest) (*file.RemoveResponse, error) {
-	path := req.RemoteFile
-	if path == "" {
-		return nil, status.Error(codes.InvalidArgument, "path must not be empty")
+// NewServer creates a new File service server instance.
+func NewServer(rootFS string) *FileServer {
+	return &FileServer{
+		rootFS: rootFS,
+	}
+}
+
+// Get streams the contents of a file to the client, followed by its MD5 hash.
+func (s *FileServer) Get(req *file.GetRequest, stream file.File_GetServer) error {
+	remoteFile := req.GetRemoteFile()
+	if remoteFile == "" {
+		return status.Error(codes.InvalidArgument, "remote_file cannot be empty")
+	}
+	if err := validatePath(remoteFile); err != nil {
+		return status.Errorf(codes.InvalidArgument, "invalid remote_file: %v", err)
+	}
+
+	path := s.translatePath(remoteFile)
+	glog.Infof("Get RPC called: remote=%s translated=%s", remoteFile, path)
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fileError(err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return fileError(err)
+	}
+	if info.IsDir() {
+		return status.Errorf(codes.InvalidArgument, "%s is a directory", remoteFile)
+	}
+
+	hasher := md5.New() // nosemgrep: go.lang.security.audit.crypto.use_of_weak_crypto.use-of-md5
+	buf := make([]byte, getChunkSize)
+	for {
+		n, err := f.Read(buf)
+		if n > 0 {
+			hasher.Write(buf[:n])
+			resp := &file.GetResponse{
+				Response: &file.GetResponse_Contents{Contents: buf[:n]},
+			}
+			if err := stream.Send(resp); err != nil {
+				return status.Errorf(codes.Internal, "failed to send contents: %v", err)
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return status.Errorf(codes.Internal, "failed to read file: %v", err)
+		}
+	}
+
+	return stream.Send(&file.GetResponse{
+		Response: &file.GetResponse_Hash{
+			Hash: &types.HashType{
+				Method: types.HashType_MD5,
+				Hash:   hasher.Sum(nil),
+			},
+		},
+	})
+}
+
+// Put receives a file from the client and writes it to the device.
+// The file is only renamed into place once its MD5 hash has been verified.
+func (s *FileServer) Put(stream file.File_PutServer) error {
+	return sharedfile.HandlePutWithOptions(stream, s.options())
+}
+
+// Stat returns metadata for a file, or for each entry of a directory.
+func (s *FileServer) Stat(ctx context.Context, req *file.StatRequest) (*file.StatResponse, error) {
+	remotePath := req.GetPath()
+	if remotePath == "" {
+		return nil, status.Error(codes.InvalidArgument, "path cannot be empty")
+	}
+	if err := validatePath(remotePath); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid path: %v", err)
 	}
-	err := os.Remove(path)
+
+	path := s.translatePath(remotePath)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fileError(err)
+	}
+
+	if !info.IsDir() {
+		return &file.StatResponse{
+			Stats: []*file.StatInfo{s.statInfo(path, info)},
+		}, nil
+	}
+
+	entries, err := os.ReadDir(path)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to remove file: %v", err)
+		return nil, fileError(err)
 	}
-	return &file.RemoveResponse{}, nil
+
+	resp := &file.StatResponse{}
+	for _, entry := range entries {
+		entryPath := filepath.Join(path, entry.Name())
+		entryInfo, err := os.Stat(entryPath)
+		if err != nil {
+			// Entries can disappear, or be dangling symlinks; report what remains
+			glog.V(1).Infof("Skipping %s: %v", entryPath, err)
+			continue
+		}
+		resp.Stats = append(resp.Stats, s.statInfo(entryPath, entryInfo))
+	}
+	sort.Slice(resp.Stats, func(i, j int) bool {
+		return resp.Stats[i].Path < resp.Stats[j].Path
+	})
+
+	return resp, nil
 }
 
-func (s *FileServer) Get(req *file.GetRequest, stream grpc.ServerStreamingServer[file.GetResponse]) error {
-	// Not implemented yet
-	return nil
+// statInfo builds the StatInfo for a translated path, reporting the device path.
+func (s *FileServer) statInfo(path string, info os.FileInfo) *file.StatInfo {
+	return &file.StatInfo{
+		Path:         s.devicePath(path),
+		LastModified: uint64(info.ModTime().UnixNano()),
+		Permissions:  octalPermissions(info.Mode()),
+		Size:         uint64(info.Size()),
+	}
 }
 
+// TransferToRemote downloads a file from a remote server to the device and
+// returns its MD5 hash. Only HTTP downloads are currently supported.
 func (s *FileServer) TransferToRemote(
 	ctx context.Context,
 	req *file.TransferToRemoteRequest,
 ) (*file.TransferToRemoteResponse, error) {
-	// Not implemented yet
-	return nil, nil
+	return sharedfile.HandleTransferToRemoteWithOptions(ctx, req, s.options())
 }
 
-func (s *FileServer) Put(stream grpc.ClientStreamingServer[file.PutRequest, file.PutResponse]) error {
-	// Not implemented yet
-	return nil
+// Remove deletes the specified file from the filesystem.
+func (s *FileServer) Remove(ctx context.Context, req *file.RemoveRequest) (*file.RemoveResponse, error) {
+	return sharedfile.HandleFileRemoveWithOptions(ctx, req, s.options())
 }
 
-func (s *FileServer) Stat(ctx context.Context, req *file.StatRequest) (*file.StatResponse, error) {
-	// Not implemented yet
-	return nil, nil
+// options describes the server's view of the device filesystem to the shared handlers.
+func (s *FileServer) options() sharedfile.Options {
+	return sharedfile.Options{
+		RootFS:           s.rootFS,
+		OctalPermissions: true,
+	}
+}
+
+// fileError maps a filesystem error to a gRPC status.
+func fileError(err error) error {
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return status.Errorf(codes.NotFound, "%v", err)
+	case errors.Is(err, os.ErrPermission):
+		return status.Errorf(codes.PermissionDenied, "%v", err)
+	default:
+		return status.Errorf(codes.Internal, "%v", err)
+	}
 }
@@ -0,0 +1,80 @@
+package file
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// validatePath checks that path is an absolute path without traversal components.
+func validatePath(path string) error {
+	if !filepath.IsAbs(path) {
+		return fmt.Errorf("path must be absolute, got: %s", path)
+	}
+
+	// Reject traversal before cleaning so that /tmp/../etc/passwd is not accepted as /etc/passwd
+	for _, elem := range strings.Split(path, "/") {
+		if elem == ".." {
+			return fmt.Errorf("path traversal not allowed: %s", path)
+		}
+	}
+
+	return nil
+}
+
+// translatePath maps a device path to the server's view of the filesystem by
+// prefixing it with rootFS. This matches the System service's handling of
+// SetPackage filenames.
+func (s *FileServer) translatePath(path string) string {
+	cleanPath := filepath.Clean(path)
+	if s.rootFS == "" || !filepath.IsAbs(cleanPath) {
+		return cleanPath
+	}
+
+	// Avoid double prefixing when the client already sent a rootFS path
+	if strings.HasPrefix(cleanPath, filepath.Clean(s.rootFS)+"/") {
+		return cleanPath
+	}
+	return filepath.Join(s.rootFS, cleanPath)
+}
+
+// devicePath maps a translated path back to the path seen on the device,
+// stripping the rootFS prefix added by translatePath.
+func (s *FileServer) devicePath(path string) string {
+	if s.rootFS == "" {
+		return path
+	}
+
+	rootFS := filepath.Clean(s.rootFS)
+	if rootFS == "/" {
+		return path
+	}
+	if trimmed := strings.TrimPrefix(path, rootFS); trimmed != path && strings.HasPrefix(trimmed, "/") {
+		return trimmed
+	}
+	return path
+}
+
+// octalPermissions converts a file mode to the gNOI representation, in which the
+// octal digits are carried as a decimal number (0755 is sent as 755).
+func octalPermissions(mode os.FileMode) uint32 {
+	perm := uint32(mode.Perm())
+	if mode&os.ModeSetuid != 0 {
+		perm |= 04000
+	}
+	if mode&os.ModeSetgid != 0 {
+		perm |= 02000
+	}
+	if mode&os.ModeSticky != 0 {
+		perm |= 01000
+	}
+
+	var result, place uint32 = 0, 1
+	for perm > 0 {
+		result += (perm % 8) * place
+		perm /= 8
+		place *= 10
+	}
+	return result
+}
@@ -0,0 +1,42 @@
+package file
+
+import (
+	"os"
+	"testing"
+)
+
+// TestTranslatePath tests rootFS translation of device paths.
+func TestTranslatePath(t *testing.T) {
+	tests := []struct {
+		rootFS string
+		path   string
+		want   string
+	}{
+		{rootFS: "", path: "/tmp/a.bin", want: "/tmp/a.bin"},
+		{rootFS: "/mnt/host", path: "/tmp/a.bin", want: "/mnt/host/tmp/a.bin"},
+		{rootFS: "/mnt/host", path: "/mnt/host/tmp/a.bin", want: "/mnt/host/tmp/a.bin"},
+		{rootFS: "/mnt/host", path: "/tmp//a.bin", want: "/mnt/host/tmp/a.bin"},
+		{rootFS: "/", path: "/tmp/a.bin", want: "/tmp/a.bin"},
+	}
+
+	for _, tt := range tests {
+		srv := NewServer(tt.rootFS)
+		if got := srv.translatePath(tt.path); got != tt.want {
+			t.Errorf("translatePath(%q) with rootFS %q = %q, want %q", tt.path, tt.rootFS, got, tt.want)
+		}
+		if got := srv.devicePath(srv.translatePath(tt.path)); got != "/tmp/a.bin" {
+			t.Errorf("devicePath(translatePath(%q)) with rootFS %q = %q, want /tmp/a.bin", tt.path, tt.rootFS, got)
+		}
+	}
+}
+
+// TestOctalPermissions tests conversion of file modes to gNOI permissions.
+func TestOctalPermissions(t *testing.T) {
+	if got := octalPermissions(0755); got != 755 {
+		t.Errorf("octalPermissions(0755) = %d, want 755", got)
+	}
+	if got := octalPermissions(0644 | os.ModeSetuid); got != 4644 {
+		t.Errorf("octalPermissions(04644) = %d, want 4644", got)
+	}
+
+}
@@ -3,24 +3,38 @@ package file
 import (
 	"context"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/openconfig/gnoi/file"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
+// newTestServer creates a FileServer rooted at a temporary directory that
+// contains the writable /tmp directory.
+func newTestServer(t *testing.T) (*FileServer, string) {
+	t.Helper()
+	rootFS := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(rootFS, "tmp"), 0755); err != nil {
+		t.Fatalf("failed to create rootfs: %v", err)
+	}
+	return NewServer(rootFS), rootFS
+}
+
 // TestFileServer_Remove tests the Remove method of FileServer.
 func TestFileServer_Remove(t *testing.T) {
-	srv := &FileServer{}
+	srv, rootFS := newTestServer(t)
 
 	// Create a temporary file to be removed.
-	tmp := "test_remove.tmp"
+	tmp := filepath.Join(rootFS, "tmp", "test_remove.tmp")
 	if err := os.WriteFile(tmp, []byte("hello"), 0644); err != nil {
 		t.Fatalf("failed to create temp file: %v", err)
 	}
 
 	// Call Remove.
 	resp, err := srv.Remove(context.Background(), &file.RemoveRequest{
-		RemoteFile: tmp,
+		RemoteFile: "/tmp/test_remove.tmp",
 	})
 	if err != nil {
 		t.Fatalf("Remove failed: %v", err)
@@ -37,22 +51,75 @@ func TestFileServer_Remove(t *testing.T) {
 
 // TestFileServer_Remove_EmptyPath tests Remove with an empty path.
 func TestFileServer_Remove_EmptyPath(t *testing.T) {
-	srv := &FileServer{}
+	srv, _ := newTestServer(t)
 	_, err := srv.Remove(context.Background(), &file.RemoveRequest{
 		RemoteFile: "",
 	})
-	if err == nil {
-		t.Fatalf("expected error for empty path, got nil")
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument for empty path, got %v", err)
 	}
 }
 
 // TestFileServer_Remove_NonexistentFile tests Remove with a non-existent file.
 func TestFileServer_Remove_NonexistentFile(t *testing.T) {
-	srv := &FileServer{}
+	srv, _ := newTestServer(t)
 	_, err := srv.Remove(context.Background(), &file.RemoveRequest{
-		RemoteFile: "nonexistent_file.tmp",
+		RemoteFile: "/tmp/nonexistent_file.tmp",
 	})
-	if err == nil {
-		t.Fatalf("expected error for nonexistent file, got nil")
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NotFound for nonexistent file, got %v", err)
+	}
+}
+
+// TestFileServer_Remove_DisallowedPath tests that Remove only deletes files in temporary directories.
+func TestFileServer_Remove_DisallowedPath(t *testing.T) {
+	srv, rootFS := newTestServer(t)
+
+	protected := filepath.Join(rootFS, "etc", "sonic", "config_db.json")
+	if err := os.MkdirAll(filepath.Dir(protected), 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(protected, []byte("{}"), 0644); err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+
+	for _, path := range []string{
+		"/etc/sonic/config_db.json",
+		"/tmp/../etc/sonic/config_db.json",
+		"relative.tmp",
+	} {
+		_, err := srv.Remove(context.Background(), &file.RemoveRequest{RemoteFile: path})
+		if status.Code(err) != codes.PermissionDenied {
+			t.Errorf("Remove(%q): expected PermissionDenied, got %v", path, err)
+		}
+	}
+
+	if _, err := os.Stat(protected); err != nil {
+		t.Fatalf("protected file was removed: %v", err)
+	}
+}
+
+// TestFileServer_Remove_SymlinkEscape tests that a symlinked directory under
+// /tmp cannot be used to remove files elsewhere.
+func TestFileServer_Remove_SymlinkEscape(t *testing.T) {
+	srv, rootFS := newTestServer(t)
+
+	protected := filepath.Join(rootFS, "etc", "sonic", "config_db.json")
+	if err := os.MkdirAll(filepath.Dir(protected), 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(protected, []byte("{}"), 0644); err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(rootFS, "etc"), filepath.Join(rootFS, "tmp", "etc")); err != nil {
+		t.Fatalf("failed to create symlink: %v", err)
+	}
+
+	_, err := srv.Remove(context.Background(), &file.RemoveRequest{RemoteFile: "/tmp/etc/sonic/config_db.json"})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("expected PermissionDenied, got %v", err)
+	}
+	if _, err := os.Stat(protected); err != nil {
+		t.Fatalf("protected file was removed: %v", err)
 	}
 }
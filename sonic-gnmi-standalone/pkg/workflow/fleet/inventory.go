@@ -0,0 +1,279 @@
+// Package fleet runs a workflow against many devices listed in an inventory,
+// with bounded concurrency, a canary batch and a failure budget that halts the
+// rollout.
+//
+// Usage:
+//
+//	inventory, err := fleet.LoadInventory("devices.yaml")
+//	runner := fleet.NewRunner(registry, fleet.Options{Concurrency: 10, CanaryCount: 1, MaxFailurePercent: 5})
+//	report, err := runner.Run(ctx, workflow, inventory)
+//	err = report.Save("report.json")
+package fleet
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// validDeviceName restricts device names to characters that are safe in file names,
+// since each device gets its own log and state file.
+var validDeviceName = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// Device describes one target of a fleet rollout.
+//
+// YAML inventory example:
+//
+//	defaults:
+//	  tls: true
+//	  username: admin
+//	  passwordEnv: SONIC_PASSWORD
+//	  caCert: /etc/sonic/ca.pem
+//	  variables:
+//	    version: "SONiC-OS-202505.01"
+//	devices:
+//	  - name: leaf1
+//	    address: 10.0.0.1:50055
+//	  - name: leaf2
+//	    address: 10.0.0.2:50055
+//	    variables:
+//	      image_url: "http://images.example.com/leaf2.bin"
+//
+// CSV inventories use a header row. The name and address columns are required;
+// tls, username, password, passwordEnv, caCert, clientCert and clientKey map to
+// the fields below and every other column becomes a variable:
+//
+//	name,address,tls,username,passwordEnv,version
+//	leaf1,10.0.0.1:50055,true,admin,SONIC_PASSWORD,SONiC-OS-202505.01
+type Device struct {
+	// Name identifies the device in logs and reports
+	Name string `yaml:"name"`
+
+	// Address is the gRPC server address in "host:port" format
+	Address string `yaml:"address"`
+
+	// TLS enables TLS for the connection; unset falls back to defaults and then the --tls flag
+	TLS *bool `yaml:"tls,omitempty"`
+
+	// Username and Password are sent for password authentication (optional)
+	Username string `yaml:"username,omitempty"`
+	Password string `yaml:"password,omitempty"`
+
+	// PasswordEnv names an environment variable holding the password, to keep
+	// secrets out of the inventory file (optional)
+	PasswordEnv string `yaml:"passwordEnv,omitempty"`
+
+	// CACert, ClientCert and ClientKey are PEM file paths for TLS (optional)
+	CACert     string `yaml:"caCert,omitempty"`
+	ClientCert string `yaml:"clientCert,omitempty"`
+	ClientKey  string `yaml:"clientKey,omitempty"`
+
+	// Variables are per-device values made available to the workflow
+	Variables map[string]string `yaml:"variables,omitempty"`
+}
+
+// Inventory is the list of devices a fleet rollout targets.
+type Inventory struct {
+	// Defaults are applied to every device for fields the device leaves unset
+	Defaults Device `yaml:"defaults"`
+
+	// Devices are rolled out in the order listed; the first ones form the canary batch
+	Devices []Device `yaml:"devices"`
+}
+
+// LoadInventory loads an inventory from a YAML or CSV file, chosen by the file
+// extension, applies the defaults to every device and validates the result.
+func LoadInventory(path string) (*Inventory, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read inventory file: %w", err)
+	}
+
+	var inventory *Inventory
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		inventory, err = parseCSVInventory(strings.NewReader(string(data)))
+	} else {
+		inventory = &Inventory{}
+		err = yaml.Unmarshal(data, inventory)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse inventory '%s': %w", path, err)
+	}
+
+	inventory.applyDefaults()
+	if err := inventory.validate(); err != nil {
+		return nil, fmt.Errorf("invalid inventory '%s': %w", path, err)
+	}
+
+	return inventory, nil
+}
+
+// parseCSVInventory parses a CSV inventory with a header row.
+func parseCSVInventory(r io.Reader) (*Inventory, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("CSV inventory has no header row")
+	}
+
+	header := records[0]
+	inventory := &Inventory{}
+	for line, record := range records[1:] {
+		device := Device{}
+		for i, column := range header {
+			value := strings.TrimSpace(record[i])
+			if value == "" {
+				continue
+			}
+
+			switch strings.TrimSpace(column) {
+			case "name":
+				device.Name = value
+			case "address":
+				device.Address = value
+			case "tls":
+				tls, err := strconv.ParseBool(value)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: invalid tls value '%s'", line+2, value)
+				}
+				device.TLS = &tls
+			case "username":
+				device.Username = value
+			case "password":
+				device.Password = value
+			case "passwordEnv":
+				device.PasswordEnv = value
+			case "caCert":
+				device.CACert = value
+			case "clientCert":
+				device.ClientCert = value
+			case "clientKey":
+				device.ClientKey = value
+			default:
+				if device.Variables == nil {
+					device.Variables = make(map[string]string)
+				}
+				device.Variables[strings.TrimSpace(column)] = value
+			}
+		}
+		inventory.Devices = append(inventory.Devices, device)
+	}
+
+	return inventory, nil
+}
+
+// applyDefaults fills unset device fields from the inventory defaults.
+// Device variables override default variables with the same name.
+func (inv *Inventory) applyDefaults() {
+	d := inv.Defaults
+	for i := range inv.Devices {
+		device := &inv.Devices[i]
+		if device.TLS == nil {
+			device.TLS = d.TLS
+		}
+		if device.Username == "" {
+			device.Username = d.Username
+		}
+		if device.Password == "" && device.PasswordEnv == "" {
+			device.Password = d.Password
+			device.PasswordEnv = d.PasswordEnv
+		}
+		if device.CACert == "" {
+			device.CACert = d.CACert
+		}
+		if device.ClientCert == "" && device.ClientKey == "" {
+			device.ClientCert = d.ClientCert
+			device.ClientKey = d.ClientKey
+		}
+
+		variables := make(map[string]string, len(d.Variables)+len(device.Variables))
+		for k, v := range d.Variables {
+			variables[k] = v
+		}
+		for k, v := range device.Variables {
+			variables[k] = v
+		}
+		device.Variables = variables
+	}
+}
+
+// validate checks that the inventory has devices with unique, file-safe names and addresses.
+func (inv *Inventory) validate() error {
+	if len(inv.Devices) == 0 {
+		return fmt.Errorf("at least one device is required")
+	}
+
+	names := make(map[string]bool, len(inv.Devices))
+	for i, device := range inv.Devices {
+		if device.Name == "" {
+			return fmt.Errorf("devices[%d]: name is required", i)
+		}
+		if !validDeviceName.MatchString(device.Name) {
+			return fmt.Errorf("devices[%d]: name '%s' may only contain letters, digits, '.', '_' and '-'", i, device.Name)
+		}
+		if names[device.Name] {
+			return fmt.Errorf("devices[%d]: duplicate device name '%s'", i, device.Name)
+		}
+		names[device.Name] = true
+
+		if device.Address == "" {
+			return fmt.Errorf("devices[%d]: address is required", i)
+		}
+	}
+
+	return nil
+}
+
+// ClientConfig returns the client configuration map passed to workflow steps
+// for this device. defaultTLS is used when neither the device nor the
+// inventory defaults set tls.
+func (d *Device) ClientConfig(defaultTLS bool) (map[string]interface{}, error) {
+	useTLS := defaultTLS
+	if d.TLS != nil {
+		useTLS = *d.TLS
+	}
+
+	password := d.Password
+	if d.PasswordEnv != "" {
+		var ok bool
+		if password, ok = os.LookupEnv(d.PasswordEnv); !ok {
+			return nil, fmt.Errorf("password environment variable %s is not set", d.PasswordEnv)
+		}
+	}
+
+	return map[string]interface{}{
+		"server_addr": d.Address,
+		"use_tls":     useTLS,
+		"username":    d.Username,
+		"password":    password,
+		"ca_cert":     d.CACert,
+		"client_cert": d.ClientCert,
+		"client_key":  d.ClientKey,
+		"device":      d.Name,
+		"variables":   d.Variables,
+	}, nil
+}
+
+// TemplateVariables returns the workflow template variables for this device:
+// its inventory variables plus "device" and "address", overridden by overrides.
+func (d *Device) TemplateVariables(overrides map[string]string) map[string]string {
+	vars := make(map[string]string, len(d.Variables)+len(overrides)+2)
+	for k, v := range d.Variables {
+		vars[k] = v
+	}
+	vars["device"] = d.Name
+	vars["address"] = d.Address
+	for k, v := range overrides {
+		vars[k] = v
+	}
+	return vars
+}
@@ -0,0 +1,121 @@
+package fleet
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestLoadInventory_YAML(t *testing.T) {
+	path := writeFile(t, "devices.yaml", `defaults:
+  tls: true
+  username: admin
+  passwordEnv: FLEET_TEST_PASSWORD
+  variables:
+    version: "202505"
+    region: west
+devices:
+  - name: leaf1
+    address: 10.0.0.1:50055
+  - name: leaf2
+    address: 10.0.0.2:50055
+    tls: false
+    password: secret
+    variables:
+      version: "202411"
+`)
+
+	inventory, err := LoadInventory(path)
+	require.NoError(t, err)
+	require.Len(t, inventory.Devices, 2)
+
+	leaf1 := inventory.Devices[0]
+	assert.True(t, *leaf1.TLS)
+	assert.Equal(t, "admin", leaf1.Username)
+	assert.Equal(t, "FLEET_TEST_PASSWORD", leaf1.PasswordEnv)
+	assert.Equal(t, map[string]string{"version": "202505", "region": "west"}, leaf1.Variables)
+
+	leaf2 := inventory.Devices[1]
+	assert.False(t, *leaf2.TLS)
+	assert.Equal(t, "secret", leaf2.Password)
+	assert.Empty(t, leaf2.PasswordEnv)
+	assert.Equal(t, map[string]string{"version": "202411", "region": "west"}, leaf2.Variables)
+
+	t.Setenv("FLEET_TEST_PASSWORD", "from-env")
+	cfg, err := leaf1.ClientConfig(false)
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.1:50055", cfg["server_addr"])
+	assert.Equal(t, true, cfg["use_tls"])
+	assert.Equal(t, "from-env", cfg["password"])
+	assert.Equal(t, "leaf1", cfg["device"])
+}
+
+func TestLoadInventory_CSV(t *testing.T) {
+	path := writeFile(t, "devices.csv", `name,address,tls,username,version
+leaf1,10.0.0.1:50055,true,admin,202505
+leaf2,10.0.0.2:50055,,admin,202411
+`)
+
+	inventory, err := LoadInventory(path)
+	require.NoError(t, err)
+	require.Len(t, inventory.Devices, 2)
+
+	assert.True(t, *inventory.Devices[0].TLS)
+	assert.Nil(t, inventory.Devices[1].TLS)
+	assert.Equal(t, map[string]string{"version": "202411"}, inventory.Devices[1].Variables)
+
+	cfg, err := inventory.Devices[1].ClientConfig(true)
+	require.NoError(t, err)
+	assert.Equal(t, true, cfg["use_tls"])
+}
+
+func TestLoadInventory_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		wantErr string
+	}{
+		{"no devices", "empty.yaml", "devices: []\n", "at least one device is required"},
+		{"missing address", "noaddr.yaml", "devices:\n  - name: leaf1\n", "address is required"},
+		{"duplicate name", "dup.csv", "name,address\nleaf1,a:1\nleaf1,b:1\n", "duplicate device name 'leaf1'"},
+		{"unsafe name", "unsafe.yaml", "devices:\n  - name: ../leaf1\n    address: a:1\n", "may only contain"},
+		{"bad tls", "badtls.csv", "name,address,tls\nleaf1,a:1,maybe\n", "invalid tls value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadInventory(writeFile(t, tt.file, tt.content))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestDevice_ClientConfig_MissingPasswordEnv(t *testing.T) {
+	device := Device{Name: "leaf1", Address: "a:1", PasswordEnv: "FLEET_TEST_UNSET_PASSWORD"}
+	_, err := device.ClientConfig(false)
+	assert.EqualError(t, err, "password environment variable FLEET_TEST_UNSET_PASSWORD is not set")
+}
+
+func TestDevice_TemplateVariables(t *testing.T) {
+	device := Device{Name: "leaf1", Address: "10.0.0.1:50055", Variables: map[string]string{"version": "202411", "role": "leaf"}}
+
+	vars := device.TemplateVariables(map[string]string{"version": "202505"})
+	assert.Equal(t, map[string]string{
+		"version": "202505",
+		"role":    "leaf",
+		"device":  "leaf1",
+		"address": "10.0.0.1:50055",
+	}, vars)
+}
@@ -0,0 +1,77 @@
+package fleet
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// DeviceStatus is the outcome of a rollout on one device.
+type DeviceStatus string
+
+const (
+	// DeviceSucceeded means the workflow completed on the device.
+	DeviceSucceeded DeviceStatus = "succeeded"
+
+	// DeviceFailed means the workflow failed on the device.
+	DeviceFailed DeviceStatus = "failed"
+
+	// DeviceSkipped means the rollout halted before the device was started.
+	DeviceSkipped DeviceStatus = "skipped"
+)
+
+// DeviceResult records the outcome of the workflow on a single device.
+type DeviceResult struct {
+	Name       string       `json:"name"`
+	Address    string       `json:"address"`
+	Canary     bool         `json:"canary,omitempty"`
+	Status     DeviceStatus `json:"status"`
+	Error      string       `json:"error,omitempty"`
+	LogFile    string       `json:"logFile,omitempty"`
+	StartedAt  *time.Time   `json:"startedAt,omitempty"`
+	FinishedAt *time.Time   `json:"finishedAt,omitempty"`
+	Duration   string       `json:"duration,omitempty"`
+}
+
+// Report summarizes a fleet rollout.
+type Report struct {
+	Workflow   string         `json:"workflow"`
+	StartedAt  time.Time      `json:"startedAt"`
+	FinishedAt time.Time      `json:"finishedAt"`
+	Total      int            `json:"total"`
+	Succeeded  int            `json:"succeeded"`
+	Failed     int            `json:"failed"`
+	Skipped    int            `json:"skipped"`
+	Halted     bool           `json:"halted"`
+	HaltReason string         `json:"haltReason,omitempty"`
+	Devices    []DeviceResult `json:"devices"`
+}
+
+// Save writes the report to path as indented JSON.
+func (r *Report) Save(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode report: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write report '%s': %w", path, err)
+	}
+	return nil
+}
+
+// summarize fills the report totals from the device results.
+func (r *Report) summarize() {
+	r.Total = len(r.Devices)
+	r.Succeeded, r.Failed, r.Skipped = 0, 0, 0
+	for _, result := range r.Devices {
+		switch result.Status {
+		case DeviceSucceeded:
+			r.Succeeded++
+		case DeviceFailed:
+			r.Failed++
+		case DeviceSkipped:
+			r.Skipped++
+		}
+	}
+}
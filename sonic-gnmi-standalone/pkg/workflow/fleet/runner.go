@@ -0,0 +1,305 @@
+package fleet
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/sonic-net/sonic-gnmi/sonic-gnmi-standalone/pkg/workflow"
+)
+
+const (
+	// DefaultConcurrency is the default number of devices upgraded in parallel.
+	DefaultConcurrency = 5
+
+	// DefaultCanaryCount is the default size of the canary batch.
+	DefaultCanaryCount = 1
+
+	// DefaultMaxFailurePercent is the default share of failed devices that halts the rollout.
+	DefaultMaxFailurePercent = 10.0
+)
+
+// Options controls how a fleet rollout is executed.
+type Options struct {
+	// Concurrency is the maximum number of devices running the workflow at once
+	Concurrency int
+
+	// CanaryCount is the number of devices, taken from the start of the inventory,
+	// that run first. Any canary failure halts the rollout.
+	CanaryCount int
+
+	// MaxFailurePercent halts the rollout once failed devices exceed this share of
+	// the fleet. Devices already running are allowed to finish.
+	MaxFailurePercent float64
+
+	// LogDir receives a <device>.log and <device>.state.json file per device
+	LogDir string
+
+	// Timeout bounds the workflow run on each device (0 means no limit)
+	Timeout time.Duration
+
+	// RequestTimeout bounds each RPC made by a step (0 means no limit)
+	RequestTimeout time.Duration
+
+	// Resume continues each device from its state file when one exists
+	Resume bool
+
+	// DefaultTLS is used for devices whose inventory entry does not set tls
+	DefaultTLS bool
+
+	// Variables override every device's inventory variables (e.g. from --set)
+	Variables map[string]string
+}
+
+// Runner executes a workflow across the devices of an inventory.
+type Runner struct {
+	registry workflow.StepRegistry
+	opts     Options
+}
+
+// NewRunner creates a fleet runner that builds steps from registry.
+// Zero Concurrency and CanaryCount values are replaced with their defaults.
+func NewRunner(registry workflow.StepRegistry, opts Options) *Runner {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = DefaultConcurrency
+	}
+	if opts.CanaryCount < 0 {
+		opts.CanaryCount = 0
+	}
+	return &Runner{registry: registry, opts: opts}
+}
+
+// rollout tracks the shared state of a running fleet rollout.
+type rollout struct {
+	mu         sync.Mutex
+	report     *Report
+	failed     int
+	halted     bool
+	haltReason string
+}
+
+// Run executes the workflow on every device in the inventory and returns a report.
+//
+// The canary batch runs first; if any canary fails, no other device is started.
+// The remaining devices run with bounded concurrency until done or until the
+// failure budget is exceeded. Devices that were never started are reported as
+// skipped. An error is returned only if the rollout could not be set up.
+func (r *Runner) Run(ctx context.Context, wf *workflow.Workflow, inventory *Inventory) (*Report, error) {
+	if r.opts.LogDir == "" {
+		return nil, fmt.Errorf("log directory is required")
+	}
+	if err := os.MkdirAll(r.opts.LogDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+
+	out := workflow.Output(ctx)
+	total := len(inventory.Devices)
+	canaries := r.opts.CanaryCount
+	if canaries > total {
+		canaries = total
+	}
+
+	state := &rollout{
+		report: &Report{
+			Workflow:  wf.Metadata.Name,
+			StartedAt: time.Now().UTC(),
+			Devices:   make([]DeviceResult, total),
+		},
+	}
+	for i, device := range inventory.Devices {
+		state.report.Devices[i] = DeviceResult{
+			Name:    device.Name,
+			Address: device.Address,
+			Canary:  i < canaries,
+			Status:  DeviceSkipped,
+		}
+	}
+
+	fmt.Fprintf(out, "Rolling out workflow %s to %d devices (canary: %d, concurrency: %d, max failures: %.1f%%)\n",
+		wf.Metadata.Name, total, canaries, r.opts.Concurrency, r.opts.MaxFailurePercent)
+
+	if canaries > 0 {
+		fmt.Fprintf(out, "Canary batch: %d devices\n", canaries)
+		r.runBatch(ctx, wf, inventory, state, 0, canaries)
+
+		for _, result := range state.report.Devices[:canaries] {
+			if result.Status == DeviceFailed {
+				state.halt(fmt.Sprintf("canary device %s failed", result.Name))
+				break
+			}
+		}
+	}
+
+	if !state.isHalted() && canaries < total {
+		fmt.Fprintf(out, "Main batch: %d devices\n", total-canaries)
+		r.runBatch(ctx, wf, inventory, state, canaries, total)
+	}
+
+	report := state.report
+	report.FinishedAt = time.Now().UTC()
+	report.Halted = state.halted
+	report.HaltReason = state.haltReason
+	report.summarize()
+
+	fmt.Fprintf(out, "Rollout finished: %d succeeded, %d failed, %d skipped\n",
+		report.Succeeded, report.Failed, report.Skipped)
+	if report.Halted {
+		fmt.Fprintf(out, "Rollout halted: %s\n", report.HaltReason)
+	}
+
+	return report, nil
+}
+
+// runBatch runs devices [start, end) of the inventory with bounded concurrency.
+// No new device is started once the rollout is halted or ctx is done.
+func (r *Runner) runBatch(
+	ctx context.Context,
+	wf *workflow.Workflow,
+	inventory *Inventory,
+	state *rollout,
+	start, end int,
+) {
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+
+	for w := 0; w < r.opts.Concurrency && w < end-start; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				// The rollout may have halted while this index was being handed over
+				if state.isHalted() || ctx.Err() != nil {
+					continue
+				}
+				result := r.runDevice(ctx, wf, &inventory.Devices[i], state.report.Devices[i])
+				state.record(workflow.Output(ctx), i, result, r.opts.MaxFailurePercent)
+			}
+		}()
+	}
+
+	for i := start; i < end; i++ {
+		if state.isHalted() || ctx.Err() != nil {
+			break
+		}
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		state.halt(fmt.Sprintf("rollout cancelled: %v", ctx.Err()))
+	}
+}
+
+// runDevice executes the workflow on one device, writing its progress to the device's log file.
+func (r *Runner) runDevice(
+	ctx context.Context,
+	wf *workflow.Workflow,
+	device *Device,
+	result DeviceResult,
+) DeviceResult {
+	startedAt := time.Now().UTC()
+	result.StartedAt = &startedAt
+	defer func() {
+		finishedAt := time.Now().UTC()
+		result.FinishedAt = &finishedAt
+		result.Duration = finishedAt.Sub(startedAt).Round(time.Millisecond).String()
+	}()
+
+	err := r.executeOnDevice(ctx, wf, device, &result)
+	if err != nil {
+		result.Status = DeviceFailed
+		result.Error = err.Error()
+	} else {
+		result.Status = DeviceSucceeded
+	}
+	return result
+}
+
+// executeOnDevice sets up the device's log, state file and client configuration
+// and runs (or resumes) the workflow.
+func (r *Runner) executeOnDevice(ctx context.Context, wf *workflow.Workflow, device *Device, result *DeviceResult) error {
+	result.LogFile = filepath.Join(r.opts.LogDir, device.Name+".log")
+	logFile, err := os.Create(result.LogFile)
+	if err != nil {
+		return fmt.Errorf("failed to create log file: %w", err)
+	}
+	defer logFile.Close()
+
+	clientConfig, err := device.ClientConfig(r.opts.DefaultTLS)
+	if err != nil {
+		fmt.Fprintf(logFile, "Invalid device configuration: %v\n", err)
+		return err
+	}
+
+	if r.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.opts.Timeout)
+		defer cancel()
+	}
+	ctx = workflow.WithOutput(ctx, logFile)
+	ctx = workflow.WithRequestTimeout(ctx, r.opts.RequestTimeout)
+
+	stateFile := filepath.Join(r.opts.LogDir, device.Name+".state.json")
+	engine := workflow.NewEngine(r.registry).
+		WithStateFile(stateFile).
+		WithVariables(device.TemplateVariables(r.opts.Variables))
+
+	fmt.Fprintf(logFile, "Device: %s (%s)\n\n", device.Name, device.Address)
+	if r.opts.Resume {
+		if _, statErr := os.Stat(stateFile); statErr == nil {
+			err = engine.Resume(ctx, wf, clientConfig)
+		} else {
+			err = engine.Execute(ctx, wf, clientConfig)
+		}
+	} else {
+		err = engine.Execute(ctx, wf, clientConfig)
+	}
+
+	if err != nil {
+		fmt.Fprintf(logFile, "\nWorkflow failed: %v\n", err)
+	}
+	return err
+}
+
+// record stores a finished device's result, prints a progress line to out and
+// halts the rollout when the failure budget is exceeded.
+func (s *rollout) record(out io.Writer, index int, result DeviceResult, maxFailurePercent float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.report.Devices[index] = result
+	fmt.Fprintf(out, "[%s] %s (%s)\n", result.Name, result.Status, result.Duration)
+	if result.Status != DeviceFailed {
+		return
+	}
+
+	s.failed++
+	failedPercent := float64(s.failed) * 100 / float64(len(s.report.Devices))
+	if failedPercent > maxFailurePercent && !s.halted {
+		s.halted = true
+		s.haltReason = fmt.Sprintf("%d failed devices (%.1f%%) exceed the %.1f%% failure budget",
+			s.failed, failedPercent, maxFailurePercent)
+	}
+}
+
+// halt stops the rollout with the given reason, keeping the first reason recorded.
+func (s *rollout) halt(reason string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.halted {
+		s.halted = true
+		s.haltReason = reason
+	}
+}
+
+// isHalted reports whether the rollout has been halted.
+func (s *rollout) isHalted() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.halted
+}
@@ -0,0 +1,135 @@
+package fleet
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sonic-net/sonic-gnmi/sonic-gnmi-standalone/pkg/workflow"
+)
+
+// deviceStep fails when the device's "fail" variable is "true".
+type deviceStep struct{}
+
+func (s *deviceStep) Execute(ctx context.Context, client interface{}) error {
+	cfg := client.(map[string]interface{})
+	variables := cfg["variables"].(map[string]string)
+	fmt.Fprintf(workflow.Output(ctx), "running on %s\n", cfg["device"])
+	if variables["fail"] == "true" {
+		return errors.New("device failure")
+	}
+	return nil
+}
+
+func (s *deviceStep) Validate() error { return nil }
+func (s *deviceStep) GetName() string { return "device" }
+func (s *deviceStep) GetType() string { return "device" }
+
+func newTestSetup(failing ...string) (*workflow.DefaultStepRegistry, *workflow.Workflow, *Inventory) {
+	registry := workflow.NewRegistry()
+	registry.Register("device", func(name string, params map[string]interface{}) (workflow.Step, error) {
+		return &deviceStep{}, nil
+	})
+
+	wf := &workflow.Workflow{APIVersion: workflow.SupportedAPIVersion, Kind: workflow.WorkflowKind}
+	wf.Metadata.Name = "fleet-test"
+	wf.Spec.Steps = []workflow.RawStep{{Name: "step", Type: "device"}}
+
+	failSet := map[string]bool{}
+	for _, name := range failing {
+		failSet[name] = true
+	}
+
+	inventory := &Inventory{}
+	for i := 0; i < 10; i++ {
+		name := fmt.Sprintf("leaf%d", i)
+		inventory.Devices = append(inventory.Devices, Device{
+			Name:      name,
+			Address:   fmt.Sprintf("10.0.0.%d:50055", i),
+			Variables: map[string]string{"fail": fmt.Sprint(failSet[name])},
+		})
+	}
+	return registry, wf, inventory
+}
+
+func runQuietly(t *testing.T, runner *Runner, wf *workflow.Workflow, inventory *Inventory) *Report {
+	t.Helper()
+	ctx := workflow.WithOutput(context.Background(), &bytes.Buffer{})
+	report, err := runner.Run(ctx, wf, inventory)
+	require.NoError(t, err)
+	return report
+}
+
+func TestRunner_AllSucceed(t *testing.T) {
+	registry, wf, inventory := newTestSetup()
+	logDir := t.TempDir()
+
+	runner := NewRunner(registry, Options{Concurrency: 3, CanaryCount: 2, MaxFailurePercent: 10, LogDir: logDir})
+	report := runQuietly(t, runner, wf, inventory)
+
+	assert.False(t, report.Halted)
+	assert.Equal(t, 10, report.Succeeded)
+	assert.True(t, report.Devices[0].Canary)
+	assert.False(t, report.Devices[2].Canary)
+
+	log, err := os.ReadFile(filepath.Join(logDir, "leaf4.log"))
+	require.NoError(t, err)
+	assert.Contains(t, string(log), "running on leaf4")
+	assert.FileExists(t, filepath.Join(logDir, "leaf4.state.json"))
+
+	reportFile := filepath.Join(logDir, "report.json")
+	require.NoError(t, report.Save(reportFile))
+	assert.FileExists(t, reportFile)
+}
+
+func TestRunner_CanaryFailureHalts(t *testing.T) {
+	registry, wf, inventory := newTestSetup("leaf0")
+
+	runner := NewRunner(registry, Options{Concurrency: 3, CanaryCount: 1, MaxFailurePercent: 50, LogDir: t.TempDir()})
+	report := runQuietly(t, runner, wf, inventory)
+
+	assert.True(t, report.Halted)
+	assert.Equal(t, "canary device leaf0 failed", report.HaltReason)
+	assert.Equal(t, 1, report.Failed)
+	assert.Equal(t, 9, report.Skipped)
+	assert.Contains(t, report.Devices[0].Error, "device failure")
+}
+
+func TestRunner_FailureBudgetHalts(t *testing.T) {
+	registry, wf, inventory := newTestSetup("leaf1", "leaf2", "leaf3")
+
+	// Sequential execution makes the halt point deterministic
+	runner := NewRunner(registry, Options{Concurrency: 1, CanaryCount: 1, MaxFailurePercent: 15, LogDir: t.TempDir()})
+	report := runQuietly(t, runner, wf, inventory)
+
+	assert.True(t, report.Halted)
+	assert.Contains(t, report.HaltReason, "exceed the 15.0% failure budget")
+	assert.Equal(t, 1, report.Succeeded)
+	assert.Equal(t, 2, report.Failed)
+	assert.Equal(t, 7, report.Skipped)
+	assert.Equal(t, DeviceSkipped, report.Devices[3].Status)
+}
+
+func TestRunner_Resume(t *testing.T) {
+	registry, wf, inventory := newTestSetup()
+	logDir := t.TempDir()
+
+	// leaf0 already completed in a previous rollout
+	done := &workflow.ExecutionState{Workflow: "fleet-test", Status: workflow.StatusCompleted, CompletedSteps: []string{"step"}}
+	require.NoError(t, done.Save(filepath.Join(logDir, "leaf0.state.json")))
+
+	runner := NewRunner(registry, Options{Concurrency: 2, LogDir: logDir, Resume: true})
+	report := runQuietly(t, runner, wf, inventory)
+	assert.Equal(t, 10, report.Succeeded)
+
+	log, err := os.ReadFile(filepath.Join(logDir, "leaf0.log"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(log), "running on leaf0")
+}
@@ -0,0 +1,26 @@
+package workflow
+
+import (
+	"context"
+	"io"
+	"os"
+)
+
+// outputKey is the context key under which the progress writer is stored.
+type outputKey struct{}
+
+// WithOutput returns a context whose workflow progress messages are written to w.
+// This lets callers running several workflows concurrently, such as the fleet
+// runner, send each run's output to its own log.
+func WithOutput(ctx context.Context, w io.Writer) context.Context {
+	return context.WithValue(ctx, outputKey{}, w)
+}
+
+// Output returns the writer that the engine and steps use for progress messages.
+// It defaults to os.Stdout when none is set on the context.
+func Output(ctx context.Context) io.Writer {
+	if w, ok := ctx.Value(outputKey{}).(io.Writer); ok {
+		return w
+	}
+	return os.Stdout
+}
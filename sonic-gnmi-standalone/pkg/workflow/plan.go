@@ -0,0 +1,77 @@
+package workflow
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Plan is the resolved form of a workflow, as produced by Engine.Plan.
+type Plan struct {
+	Workflow  string
+	Steps     []PlannedStep
+	OnFailure []PlannedStep
+}
+
+// PlannedStep describes what Execute would do for a single step.
+type PlannedStep struct {
+	// Name and Type identify the step.
+	Name string
+	Type string
+
+	// Skipped is true when the step's `when` condition is false.
+	Skipped bool
+
+	// Params are the step params after template resolution.
+	Params map[string]interface{}
+
+	// Action describes the operation the step would perform.
+	Action string
+
+	// Rollback is the planned rollback action, if the step defines one.
+	Rollback *PlannedStep
+
+	// Error is set when the step cannot be resolved, created or validated.
+	Error string
+}
+
+// Print writes a human-readable rendering of the plan to w.
+func (p *Plan) Print(w io.Writer) {
+	fmt.Fprintf(w, "Plan for workflow: %s\n", p.Workflow)
+	fmt.Fprintf(w, "Steps: %d\n\n", len(p.Steps))
+
+	for i, step := range p.Steps {
+		fmt.Fprintf(w, "Step %d/%d: %s\n", i+1, len(p.Steps), step.Name)
+		step.print(w)
+	}
+
+	for _, step := range p.OnFailure {
+		fmt.Fprintf(w, "OnFailure: %s\n", step.Name)
+		step.print(w)
+	}
+}
+
+// print writes the details of a planned step to w.
+func (s *PlannedStep) print(w io.Writer) {
+	fmt.Fprintf(w, "  Type: %s\n", s.Type)
+	switch {
+	case s.Error != "":
+		fmt.Fprintf(w, "  ✗ %s\n", s.Error)
+	case s.Skipped:
+		fmt.Fprintf(w, "  - Skipped (when condition is false)\n")
+	default:
+		fmt.Fprintf(w, "  Action: %s\n", s.Action)
+		keys := make([]string, 0, len(s.Params))
+		for key := range s.Params {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Fprintf(w, "    %s: %v\n", key, s.Params[key])
+		}
+		if s.Rollback != nil {
+			fmt.Fprintf(w, "  Rollback: %s\n", s.Rollback.Action)
+		}
+	}
+	fmt.Fprintln(w)
+}
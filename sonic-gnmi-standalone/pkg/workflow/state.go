@@ -0,0 +1,129 @@
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ExecutionStatus describes the overall state of a workflow run.
+type ExecutionStatus string
+
+const (
+	// StatusRunning means steps are still being executed, or the agent died mid-run.
+	StatusRunning ExecutionStatus = "running"
+
+	// StatusCompleted means every step finished successfully.
+	StatusCompleted ExecutionStatus = "completed"
+
+	// StatusFailed means a step failed and rollback/onFailure handling has run.
+	StatusFailed ExecutionStatus = "failed"
+
+	// StatusInterrupted means the run was stopped by context cancellation, or by
+	// an RPC that timed out or was cancelled.
+	// No rollback is performed, so the run can be resumed.
+	StatusInterrupted ExecutionStatus = "interrupted"
+)
+
+// ExecutionState is the persisted progress of a workflow run.
+// It is written to the engine's state file after every step so that
+// an interrupted run can be continued with Engine.Resume.
+type ExecutionState struct {
+	// Workflow is the metadata.name of the workflow this state belongs to.
+	Workflow string `json:"workflow"`
+
+	// Status is the overall status of the run.
+	Status ExecutionStatus `json:"status"`
+
+	// CompletedSteps lists the names of the steps that finished successfully, in order.
+	// Steps skipped by their `when` condition are included.
+	CompletedSteps []string `json:"completedSteps"`
+
+	// SkippedSteps lists the completed steps that were skipped by their `when` condition.
+	// Their rollback actions are not run.
+	SkippedSteps []string `json:"skippedSteps,omitempty"`
+
+	// FailedStep is the name of the step that failed or was interrupted, if any.
+	FailedStep string `json:"failedStep,omitempty"`
+
+	// Error is the error message of the failed or interrupted step, if any.
+	Error string `json:"error,omitempty"`
+
+	// UpdatedAt is the time the state was last written.
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// LoadState reads a previously saved execution state from path.
+func LoadState(path string) (*ExecutionState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("state file '%s' does not exist", path)
+		}
+		return nil, fmt.Errorf("failed to read state file '%s': %w", path, err)
+	}
+
+	var state ExecutionState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse state file '%s': %w", path, err)
+	}
+
+	return &state, nil
+}
+
+// Save writes the execution state to path.
+// The file is written to a temporary name and renamed so that a crash
+// during the write never leaves a truncated state file behind.
+func (s *ExecutionState) Save(path string) error {
+	s.UpdatedAt = time.Now().UTC()
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode state: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write state file '%s': %w", tmpPath, err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to replace state file '%s': %w", path, err)
+	}
+
+	return nil
+}
+
+// checkResumable verifies that the saved state belongs to the given workflow
+// and that its completed steps are still a prefix of the workflow's steps.
+func (s *ExecutionState) checkResumable(workflow *Workflow) error {
+	if s.Workflow != workflow.Metadata.Name {
+		return fmt.Errorf("state belongs to workflow '%s', not '%s'", s.Workflow, workflow.Metadata.Name)
+	}
+
+	switch s.Status {
+	case StatusRunning, StatusInterrupted, StatusCompleted:
+	case StatusFailed:
+		return fmt.Errorf("previous run failed at step '%s' and was rolled back; start a new run instead", s.FailedStep)
+	default:
+		return fmt.Errorf("unknown state status '%s'", s.Status)
+	}
+
+	steps := workflow.Spec.Steps
+	if len(s.CompletedSteps) > len(steps) {
+		return fmt.Errorf("state has %d completed steps but workflow only defines %d", len(s.CompletedSteps), len(steps))
+	}
+	for i, name := range s.CompletedSteps {
+		if steps[i].Name != name {
+			return fmt.Errorf("completed step %d is '%s' but workflow defines '%s'", i+1, name, steps[i].Name)
+		}
+	}
+
+	return nil
+}
@@ -8,7 +8,6 @@ import (
 	"regexp"
 	"strings"
 
-	"github.com/sonic-net/sonic-gnmi/sonic-gnmi-standalone/pkg/client/config"
 	"github.com/sonic-net/sonic-gnmi/sonic-gnmi-standalone/pkg/client/gnoi"
 	"github.com/sonic-net/sonic-gnmi/sonic-gnmi-standalone/pkg/workflow"
 )
@@ -100,10 +99,9 @@ func NewDownloadStep(name string, params map[string]interface{}) (workflow.Step,
 		}
 	}
 
-	if activate, exists := params["activate"]; exists {
-		if step.Activate, ok = activate.(bool); !ok {
-			return nil, fmt.Errorf("activate parameter must be a boolean")
-		}
+	var err error
+	if step.Activate, err = boolParam(params, "activate", false); err != nil {
+		return nil, err
 	}
 
 	return step, nil
@@ -119,6 +117,15 @@ func (s *DownloadStep) GetType() string {
 	return DownloadStepType
 }
 
+// Describe returns a one-line summary of the download for workflow plans.
+func (s *DownloadStep) Describe() string {
+	desc := fmt.Sprintf("System.SetPackage: download %s to %s (md5 %s", s.URL, s.Filename, s.MD5)
+	if s.Version != "" {
+		desc += ", version " + s.Version
+	}
+	return desc + fmt.Sprintf(", activate %v)", s.Activate)
+}
+
 // Validate performs comprehensive validation of the download step parameters.
 //
 // Validation includes:
@@ -166,7 +173,7 @@ func (s *DownloadStep) Validate() error {
 //	}
 func (s *DownloadStep) Execute(ctx context.Context, client interface{}) error {
 	// Extract client configuration
-	clientConfig, err := s.extractClientConfig(client)
+	clientConfig, err := extractClientConfig(client)
 	if err != nil {
 		return fmt.Errorf("invalid client configuration: %w", err)
 	}
@@ -188,13 +195,15 @@ func (s *DownloadStep) Execute(ctx context.Context, client interface{}) error {
 	}
 
 	// Execute the download and installation
-	fmt.Printf("  Downloading: %s -> %s\n", s.URL, s.Filename)
+	fmt.Fprintf(workflow.Output(ctx), "  Downloading: %s -> %s\n", s.URL, s.Filename)
 	if s.Version != "" {
-		fmt.Printf("  Version: %s\n", s.Version)
+		fmt.Fprintf(workflow.Output(ctx), "  Version: %s\n", s.Version)
 	}
-	fmt.Printf("  Activate: %v\n", s.Activate)
+	fmt.Fprintf(workflow.Output(ctx), "  Activate: %v\n", s.Activate)
 
-	if err := gnoiClient.SetPackage(ctx, params); err != nil {
+	reqCtx, cancel := workflow.RequestContext(ctx)
+	defer cancel()
+	if err := gnoiClient.SetPackage(reqCtx, params); err != nil {
 		return fmt.Errorf("package download and installation failed: %w", err)
 	}
 
@@ -251,29 +260,3 @@ func (s *DownloadStep) validateMD5() error {
 
 	return nil
 }
-
-// extractClientConfig extracts gNOI client configuration from the generic client interface.
-// This method expects the client to be a struct with ServerAddr and UseTLS fields.
-func (s *DownloadStep) extractClientConfig(client interface{}) (*config.Config, error) {
-	// Use type assertion to extract configuration
-	// This is a simplified approach - in a production system, you might want
-	// to define a proper interface for client configuration
-	switch c := client.(type) {
-	case map[string]interface{}:
-		// Handle configuration passed as a map
-		serverAddr, ok := c["server_addr"].(string)
-		if !ok || serverAddr == "" {
-			return nil, fmt.Errorf("server_addr is required in client configuration")
-		}
-
-		useTLS, _ := c["use_tls"].(bool) // defaults to false if not present
-
-		return &config.Config{
-			Address: serverAddr,
-			TLS:     useTLS,
-		}, nil
-
-	default:
-		return nil, fmt.Errorf("unsupported client configuration type: %T", client)
-	}
-}
@@ -0,0 +1,74 @@
+package steps
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/openconfig/gnoi/os"
+	"github.com/openconfig/gnoi/system"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+// fakeDevice is a gNOI System and OS server with canned responses, for step tests.
+type fakeDevice struct {
+	system.UnimplementedSystemServer
+	os.UnimplementedOSServer
+
+	mu            sync.Mutex
+	rebootReq     *system.RebootRequest
+	rebootErr     error
+	statusReplies []*system.RebootStatusResponse // returned in order, the last one repeats
+	statusErr     error
+	statusCalls   int
+	verifyResp    *os.VerifyResponse
+}
+
+func (d *fakeDevice) Reboot(ctx context.Context, req *system.RebootRequest) (*system.RebootResponse, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.rebootReq = req
+	if d.rebootErr != nil {
+		return nil, d.rebootErr
+	}
+	return &system.RebootResponse{}, nil
+}
+
+func (d *fakeDevice) RebootStatus(ctx context.Context, req *system.RebootStatusRequest) (*system.RebootStatusResponse, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.statusCalls++
+	if d.statusErr != nil {
+		return nil, d.statusErr
+	}
+	if len(d.statusReplies) == 0 {
+		return &system.RebootStatusResponse{}, nil
+	}
+	resp := d.statusReplies[0]
+	if len(d.statusReplies) > 1 {
+		d.statusReplies = d.statusReplies[1:]
+	}
+	return resp, nil
+}
+
+func (d *fakeDevice) Verify(ctx context.Context, req *os.VerifyRequest) (*os.VerifyResponse, error) {
+	return d.verifyResp, nil
+}
+
+// startFakeDevice serves d on a local port and returns the client
+// configuration of the steps to reach it.
+func startFakeDevice(t *testing.T, d *fakeDevice) map[string]interface{} {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	srv := grpc.NewServer()
+	system.RegisterSystemServer(srv, d)
+	os.RegisterOSServer(srv, d)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	return map[string]interface{}{"server_addr": lis.Addr().String()}
+}
@@ -0,0 +1,335 @@
+package steps
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sonic-net/sonic-gnmi/sonic-gnmi-standalone/pkg/client/gnmi"
+	"github.com/sonic-net/sonic-gnmi/sonic-gnmi-standalone/pkg/workflow"
+)
+
+const (
+	// HealthGateStepType is the step type identifier for health checks.
+	HealthGateStepType = "health-gate"
+
+	// DefaultHealthGateInterval is the default interval between evaluations.
+	DefaultHealthGateInterval = 15 * time.Second
+)
+
+// supportedOperators lists the comparison operators accepted in health checks.
+var supportedOperators = map[string]bool{
+	"==": true, "!=": true, "<": true, "<=": true, ">": true, ">=": true,
+}
+
+// HealthCheck is a single threshold evaluated by a HealthGateStep.
+type HealthCheck struct {
+	// Target is the gNMI prefix target (e.g. "COUNTERS_DB"), overriding the step's target
+	Target string
+
+	// Path is the gNMI path to read
+	Path string
+
+	// Field is an optional dot-separated key into a JSON value (e.g. "SAI_PORT_STAT_IF_IN_ERRORS")
+	Field string
+
+	// Op is the comparison operator: ==, !=, <, <=, >, >=
+	Op string
+
+	// Value is the threshold the actual value is compared against
+	Value interface{}
+}
+
+// HealthGateStep reads values with gNMI Get and compares them against thresholds.
+//
+// All checks must pass for the step to succeed. With a timeout, the checks are
+// re-evaluated every interval until they all pass or the timeout expires, which
+// allows waiting for a device to settle after a reboot.
+//
+// YAML configuration example:
+//
+//	steps:
+//	  - name: check-health
+//	    type: health-gate
+//	    params:
+//	      target: COUNTERS_DB     # optional default target for all checks
+//	      timeout: "5m"           # optional, default 0 (evaluate once)
+//	      interval: "15s"         # optional, default 15s
+//	      checks:
+//	        - path: "COUNTERS/Ethernet0"
+//	          field: "SAI_PORT_STAT_IF_IN_ERRORS"
+//	          op: "<="
+//	          value: 100
+//	        - target: STATE_DB
+//	          path: "PORT_TABLE/Ethernet0"
+//	          field: "oper_status"
+//	          value: "up"         # op defaults to ==
+//
+// Values are compared numerically when both sides are numbers, and as strings otherwise.
+// Ordering operators (<, <=, >, >=) require numeric values.
+type HealthGateStep struct {
+	name     string
+	Target   string
+	Timeout  time.Duration
+	Interval time.Duration
+	Checks   []HealthCheck
+}
+
+// NewHealthGateStep creates a new health-gate step from raw YAML parameters.
+// This function serves as the factory function for the health-gate step type.
+func NewHealthGateStep(name string, params map[string]interface{}) (workflow.Step, error) {
+	step := &HealthGateStep{name: name}
+
+	var err error
+	if step.Target, err = stringParam(params, "target", ""); err != nil {
+		return nil, err
+	}
+	if step.Timeout, err = durationParam(params, "timeout", 0); err != nil {
+		return nil, err
+	}
+	if step.Interval, err = durationParam(params, "interval", DefaultHealthGateInterval); err != nil {
+		return nil, err
+	}
+
+	rawChecks, ok := params["checks"].([]interface{})
+	if !ok || len(rawChecks) == 0 {
+		return nil, fmt.Errorf("checks parameter is required and must be a non-empty list")
+	}
+
+	for i, rawCheck := range rawChecks {
+		checkParams, ok := rawCheck.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("checks[%d] must be a mapping", i)
+		}
+
+		check := HealthCheck{Value: checkParams["value"]}
+		if check.Target, err = stringParam(checkParams, "target", step.Target); err != nil {
+			return nil, fmt.Errorf("checks[%d]: %w", i, err)
+		}
+		if check.Path, err = stringParam(checkParams, "path", ""); err != nil {
+			return nil, fmt.Errorf("checks[%d]: %w", i, err)
+		}
+		if check.Field, err = stringParam(checkParams, "field", ""); err != nil {
+			return nil, fmt.Errorf("checks[%d]: %w", i, err)
+		}
+		if check.Op, err = stringParam(checkParams, "op", "=="); err != nil {
+			return nil, fmt.Errorf("checks[%d]: %w", i, err)
+		}
+		step.Checks = append(step.Checks, check)
+	}
+
+	return step, nil
+}
+
+// GetName returns the human-readable name of this step.
+func (s *HealthGateStep) GetName() string {
+	return s.name
+}
+
+// GetType returns the step type identifier.
+func (s *HealthGateStep) GetType() string {
+	return HealthGateStepType
+}
+
+// Describe returns a one-line summary of the checks for workflow plans.
+func (s *HealthGateStep) Describe() string {
+	checks := make([]string, 0, len(s.Checks))
+	for _, check := range s.Checks {
+		checks = append(checks, fmt.Sprintf("%s %s %v", check.describe(), check.Op, check.Value))
+	}
+	return fmt.Sprintf("gNMI Get, require all of [%s] within %v", strings.Join(checks, ", "), s.Timeout)
+}
+
+// Validate checks the timing parameters and that every check is complete.
+func (s *HealthGateStep) Validate() error {
+	if s.Timeout < 0 {
+		return fmt.Errorf("timeout must not be negative, got: %v", s.Timeout)
+	}
+	if s.Interval <= 0 {
+		return fmt.Errorf("interval must be positive, got: %v", s.Interval)
+	}
+	if len(s.Checks) == 0 {
+		return fmt.Errorf("at least one check is required")
+	}
+
+	for i, check := range s.Checks {
+		if check.Path == "" {
+			return fmt.Errorf("checks[%d]: path is required", i)
+		}
+		if !supportedOperators[check.Op] {
+			return fmt.Errorf("checks[%d]: unsupported operator '%s'", i, check.Op)
+		}
+		if check.Value == nil {
+			return fmt.Errorf("checks[%d]: value is required", i)
+		}
+		if _, err := gnmi.ParsePath(check.Path); err != nil {
+			return fmt.Errorf("checks[%d]: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// Execute evaluates all checks, retrying until they pass or the timeout expires.
+func (s *HealthGateStep) Execute(ctx context.Context, client interface{}) error {
+	clientConfig, err := extractClientConfig(client)
+	if err != nil {
+		return fmt.Errorf("invalid client configuration: %w", err)
+	}
+
+	gnmiClient, err := gnmi.NewClient(clientConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create gNMI client: %w", err)
+	}
+	defer gnmiClient.Close()
+
+	deadline := time.Now().Add(s.Timeout)
+	for {
+		failures := s.evaluate(ctx, gnmiClient)
+		if len(failures) == 0 {
+			fmt.Fprintf(workflow.Output(ctx), "  All %d health checks passed\n", len(s.Checks))
+			return nil
+		}
+		for _, failure := range failures {
+			fmt.Fprintf(workflow.Output(ctx), "  Check failed: %s\n", failure)
+		}
+
+		if time.Now().Add(s.Interval).After(deadline) {
+			return fmt.Errorf("%d of %d health checks failed: %s",
+				len(failures), len(s.Checks), strings.Join(failures, "; "))
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(s.Interval):
+		}
+	}
+}
+
+// evaluate runs every check once and returns a description of each failure.
+func (s *HealthGateStep) evaluate(ctx context.Context, gnmiClient *gnmi.Client) []string {
+	var failures []string
+	for _, check := range s.Checks {
+		reqCtx, cancel := workflow.RequestContext(ctx)
+		value, err := gnmiClient.Get(reqCtx, check.Target, check.Path)
+		cancel()
+		if err == nil {
+			err = check.Evaluate(value)
+		}
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", check.describe(), err))
+		}
+	}
+	return failures
+}
+
+// Evaluate compares the value read from the device against the check's threshold.
+// If Field is set, value must be a JSON object containing that field.
+func (c *HealthCheck) Evaluate(value interface{}) error {
+	actual, err := lookupField(value, c.Field)
+	if err != nil {
+		return err
+	}
+
+	actualNum, actualIsNum := toFloat(actual)
+	expectedNum, expectedIsNum := toFloat(c.Value)
+
+	var passed bool
+	if actualIsNum && expectedIsNum {
+		passed = compareNumbers(actualNum, c.Op, expectedNum)
+	} else {
+		actualStr := fmt.Sprint(actual)
+		expectedStr := fmt.Sprint(c.Value)
+		switch c.Op {
+		case "==":
+			passed = actualStr == expectedStr
+		case "!=":
+			passed = actualStr != expectedStr
+		default:
+			return fmt.Errorf("operator '%s' requires numeric values, got '%s' and '%s'", c.Op, actualStr, expectedStr)
+		}
+	}
+
+	if !passed {
+		return fmt.Errorf("got %v, want %s %v", actual, c.Op, c.Value)
+	}
+	return nil
+}
+
+// describe returns a short human-readable identifier of the check.
+func (c *HealthCheck) describe() string {
+	desc := c.Path
+	if c.Target != "" {
+		desc = c.Target + ":" + desc
+	}
+	if c.Field != "" {
+		desc += "#" + c.Field
+	}
+	return desc
+}
+
+// lookupField follows a dot-separated field path through nested JSON objects.
+func lookupField(value interface{}, field string) (interface{}, error) {
+	if field == "" {
+		return value, nil
+	}
+
+	current := value
+	for _, key := range strings.Split(field, ".") {
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("cannot look up field '%s' in non-object value", key)
+		}
+		if current, ok = object[key]; !ok {
+			return nil, fmt.Errorf("field '%s' not found", key)
+		}
+	}
+	return current, nil
+}
+
+// toFloat converts numeric values (and numeric strings, as SONiC DBs store all
+// values as strings) to float64.
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float64:
+		return v, true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// compareNumbers applies op to actual and expected.
+func compareNumbers(actual float64, op string, expected float64) bool {
+	switch op {
+	case "==":
+		return actual == expected
+	case "!=":
+		return actual != expected
+	case "<":
+		return actual < expected
+	case "<=":
+		return actual <= expected
+	case ">":
+		return actual > expected
+	case ">=":
+		return actual >= expected
+	default:
+		return false
+	}
+}
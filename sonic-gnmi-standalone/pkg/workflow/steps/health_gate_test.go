@@ -0,0 +1,115 @@
+package steps
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHealthGateStep(t *testing.T) {
+	params := map[string]interface{}{
+		"target":  "COUNTERS_DB",
+		"timeout": "2m",
+		"checks": []interface{}{
+			map[string]interface{}{
+				"path":  "COUNTERS/Ethernet0",
+				"field": "SAI_PORT_STAT_IF_IN_ERRORS",
+				"op":    "<=",
+				"value": 100,
+			},
+			map[string]interface{}{
+				"target": "STATE_DB",
+				"path":   "PORT_TABLE/Ethernet0",
+				"field":  "oper_status",
+				"value":  "up",
+			},
+		},
+	}
+
+	step, err := NewHealthGateStep("health", params)
+	require.NoError(t, err)
+	require.NoError(t, step.Validate())
+
+	gate := step.(*HealthGateStep)
+	assert.Equal(t, 2*time.Minute, gate.Timeout)
+	assert.Equal(t, DefaultHealthGateInterval, gate.Interval)
+	require.Len(t, gate.Checks, 2)
+	assert.Equal(t, "COUNTERS_DB", gate.Checks[0].Target)
+	assert.Equal(t, "STATE_DB", gate.Checks[1].Target)
+	assert.Equal(t, "==", gate.Checks[1].Op)
+}
+
+func TestNewHealthGateStep_Invalid(t *testing.T) {
+	_, err := NewHealthGateStep("health", map[string]interface{}{})
+	assert.Error(t, err)
+
+	_, err = NewHealthGateStep("health", map[string]interface{}{"checks": []interface{}{"bad"}})
+	assert.Error(t, err)
+
+	step, err := NewHealthGateStep("health", map[string]interface{}{
+		"checks": []interface{}{map[string]interface{}{"path": "a/b", "op": "~", "value": 1}},
+	})
+	require.NoError(t, err)
+	assert.EqualError(t, step.Validate(), "checks[0]: unsupported operator '~'")
+
+	step, err = NewHealthGateStep("health", map[string]interface{}{
+		"checks": []interface{}{map[string]interface{}{"path": "a/b"}},
+	})
+	require.NoError(t, err)
+	assert.EqualError(t, step.Validate(), "checks[0]: value is required")
+}
+
+func TestHealthCheck_Evaluate(t *testing.T) {
+	counters := map[string]interface{}{
+		"SAI_PORT_STAT_IF_IN_ERRORS": "42",
+		"nested":                     map[string]interface{}{"count": json.Number("7")},
+		"oper_status":                "up",
+	}
+
+	tests := []struct {
+		name    string
+		check   HealthCheck
+		value   interface{}
+		wantErr string
+	}{
+		{"numeric string below threshold", HealthCheck{Field: "SAI_PORT_STAT_IF_IN_ERRORS", Op: "<=", Value: 100}, counters, ""},
+		{"numeric string above threshold", HealthCheck{Field: "SAI_PORT_STAT_IF_IN_ERRORS", Op: "<", Value: 10}, counters, "got 42, want < 10"},
+		{"nested field", HealthCheck{Field: "nested.count", Op: ">=", Value: 7}, counters, ""},
+		{"string equality", HealthCheck{Field: "oper_status", Op: "==", Value: "up"}, counters, ""},
+		{"string inequality", HealthCheck{Field: "oper_status", Op: "!=", Value: "up"}, counters, "got up, want != up"},
+		{"ordering on strings", HealthCheck{Field: "oper_status", Op: ">", Value: "down"}, counters, "requires numeric values"},
+		{"missing field", HealthCheck{Field: "missing", Op: "==", Value: 1}, counters, "field 'missing' not found"},
+		{"scalar value", HealthCheck{Op: "==", Value: true}, true, ""},
+		{"field on scalar", HealthCheck{Field: "x", Op: "==", Value: 1}, int64(1), "non-object value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.check.Evaluate(tt.value)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRebootStep_Validate(t *testing.T) {
+	step, err := NewRebootStep("reboot", map[string]interface{}{"method": "warm", "delay": "10s"})
+	require.NoError(t, err)
+	require.NoError(t, step.Validate())
+	assert.Equal(t, "WARM", step.(*RebootStep).Method)
+	assert.Equal(t, 10*time.Second, step.(*RebootStep).Delay)
+
+	step, err = NewRebootStep("reboot", map[string]interface{}{"method": "sideways"})
+	require.NoError(t, err)
+	assert.EqualError(t, step.Validate(), "unsupported reboot method 'SIDEWAYS'")
+
+	_, err = NewRebootStep("reboot", map[string]interface{}{"delay": 10})
+	assert.Error(t, err)
+}
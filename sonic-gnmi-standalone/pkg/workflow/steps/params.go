@@ -0,0 +1,103 @@
+package steps
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/sonic-net/sonic-gnmi/sonic-gnmi-standalone/pkg/client/config"
+)
+
+// extractClientConfig extracts gRPC client configuration from the generic client interface.
+// This function expects the client to be a map with a server_addr key and the
+// optional use_tls, username, password, ca_cert, client_cert and client_key keys.
+func extractClientConfig(client interface{}) (*config.Config, error) {
+	// Use type assertion to extract configuration
+	// This is a simplified approach - in a production system, you might want
+	// to define a proper interface for client configuration
+	switch c := client.(type) {
+	case map[string]interface{}:
+		// Handle configuration passed as a map
+		serverAddr, ok := c["server_addr"].(string)
+		if !ok || serverAddr == "" {
+			return nil, fmt.Errorf("server_addr is required in client configuration")
+		}
+
+		useTLS, _ := c["use_tls"].(bool) // defaults to false if not present
+		username, _ := c["username"].(string)
+		password, _ := c["password"].(string)
+
+		cfg := &config.Config{
+			Address:  serverAddr,
+			TLS:      useTLS,
+			Username: username,
+			Password: password,
+		}
+
+		caCert, _ := c["ca_cert"].(string)
+		clientCert, _ := c["client_cert"].(string)
+		clientKey, _ := c["client_key"].(string)
+		if useTLS && (caCert != "" || clientCert != "" || clientKey != "") {
+			tlsConfig, err := config.LoadTLSConfig(caCert, clientCert, clientKey)
+			if err != nil {
+				return nil, err
+			}
+			cfg.TLSConfig = tlsConfig
+		}
+
+		return cfg, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported client configuration type: %T", client)
+	}
+}
+
+// stringParam returns an optional string parameter, or def if it is absent.
+func stringParam(params map[string]interface{}, key, def string) (string, error) {
+	raw, exists := params[key]
+	if !exists {
+		return def, nil
+	}
+	value, ok := raw.(string)
+	if !ok {
+		return "", fmt.Errorf("%s parameter must be a string", key)
+	}
+	return value, nil
+}
+
+// boolParam returns an optional boolean parameter, or def if it is absent.
+// String forms such as "true" are accepted since templated params render to strings.
+func boolParam(params map[string]interface{}, key string, def bool) (bool, error) {
+	raw, exists := params[key]
+	if !exists {
+		return def, nil
+	}
+	switch value := raw.(type) {
+	case bool:
+		return value, nil
+	case string:
+		parsed, err := strconv.ParseBool(value)
+		if err == nil {
+			return parsed, nil
+		}
+	}
+	return false, fmt.Errorf("%s parameter must be a boolean", key)
+}
+
+// durationParam returns an optional duration parameter such as "30s" or "5m",
+// or def if it is absent.
+func durationParam(params map[string]interface{}, key string, def time.Duration) (time.Duration, error) {
+	raw, exists := params[key]
+	if !exists {
+		return def, nil
+	}
+	value, ok := raw.(string)
+	if !ok {
+		return 0, fmt.Errorf("%s parameter must be a duration string (e.g. \"30s\")", key)
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s parameter is not a valid duration: %w", key, err)
+	}
+	return duration, nil
+}
@@ -0,0 +1,221 @@
+package steps
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/openconfig/gnoi/system"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/sonic-net/sonic-gnmi/sonic-gnmi-standalone/pkg/client/gnoi"
+	"github.com/sonic-net/sonic-gnmi/sonic-gnmi-standalone/pkg/workflow"
+)
+
+const (
+	// RebootStepType is the step type identifier for reboot operations.
+	RebootStepType = "reboot"
+
+	// DefaultRebootMethod is the reboot method used when none is specified.
+	DefaultRebootMethod = "COLD"
+
+	// DefaultRebootPollInterval is the default interval between RebootStatus polls.
+	DefaultRebootPollInterval = 5 * time.Second
+
+	// DefaultRebootStatusTimeout is the default time to wait for the reboot to start.
+	DefaultRebootStatusTimeout = 5 * time.Minute
+)
+
+// RebootStep reboots the target device via gNOI System.Reboot and then polls
+// System.RebootStatus until the reboot is no longer pending.
+//
+// The step completes when RebootStatus reports no active reboot, or when the
+// device stops answering (it went down to reboot). Pair it with a
+// wait-for-reachable step to wait for the device to come back.
+//
+// YAML configuration example:
+//
+//	steps:
+//	  - name: reboot-device
+//	    type: reboot
+//	    params:
+//	      method: COLD          # optional, default COLD
+//	      delay: "10s"          # optional, default 0
+//	      message: "upgrade"    # optional
+//	      force: false          # optional, default false
+//	      pollInterval: "5s"    # optional, default 5s
+//	      statusTimeout: "5m"   # optional, default 5m
+//
+// Supported methods are the gNOI RebootMethod names: COLD, POWERDOWN, HALT, WARM, NSF, POWERUP.
+type RebootStep struct {
+	name          string
+	Method        string
+	Delay         time.Duration
+	Message       string
+	Force         bool
+	PollInterval  time.Duration
+	StatusTimeout time.Duration
+}
+
+// NewRebootStep creates a new reboot step from raw YAML parameters.
+// This function serves as the factory function for the reboot step type.
+func NewRebootStep(name string, params map[string]interface{}) (workflow.Step, error) {
+	step := &RebootStep{name: name}
+
+	var err error
+	if step.Method, err = stringParam(params, "method", DefaultRebootMethod); err != nil {
+		return nil, err
+	}
+	step.Method = strings.ToUpper(step.Method)
+	if step.Delay, err = durationParam(params, "delay", 0); err != nil {
+		return nil, err
+	}
+	if step.Message, err = stringParam(params, "message", ""); err != nil {
+		return nil, err
+	}
+	if step.Force, err = boolParam(params, "force", false); err != nil {
+		return nil, err
+	}
+	if step.PollInterval, err = durationParam(params, "pollInterval", DefaultRebootPollInterval); err != nil {
+		return nil, err
+	}
+	if step.StatusTimeout, err = durationParam(params, "statusTimeout", DefaultRebootStatusTimeout); err != nil {
+		return nil, err
+	}
+
+	return step, nil
+}
+
+// GetName returns the human-readable name of this step.
+func (s *RebootStep) GetName() string {
+	return s.name
+}
+
+// GetType returns the step type identifier.
+func (s *RebootStep) GetType() string {
+	return RebootStepType
+}
+
+// Describe returns a one-line summary of the reboot for workflow plans.
+func (s *RebootStep) Describe() string {
+	return fmt.Sprintf("System.Reboot: method %s, delay %v, force %v; then poll RebootStatus every %v for up to %v",
+		s.Method, s.Delay, s.Force, s.PollInterval, s.StatusTimeout)
+}
+
+// Validate checks the reboot method and timing parameters.
+func (s *RebootStep) Validate() error {
+	if _, err := s.rebootMethod(); err != nil {
+		return err
+	}
+	if s.Delay < 0 {
+		return fmt.Errorf("delay must not be negative, got: %v", s.Delay)
+	}
+	if s.PollInterval <= 0 {
+		return fmt.Errorf("pollInterval must be positive, got: %v", s.PollInterval)
+	}
+	if s.StatusTimeout <= 0 {
+		return fmt.Errorf("statusTimeout must be positive, got: %v", s.StatusTimeout)
+	}
+	return nil
+}
+
+// Execute issues the Reboot RPC and polls RebootStatus until the reboot
+// is no longer pending or the device becomes unreachable.
+func (s *RebootStep) Execute(ctx context.Context, client interface{}) error {
+	clientConfig, err := extractClientConfig(client)
+	if err != nil {
+		return fmt.Errorf("invalid client configuration: %w", err)
+	}
+
+	method, err := s.rebootMethod()
+	if err != nil {
+		return err
+	}
+
+	gnoiClient, err := gnoi.NewSystemClient(clientConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create gNOI client: %w", err)
+	}
+	defer gnoiClient.Close()
+
+	fmt.Fprintf(workflow.Output(ctx), "  Rebooting: method=%s delay=%v\n", s.Method, s.Delay)
+	params := &gnoi.RebootParams{
+		Method:  method,
+		Delay:   s.Delay,
+		Message: s.Message,
+		Force:   s.Force,
+	}
+	reqCtx, cancel := workflow.RequestContext(ctx)
+	err = gnoiClient.Reboot(reqCtx, params)
+	cancel()
+	if err != nil {
+		// The device may drop the connection as it goes down
+		if grpcCode(err) != codes.Unavailable {
+			return fmt.Errorf("reboot request failed: %w", err)
+		}
+		fmt.Fprintf(workflow.Output(ctx), "  Device closed the connection, reboot in progress\n")
+		return nil
+	}
+
+	return s.waitForReboot(ctx, gnoiClient)
+}
+
+// waitForReboot polls RebootStatus until the reboot is no longer active.
+func (s *RebootStep) waitForReboot(ctx context.Context, gnoiClient *gnoi.SystemClient) error {
+	ctx, cancel := context.WithTimeout(ctx, s.StatusTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(s.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		reqCtx, reqCancel := workflow.RequestContext(ctx)
+		result, err := gnoiClient.RebootStatus(reqCtx)
+		reqCancel()
+		switch {
+		case err == nil && !result.Active:
+			fmt.Fprintf(workflow.Output(ctx), "  Reboot no longer pending\n")
+			return nil
+		case err == nil:
+			fmt.Fprintf(workflow.Output(ctx), "  Reboot pending (wait=%v)\n", result.Wait)
+		case grpcCode(err) == codes.Unavailable:
+			fmt.Fprintf(workflow.Output(ctx), "  Device is down, reboot in progress\n")
+			return nil
+		case grpcCode(err) == codes.Unimplemented:
+			fmt.Fprintf(workflow.Output(ctx), "  RebootStatus not supported by device, not waiting\n")
+			return nil
+		case ctx.Err() != nil:
+			return fmt.Errorf("reboot did not start within %v: %w", s.StatusTimeout, ctx.Err())
+		default:
+			return fmt.Errorf("failed to query reboot status: %w", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("reboot did not start within %v: %w", s.StatusTimeout, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// rebootMethod converts the configured method name to a gNOI RebootMethod.
+func (s *RebootStep) rebootMethod() (system.RebootMethod, error) {
+	value, ok := system.RebootMethod_value[s.Method]
+	if !ok || system.RebootMethod(value) == system.RebootMethod_UNKNOWN {
+		return system.RebootMethod_UNKNOWN, fmt.Errorf("unsupported reboot method '%s'", s.Method)
+	}
+	return system.RebootMethod(value), nil
+}
+
+// grpcCode returns the gRPC status code of err, looking through wrapped errors.
+func grpcCode(err error) codes.Code {
+	for ; err != nil; err = errors.Unwrap(err) {
+		if st, ok := status.FromError(err); ok {
+			return st.Code()
+		}
+	}
+	return codes.Unknown
+}
@@ -0,0 +1,82 @@
+package steps
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/openconfig/gnoi/system"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/sonic-net/sonic-gnmi/sonic-gnmi-standalone/pkg/workflow"
+)
+
+func newTestRebootStep(t *testing.T, params map[string]interface{}) *RebootStep {
+	t.Helper()
+	step, err := NewRebootStep("reboot", params)
+	require.NoError(t, err)
+	require.NoError(t, step.Validate())
+	return step.(*RebootStep)
+}
+
+func TestRebootStep_Execute(t *testing.T) {
+	device := &fakeDevice{statusReplies: []*system.RebootStatusResponse{
+		{Active: true, Wait: uint64(time.Second)},
+		{Active: false},
+	}}
+	client := startFakeDevice(t, device)
+
+	step := newTestRebootStep(t, map[string]interface{}{
+		"method":       "warm",
+		"delay":        "1s",
+		"message":      "upgrade",
+		"pollInterval": "10ms",
+	})
+	ctx := workflow.WithOutput(context.Background(), io.Discard)
+	require.NoError(t, step.Execute(ctx, client))
+
+	assert.Equal(t, system.RebootMethod_WARM, device.rebootReq.GetMethod())
+	assert.Equal(t, uint64(time.Second), device.rebootReq.GetDelay())
+	assert.Equal(t, "upgrade", device.rebootReq.GetMessage())
+	assert.Equal(t, 2, device.statusCalls)
+}
+
+func TestRebootStep_ExecuteDeviceGoesDown(t *testing.T) {
+	ctx := workflow.WithOutput(context.Background(), io.Discard)
+
+	// The device drops the connection while handling Reboot
+	device := &fakeDevice{rebootErr: status.Error(codes.Unavailable, "going down")}
+	step := newTestRebootStep(t, map[string]interface{}{"pollInterval": "10ms"})
+	require.NoError(t, step.Execute(ctx, startFakeDevice(t, device)))
+	assert.Equal(t, 0, device.statusCalls)
+
+	// The device stops answering RebootStatus
+	device = &fakeDevice{statusErr: status.Error(codes.Unavailable, "down")}
+	require.NoError(t, step.Execute(ctx, startFakeDevice(t, device)))
+	assert.Equal(t, 1, device.statusCalls)
+
+	// The device does not implement RebootStatus
+	device = &fakeDevice{statusErr: status.Error(codes.Unimplemented, "no")}
+	require.NoError(t, step.Execute(ctx, startFakeDevice(t, device)))
+}
+
+func TestRebootStep_ExecuteErrors(t *testing.T) {
+	ctx := workflow.WithOutput(context.Background(), io.Discard)
+
+	device := &fakeDevice{rebootErr: status.Error(codes.PermissionDenied, "denied")}
+	step := newTestRebootStep(t, map[string]interface{}{"pollInterval": "10ms"})
+	err := step.Execute(ctx, startFakeDevice(t, device))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "reboot request failed")
+
+	// The reboot stays pending past statusTimeout
+	device = &fakeDevice{statusReplies: []*system.RebootStatusResponse{{Active: true}}}
+	step = newTestRebootStep(t, map[string]interface{}{"pollInterval": "10ms", "statusTimeout": "50ms"})
+	err = step.Execute(ctx, startFakeDevice(t, device))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "reboot did not start within 50ms")
+}
@@ -0,0 +1,97 @@
+package steps
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sonic-net/sonic-gnmi/sonic-gnmi-standalone/pkg/client/gnoi"
+	"github.com/sonic-net/sonic-gnmi/sonic-gnmi-standalone/pkg/workflow"
+)
+
+// VerifyOSStepType is the step type identifier for OS version verification.
+const VerifyOSStepType = "verify-os"
+
+// VerifyOSStep checks the running OS version via gNOI OS.Verify.
+//
+// The step fails if the running version differs from the expected one, or if
+// the device reports that the activated image failed to boot.
+//
+// YAML configuration example:
+//
+//	steps:
+//	  - name: verify-new-image
+//	    type: verify-os
+//	    params:
+//	      version: "SONiC-OS-202505.01"
+//
+// Required parameters:
+//   - version: Expected OS version string, as reported by OS.Verify
+type VerifyOSStep struct {
+	name    string
+	Version string
+}
+
+// NewVerifyOSStep creates a new verify-os step from raw YAML parameters.
+// This function serves as the factory function for the verify-os step type.
+func NewVerifyOSStep(name string, params map[string]interface{}) (workflow.Step, error) {
+	version, ok := params["version"].(string)
+	if !ok || version == "" {
+		return nil, fmt.Errorf("version parameter is required and must be a non-empty string")
+	}
+
+	return &VerifyOSStep{name: name, Version: version}, nil
+}
+
+// GetName returns the human-readable name of this step.
+func (s *VerifyOSStep) GetName() string {
+	return s.name
+}
+
+// GetType returns the step type identifier.
+func (s *VerifyOSStep) GetType() string {
+	return VerifyOSStepType
+}
+
+// Describe returns a one-line summary of the verification for workflow plans.
+func (s *VerifyOSStep) Describe() string {
+	return fmt.Sprintf("OS.Verify: expect running version %s", s.Version)
+}
+
+// Validate checks that an expected version is set.
+func (s *VerifyOSStep) Validate() error {
+	if s.Version == "" {
+		return fmt.Errorf("version cannot be empty")
+	}
+	return nil
+}
+
+// Execute calls OS.Verify and compares the running version with the expected one.
+func (s *VerifyOSStep) Execute(ctx context.Context, client interface{}) error {
+	clientConfig, err := extractClientConfig(client)
+	if err != nil {
+		return fmt.Errorf("invalid client configuration: %w", err)
+	}
+
+	gnoiClient, err := gnoi.NewOSClient(clientConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create gNOI client: %w", err)
+	}
+	defer gnoiClient.Close()
+
+	reqCtx, cancel := workflow.RequestContext(ctx)
+	defer cancel()
+	result, err := gnoiClient.Verify(reqCtx)
+	if err != nil {
+		return fmt.Errorf("OS verification failed: %w", err)
+	}
+
+	fmt.Fprintf(workflow.Output(ctx), "  Running version: %s\n", result.Version)
+	if result.ActivationFailMessage != "" {
+		return fmt.Errorf("activated OS failed to boot: %s", result.ActivationFailMessage)
+	}
+	if result.Version != s.Version {
+		return fmt.Errorf("running OS version '%s' does not match expected '%s'", result.Version, s.Version)
+	}
+
+	return nil
+}
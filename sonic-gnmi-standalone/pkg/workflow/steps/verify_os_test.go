@@ -0,0 +1,55 @@
+package steps
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/openconfig/gnoi/os"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sonic-net/sonic-gnmi/sonic-gnmi-standalone/pkg/workflow"
+)
+
+func TestNewVerifyOSStep(t *testing.T) {
+	step, err := NewVerifyOSStep("verify", map[string]interface{}{"version": "SONiC-OS-1"})
+	require.NoError(t, err)
+	require.NoError(t, step.Validate())
+	assert.Equal(t, "SONiC-OS-1", step.(*VerifyOSStep).Version)
+
+	_, err = NewVerifyOSStep("verify", map[string]interface{}{})
+	assert.Error(t, err)
+	_, err = NewVerifyOSStep("verify", map[string]interface{}{"version": 1})
+	assert.Error(t, err)
+}
+
+func TestVerifyOSStep_Execute(t *testing.T) {
+	tests := []struct {
+		name    string
+		resp    *os.VerifyResponse
+		wantErr string
+	}{
+		{"expected version", &os.VerifyResponse{Version: "SONiC-OS-1"}, ""},
+		{"other version", &os.VerifyResponse{Version: "SONiC-OS-0"}, "does not match expected 'SONiC-OS-1'"},
+		{"activation failed", &os.VerifyResponse{Version: "SONiC-OS-0", ActivationFailMessage: "kernel panic"},
+			"activated OS failed to boot: kernel panic"},
+	}
+
+	ctx := workflow.WithOutput(context.Background(), io.Discard)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := startFakeDevice(t, &fakeDevice{verifyResp: tt.resp})
+			step, err := NewVerifyOSStep("verify", map[string]interface{}{"version": "SONiC-OS-1"})
+			require.NoError(t, err)
+
+			err = step.Execute(ctx, client)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package steps
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/sonic-net/sonic-gnmi/sonic-gnmi-standalone/pkg/workflow"
+)
+
+const (
+	// WaitForReachableStepType is the step type identifier for reachability checks.
+	WaitForReachableStepType = "wait-for-reachable"
+
+	// DefaultReachableTimeout is the default overall time to wait for the device.
+	DefaultReachableTimeout = 15 * time.Minute
+
+	// DefaultReachableInterval is the default interval between connection attempts.
+	DefaultReachableInterval = 10 * time.Second
+
+	// DefaultReachableAttemptTimeout is the default timeout of a single connection attempt.
+	DefaultReachableAttemptTimeout = 5 * time.Second
+)
+
+// WaitForReachableStep retries a gRPC connection to the target until it
+// succeeds or the timeout expires. It is typically used after a reboot step.
+//
+// YAML configuration example:
+//
+//	steps:
+//	  - name: wait-for-device
+//	    type: wait-for-reachable
+//	    params:
+//	      initialDelay: "30s"    # optional, default 0
+//	      timeout: "15m"         # optional, default 15m
+//	      interval: "10s"        # optional, default 10s
+//	      attemptTimeout: "5s"   # optional, default 5s
+type WaitForReachableStep struct {
+	name           string
+	InitialDelay   time.Duration
+	Timeout        time.Duration
+	Interval       time.Duration
+	AttemptTimeout time.Duration
+}
+
+// NewWaitForReachableStep creates a new wait-for-reachable step from raw YAML parameters.
+// This function serves as the factory function for the wait-for-reachable step type.
+func NewWaitForReachableStep(name string, params map[string]interface{}) (workflow.Step, error) {
+	step := &WaitForReachableStep{name: name}
+
+	var err error
+	if step.InitialDelay, err = durationParam(params, "initialDelay", 0); err != nil {
+		return nil, err
+	}
+	if step.Timeout, err = durationParam(params, "timeout", DefaultReachableTimeout); err != nil {
+		return nil, err
+	}
+	if step.Interval, err = durationParam(params, "interval", DefaultReachableInterval); err != nil {
+		return nil, err
+	}
+	if step.AttemptTimeout, err = durationParam(params, "attemptTimeout", DefaultReachableAttemptTimeout); err != nil {
+		return nil, err
+	}
+
+	return step, nil
+}
+
+// GetName returns the human-readable name of this step.
+func (s *WaitForReachableStep) GetName() string {
+	return s.name
+}
+
+// GetType returns the step type identifier.
+func (s *WaitForReachableStep) GetType() string {
+	return WaitForReachableStepType
+}
+
+// Describe returns a one-line summary of the wait for workflow plans.
+func (s *WaitForReachableStep) Describe() string {
+	return fmt.Sprintf("wait %v, then connect every %v (attempt timeout %v) for up to %v",
+		s.InitialDelay, s.Interval, s.AttemptTimeout, s.Timeout)
+}
+
+// Validate checks that all durations are usable.
+func (s *WaitForReachableStep) Validate() error {
+	if s.InitialDelay < 0 {
+		return fmt.Errorf("initialDelay must not be negative, got: %v", s.InitialDelay)
+	}
+	if s.Timeout <= 0 {
+		return fmt.Errorf("timeout must be positive, got: %v", s.Timeout)
+	}
+	if s.Interval <= 0 {
+		return fmt.Errorf("interval must be positive, got: %v", s.Interval)
+	}
+	if s.AttemptTimeout <= 0 {
+		return fmt.Errorf("attemptTimeout must be positive, got: %v", s.AttemptTimeout)
+	}
+	return nil
+}
+
+// Execute attempts to establish a gRPC connection until one succeeds.
+func (s *WaitForReachableStep) Execute(ctx context.Context, client interface{}) error {
+	clientConfig, err := extractClientConfig(client)
+	if err != nil {
+		return fmt.Errorf("invalid client configuration: %w", err)
+	}
+
+	if s.InitialDelay > 0 {
+		fmt.Fprintf(workflow.Output(ctx), "  Waiting %v before first attempt\n", s.InitialDelay)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(s.InitialDelay):
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.Timeout)
+	defer cancel()
+
+	opts := append(clientConfig.DialOptions(), grpc.WithBlock())
+	for attempt := 1; ; attempt++ {
+		attemptCtx, attemptCancel := context.WithTimeout(ctx, s.AttemptTimeout)
+		conn, err := grpc.DialContext(attemptCtx, clientConfig.Address, opts...)
+		attemptCancel()
+		if err == nil {
+			conn.Close()
+			fmt.Fprintf(workflow.Output(ctx), "  %s is reachable (attempt %d)\n", clientConfig.Address, attempt)
+			return nil
+		}
+		fmt.Fprintf(workflow.Output(ctx), "  Attempt %d: %s not reachable yet\n", attempt, clientConfig.Address)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s not reachable within %v", clientConfig.Address, s.Timeout)
+		case <-time.After(s.Interval):
+		}
+	}
+}
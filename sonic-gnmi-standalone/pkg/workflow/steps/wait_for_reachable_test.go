@@ -0,0 +1,84 @@
+package steps
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"github.com/sonic-net/sonic-gnmi/sonic-gnmi-standalone/pkg/workflow"
+)
+
+func TestNewWaitForReachableStep(t *testing.T) {
+	step, err := NewWaitForReachableStep("wait", map[string]interface{}{})
+	require.NoError(t, err)
+	require.NoError(t, step.Validate())
+	wait := step.(*WaitForReachableStep)
+	assert.Equal(t, DefaultReachableTimeout, wait.Timeout)
+	assert.Equal(t, DefaultReachableInterval, wait.Interval)
+	assert.Equal(t, DefaultReachableAttemptTimeout, wait.AttemptTimeout)
+
+	step, err = NewWaitForReachableStep("wait", map[string]interface{}{"interval": "0s"})
+	require.NoError(t, err)
+	assert.EqualError(t, step.Validate(), "interval must be positive, got: 0s")
+
+	_, err = NewWaitForReachableStep("wait", map[string]interface{}{"timeout": "soon"})
+	assert.Error(t, err)
+}
+
+func TestWaitForReachableStep_Execute(t *testing.T) {
+	ctx := workflow.WithOutput(context.Background(), io.Discard)
+	client := startFakeDevice(t, &fakeDevice{})
+
+	step, err := NewWaitForReachableStep("wait", map[string]interface{}{
+		"timeout": "5s", "interval": "10ms", "attemptTimeout": "1s",
+	})
+	require.NoError(t, err)
+	assert.NoError(t, step.Execute(ctx, client))
+}
+
+func TestWaitForReachableStep_ExecuteComesUp(t *testing.T) {
+	ctx := workflow.WithOutput(context.Background(), io.Discard)
+
+	// Reserve a port, and only start serving on it after a few attempts
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := lis.Addr().String()
+	lis.Close()
+
+	srv := grpc.NewServer()
+	t.Cleanup(srv.Stop)
+	time.AfterFunc(200*time.Millisecond, func() {
+		if lis, err := net.Listen("tcp", addr); err == nil {
+			srv.Serve(lis)
+		}
+	})
+
+	step, err := NewWaitForReachableStep("wait", map[string]interface{}{
+		"timeout": "5s", "interval": "50ms", "attemptTimeout": "100ms",
+	})
+	require.NoError(t, err)
+	assert.NoError(t, step.Execute(ctx, map[string]interface{}{"server_addr": addr}))
+}
+
+func TestWaitForReachableStep_ExecuteTimeout(t *testing.T) {
+	ctx := workflow.WithOutput(context.Background(), io.Discard)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := lis.Addr().String()
+	lis.Close()
+
+	step, err := NewWaitForReachableStep("wait", map[string]interface{}{
+		"timeout": "200ms", "interval": "20ms", "attemptTimeout": "50ms",
+	})
+	require.NoError(t, err)
+	err = step.Execute(ctx, map[string]interface{}{"server_addr": addr})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not reachable within 200ms")
+}
@@ -0,0 +1,120 @@
+package workflow
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+)
+
+// templateFuncs are the functions available to workflow templates in addition
+// to the text/template builtins (eq, ne, lt, and, or, not, printf, ...).
+var templateFuncs = template.FuncMap{
+	// env returns the value of an environment variable, or "" if it is unset.
+	"env": os.Getenv,
+	// hasPrefix and hasSuffix allow conditions on version strings and device names.
+	"hasPrefix": strings.HasPrefix,
+	"hasSuffix": strings.HasSuffix,
+}
+
+// falseValues are the rendered `when` results that skip a step.
+var falseValues = map[string]bool{
+	"":      true,
+	"false": true,
+	"0":     true,
+	"no":    true,
+}
+
+// renderTemplate expands a Go text/template string against vars.
+// Referencing an undefined variable is an error.
+func renderTemplate(text string, vars map[string]string) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+
+	tmpl, err := template.New("").Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid template '%s': %w", text, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, vars); err != nil {
+		return "", fmt.Errorf("failed to render template '%s': %w", text, err)
+	}
+	return buf.String(), nil
+}
+
+// resolveParams returns a copy of params with every string value rendered as a
+// template. Nested maps and lists are resolved recursively; other values are
+// copied unchanged. Rendered values are always strings, so step factories
+// accept string forms of numbers and booleans.
+func resolveParams(params map[string]interface{}, vars map[string]string) (map[string]interface{}, error) {
+	if params == nil {
+		return nil, nil
+	}
+
+	resolved := make(map[string]interface{}, len(params))
+	for key, value := range params {
+		v, err := resolveValue(value, vars)
+		if err != nil {
+			return nil, fmt.Errorf("param '%s': %w", key, err)
+		}
+		resolved[key] = v
+	}
+	return resolved, nil
+}
+
+// resolveValue renders templates inside a single parameter value.
+func resolveValue(value interface{}, vars map[string]string) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		return renderTemplate(v, vars)
+	case map[string]interface{}:
+		return resolveParams(v, vars)
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			resolved, err := resolveValue(item, vars)
+			if err != nil {
+				return nil, fmt.Errorf("[%d]: %w", i, err)
+			}
+			list[i] = resolved
+		}
+		return list, nil
+	default:
+		return value, nil
+	}
+}
+
+// evaluateWhen reports whether a step with the given `when` condition should run.
+//
+// An empty condition always runs. The condition is a template expression, with
+// or without surrounding braces, e.g. `eq .role "leaf"` or `{{ ne .version "" }}`.
+// The step is skipped if the rendered result is empty, "false", "0" or "no".
+func evaluateWhen(when string, vars map[string]string) (bool, error) {
+	when = strings.TrimSpace(when)
+	if when == "" {
+		return true, nil
+	}
+	if !strings.Contains(when, "{{") {
+		when = "{{ " + when + " }}"
+	}
+
+	result, err := renderTemplate(when, vars)
+	if err != nil {
+		return false, fmt.Errorf("invalid when condition: %w", err)
+	}
+	return !falseValues[strings.ToLower(strings.TrimSpace(result))], nil
+}
+
+// mergeVariables overlays the given variable maps in order, later maps taking precedence.
+func mergeVariables(maps ...map[string]string) map[string]string {
+	merged := make(map[string]string)
+	for _, m := range maps {
+		for k, v := range m {
+			merged[k] = v
+		}
+	}
+	return merged
+}
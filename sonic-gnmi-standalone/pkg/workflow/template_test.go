@@ -0,0 +1,70 @@
+package workflow
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveParams(t *testing.T) {
+	t.Setenv("WORKFLOW_TEST_MIRROR", "mirror.example.com")
+	vars := map[string]string{"version": "202505", "device": "leaf1"}
+
+	params := map[string]interface{}{
+		"url":      `http://{{ env "WORKFLOW_TEST_MIRROR" }}/sonic-{{ .version }}.bin`,
+		"activate": true,
+		"checks": []interface{}{
+			map[string]interface{}{"path": "PORT_TABLE/{{ .device }}", "value": 1},
+		},
+	}
+
+	resolved, err := resolveParams(params, vars)
+	require.NoError(t, err)
+	assert.Equal(t, "http://mirror.example.com/sonic-202505.bin", resolved["url"])
+	assert.Equal(t, true, resolved["activate"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"path": "PORT_TABLE/leaf1", "value": 1},
+	}, resolved["checks"])
+
+	// The original params are not modified
+	assert.Equal(t, "PORT_TABLE/{{ .device }}", params["checks"].([]interface{})[0].(map[string]interface{})["path"])
+}
+
+func TestResolveParams_MissingVariable(t *testing.T) {
+	_, err := resolveParams(map[string]interface{}{"url": "{{ .missing }}"}, map[string]string{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "param 'url'")
+	assert.Contains(t, err.Error(), "missing")
+}
+
+func TestEvaluateWhen(t *testing.T) {
+	vars := map[string]string{"role": "leaf", "enabled": "false", "version": "202505.01"}
+
+	tests := []struct {
+		when    string
+		want    bool
+		wantErr bool
+	}{
+		{"", true, false},
+		{`eq .role "leaf"`, true, false},
+		{`{{ eq .role "spine" }}`, false, false},
+		{"{{ .enabled }}", false, false},
+		{`hasPrefix .version "2025"`, true, false},
+		{`and (eq .role "leaf") (ne .enabled "true")`, true, false},
+		{"{{ .undefined }}", false, true},
+		{"eq .role", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.when, func(t *testing.T) {
+			got, err := evaluateWhen(tt.when, vars)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package workflow
+
+import (
+	"context"
+	"time"
+)
+
+// requestTimeoutKey is the context key under which the per-request timeout is stored.
+type requestTimeoutKey struct{}
+
+// WithRequestTimeout returns a context whose steps bound each individual RPC to d.
+// Steps that wait for the device, such as wait-for-reachable, keep their own
+// overall timeouts, so a workflow is not limited to d as a whole.
+func WithRequestTimeout(ctx context.Context, d time.Duration) context.Context {
+	return context.WithValue(ctx, requestTimeoutKey{}, d)
+}
+
+// RequestContext returns a context for a single RPC issued by a step, bounded by
+// the request timeout set on ctx. It is not bounded when none is set.
+func RequestContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if d, ok := ctx.Value(requestTimeoutKey{}).(time.Duration); ok && d > 0 {
+		return context.WithTimeout(ctx, d)
+	}
+	return context.WithCancel(ctx)
+}
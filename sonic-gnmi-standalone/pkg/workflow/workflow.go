@@ -5,15 +5,24 @@
 //	workflow, err := LoadWorkflowFromFile("workflow.yaml")
 //	registry := NewRegistry()
 //	registry.Register("download", steps.NewDownloadStep)
-//	engine := NewEngine(registry)
+//	engine := NewEngine(registry).WithStateFile("/var/tmp/upgrade.state.json")
 //	err = engine.Execute(ctx, workflow, client)
+//
+// A run interrupted by a crash or timeout can be continued with engine.Resume.
+// Step params and `when` conditions are Go text/templates rendered against the
+// workflow's variables; engine.Plan resolves and validates every step without
+// executing anything.
 package workflow
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"gopkg.in/yaml.v3"
 )
 
@@ -90,7 +99,8 @@ type StepRegistry interface {
 // for consistency and future extensibility.
 //
 // The workflow executes steps sequentially, stopping on the first error.
-// Future versions may support parallel execution and conditional steps.
+// When a step fails, the rollback actions of that step and every step before it
+// are run in reverse order, followed by the workflow-level onFailure steps.
 type Workflow struct {
 	APIVersion string `yaml:"apiVersion"`
 	Kind       string `yaml:"kind"`
@@ -98,7 +108,14 @@ type Workflow struct {
 		Name string `yaml:"name"`
 	} `yaml:"metadata"`
 	Spec struct {
+		// Variables are default values for template variables. They are
+		// overridden by variables passed to the engine (inventory, --set).
+		Variables map[string]string `yaml:"variables,omitempty"`
+
 		Steps []RawStep `yaml:"steps"`
+
+		// OnFailure steps run after rollback when any step fails.
+		OnFailure []RawStep `yaml:"onFailure,omitempty"`
 	} `yaml:"spec"`
 }
 
@@ -114,12 +131,56 @@ type RawStep struct {
 
 	// Params contains type-specific parameters as key-value pairs.
 	// These will be parsed by the appropriate step factory.
+	// String values are rendered as templates, e.g. "{{ .version }}".
+	Params map[string]interface{} `yaml:"params"`
+
+	// When is an optional template condition, e.g. `eq .role "leaf"`.
+	// The step is skipped when it renders to "", "false", "0" or "no".
+	When string `yaml:"when,omitempty"`
+
+	// Rollback is an optional action that undoes this step.
+	// It runs if this step or any later step fails.
+	Rollback *RollbackAction `yaml:"rollback,omitempty"`
+}
+
+// RollbackAction describes the step used to undo a RawStep.
+// It is created through the same step registry as regular steps.
+//
+// YAML configuration example:
+//
+//	steps:
+//	  - name: download-image
+//	    type: download
+//	    params:
+//	      url: "http://example.com/sonic.bin"
+//	      filename: "/tmp/sonic.bin"
+//	      md5: "d41d8cd98f00b204e9800998ecf8427e"
+//	    rollback:
+//	      type: download
+//	      params:
+//	        url: "http://example.com/sonic-previous.bin"
+//	        filename: "/tmp/sonic.bin"
+//	        md5: "0cc175b9c0f1b6a831c399e269772661"
+type RollbackAction struct {
+	// Type determines which step implementation to use.
+	Type string `yaml:"type"`
+
+	// Params contains type-specific parameters as key-value pairs.
 	Params map[string]interface{} `yaml:"params"`
 }
 
+// Describer is implemented by steps that can summarize the action they would
+// perform. It is used by Engine.Plan; steps without it are shown by their params.
+type Describer interface {
+	// Describe returns a one-line, human-readable description of the step's action.
+	Describe() string
+}
+
 // Engine executes workflows step by step.
 type Engine struct {
-	registry StepRegistry
+	registry  StepRegistry
+	stateFile string
+	variables map[string]string
 }
 
 // NewEngine creates a new workflow execution engine with the provided step registry.
@@ -127,43 +188,368 @@ func NewEngine(registry StepRegistry) *Engine {
 	return &Engine{registry: registry}
 }
 
+// WithStateFile makes the engine persist its progress to path after every step.
+// The state file is required by Resume.
+func (e *Engine) WithStateFile(path string) *Engine {
+	e.stateFile = path
+	return e
+}
+
+// WithVariables sets the template variables used to resolve step params and
+// `when` conditions. They take precedence over the workflow's spec.variables.
+func (e *Engine) WithVariables(vars map[string]string) *Engine {
+	e.variables = vars
+	return e
+}
+
 // Execute runs a workflow by converting each raw step to its typed implementation
 // and executing them sequentially. Execution stops on the first error.
 //
+// When a step fails, the rollback actions of the failed step and all completed
+// steps are run in reverse order, followed by the workflow's onFailure steps.
+// If the failure is caused by context cancellation, or by an RPC that timed out
+// or was cancelled, no rollback is performed and the run is recorded as
+// interrupted so it can be resumed.
+//
 // The client parameter is passed to each step's Execute method and typically
 // contains gRPC clients or other service connections needed for operations.
 func (e *Engine) Execute(ctx context.Context, workflow *Workflow, client interface{}) error {
-	fmt.Printf("Executing workflow: %s\n", workflow.Metadata.Name)
-	fmt.Printf("Steps: %d\n", len(workflow.Spec.Steps))
-	fmt.Println()
+	state := &ExecutionState{
+		Workflow: workflow.Metadata.Name,
+		Status:   StatusRunning,
+	}
+	return e.run(ctx, workflow, client, state)
+}
+
+// Resume continues a workflow from the state file written by a previous run,
+// skipping the steps that already completed. It fails if no state file is
+// configured, if the state belongs to a different workflow, or if the previous
+// run failed and was rolled back.
+func (e *Engine) Resume(ctx context.Context, workflow *Workflow, client interface{}) error {
+	if e.stateFile == "" {
+		return fmt.Errorf("cannot resume workflow: no state file configured")
+	}
+
+	state, err := LoadState(e.stateFile)
+	if err != nil {
+		return fmt.Errorf("cannot resume workflow: %w", err)
+	}
+	if err := state.checkResumable(workflow); err != nil {
+		return fmt.Errorf("cannot resume workflow: %w", err)
+	}
+
+	if state.Status == StatusCompleted {
+		fmt.Fprintf(Output(ctx), "Workflow %s already completed, nothing to resume\n", workflow.Metadata.Name)
+		return nil
+	}
 
-	for i, rawStep := range workflow.Spec.Steps {
-		fmt.Printf("Step %d/%d: %s\n", i+1, len(workflow.Spec.Steps), rawStep.Name)
-		fmt.Printf("  Type: %s\n", rawStep.Type)
+	state.Status = StatusRunning
+	state.FailedStep = ""
+	state.Error = ""
+	return e.run(ctx, workflow, client, state)
+}
 
-		// Convert raw step to typed implementation
-		step, err := e.registry.CreateStep(rawStep.Type, rawStep.Name, rawStep.Params)
+// run executes the steps of workflow that are not yet listed in state.CompletedSteps.
+func (e *Engine) run(ctx context.Context, workflow *Workflow, client interface{}, state *ExecutionState) error {
+	out := Output(ctx)
+	vars := e.variablesFor(workflow)
+	total := len(workflow.Spec.Steps)
+	start := len(state.CompletedSteps)
+
+	fmt.Fprintf(out, "Executing workflow: %s\n", workflow.Metadata.Name)
+	fmt.Fprintf(out, "Steps: %d\n", total)
+	if start > 0 {
+		fmt.Fprintf(out, "Resuming after step %d/%d\n", start, total)
+	}
+	fmt.Fprintln(out)
+
+	// Fail before touching the device if the state cannot be persisted
+	if err := e.saveState(state); err != nil {
+		return err
+	}
+
+	for i := start; i < total; i++ {
+		rawStep := workflow.Spec.Steps[i]
+		fmt.Fprintf(out, "Step %d/%d: %s\n", i+1, total, rawStep.Name)
+		fmt.Fprintf(out, "  Type: %s\n", rawStep.Type)
+
+		params, shouldRun, err := resolveStep(rawStep.Name, rawStep.When, rawStep.Params, vars)
 		if err != nil {
-			return fmt.Errorf("failed to create step '%s': %w", rawStep.Name, err)
+			return e.handleFailure(ctx, workflow, client, state, i, err)
 		}
 
-		// Validate step parameters
-		if err := step.Validate(); err != nil {
-			return fmt.Errorf("step '%s' validation failed: %w", rawStep.Name, err)
+		if !shouldRun {
+			state.CompletedSteps = append(state.CompletedSteps, rawStep.Name)
+			state.SkippedSteps = append(state.SkippedSteps, rawStep.Name)
+			e.saveStateOrWarn(ctx, state)
+			fmt.Fprintf(out, "  - Step skipped (when: %s)\n\n", rawStep.When)
+			continue
 		}
 
-		// Execute the step
-		if err := step.Execute(ctx, client); err != nil {
-			return fmt.Errorf("step '%s' execution failed: %w", rawStep.Name, err)
+		if err := e.runStep(ctx, rawStep.Type, rawStep.Name, params, client); err != nil {
+			return e.handleFailure(ctx, workflow, client, state, i, err)
 		}
 
-		fmt.Printf("  ✓ Step completed successfully\n\n")
+		state.CompletedSteps = append(state.CompletedSteps, rawStep.Name)
+		e.saveStateOrWarn(ctx, state)
+
+		fmt.Fprintf(out, "  ✓ Step completed successfully\n\n")
 	}
 
-	fmt.Println("Workflow completed successfully!")
+	state.Status = StatusCompleted
+	e.saveStateOrWarn(ctx, state)
+
+	fmt.Fprintln(out, "Workflow completed successfully!")
 	return nil
 }
 
+// runStep creates, validates and executes a single step.
+func (e *Engine) runStep(
+	ctx context.Context,
+	stepType, name string,
+	params map[string]interface{},
+	client interface{},
+) error {
+	// Convert raw step to typed implementation
+	step, err := e.registry.CreateStep(stepType, name, params)
+	if err != nil {
+		return fmt.Errorf("failed to create step '%s': %w", name, err)
+	}
+
+	// Validate step parameters
+	if err := step.Validate(); err != nil {
+		return fmt.Errorf("step '%s' validation failed: %w", name, err)
+	}
+
+	// Execute the step
+	if err := step.Execute(ctx, client); err != nil {
+		return fmt.Errorf("step '%s' execution failed: %w", name, err)
+	}
+
+	return nil
+}
+
+// handleFailure records a failed step, runs rollback actions for steps
+// failedIndex down to 0 and then the workflow's onFailure steps.
+// Errors during recovery are reported but do not stop the remaining actions.
+func (e *Engine) handleFailure(
+	ctx context.Context,
+	workflow *Workflow,
+	client interface{},
+	state *ExecutionState,
+	failedIndex int,
+	stepErr error,
+) error {
+	out := Output(ctx)
+	state.FailedStep = workflow.Spec.Steps[failedIndex].Name
+	state.Error = stepErr.Error()
+
+	if isInterruption(ctx, stepErr) {
+		fmt.Fprintf(out, "  ✗ Step interrupted: %v\n\n", stepErr)
+		state.Status = StatusInterrupted
+		e.saveStateOrWarn(ctx, state)
+		return stepErr
+	}
+
+	fmt.Fprintf(out, "  ✗ Step failed: %v\n\n", stepErr)
+
+	vars := e.variablesFor(workflow)
+	skipped := make(map[string]bool, len(state.SkippedSteps))
+	for _, name := range state.SkippedSteps {
+		skipped[name] = true
+	}
+
+	var recoveryErrors []string
+	for i := failedIndex; i >= 0; i-- {
+		rawStep := workflow.Spec.Steps[i]
+		if rawStep.Rollback == nil || skipped[rawStep.Name] {
+			continue
+		}
+
+		name := rawStep.Name + "-rollback"
+		fmt.Fprintf(out, "Rollback: %s\n", name)
+		fmt.Fprintf(out, "  Type: %s\n", rawStep.Rollback.Type)
+		params, _, err := resolveStep(name, "", rawStep.Rollback.Params, vars)
+		if err == nil {
+			err = e.runStep(ctx, rawStep.Rollback.Type, name, params, client)
+		}
+		if err != nil {
+			fmt.Fprintf(out, "  ✗ Rollback failed: %v\n\n", err)
+			recoveryErrors = append(recoveryErrors, err.Error())
+			continue
+		}
+		fmt.Fprintf(out, "  ✓ Rollback completed successfully\n\n")
+	}
+
+	for _, rawStep := range workflow.Spec.OnFailure {
+		fmt.Fprintf(out, "OnFailure: %s\n", rawStep.Name)
+		fmt.Fprintf(out, "  Type: %s\n", rawStep.Type)
+		params, shouldRun, err := resolveStep(rawStep.Name, rawStep.When, rawStep.Params, vars)
+		if err == nil && !shouldRun {
+			fmt.Fprintf(out, "  - OnFailure step skipped (when: %s)\n\n", rawStep.When)
+			continue
+		}
+		if err == nil {
+			err = e.runStep(ctx, rawStep.Type, rawStep.Name, params, client)
+		}
+		if err != nil {
+			fmt.Fprintf(out, "  ✗ OnFailure step failed: %v\n\n", err)
+			recoveryErrors = append(recoveryErrors, err.Error())
+			continue
+		}
+		fmt.Fprintf(out, "  ✓ OnFailure step completed successfully\n\n")
+	}
+
+	state.Status = StatusFailed
+	e.saveStateOrWarn(ctx, state)
+
+	if len(recoveryErrors) > 0 {
+		return fmt.Errorf("%w (recovery errors: %s)", stepErr, strings.Join(recoveryErrors, "; "))
+	}
+	return stepErr
+}
+
+// isInterruption reports whether a step error is caused by the cancellation of
+// the workflow, or by an RPC that timed out or was cancelled. The device state
+// is then unknown rather than broken, so the run is left to be resumed.
+func isInterruption(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return true
+	}
+	var s interface{ GRPCStatus() *status.Status }
+	if errors.As(err, &s) {
+		switch s.GRPCStatus().Code() {
+		case codes.DeadlineExceeded, codes.Canceled:
+			return true
+		}
+	}
+	return false
+}
+
+// Plan resolves and validates every step of the workflow without executing it.
+//
+// For each step and onFailure step, the `when` condition and templated params
+// are resolved, the step is created through the registry and validated, and the
+// action it would perform is described. All steps are planned even if some are
+// invalid; the returned error reports how many failed.
+func (e *Engine) Plan(workflow *Workflow) (*Plan, error) {
+	vars := e.variablesFor(workflow)
+	plan := &Plan{Workflow: workflow.Metadata.Name}
+
+	failed := 0
+	for _, rawStep := range workflow.Spec.Steps {
+		planned := e.planStep(rawStep, vars)
+		if planned.Error == "" && rawStep.Rollback != nil && !planned.Skipped {
+			rollback := e.planStep(RawStep{
+				Name:   rawStep.Name + "-rollback",
+				Type:   rawStep.Rollback.Type,
+				Params: rawStep.Rollback.Params,
+			}, vars)
+			planned.Rollback = &rollback
+			if rollback.Error != "" {
+				planned.Error = "rollback: " + rollback.Error
+			}
+		}
+		if planned.Error != "" {
+			failed++
+		}
+		plan.Steps = append(plan.Steps, planned)
+	}
+
+	for _, rawStep := range workflow.Spec.OnFailure {
+		planned := e.planStep(rawStep, vars)
+		if planned.Error != "" {
+			failed++
+		}
+		plan.OnFailure = append(plan.OnFailure, planned)
+	}
+
+	if failed > 0 {
+		return plan, fmt.Errorf("%d step(s) failed validation", failed)
+	}
+	return plan, nil
+}
+
+// planStep resolves, creates and validates a single step for Plan.
+func (e *Engine) planStep(rawStep RawStep, vars map[string]string) PlannedStep {
+	planned := PlannedStep{Name: rawStep.Name, Type: rawStep.Type}
+
+	params, shouldRun, err := resolveStep(rawStep.Name, rawStep.When, rawStep.Params, vars)
+	if err != nil {
+		planned.Error = err.Error()
+		return planned
+	}
+	if !shouldRun {
+		planned.Skipped = true
+		return planned
+	}
+	planned.Params = params
+
+	step, err := e.registry.CreateStep(rawStep.Type, rawStep.Name, params)
+	if err != nil {
+		planned.Error = err.Error()
+		return planned
+	}
+	if err := step.Validate(); err != nil {
+		planned.Error = fmt.Sprintf("validation failed: %v", err)
+		return planned
+	}
+
+	if describer, ok := step.(Describer); ok {
+		planned.Action = describer.Describe()
+	} else {
+		planned.Action = fmt.Sprintf("%s %v", rawStep.Type, params)
+	}
+	return planned
+}
+
+// variablesFor returns the template variables for workflow: its spec.variables
+// overridden by the engine's variables.
+func (e *Engine) variablesFor(workflow *Workflow) map[string]string {
+	return mergeVariables(workflow.Spec.Variables, e.variables)
+}
+
+// resolveStep evaluates a step's `when` condition and, if it should run,
+// renders the templates in its params.
+func resolveStep(
+	name, when string,
+	params map[string]interface{},
+	vars map[string]string,
+) (map[string]interface{}, bool, error) {
+	shouldRun, err := evaluateWhen(when, vars)
+	if err != nil {
+		return nil, false, fmt.Errorf("step '%s': %w", name, err)
+	}
+	if !shouldRun {
+		return nil, false, nil
+	}
+
+	resolved, err := resolveParams(params, vars)
+	if err != nil {
+		return nil, false, fmt.Errorf("step '%s': %w", name, err)
+	}
+	return resolved, true, nil
+}
+
+// saveState persists state to the configured state file, if any.
+func (e *Engine) saveState(state *ExecutionState) error {
+	if e.stateFile == "" {
+		return nil
+	}
+	if err := state.Save(e.stateFile); err != nil {
+		return fmt.Errorf("failed to save workflow state: %w", err)
+	}
+	return nil
+}
+
+// saveStateOrWarn persists state but only warns on failure, so that a state
+// file problem never prevents rollback or masks the original step error.
+func (e *Engine) saveStateOrWarn(ctx context.Context, state *ExecutionState) {
+	if err := e.saveState(state); err != nil {
+		fmt.Fprintf(Output(ctx), "  Warning: %v\n", err)
+	}
+}
+
 // LoadWorkflowFromFile loads and validates a workflow configuration from a YAML file.
 //
 // The function performs these validations:
@@ -251,6 +637,7 @@ func validateWorkflowStructure(workflow *Workflow) error {
 	}
 
 	// Validate each step
+	names := make(map[string]bool, len(workflow.Spec.Steps))
 	for i, step := range workflow.Spec.Steps {
 		if step.Name == "" {
 			return fmt.Errorf("step[%d]: name is required", i)
@@ -258,6 +645,23 @@ func validateWorkflowStructure(workflow *Workflow) error {
 		if step.Type == "" {
 			return fmt.Errorf("step[%d]: type is required", i)
 		}
+		if step.Rollback != nil && step.Rollback.Type == "" {
+			return fmt.Errorf("step[%d]: rollback type is required", i)
+		}
+		// Step names identify progress in the state file, so they must be unique
+		if names[step.Name] {
+			return fmt.Errorf("step[%d]: duplicate step name '%s'", i, step.Name)
+		}
+		names[step.Name] = true
+	}
+
+	for i, step := range workflow.Spec.OnFailure {
+		if step.Name == "" {
+			return fmt.Errorf("onFailure[%d]: name is required", i)
+		}
+		if step.Type == "" {
+			return fmt.Errorf("onFailure[%d]: type is required", i)
+		}
 	}
 
 	return nil
@@ -0,0 +1,279 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// recordingStep is a test step that records its execution and optionally fails.
+type recordingStep struct {
+	name    string
+	fail    bool
+	timeout bool
+	log     *[]string
+}
+
+func (s *recordingStep) Execute(ctx context.Context, client interface{}) error {
+	*s.log = append(*s.log, s.name)
+	if s.fail {
+		return errors.New("boom")
+	}
+	if s.timeout {
+		return fmt.Errorf("request failed: %w", status.Error(codes.DeadlineExceeded, "context deadline exceeded"))
+	}
+	return ctx.Err()
+}
+
+func (s *recordingStep) Validate() error { return nil }
+func (s *recordingStep) GetName() string { return s.name }
+func (s *recordingStep) GetType() string { return "record" }
+
+// newTestRegistry returns a registry with a "record" step type whose steps
+// append their name to log and fail when the "fail" param is true, or time out
+// an RPC when the "timeout" param is true.
+func newTestRegistry(log *[]string) *DefaultStepRegistry {
+	registry := NewRegistry()
+	registry.Register("record", func(name string, params map[string]interface{}) (Step, error) {
+		fail, _ := params["fail"].(bool)
+		timeout, _ := params["timeout"].(bool)
+		return &recordingStep{name: name, fail: fail, timeout: timeout, log: log}, nil
+	})
+	return registry
+}
+
+// newTestWorkflow builds a workflow with the given step names, each with a rollback action.
+func newTestWorkflow(names ...string) *Workflow {
+	wf := &Workflow{APIVersion: SupportedAPIVersion, Kind: WorkflowKind}
+	wf.Metadata.Name = "test-workflow"
+	for _, name := range names {
+		wf.Spec.Steps = append(wf.Spec.Steps, RawStep{
+			Name:     name,
+			Type:     "record",
+			Params:   map[string]interface{}{},
+			Rollback: &RollbackAction{Type: "record"},
+		})
+	}
+	return wf
+}
+
+func TestEngine_Execute_RollbackInReverseOrder(t *testing.T) {
+	var log []string
+	wf := newTestWorkflow("a", "b", "c")
+	wf.Spec.Steps[2].Params["fail"] = true
+	wf.Spec.OnFailure = []RawStep{{Name: "notify", Type: "record"}}
+
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	engine := NewEngine(newTestRegistry(&log)).WithStateFile(stateFile)
+
+	err := engine.Execute(context.Background(), wf, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "step 'c' execution failed")
+
+	assert.Equal(t, []string{"a", "b", "c", "c-rollback", "b-rollback", "a-rollback", "notify"}, log)
+
+	state, err := LoadState(stateFile)
+	require.NoError(t, err)
+	assert.Equal(t, StatusFailed, state.Status)
+	assert.Equal(t, "c", state.FailedStep)
+	assert.Equal(t, []string{"a", "b"}, state.CompletedSteps)
+}
+
+func TestEngine_Execute_RecoveryErrorsReported(t *testing.T) {
+	var log []string
+	wf := newTestWorkflow("a", "b")
+	wf.Spec.Steps[0].Rollback.Params = map[string]interface{}{"fail": true}
+	wf.Spec.Steps[1].Params["fail"] = true
+
+	err := NewEngine(newTestRegistry(&log)).Execute(context.Background(), wf, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "recovery errors")
+	assert.Contains(t, err.Error(), "a-rollback")
+	assert.Equal(t, []string{"a", "b", "b-rollback", "a-rollback"}, log)
+}
+
+func TestEngine_Resume(t *testing.T) {
+	var log []string
+	wf := newTestWorkflow("a", "b", "c")
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+
+	// Simulate an agent that crashed after completing step "a"
+	saved := &ExecutionState{Workflow: "test-workflow", Status: StatusRunning, CompletedSteps: []string{"a"}}
+	require.NoError(t, saved.Save(stateFile))
+
+	engine := NewEngine(newTestRegistry(&log)).WithStateFile(stateFile)
+	require.NoError(t, engine.Resume(context.Background(), wf, nil))
+	assert.Equal(t, []string{"b", "c"}, log)
+
+	state, err := LoadState(stateFile)
+	require.NoError(t, err)
+	assert.Equal(t, StatusCompleted, state.Status)
+	assert.Equal(t, []string{"a", "b", "c"}, state.CompletedSteps)
+
+	// Resuming a completed run is a no-op
+	log = nil
+	require.NoError(t, engine.Resume(context.Background(), wf, nil))
+	assert.Empty(t, log)
+}
+
+func TestEngine_Execute_InterruptedIsResumable(t *testing.T) {
+	var log []string
+	wf := newTestWorkflow("a", "b")
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	engine := NewEngine(newTestRegistry(&log)).WithStateFile(stateFile)
+	require.Error(t, engine.Execute(ctx, wf, nil))
+
+	// No rollback on interruption
+	assert.Equal(t, []string{"a"}, log)
+
+	state, err := LoadState(stateFile)
+	require.NoError(t, err)
+	assert.Equal(t, StatusInterrupted, state.Status)
+	assert.Empty(t, state.CompletedSteps)
+
+	log = nil
+	require.NoError(t, engine.Resume(context.Background(), wf, nil))
+	assert.Equal(t, []string{"a", "b"}, log)
+}
+
+func TestEngine_Execute_RequestTimeoutIsResumable(t *testing.T) {
+	var log []string
+	wf := newTestWorkflow("a", "b")
+	wf.Spec.Steps[1].Params["timeout"] = true
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+
+	engine := NewEngine(newTestRegistry(&log)).WithStateFile(stateFile)
+	require.Error(t, engine.Execute(context.Background(), wf, nil))
+
+	// A timed out RPC interrupts the run without rollback
+	assert.Equal(t, []string{"a", "b"}, log)
+
+	state, err := LoadState(stateFile)
+	require.NoError(t, err)
+	assert.Equal(t, StatusInterrupted, state.Status)
+	assert.Equal(t, []string{"a"}, state.CompletedSteps)
+
+	log = nil
+	wf.Spec.Steps[1].Params["timeout"] = false
+	require.NoError(t, engine.Resume(context.Background(), wf, nil))
+	assert.Equal(t, []string{"b"}, log)
+}
+
+func TestEngine_Resume_Rejected(t *testing.T) {
+	wf := newTestWorkflow("a", "b")
+
+	tests := []struct {
+		name    string
+		state   *ExecutionState
+		wantErr string
+	}{
+		{
+			name:    "different workflow",
+			state:   &ExecutionState{Workflow: "other", Status: StatusRunning},
+			wantErr: "belongs to workflow 'other'",
+		},
+		{
+			name:    "failed run",
+			state:   &ExecutionState{Workflow: "test-workflow", Status: StatusFailed, FailedStep: "b"},
+			wantErr: "was rolled back",
+		},
+		{
+			name:    "steps changed",
+			state:   &ExecutionState{Workflow: "test-workflow", Status: StatusRunning, CompletedSteps: []string{"x"}},
+			wantErr: "completed step 1 is 'x'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var log []string
+			stateFile := filepath.Join(t.TempDir(), "state.json")
+			require.NoError(t, tt.state.Save(stateFile))
+
+			err := NewEngine(newTestRegistry(&log)).WithStateFile(stateFile).Resume(context.Background(), wf, nil)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+			assert.Empty(t, log)
+		})
+	}
+
+	err := NewEngine(NewRegistry()).Resume(context.Background(), wf, nil)
+	assert.EqualError(t, err, "cannot resume workflow: no state file configured")
+}
+
+func TestValidateWorkflowStructure_Rollback(t *testing.T) {
+	wf := newTestWorkflow("a", "a")
+	assert.EqualError(t, validateWorkflowStructure(wf), "step[1]: duplicate step name 'a'")
+
+	wf = newTestWorkflow("a")
+	wf.Spec.Steps[0].Rollback.Type = ""
+	assert.EqualError(t, validateWorkflowStructure(wf), "step[0]: rollback type is required")
+
+	wf = newTestWorkflow("a")
+	wf.Spec.OnFailure = []RawStep{{Type: "record"}}
+	assert.EqualError(t, validateWorkflowStructure(wf), "onFailure[0]: name is required")
+}
+
+func TestEngine_Execute_WhenAndVariables(t *testing.T) {
+	var log []string
+	wf := newTestWorkflow("a", "b", "c")
+	wf.Spec.Variables = map[string]string{"run_b": "true", "fail_c": "false"}
+	wf.Spec.Steps[1].When = `eq .run_b "true"`
+	wf.Spec.Steps[2].Params["fail"] = "{{ .fail_c }}"
+
+	// Engine variables override the workflow defaults
+	engine := NewEngine(newTestRegistry(&log)).WithVariables(map[string]string{"run_b": "false"})
+	require.NoError(t, engine.Execute(context.Background(), wf, nil))
+	assert.Equal(t, []string{"a", "c"}, log)
+}
+
+func TestEngine_Execute_SkippedStepNotRolledBack(t *testing.T) {
+	var log []string
+	wf := newTestWorkflow("a", "b", "c")
+	wf.Spec.Steps[1].When = "false"
+	wf.Spec.Steps[2].Params["fail"] = true
+
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	err := NewEngine(newTestRegistry(&log)).WithStateFile(stateFile).Execute(context.Background(), wf, nil)
+	require.Error(t, err)
+	assert.Equal(t, []string{"a", "c", "c-rollback", "a-rollback"}, log)
+
+	state, err := LoadState(stateFile)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, state.CompletedSteps)
+	assert.Equal(t, []string{"b"}, state.SkippedSteps)
+}
+
+func TestEngine_Plan(t *testing.T) {
+	var log []string
+	wf := newTestWorkflow("a", "b", "c")
+	wf.Spec.Steps[0].Params["target"] = "{{ .device }}"
+	wf.Spec.Steps[1].When = `eq .device "spine1"`
+	wf.Spec.Steps[2].Params["target"] = "{{ .missing }}"
+	wf.Spec.OnFailure = []RawStep{{Name: "notify", Type: "unknown"}}
+
+	engine := NewEngine(newTestRegistry(&log)).WithVariables(map[string]string{"device": "leaf1"})
+	plan, err := engine.Plan(wf)
+	assert.EqualError(t, err, "2 step(s) failed validation")
+	assert.Empty(t, log, "Plan must not execute steps")
+
+	require.Len(t, plan.Steps, 3)
+	assert.Equal(t, "leaf1", plan.Steps[0].Params["target"])
+	assert.NotEmpty(t, plan.Steps[0].Action)
+	require.NotNil(t, plan.Steps[0].Rollback)
+	assert.True(t, plan.Steps[1].Skipped)
+	assert.Contains(t, plan.Steps[2].Error, "missing")
+	require.Len(t, plan.OnFailure, 1)
+	assert.Contains(t, plan.OnFailure[0].Error, "unknown step type 'unknown'")
+}
@@ -0,0 +1,211 @@
+package loopback
+
+import (
+	"crypto/md5"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/openconfig/gnoi/common"
+	"github.com/openconfig/gnoi/file"
+	"github.com/openconfig/gnoi/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// setupFileClient starts a server with the gNOI File service rooted at rootFS
+// and returns a connected File client.
+func setupFileClient(t *testing.T, rootFS string) file.FileClient {
+	require.NoError(t, os.MkdirAll(filepath.Join(rootFS, "tmp"), 0755))
+
+	testServer := SetupInsecureTestServer(t, rootFS, []string{"gnoi.file"})
+	t.Cleanup(testServer.Stop)
+
+	conn, err := grpc.Dial(testServer.Addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err, "Failed to connect to server")
+	t.Cleanup(func() { conn.Close() })
+
+	return file.NewFileClient(conn)
+}
+
+// TestGNOIFilePutGetLoopback writes a file with Put, then reads it back with Get and Stat.
+func TestGNOIFilePutGetLoopback(t *testing.T) {
+	rootFS := t.TempDir()
+	client := setupFileClient(t, rootFS)
+
+	ctx, cancel := WithTestTimeout(30 * time.Second)
+	defer cancel()
+
+	testContent := []byte("test file content for gNOI File loopback test")
+	testMD5 := md5.Sum(testContent)
+
+	// Put the file in two chunks
+	putStream, err := client.Put(ctx)
+	require.NoError(t, err)
+	require.NoError(t, putStream.Send(&file.PutRequest{Request: &file.PutRequest_Open{
+		Open: &file.PutRequest_Details{RemoteFile: "/tmp/loopback.bin", Permissions: 640},
+	}}))
+	require.NoError(t, putStream.Send(&file.PutRequest{Request: &file.PutRequest_Contents{Contents: testContent[:10]}}))
+	require.NoError(t, putStream.Send(&file.PutRequest{Request: &file.PutRequest_Contents{Contents: testContent[10:]}}))
+	require.NoError(t, putStream.Send(&file.PutRequest{Request: &file.PutRequest_Hash{
+		Hash: &types.HashType{Method: types.HashType_MD5, Hash: testMD5[:]},
+	}}))
+	_, err = putStream.CloseAndRecv()
+	require.NoError(t, err, "Put RPC failed")
+
+	// The file lands under rootFS with the requested permissions
+	hostPath := filepath.Join(rootFS, "tmp", "loopback.bin")
+	written, err := os.ReadFile(hostPath)
+	require.NoError(t, err)
+	assert.Equal(t, testContent, written)
+	info, err := os.Stat(hostPath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+
+	// Get returns the contents followed by the hash
+	getStream, err := client.Get(ctx, &file.GetRequest{RemoteFile: "/tmp/loopback.bin"})
+	require.NoError(t, err)
+	var received []byte
+	var hash *types.HashType
+	for {
+		resp, err := getStream.Recv()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err, "Get RPC failed")
+		received = append(received, resp.GetContents()...)
+		if resp.GetHash() != nil {
+			hash = resp.GetHash()
+		}
+	}
+	assert.Equal(t, testContent, received)
+	require.NotNil(t, hash, "Get did not return a hash")
+	assert.Equal(t, testMD5[:], hash.GetHash())
+
+	// Stat reports device paths, not rootFS paths
+	statResp, err := client.Stat(ctx, &file.StatRequest{Path: "/tmp"})
+	require.NoError(t, err, "Stat RPC failed")
+	require.Len(t, statResp.GetStats(), 1)
+	stat := statResp.GetStats()[0]
+	assert.Equal(t, "/tmp/loopback.bin", stat.GetPath())
+	assert.Equal(t, uint64(len(testContent)), stat.GetSize())
+	assert.Equal(t, uint32(640), stat.GetPermissions())
+
+	// Remove deletes the file
+	_, err = client.Remove(ctx, &file.RemoveRequest{RemoteFile: "/tmp/loopback.bin"})
+	require.NoError(t, err, "Remove RPC failed")
+	_, err = os.Stat(hostPath)
+	assert.True(t, os.IsNotExist(err), "file still exists after Remove")
+}
+
+// TestGNOIFilePutLoopback_HashMismatch verifies a corrupted transfer does not create the file.
+func TestGNOIFilePutLoopback_HashMismatch(t *testing.T) {
+	rootFS := t.TempDir()
+	client := setupFileClient(t, rootFS)
+
+	ctx, cancel := WithTestTimeout(30 * time.Second)
+	defer cancel()
+
+	putStream, err := client.Put(ctx)
+	require.NoError(t, err)
+	require.NoError(t, putStream.Send(&file.PutRequest{Request: &file.PutRequest_Open{
+		Open: &file.PutRequest_Details{RemoteFile: "/tmp/corrupt.bin"},
+	}}))
+	require.NoError(t, putStream.Send(&file.PutRequest{Request: &file.PutRequest_Contents{Contents: []byte("data")}}))
+	require.NoError(t, putStream.Send(&file.PutRequest{Request: &file.PutRequest_Hash{
+		Hash: &types.HashType{Method: types.HashType_MD5, Hash: make([]byte, md5.Size)},
+	}}))
+	_, err = putStream.CloseAndRecv()
+	assert.Equal(t, codes.DataLoss, status.Code(err))
+
+	entries, err := os.ReadDir(filepath.Join(rootFS, "tmp"))
+	require.NoError(t, err)
+	assert.Empty(t, entries, "failed Put left files behind")
+}
+
+// TestGNOIFilePutLoopback_TempSymlink verifies Put does not write through a
+// pre-created file at a predictable temporary name next to the target.
+func TestGNOIFilePutLoopback_TempSymlink(t *testing.T) {
+	rootFS := t.TempDir()
+	client := setupFileClient(t, rootFS)
+
+	ctx, cancel := WithTestTimeout(30 * time.Second)
+	defer cancel()
+
+	victim := filepath.Join(t.TempDir(), "victim")
+	require.NoError(t, os.WriteFile(victim, []byte("original"), 0600))
+	require.NoError(t, os.Symlink(victim, filepath.Join(rootFS, "tmp", "target.bin.tmp")))
+
+	testContent := []byte("new contents")
+	testMD5 := md5.Sum(testContent)
+	putStream, err := client.Put(ctx)
+	require.NoError(t, err)
+	require.NoError(t, putStream.Send(&file.PutRequest{Request: &file.PutRequest_Open{
+		Open: &file.PutRequest_Details{RemoteFile: "/tmp/target.bin"},
+	}}))
+	require.NoError(t, putStream.Send(&file.PutRequest{Request: &file.PutRequest_Contents{Contents: testContent}}))
+	require.NoError(t, putStream.Send(&file.PutRequest{Request: &file.PutRequest_Hash{
+		Hash: &types.HashType{Method: types.HashType_MD5, Hash: testMD5[:]},
+	}}))
+	_, err = putStream.CloseAndRecv()
+	require.NoError(t, err, "Put RPC failed")
+
+	written, err := os.ReadFile(filepath.Join(rootFS, "tmp", "target.bin"))
+	require.NoError(t, err)
+	assert.Equal(t, testContent, written)
+	original, err := os.ReadFile(victim)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("original"), original, "Put wrote through the symlink")
+}
+
+// TestGNOIFilePutLoopback_DisallowedPath verifies Put cannot write outside temporary directories.
+func TestGNOIFilePutLoopback_DisallowedPath(t *testing.T) {
+	client := setupFileClient(t, t.TempDir())
+
+	ctx, cancel := WithTestTimeout(30 * time.Second)
+	defer cancel()
+
+	putStream, err := client.Put(ctx)
+	require.NoError(t, err)
+	require.NoError(t, putStream.Send(&file.PutRequest{Request: &file.PutRequest_Open{
+		Open: &file.PutRequest_Details{RemoteFile: "/host/grub/grub.cfg"},
+	}}))
+	_, err = putStream.CloseAndRecv()
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+// TestGNOIFileTransferToRemoteLoopback tests downloading a file onto the device over HTTP.
+func TestGNOIFileTransferToRemoteLoopback(t *testing.T) {
+	testContent := []byte("test content for gNOI File TransferToRemote loopback test")
+	testMD5 := md5.Sum(testContent)
+
+	httpServer := SetupHTTPTestServer(testContent)
+	defer httpServer.Close()
+
+	rootFS := t.TempDir()
+	client := setupFileClient(t, rootFS)
+
+	ctx, cancel := WithTestTimeout(30 * time.Second)
+	defer cancel()
+
+	resp, err := client.TransferToRemote(ctx, &file.TransferToRemoteRequest{
+		LocalPath: "/tmp/image.bin",
+		RemoteDownload: &common.RemoteDownload{
+			Path:     httpServer.URL,
+			Protocol: common.RemoteDownload_HTTP,
+		},
+	})
+	require.NoError(t, err, "TransferToRemote RPC failed")
+	assert.Equal(t, types.HashType_MD5, resp.GetHash().GetMethod())
+	assert.Equal(t, testMD5[:], resp.GetHash().GetHash())
+
+	downloaded, err := os.ReadFile(filepath.Join(rootFS, "tmp", "image.bin"))
+	require.NoError(t, err)
+	assert.Equal(t, testContent, downloaded)
+}
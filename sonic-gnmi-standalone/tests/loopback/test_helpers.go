@@ -85,6 +85,8 @@ func SetupTestServer(t *testing.T, tempDir string, cfg *TestServerConfig) *TestS
 		switch service {
 		case "gnoi.system":
 			builder = builder.EnableGNOISystem()
+		case "gnoi.file":
+			builder = builder.EnableGNOIFile()
 		}
 	}
 
@@ -7,15 +7,22 @@ import (
 	"io/ioutil"
 	"os"
 	"reflect"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/Workiva/go-datastructures/queue"
+	"github.com/agiledragon/gomonkey/v2"
+	"github.com/go-redis/redis"
 	"github.com/google/gnxi/utils/xpath"
 	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+	spb "github.com/sonic-net/sonic-gnmi/proto"
+	sdcfg "github.com/sonic-net/sonic-gnmi/sonic_db_config"
 	"github.com/sonic-net/sonic-gnmi/swsscommon"
 	"github.com/sonic-net/sonic-gnmi/test_utils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 var testFile string = "/etc/sonic/ut.cp.json"
@@ -468,6 +475,65 @@ func TestParseDatabase(t *testing.T) {
 	}
 }
 
+func TestExpandInstancePath(t *testing.T) {
+	client := MixedDbClient{
+		instances: map[string]swsscommon.SonicDBKey{},
+	}
+	for _, ns := range []string{"", "asic0", "asic1"} {
+		dbkey := swsscommon.NewSonicDBKey()
+		dbkey.SetNetns(ns)
+		client.instances[instanceName(dbkey)] = dbkey
+	}
+	defer client.Close()
+
+	if !isInstanceWildcard("asic*") || isInstanceWildcard("asic0") {
+		t.Errorf("isInstanceWildcard returned wrong result")
+	}
+
+	path, _ := xpath.ToGNMIPath("COUNTERS_DB/asic*/COUNTERS/Ethernet0")
+	paths, err := client.expandPath(path)
+	if err != nil {
+		t.Fatalf("expandPath failed: %v", err)
+	}
+	if len(paths) != 2 {
+		t.Fatalf("expandPath returned %d paths, want 2", len(paths))
+	}
+	for i, want := range []string{"asic0", "asic1"} {
+		if name := paths[i].GetElem()[ELEM_INDEX_INSTANCE].GetName(); name != want {
+			t.Errorf("expanded path %d has instance %v, want %v", i, name, want)
+		}
+		if dbkey := client.dbkeyForPath(paths[i]); dbkey.GetNetns() != want {
+			t.Errorf("expanded path %d reads namespace %v, want %v", i, dbkey.GetNetns(), want)
+		}
+	}
+	if name := path.GetElem()[ELEM_INDEX_INSTANCE].GetName(); name != "asic*" {
+		t.Errorf("expandPath modified the request path: %v", name)
+	}
+
+	path, _ = xpath.ToGNMIPath("COUNTERS_DB/*/COUNTERS")
+	paths, err = client.expandPath(path)
+	if err != nil || len(paths) != 3 {
+		t.Errorf("expandPath should match every instance: %v %v", paths, err)
+	}
+
+	path, _ = xpath.ToGNMIPath("COUNTERS_DB/dpu*/COUNTERS")
+	if _, err = client.expandPath(path); err == nil {
+		t.Errorf("expandPath should fail without matching instance")
+	}
+
+	path, _ = xpath.ToGNMIPath("COUNTERS_DB/asic0/COUNTERS")
+	paths, err = client.expandPath(path)
+	if err != nil || len(paths) != 1 || paths[0] != path {
+		t.Errorf("expandPath should not change a concrete path: %v %v", paths, err)
+	}
+
+	client.prefix, _ = xpath.ToGNMIPath("COUNTERS_DB/asic*")
+	path, _ = xpath.ToGNMIPath("COUNTERS")
+	if _, err = client.expandPath(path); err == nil {
+		t.Errorf("expandPath should reject wildcard in prefix")
+	}
+}
+
 func TestSubscribeInternal(t *testing.T) {
 	// Test StreamRun
 	{
@@ -556,6 +622,95 @@ func TestSubscribeInternal(t *testing.T) {
 	}
 }
 
+// drainQueue returns the values put on q by a finished subscription routine.
+func drainQueue(t *testing.T, q *queue.PriorityQueue) []*spb.Value {
+	var values []*spb.Value
+	for !q.Empty() {
+		items, err := q.Get(q.Len())
+		if err != nil {
+			t.Fatalf("failed to get from queue: %v", err)
+		}
+		for _, item := range items {
+			v, ok := item.(Value)
+			if !ok {
+				t.Fatalf("unexpected item in queue: %v", item)
+			}
+			values = append(values, v.Value)
+		}
+	}
+	return values
+}
+
+func TestMixedDbClientOnceRun(t *testing.T) {
+	if !swsscommon.SonicDBConfigIsInit() {
+		swsscommon.SonicDBConfigInitialize()
+	}
+
+	configDb := swsscommon.NewDBConnector("CONFIG_DB", uint(0), true)
+	defer swsscommon.DeleteDBConnector(configDb)
+	configDb.Flushdb()
+	vlanTable := swsscommon.NewTable(configDb, "VLAN")
+	defer swsscommon.DeleteTable(vlanTable)
+	vlanTable.Hset("Vlan100", "vlanid", "100")
+
+	runOnce := func(client Client, trigger bool) []*spb.Value {
+		pq := queue.NewPriorityQueue(1, false)
+		once := make(chan struct{}, 1)
+		if trigger {
+			once <- struct{}{}
+		} else {
+			close(once)
+		}
+		w := sync.WaitGroup{}
+		w.Add(1)
+		client.OnceRun(pq, once, &w, nil)
+		w.Wait()
+		return drainQueue(t, pq)
+	}
+
+	// A snapshot of the path, then sync_response
+	path, _ := xpath.ToGNMIPath("CONFIG_DB/localhost/VLAN/Vlan100")
+	var target string
+	client, err := NewMixedDbClient([]*gnmipb.Path{path}, nil, "sonic-db", gnmipb.Encoding_JSON_IETF, "", "", &target)
+	if err != nil {
+		t.Fatalf("NewMixedDbClient failed: %v", err)
+	}
+	defer client.Close()
+
+	values := runOnce(client, true)
+	if len(values) != 2 {
+		t.Fatalf("OnceRun should put an update and sync_response, got %v", values)
+	}
+	if values[0].GetSyncResponse() || values[0].GetFatal() != "" {
+		t.Errorf("OnceRun should put the update first, got %v", values[0])
+	}
+	ok, err := JsonEqual(values[0].GetVal().GetJsonIetfVal(), []byte(`{"vlanid": "100"}`))
+	if err != nil || !ok {
+		t.Errorf("OnceRun returned wrong value: %s", values[0].GetVal().GetJsonIetfVal())
+	}
+	if !values[1].GetSyncResponse() {
+		t.Errorf("OnceRun should end with sync_response, got %v", values[1])
+	}
+
+	// Nothing is sent when the once channel is closed before the trigger
+	if values := runOnce(client, false); len(values) != 0 {
+		t.Errorf("OnceRun should not send after the channel is closed, got %v", values)
+	}
+
+	// A missing field is reported as a fatal message, without sync_response
+	path, _ = xpath.ToGNMIPath("CONFIG_DB/localhost/VLAN/Vlan100/no_such_field")
+	missing, err := NewMixedDbClient([]*gnmipb.Path{path}, nil, "sonic-db", gnmipb.Encoding_JSON_IETF, "", "", &target)
+	if err != nil {
+		t.Fatalf("NewMixedDbClient failed: %v", err)
+	}
+	defer missing.Close()
+
+	values = runOnce(missing, true)
+	if len(values) != 1 || values[0].GetFatal() == "" {
+		t.Errorf("OnceRun should report a fatal message for a missing field, got %v", values)
+	}
+}
+
 func mockGetFunc() ([]byte, error) {
 	return nil, errors.New("mock error")
 }
@@ -581,6 +736,117 @@ func TestNonDbClientGetError(t *testing.T) {
 	}
 }
 
+// newRollbackTestClient returns a client writing to APPL_DB, and APPL_DB emptied.
+func newRollbackTestClient(t *testing.T) (*MixedDbClient, *redis.Client) {
+	initRedisDbMap()
+	redisDb, ok := RedisDbMap[sdcfg.SONIC_DEFAULT_NAMESPACE+":"+sdcfg.SONIC_DEFAULT_CONTAINER+":"+APPL_DB_NAME]
+	if !ok {
+		t.Fatalf("APPL_DB not present in RedisDbMap")
+	}
+	if err := redisDb.FlushDB().Err(); err != nil {
+		t.Fatalf("failed to flush APPL_DB: %v", err)
+	}
+	client := &MixedDbClient{
+		applDB:      swsscommon.NewDBConnector(APPL_DB_NAME, SWSS_TIMEOUT, false),
+		tableMap:    map[string]swsscommon.ProducerStateTable{},
+		zmqTableMap: map[string]swsscommon.ZmqProducerStateTable{},
+	}
+	return client, redisDb
+}
+
+func checkProducerView(t *testing.T, redisDb *redis.Client, table string, key string, want map[string]string) {
+	state, err := producerView(&dbWrite{redisDb: redisDb, table: table, delimitor: ":", key: key})
+	if err != nil {
+		t.Fatalf("producerView failed: %v", err)
+	}
+	if !reflect.DeepEqual(state.values, want) {
+		t.Errorf("%s:%s is %v after rollback, want %v", table, key, state.values, want)
+	}
+}
+
+func TestApplyWritesRollbackFlushFailure(t *testing.T) {
+	client, redisDb := newRollbackTestClient(t)
+	defer client.Close()
+
+	// Keys already consumed by orchagent
+	redisDb.HMSet("ROLLBACK_A:k1", map[string]interface{}{"a": "1", "b": "2"})
+	redisDb.HMSet("ROLLBACK_A:k0", map[string]interface{}{"p": "1"})
+
+	// The second table fails to flush, after its writes reached redis
+	flushes := 0
+	patches := gomonkey.ApplyFunc(ProducerStateTableFlushWrapper, func(pt swsscommon.ProducerStateTable) error {
+		pt.Flush()
+		flushes++
+		if flushes == 2 {
+			return errors.New("connection reset")
+		}
+		return nil
+	})
+	defer patches.Reset()
+
+	write := func(table string, key string, values map[string]string) dbWrite {
+		return dbWrite{operation: "update " + key, redisDb: redisDb, table: table, delimitor: ":", key: key, values: values}
+	}
+	err := client.applyWrites([]dbWrite{
+		write("ROLLBACK_A", "k1", map[string]string{"a": "10", "c": "3"}),
+		write("ROLLBACK_A", "k0", nil),
+		write("ROLLBACK_B", "k2", map[string]string{"x": "1"}),
+	})
+	if status.Code(err) != codes.Aborted {
+		t.Fatalf("applyWrites should abort after a flush failure, got %v", err)
+	}
+
+	checkProducerView(t, redisDb, "ROLLBACK_A", "k1", map[string]string{"a": "1", "b": "2"})
+	checkProducerView(t, redisDb, "ROLLBACK_A", "k0", map[string]string{"p": "1"})
+	checkProducerView(t, redisDb, "ROLLBACK_B", "k2", map[string]string{})
+
+	// k1 is restored field by field, without a delete
+	if redisDb.SIsMember("ROLLBACK_A_DEL_SET", "k1").Val() {
+		t.Errorf("rollback should not delete a key which existed")
+	}
+	if pending := redisDb.HGetAll("_ROLLBACK_A:k1").Val(); !reflect.DeepEqual(pending, map[string]string{"a": "1"}) {
+		t.Errorf("rollback should only set the changed field of k1, pending %v", pending)
+	}
+	// k2 was created by the request, so it is deleted
+	if !redisDb.SIsMember("ROLLBACK_B_DEL_SET", "k2").Val() {
+		t.Errorf("rollback should delete a key created by the request")
+	}
+}
+
+func TestApplyWritesRollbackWriteFailure(t *testing.T) {
+	client, redisDb := newRollbackTestClient(t)
+	defer client.Close()
+
+	redisDb.HMSet("ROLLBACK_A:k1", map[string]interface{}{"a": "1"})
+
+	// The last write fails, after the others were pipelined
+	patches := gomonkey.ApplyFunc(ProducerStateTableSetWrapper, func(pt swsscommon.ProducerStateTable, key string, value swsscommon.FieldValuePairs) error {
+		if key == "k_fail" {
+			return errors.New("injected failure")
+		}
+		pt.Set(key, value, "SET", "")
+		return nil
+	})
+	defer patches.Reset()
+
+	write := func(table string, key string, values map[string]string) dbWrite {
+		return dbWrite{operation: "update " + key, redisDb: redisDb, table: table, delimitor: ":", key: key, values: values}
+	}
+	err := client.applyWrites([]dbWrite{
+		write("ROLLBACK_A", "k1", map[string]string{"c": "3"}),
+		write("ROLLBACK_A", "k_fail", map[string]string{"c": "3"}),
+	})
+	if status.Code(err) != codes.Aborted || !strings.Contains(err.Error(), "update k_fail failed") {
+		t.Fatalf("applyWrites should abort naming the failed write, got %v", err)
+	}
+
+	checkProducerView(t, redisDb, "ROLLBACK_A", "k1", map[string]string{"a": "1"})
+	// The added field never reached orchagent, so the pending set is withdrawn
+	if redisDb.Exists("_ROLLBACK_A:k1").Val() != 0 || redisDb.SIsMember("ROLLBACK_A_KEY_SET", "k1").Val() {
+		t.Errorf("rollback should withdraw the pending set of k1")
+	}
+}
+
 /*
 Helper method for receive data from ZmqConsumerStateTable
 
@@ -608,6 +874,53 @@ func ReceiveFromZmq(consumer swsscommon.ZmqConsumerStateTable) bool {
 	}
 }
 
+func TestPruneZmqSent(t *testing.T) {
+	zmqSent.Lock()
+	defer zmqSent.Unlock()
+	saved := zmqSent.states
+	defer func() { zmqSent.states = saved }()
+
+	now := time.Now()
+	zmqSent.states = map[string]zmqSentState{
+		"expired": {sent: now.Add(-ZMQ_SENT_TTL)},
+		"recent":  {sent: now.Add(-time.Second)},
+	}
+	pruneZmqSent(now)
+	if _, ok := zmqSent.states["expired"]; ok {
+		t.Errorf("expired key was not forgotten")
+	}
+	if _, ok := zmqSent.states["recent"]; !ok {
+		t.Errorf("recent key was forgotten")
+	}
+
+	zmqSent.states = map[string]zmqSentState{}
+	for i := 0; i < ZMQ_SENT_MAX+10; i++ {
+		zmqSent.states[fmt.Sprintf("key%d", i)] = zmqSentState{sent: now.Add(time.Duration(i-ZMQ_SENT_MAX) * time.Millisecond)}
+	}
+	pruneZmqSent(now)
+	if len(zmqSent.states) != ZMQ_SENT_MAX {
+		t.Errorf("%d keys remembered, want %d", len(zmqSent.states), ZMQ_SENT_MAX)
+	}
+	for i := 0; i < 10; i++ {
+		if _, ok := zmqSent.states[fmt.Sprintf("key%d", i)]; ok {
+			t.Errorf("oldest key%d was not forgotten", i)
+		}
+	}
+}
+
+func TestKeyStateEqual(t *testing.T) {
+	set := keyState{exists: true, values: map[string]string{"a": "1"}}
+	if !set.equal(keyState{exists: true, values: map[string]string{"a": "1"}}) {
+		t.Errorf("identical states are not equal")
+	}
+	if set.equal(keyState{exists: true, values: map[string]string{"a": "2"}}) {
+		t.Errorf("states with different values are equal")
+	}
+	if !(keyState{values: map[string]string{}}).equal(keyState{}) {
+		t.Errorf("missing keys are not equal")
+	}
+}
+
 func TestZmqReconnect(t *testing.T) {
 	// create ZMQ server
 	db := swsscommon.NewDBConnector(APPL_DB_NAME, SWSS_TIMEOUT, false)
@@ -117,6 +117,7 @@ func GetIntervalTicker() func(interval time.Duration) <-chan time.Time {
 
 type tablePath struct {
 	dbNamespace string
+	dbContainer string
 	dbName      string
 	tableName   string
 	tableKey    string
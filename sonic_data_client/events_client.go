@@ -43,8 +43,10 @@ const HEARTBEAT_MAX = 600 // 10 mins
 const MISSED = "COUNTERS_EVENTS:missed_internal"
 const DROPPED = "COUNTERS_EVENTS:missed_by_slow_receiver"
 const LATENCY = "COUNTERS_EVENTS:latency_in_ms"
+const FILTERED = "COUNTERS_EVENTS:filtered_by_subscription"
+const AGGREGATION_DROPPED = "COUNTERS_EVENTS:dropped_by_aggregation"
 
-var STATS_CUMULATIVE_KEYS = [...]string{MISSED, DROPPED}
+var STATS_CUMULATIVE_KEYS = [...]string{MISSED, DROPPED, FILTERED, AGGREGATION_DROPPED}
 var STATS_ABSOLUTE_KEYS = [...]string{LATENCY}
 
 const STATS_FIELD_NAME = "value"
@@ -70,6 +72,10 @@ type EventClient struct {
 
 	subs_handle unsafe.Pointer
 
+	// Events selected and coalesced for the subscription
+	filter     eventFilter
+	aggregator *eventAggregator
+
 	stopped   int
 	stopMutex sync.RWMutex
 
@@ -140,6 +146,24 @@ func NewEventClient(paths []*gnmipb.Path, prefix *gnmipb.Path, logLevel int) (Cl
 					use_cache = false
 					log.V(7).Infof("Cache use is turned off")
 				}
+			} else if k == PARAM_AGGREGATE {
+				val, err := strconv.Atoi(v)
+				if err != nil || val < 0 {
+					return nil, fmt.Errorf("invalid %s %q, expected seconds", k, v)
+				}
+				if val > AGGREGATE_MAX {
+					log.V(4).Infof("aggregate req %v > max %v; default to max", val, AGGREGATE_MAX)
+					val = AGGREGATE_MAX
+				}
+				if val > 0 {
+					evtc.aggregator = newEventAggregator(time.Duration(val) * time.Second)
+					log.V(7).Infof("Events aggregated in %d seconds windows", val)
+				}
+			} else if ok, err := evtc.filter.setParam(k, v); ok {
+				if err != nil {
+					return nil, err
+				}
+				log.V(7).Infof("Events filtered by %s=%s", k, v)
 			}
 		}
 	}
@@ -344,33 +368,23 @@ func get_events(evtc *EventClient) {
 			evtc.countersMutex.RUnlock()
 
 			if !strings.HasPrefix(evt.Event_str, TEST_EVENT) {
-				qlen := evtc.q.Len()
-
-				if qlen < evtc.pq_max {
-					var fvp map[string]interface{}
-					json.Unmarshal([]byte(evt.Event_str), &fvp)
-
-					jv, err := json.Marshal(fvp)
-
-					if err == nil {
-						evtTv := &gnmipb.TypedValue{
-							Value: &gnmipb.TypedValue_JsonIetfVal{
-								JsonIetfVal: jv,
-							}}
-						if err := send_event(evtc, evtTv, evt.Publish_epoch_ms); err != nil {
-							break
-						}
-					} else {
-						log.V(1).Infof("Invalid event string: %v", evt.Event_str)
-					}
-				} else {
+				var fvp map[string]interface{}
+				json.Unmarshal([]byte(evt.Event_str), &fvp)
+
+				// The heartbeats of eventd are neither filtered nor aggregated
+				heartbeat := strings.HasPrefix(evt.Event_str, EVENTD_PUBLISHER_SOURCE)
+				if !heartbeat && !evtc.filter.match(fvp) {
 					evtc.countersMutex.Lock()
-					dropped_cnt := evtc.counters[DROPPED]
+					evtc.counters[FILTERED]++
 					evtc.countersMutex.Unlock()
-
-					evtc.countersMutex.RLock()
-					evtc.counters[DROPPED] = dropped_cnt + 1
-					evtc.countersMutex.RUnlock()
+				} else if !heartbeat && evtc.aggregator != nil && aggregatable(fvp) {
+					if !evtc.aggregator.add(fvp, evt.Publish_epoch_ms, time.Now()) {
+						evtc.countersMutex.Lock()
+						evtc.counters[AGGREGATION_DROPPED]++
+						evtc.countersMutex.Unlock()
+					}
+				} else if err := enqueue_event(evtc, fvp, evt.Publish_epoch_ms); err != nil {
+					break
 				}
 			}
 		}
@@ -389,6 +403,56 @@ func get_events(evtc *EventClient) {
 	evtc.stopMutex.RUnlock()
 }
 
+// enqueue_event sends the event fvp, or counts it as dropped when the queue is full.
+func enqueue_event(evtc *EventClient, fvp map[string]interface{}, publish_epoch_ms int64) error {
+	if evtc.q.Len() >= evtc.pq_max {
+		evtc.countersMutex.Lock()
+		evtc.counters[DROPPED]++
+		evtc.countersMutex.Unlock()
+		return nil
+	}
+
+	jv, err := json.Marshal(fvp)
+	if err != nil {
+		log.V(1).Infof("Invalid event: %v", fvp)
+		return nil
+	}
+	evtTv := &gnmipb.TypedValue{
+		Value: &gnmipb.TypedValue_JsonIetfVal{
+			JsonIetfVal: jv,
+		}}
+	return send_event(evtc, evtTv, publish_epoch_ms)
+}
+
+// send_aggregated sends the aggregated events at the end of their window. The events
+// pending when the subscription stops are discarded, as the queue is disposed by then.
+func send_aggregated(evtc *EventClient) {
+	defer evtc.wg.Done()
+
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for !evtc.isStopped() {
+		now := <-ticker.C
+		if !send_aggregated_events(evtc, evtc.aggregator.expired(now)) {
+			break
+		}
+	}
+	if n := evtc.aggregator.discard(); n > 0 {
+		log.V(3).Infof("%v discarded %d pending aggregated events on stop", evtc, n)
+	}
+}
+
+// send_aggregated_events enqueues the aggregated events, returning false on queue error.
+func send_aggregated_events(evtc *EventClient, events []*aggregatedEvent) bool {
+	for _, p := range events {
+		if err := enqueue_event(evtc, p.event, p.publish_epoch_ms); err != nil {
+			log.V(3).Infof("%v failed to send aggregated event: %v", evtc, err)
+			return false
+		}
+	}
+	return true
+}
+
 func send_event(evtc *EventClient, tv *gnmipb.TypedValue,
 	timestamp int64) error {
 	spbv := &spb.Value{
@@ -417,6 +481,10 @@ func (evtc *EventClient) StreamRun(q *queue.PriorityQueue, stop chan struct{}, w
 	evtc.wg.Add(1)
 	go update_stats(evtc)
 	evtc.wg.Add(1)
+	if evtc.aggregator != nil {
+		go send_aggregated(evtc)
+		evtc.wg.Add(1)
+	}
 
 	for !evtc.isStopped() {
 		select {
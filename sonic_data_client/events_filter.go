@@ -0,0 +1,341 @@
+package client
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/golang/glog"
+)
+
+// Path parameters filtering and aggregating the events
+const PARAM_SOURCE = "source"
+const PARAM_TAG = "tag"
+const PARAM_FILTER = "filter"
+const PARAM_AGGREGATE = "aggregate"
+
+const AGGREGATE_MAX = 600 // 10 mins
+
+// Max number of distinct events pending aggregation, the events beyond are dropped
+const AGGREGATE_PENDING_MAX = 4096
+
+// Field of an aggregated event with the number of events it stands for. The events which
+// have this field already are not aggregated.
+const AGGREGATE_COUNT_FIELD = "count"
+
+// Field of the events ignored when comparing them for aggregation
+const EVENT_TIMESTAMP_FIELD = "timestamp"
+
+// eventPredicate is a condition on a field of an event, like status=down.
+// = and != compare with a glob pattern, <, <=, > and >= compare numbers.
+// The field ends at the first operator, so the value may contain operators,
+// e.g. msg=a>=b compares msg with "a>=b".
+type eventPredicate struct {
+	field string
+	op    string
+	value string
+	num   float64
+}
+
+// eventFilter selects the events of a subscription by source, tag and field predicates.
+// Each of them matches if empty.
+type eventFilter struct {
+	sources    []string
+	tags       []string
+	predicates []eventPredicate
+}
+
+// Operators of eventPredicate, the longest first for parsing
+var eventPredicateOps = []string{"!=", "<=", ">=", "=", "<", ">"}
+
+// splitEventParam splits a comma separated parameter value. A comma escaped
+// as \, is part of the item, other backslashes are kept for the glob patterns.
+func splitEventParam(value string) []string {
+	var items []string
+	var item strings.Builder
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; {
+		case c == '\\' && i+1 < len(value):
+			i++
+			if value[i] != ',' {
+				item.WriteByte(c)
+			}
+			item.WriteByte(value[i])
+		case c == ',':
+			items = append(items, item.String())
+			item.Reset()
+		default:
+			item.WriteByte(c)
+		}
+	}
+	return append(items, item.String())
+}
+
+// parseEventPatterns parses a comma separated list of glob patterns.
+func parseEventPatterns(param, value string) ([]string, error) {
+	var patterns []string
+	for _, p := range splitEventParam(value) {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if _, err := filepath.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %v", param, p, err)
+		}
+		patterns = append(patterns, p)
+	}
+	return patterns, nil
+}
+
+func parseEventPredicate(s string) (eventPredicate, error) {
+	for i := 1; i < len(s); i++ {
+		var op string
+		for _, o := range eventPredicateOps {
+			if strings.HasPrefix(s[i:], o) {
+				op = o
+				break
+			}
+		}
+		if op == "" {
+			continue
+		}
+		p := eventPredicate{field: strings.TrimSpace(s[:i]), op: op, value: strings.TrimSpace(s[i+len(op):])}
+		switch op {
+		case "=", "!=":
+			if _, err := filepath.Match(p.value, ""); err != nil {
+				return p, fmt.Errorf("invalid filter %q: %v", s, err)
+			}
+		default:
+			num, err := strconv.ParseFloat(p.value, 64)
+			if err != nil {
+				return p, fmt.Errorf("invalid filter %q: %s needs a number", s, op)
+			}
+			p.num = num
+		}
+		return p, nil
+	}
+	return eventPredicate{}, fmt.Errorf("invalid filter %q, expected <field><op><value> with op in %v", s, eventPredicateOps)
+}
+
+// setParam sets the filter path parameter k to v. It returns false if k is not a filter parameter.
+func (f *eventFilter) setParam(k, v string) (bool, error) {
+	var err error
+	switch k {
+	case PARAM_SOURCE:
+		f.sources, err = parseEventPatterns(k, v)
+	case PARAM_TAG:
+		f.tags, err = parseEventPatterns(k, v)
+	case PARAM_FILTER:
+		f.predicates = nil
+		for _, s := range splitEventParam(v) {
+			if strings.TrimSpace(s) == "" {
+				continue
+			}
+			p, err := parseEventPredicate(s)
+			if err != nil {
+				return true, err
+			}
+			f.predicates = append(f.predicates, p)
+		}
+	default:
+		return false, nil
+	}
+	return true, err
+}
+
+func (f *eventFilter) empty() bool {
+	return len(f.sources) == 0 && len(f.tags) == 0 && len(f.predicates) == 0
+}
+
+func matchAny(patterns []string, s string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, p := range patterns {
+		if ok, _ := filepath.Match(p, s); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *eventPredicate) match(fields map[string]interface{}) bool {
+	value, ok := fields[p.field]
+	if !ok {
+		return p.op == "!="
+	}
+	switch p.op {
+	case "=", "!=":
+		ok, _ := filepath.Match(p.value, fmt.Sprint(value))
+		return ok == (p.op == "=")
+	}
+	var num float64
+	switch v := value.(type) {
+	case float64:
+		num = v
+	case string:
+		var err error
+		if num, err = strconv.ParseFloat(v, 64); err != nil {
+			return false
+		}
+	default:
+		return false
+	}
+	switch p.op {
+	case "<":
+		return num < p.num
+	case "<=":
+		return num <= p.num
+	case ">":
+		return num > p.num
+	default:
+		return num >= p.num
+	}
+}
+
+// parseEvent returns the source, tag and fields of an event, like
+// {"sonic-events-bgp:bgp-state": {"ip": "10.0.0.1", "status": "down", "timestamp": "..."}}
+func parseEvent(evt map[string]interface{}) (source, tag string, fields map[string]interface{}, ok bool) {
+	if len(evt) != 1 {
+		return "", "", nil, false
+	}
+	for key, value := range evt {
+		i := strings.Index(key, ":")
+		if i < 0 {
+			return "", "", nil, false
+		}
+		fields, _ = value.(map[string]interface{})
+		return key[:i], key[i+1:], fields, true
+	}
+	return "", "", nil, false
+}
+
+// match returns if the event is selected by the filter.
+func (f *eventFilter) match(evt map[string]interface{}) bool {
+	if f.empty() {
+		return true
+	}
+	source, tag, fields, ok := parseEvent(evt)
+	if !ok || !matchAny(f.sources, source) || !matchAny(f.tags, tag) {
+		return false
+	}
+	for i := range f.predicates {
+		if !f.predicates[i].match(fields) {
+			return false
+		}
+	}
+	return true
+}
+
+// aggregatedEvent is an event standing for count identical events received in its window
+type aggregatedEvent struct {
+	event            map[string]interface{}
+	count            uint64
+	first            time.Time
+	publish_epoch_ms int64
+}
+
+// eventAggregator coalesces the identical events received within window of the first one,
+// ignoring their timestamp.
+type eventAggregator struct {
+	window  time.Duration
+	max     int // max number of pending events
+	mu      sync.Mutex
+	pending map[string]*aggregatedEvent
+	closed  bool
+}
+
+func newEventAggregator(window time.Duration) *eventAggregator {
+	return &eventAggregator{window: window, max: AGGREGATE_PENDING_MAX, pending: map[string]*aggregatedEvent{}}
+}
+
+// aggregateKey returns the string identifying the identical events of evt.
+func aggregateKey(evt map[string]interface{}) string {
+	key := map[string]interface{}{}
+	for name, value := range evt {
+		if fields, ok := value.(map[string]interface{}); ok {
+			f := map[string]interface{}{}
+			for k, v := range fields {
+				if k != EVENT_TIMESTAMP_FIELD {
+					f[k] = v
+				}
+			}
+			value = f
+		}
+		key[name] = value
+	}
+	// maps are printed sorted by key
+	return fmt.Sprint(key)
+}
+
+// aggregatable returns if evt can be aggregated, i.e. it has no field named AGGREGATE_COUNT_FIELD
+// which the count of the aggregated event would overwrite.
+func aggregatable(evt map[string]interface{}) bool {
+	_, _, fields, _ := parseEvent(evt)
+	_, exist := fields[AGGREGATE_COUNT_FIELD]
+	return !exist
+}
+
+// add adds evt received at now to the events pending in the aggregator. It returns false,
+// dropping evt, when max events are already pending or the aggregator is discarded.
+func (a *eventAggregator) add(evt map[string]interface{}, publish_epoch_ms int64, now time.Time) bool {
+	key := aggregateKey(evt)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.closed {
+		return false
+	}
+	if p, ok := a.pending[key]; ok {
+		p.count++
+		p.publish_epoch_ms = publish_epoch_ms
+		return true
+	}
+	if len(a.pending) >= a.max {
+		return false
+	}
+	a.pending[key] = &aggregatedEvent{event: evt, count: 1, first: now, publish_epoch_ms: publish_epoch_ms}
+	return true
+}
+
+// expired removes and returns the events whose window ended at now, the oldest first.
+// The events get a count field with the number of events they stand for.
+func (a *eventAggregator) expired(now time.Time) []*aggregatedEvent {
+	return a.take(func(p *aggregatedEvent) bool { return now.Sub(p.first) >= a.window })
+}
+
+// discard drops all the pending events when the subscription stops, returning their number.
+// Events added afterwards are dropped.
+func (a *eventAggregator) discard() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.closed = true
+	n := len(a.pending)
+	a.pending = map[string]*aggregatedEvent{}
+	return n
+}
+
+func (a *eventAggregator) take(done func(p *aggregatedEvent) bool) []*aggregatedEvent {
+	a.mu.Lock()
+	var events []*aggregatedEvent
+	for key, p := range a.pending {
+		if done(p) {
+			events = append(events, p)
+			delete(a.pending, key)
+		}
+	}
+	a.mu.Unlock()
+
+	sort.Slice(events, func(i, j int) bool { return events[i].first.Before(events[j].first) })
+	for _, p := range events {
+		if _, _, fields, ok := parseEvent(p.event); ok && fields != nil {
+			fields[AGGREGATE_COUNT_FIELD] = p.count
+		} else {
+			log.V(4).Infof("Aggregated %d events without fields: %v", p.count, p.event)
+		}
+	}
+	return events
+}
@@ -0,0 +1,313 @@
+package client
+
+import (
+	"encoding/json"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/Workiva/go-datastructures/queue"
+	"github.com/agiledragon/gomonkey/v2"
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+func parseTestEvent(t *testing.T, s string) map[string]interface{} {
+	t.Helper()
+	var evt map[string]interface{}
+	if err := json.Unmarshal([]byte(s), &evt); err != nil {
+		t.Fatalf("Invalid event %s: %v", s, err)
+	}
+	return evt
+}
+
+func TestEventFilter(t *testing.T) {
+	bgpDown := `{"sonic-events-bgp:bgp-state": {"ip": "10.0.0.1", "status": "down", "timestamp": "2024-01-01T00:00:00.000000Z"}}`
+	bgpUp := `{"sonic-events-bgp:bgp-state": {"ip": "fc00::1", "status": "up", "timestamp": "2024-01-01T00:00:01.000000Z"}}`
+	memUsage := `{"sonic-events-host:mem-usage": {"usage": "91.5", "limit": 90, "timestamp": "2024-01-01T00:00:02.000000Z"}}`
+	events := []string{bgpDown, bgpUp, memUsage}
+
+	tests := []struct {
+		params map[string]string
+		want   []string
+	}{
+		{params: nil, want: events},
+		{params: map[string]string{PARAM_SOURCE: "sonic-events-bgp"}, want: []string{bgpDown, bgpUp}},
+		{params: map[string]string{PARAM_SOURCE: "sonic-events-swss, sonic-events-host"}, want: []string{memUsage}},
+		{params: map[string]string{PARAM_TAG: "bgp-*"}, want: []string{bgpDown, bgpUp}},
+		{params: map[string]string{PARAM_SOURCE: "sonic-events-bgp", PARAM_TAG: "mem-usage"}, want: nil},
+		{params: map[string]string{PARAM_FILTER: "status=down"}, want: []string{bgpDown}},
+		{params: map[string]string{PARAM_FILTER: "status!=down"}, want: []string{bgpUp, memUsage}},
+		{params: map[string]string{PARAM_FILTER: "ip=10.*,status=down"}, want: []string{bgpDown}},
+		{params: map[string]string{PARAM_FILTER: "usage>90"}, want: []string{memUsage}},
+		{params: map[string]string{PARAM_FILTER: "usage<=91.5,limit>=90"}, want: []string{memUsage}},
+		{params: map[string]string{PARAM_FILTER: "limit<90"}, want: nil},
+		{params: map[string]string{PARAM_FILTER: "ip>1"}, want: nil},
+	}
+	for _, test := range tests {
+		var f eventFilter
+		for k, v := range test.params {
+			if ok, err := f.setParam(k, v); !ok || err != nil {
+				t.Fatalf("setParam(%s, %s) = %v, %v", k, v, ok, err)
+			}
+		}
+		var got []string
+		for _, evt := range events {
+			if f.match(parseTestEvent(t, evt)) {
+				got = append(got, evt)
+			}
+		}
+		if len(got) != len(test.want) {
+			t.Errorf("Filter %v selected %v, want %v", test.params, got, test.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != test.want[i] {
+				t.Errorf("Filter %v selected %v, want %v", test.params, got, test.want)
+			}
+		}
+	}
+
+	var f eventFilter
+	if ok, _ := f.setParam(PARAM_QSIZE, "1024"); ok {
+		t.Errorf("Expected %s to not be a filter parameter", PARAM_QSIZE)
+	}
+	for k, v := range map[string]string{PARAM_SOURCE: "sonic-events-[", PARAM_FILTER: "status", PARAM_TAG: "[a-"} {
+		if _, err := f.setParam(k, v); err == nil {
+			t.Errorf("Expected invalid %s=%s to fail", k, v)
+		}
+	}
+	if _, err := f.setParam(PARAM_FILTER, "usage>high"); err == nil {
+		t.Errorf("Expected non numeric comparison to fail")
+	}
+	f = eventFilter{}
+	f.setParam(PARAM_SOURCE, "sonic-events-bgp")
+	if f.match(nil) || f.match(parseTestEvent(t, `{"a": 1, "b": 2}`)) {
+		t.Errorf("Expected invalid events to not match a filter")
+	}
+}
+
+func TestParseEventParams(t *testing.T) {
+	if got := splitEventParam(`a\,b,c\*,,d`); !reflect.DeepEqual(got, []string{"a,b", `c\*`, "", "d"}) {
+		t.Errorf("splitEventParam = %q", got)
+	}
+
+	tests := []struct {
+		s, field, op, value string
+	}{
+		{"status=down", "status", "=", "down"},
+		{"status != down", "status", "!=", "down"},
+		{"usage>=90", "usage", ">=", "90"},
+		{"msg=a>=b", "msg", "=", "a>=b"},
+		{"msg!=x=y", "msg", "!=", "x=y"},
+	}
+	for _, test := range tests {
+		p, err := parseEventPredicate(test.s)
+		if err != nil || p.field != test.field || p.op != test.op || p.value != test.value {
+			t.Errorf("parseEventPredicate(%q) = %+v, %v", test.s, p, err)
+		}
+	}
+	for _, s := range []string{"=down", "status", "msg<a=b"} {
+		if _, err := parseEventPredicate(s); err == nil {
+			t.Errorf("Expected parseEventPredicate(%q) to fail", s)
+		}
+	}
+
+	var f eventFilter
+	if _, err := f.setParam(PARAM_FILTER, `peers=10.0.0.1\,10.0.0.2,status=down`); err != nil {
+		t.Fatalf("setParam failed: %v", err)
+	}
+	if len(f.predicates) != 2 || f.predicates[0].value != "10.0.0.1,10.0.0.2" {
+		t.Fatalf("Unexpected predicates %+v", f.predicates)
+	}
+	if !f.match(parseTestEvent(t, `{"sonic-events-bgp:bgp-state": {"peers": "10.0.0.1,10.0.0.2", "status": "down"}}`)) {
+		t.Errorf("Expected the escaped comma to be part of the value")
+	}
+}
+
+func TestEventAggregator(t *testing.T) {
+	a := newEventAggregator(time.Second)
+	start := time.Now()
+	down := func(ts string) map[string]interface{} {
+		return parseTestEvent(t, `{"sonic-events-bgp:bgp-state": {"ip": "10.0.0.1", "status": "down", "timestamp": "`+ts+`"}}`)
+	}
+	a.add(down("t1"), 1, start)
+	a.add(parseTestEvent(t, `{"sonic-events-bgp:bgp-state": {"ip": "10.0.0.2", "status": "down", "timestamp": "t2"}}`), 2, start.Add(100*time.Millisecond))
+	a.add(down("t3"), 3, start.Add(200*time.Millisecond))
+	a.add(down("t4"), 4, start.Add(300*time.Millisecond))
+
+	if events := a.expired(start.Add(500 * time.Millisecond)); len(events) != 0 {
+		t.Fatalf("Expected no event before the end of the window, got %v", events)
+	}
+	events := a.expired(start.Add(time.Second))
+	if len(events) != 1 || events[0].count != 3 || events[0].publish_epoch_ms != 4 {
+		t.Fatalf("Expected 3 identical events coalesced, got %v", events)
+	}
+	b, _ := json.Marshal(events[0].event)
+	want := `{"sonic-events-bgp:bgp-state":{"count":3,"ip":"10.0.0.1","status":"down","timestamp":"t1"}}`
+	if string(b) != want {
+		t.Errorf("Aggregated event %s, want %s", b, want)
+	}
+
+	// A new window starts after the previous one is sent
+	a.add(down("t5"), 5, start.Add(time.Second))
+	events = a.expired(start.Add(2 * time.Second))
+	if len(events) != 2 || events[0].count != 1 || events[1].count != 1 {
+		t.Fatalf("Expected 2 single events, got %v", events)
+	}
+	if ip := events[0].event["sonic-events-bgp:bgp-state"].(map[string]interface{})["ip"]; ip != "10.0.0.2" {
+		t.Errorf("Expected the oldest event first, got %v", events[0].event)
+	}
+	if events := a.expired(start.Add(time.Hour)); len(events) != 0 {
+		t.Errorf("Expected no pending event, got %v", events)
+	}
+}
+
+func TestEventAggregatorLimits(t *testing.T) {
+	a := newEventAggregator(time.Minute)
+	a.max = 2
+	start := time.Now()
+	state := func(ip string) map[string]interface{} {
+		return parseTestEvent(t, `{"sonic-events-bgp:bgp-state": {"ip": "`+ip+`", "status": "down"}}`)
+	}
+	if !a.add(state("10.0.0.1"), 1, start) || !a.add(state("10.0.0.2"), 2, start) {
+		t.Fatalf("Expected the events to be added")
+	}
+	if a.add(state("10.0.0.3"), 3, start) {
+		t.Errorf("Expected a new event beyond the limit to be dropped")
+	}
+	if !a.add(state("10.0.0.1"), 4, start) {
+		t.Errorf("Expected an event pending already to be aggregated")
+	}
+
+	// Pending events are discarded when the subscription stops
+	if n := a.discard(); n != 2 {
+		t.Fatalf("Expected the 2 pending events to be discarded, got %d", n)
+	}
+	if events := a.expired(start.Add(time.Hour)); len(events) != 0 {
+		t.Errorf("Expected no pending event once discarded, got %v", events)
+	}
+	if a.add(state("10.0.0.1"), 5, start) {
+		t.Errorf("Expected events to be dropped once discarded")
+	}
+
+	// Events with a count field of their own are not aggregated
+	if !aggregatable(state("10.0.0.1")) {
+		t.Errorf("Expected an event without count field to be aggregatable")
+	}
+	counted := parseTestEvent(t, `{"sonic-events-bgp:bgp-state": {"ip": "10.0.0.1", "count": "7"}}`)
+	if aggregatable(counted) {
+		t.Errorf("Expected an event with a count field not to be aggregatable")
+	}
+}
+
+// TestGetEventsFilterAndAggregate runs get_events and send_aggregated on received events
+func TestGetEventsFilterAndAggregate(t *testing.T) {
+	events := []string{
+		`{"sonic-events-bgp:bgp-state": {"ip": "10.0.0.1", "status": "down", "timestamp": "t1"}}`,
+		`{"sonic-events-bgp:bgp-state": {"ip": "10.0.0.1", "status": "up", "timestamp": "t2"}}`,
+		`{"sonic-events-eventd:heartbeat": {"timestamp": "t3"}}`,
+		`{"sonic-events-host:mem-usage": {"usage": "91.5", "timestamp": "t4"}}`,
+		`{"sonic-events-bgp:bgp-state": {"ip": "10.0.0.1", "status": "down", "timestamp": "t5"}}`,
+		`{"sonic-events-bgp:bgp-state": {"ip": "10.0.0.1", "status": "down", "timestamp": "t6"}}`,
+		`{"sonic-events-bgp:bgp-state": {"ip": "10.0.0.2", "status": "down", "count": "7", "timestamp": "t7"}}`,
+		`{"sonic-events-bgp:bgp-state": {"ip": "10.0.0.3", "status": "down", "timestamp": "t8"}}`,
+	}
+	var mu sync.Mutex
+	next := 0
+	mock1 := gomonkey.ApplyFunc(C_init_subs, func(use_cache bool) unsafe.Pointer {
+		return nil
+	})
+	defer mock1.Reset()
+	mock2 := gomonkey.ApplyFunc(C_recv_evt, func(h unsafe.Pointer) (int, Evt_rcvd) {
+		mu.Lock()
+		defer mu.Unlock()
+		if next < len(events) {
+			next++
+			return 0, Evt_rcvd{Event_str: events[next-1], Publish_epoch_ms: int64(next)}
+		}
+		time.Sleep(10 * time.Millisecond)
+		return -1, Evt_rcvd{}
+	})
+	defer mock2.Reset()
+	mock3 := gomonkey.ApplyFunc(C_deinit_subs, func(h unsafe.Pointer) {})
+	defer mock3.Reset()
+
+	path := &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "all", Key: map[string]string{
+		PARAM_SOURCE: "sonic-events-bgp", PARAM_FILTER: "status=down", PARAM_AGGREGATE: "600"}}}}
+	c, err := NewEventClient([]*gnmipb.Path{path}, nil, 4)
+	if err != nil {
+		t.Fatalf("NewEventClient failed: %v", err)
+	}
+	evtc := c.(*EventClient)
+	evtc.aggregator.max = 1
+	var wg sync.WaitGroup
+	evtc.wg = &wg
+	evtc.q = queue.NewPriorityQueue(1, false)
+	wg.Add(2)
+	go get_events(evtc)
+	go send_aggregated(evtc)
+
+	counter := func(key string) uint64 {
+		evtc.countersMutex.Lock()
+		defer evtc.countersMutex.Unlock()
+		return evtc.counters[key]
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mu.Lock()
+		received := next
+		mu.Unlock()
+		if received == len(events) && counter(FILTERED) == 2 && counter(AGGREGATION_DROPPED) == 1 && evtc.q.Len() == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected 2 filtered events, 1 dropped by aggregation and 2 sent, got filtered %d, dropped %d, queued %d",
+				counter(FILTERED), counter(AGGREGATION_DROPPED), evtc.q.Len())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// The pending aggregate is discarded on stop
+	evtc.stopMutex.Lock()
+	evtc.stopped = 1
+	evtc.stopMutex.Unlock()
+	wg.Wait()
+
+	items, err := evtc.q.Get(2)
+	if err != nil || len(items) != 2 {
+		t.Fatalf("Expected the heartbeat and the event with a count field, got %v %v", items, err)
+	}
+	var got []string
+	for _, item := range items {
+		got = append(got, string(item.(Value).GetVal().GetJsonIetfVal()))
+	}
+	want := []string{
+		`{"sonic-events-eventd:heartbeat":{"timestamp":"t3"}}`,
+		`{"sonic-events-bgp:bgp-state":{"count":"7","ip":"10.0.0.2","status":"down","timestamp":"t7"}}`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Got events %v, want %v", got, want)
+	}
+	if evtc.q.Len() != 0 {
+		t.Errorf("Expected the pending aggregate to be discarded, got %d queued", evtc.q.Len())
+	}
+	if counter(DROPPED) != 0 {
+		t.Errorf("Expected no dropped event, got %d", counter(DROPPED))
+	}
+}
+
+func TestNewEventClientInvalidParams(t *testing.T) {
+	for _, key := range []map[string]string{
+		{PARAM_FILTER: "status"},
+		{PARAM_SOURCE: "sonic-events-["},
+		{PARAM_AGGREGATE: "-1"},
+		{PARAM_AGGREGATE: "soon"},
+	} {
+		path := &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "all", Key: key}}}
+		if _, err := NewEventClient([]*gnmipb.Path{path}, nil, 4); err == nil {
+			t.Errorf("Expected NewEventClient with %v to fail", key)
+		}
+	}
+}
@@ -1,9 +1,5 @@
 package client
 
-// #cgo pkg-config: python3-embed
-// #include <Python.h>
-import "C"
-
 import (
 	"bytes"
 	"encoding/json"
@@ -11,18 +7,20 @@ import (
 	"io/ioutil"
 	"net"
 	"os"
+	"path/filepath"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
-	"unsafe"
 
 	"github.com/Workiva/go-datastructures/queue"
 	"github.com/go-redis/redis"
 	log "github.com/golang/glog"
 	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
 	"github.com/sonic-net/sonic-gnmi/common_utils"
+	"github.com/sonic-net/sonic-gnmi/pkg/yangvalidator"
 	spb "github.com/sonic-net/sonic-gnmi/proto"
 	sdcfg "github.com/sonic-net/sonic-gnmi/sonic_db_config"
 	ssc "github.com/sonic-net/sonic-gnmi/sonic_service_client"
@@ -88,6 +86,9 @@ type MixedDbClient struct {
 	mapkey        string
 	namespace_cnt int
 	container_cnt int
+	// Database instances matched by a wildcard namespace/container element,
+	// keyed by the instance name used in the expanded paths
+	instances map[string]swsscommon.SonicDBKey
 
 	synced sync.WaitGroup  // Control when to send gNMI sync_response
 	w      *sync.WaitGroup // wait for all sub go routines to finish
@@ -294,6 +295,20 @@ func ProducerStateTableDeleteWrapper(pt swsscommon.ProducerStateTable, key strin
 	return
 }
 
+func ProducerStateTableSetBufferedWrapper(pt swsscommon.ProducerStateTable, buffered bool) (err error) {
+	// convert panic to error
+	defer CatchException(&err)
+	pt.SetBuffered(buffered)
+	return
+}
+
+func ProducerStateTableFlushWrapper(pt swsscommon.ProducerStateTable) (err error) {
+	// convert panic to error
+	defer CatchException(&err)
+	pt.Flush()
+	return
+}
+
 type ActionNeedRetry func() error
 
 func RetryHelper(zmqClient swsscommon.ZmqClient, action ActionNeedRetry) error {
@@ -428,6 +443,139 @@ func (c *MixedDbClient) ParseDatabase(prefix *gnmipb.Path, paths []*gnmipb.Path)
 	return target, dbkey, nil
 }
 
+// isInstanceWildcard reports whether a namespace/container path element
+// selects several database instances, e.g. "*", "asic*" or "dpu[0-3]".
+func isInstanceWildcard(name string) bool {
+	return strings.ContainsAny(name, "*?[")
+}
+
+// instanceName returns the namespace/container path element that addresses a
+// database instance: the namespace, the container, or "localhost" for the
+// default instance.
+func instanceName(dbkey swsscommon.SonicDBKey) string {
+	if ns := dbkey.GetNetns(); ns != sdcfg.SONIC_DEFAULT_NAMESPACE {
+		return ns
+	}
+	if container := dbkey.GetContainerName(); container != sdcfg.SONIC_DEFAULT_CONTAINER {
+		return container
+	}
+	return HOSTNAME
+}
+
+// hasInstanceWildcard reports whether any path selects database instances with a wildcard.
+func hasInstanceWildcard(prefix *gnmipb.Path, paths []*gnmipb.Path) bool {
+	index := ELEM_INDEX_INSTANCE - len(prefix.GetElem())
+	if index < 0 {
+		return isInstanceWildcard(prefix.GetElem()[ELEM_INDEX_INSTANCE].GetName())
+	}
+	for _, path := range paths {
+		elems := path.GetElem()
+		if index < len(elems) && isInstanceWildcard(elems[index].GetName()) {
+			return true
+		}
+	}
+	return false
+}
+
+// loadInstances collects every database instance holding the target database,
+// keyed by instance name.
+func (c *MixedDbClient) loadInstances(target string) error {
+	dbkeys, err := sdcfg.GetDbAllInstances()
+	if err != nil {
+		return err
+	}
+	c.instances = make(map[string]swsscommon.SonicDBKey)
+	for _, dbkey := range dbkeys {
+		if IsTargetDbByDBKey(target, dbkey) {
+			c.instances[instanceName(dbkey)] = dbkey
+		} else {
+			swsscommon.DeleteSonicDBKey(dbkey)
+		}
+	}
+	return nil
+}
+
+// expandPath returns the paths a request path resolves to: one path per
+// database instance matching a wildcard namespace/container element, with the
+// element replaced by the instance name, or the path itself otherwise.
+// The wildcard must be in the path; a prefix selects a single instance.
+func (c *MixedDbClient) expandPath(path *gnmipb.Path) ([]*gnmipb.Path, error) {
+	index := ELEM_INDEX_INSTANCE - len(c.prefix.GetElem())
+	if index < 0 {
+		if isInstanceWildcard(c.prefix.GetElem()[ELEM_INDEX_INSTANCE].GetName()) {
+			return nil, status.Error(codes.Unimplemented, "Wildcard namespace/container is not supported in prefix")
+		}
+		return []*gnmipb.Path{path}, nil
+	}
+	elems := path.GetElem()
+	if index >= len(elems) || !isInstanceWildcard(elems[index].GetName()) {
+		return []*gnmipb.Path{path}, nil
+	}
+
+	pattern := elems[index].GetName()
+	names := make([]string, 0, len(c.instances))
+	for name := range c.instances {
+		matched, err := filepath.Match(pattern, name)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "Invalid namespace/container pattern %s", pattern)
+		}
+		if matched {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil, status.Errorf(codes.NotFound, "No namespace/container matches %s", pattern)
+	}
+	sort.Strings(names)
+
+	expanded := make([]*gnmipb.Path, 0, len(names))
+	for _, name := range names {
+		newElems := make([]*gnmipb.PathElem, len(elems))
+		copy(newElems, elems)
+		newElems[index] = &gnmipb.PathElem{Name: name, Key: elems[index].GetKey()}
+		expanded = append(expanded, &gnmipb.Path{
+			Origin: path.GetOrigin(),
+			Target: path.GetTarget(),
+			Elem:   newElems,
+		})
+	}
+	return expanded, nil
+}
+
+// expandPaths expands every request path, see expandPath.
+func (c *MixedDbClient) expandPaths(paths []*gnmipb.Path) ([]*gnmipb.Path, error) {
+	var expanded []*gnmipb.Path
+	for _, path := range paths {
+		p, err := c.expandPath(path)
+		if err != nil {
+			return nil, err
+		}
+		expanded = append(expanded, p...)
+	}
+	return expanded, nil
+}
+
+// dbkeyForPath returns the database instance a path reads from. For a
+// wildcard request this is the instance named in the expanded path, otherwise
+// the instance resolved by ParseDatabase.
+func (c *MixedDbClient) dbkeyForPath(path *gnmipb.Path) swsscommon.SonicDBKey {
+	if c.instances == nil {
+		return c.dbkey
+	}
+	elems := append(append([]*gnmipb.PathElem{}, c.prefix.GetElem()...), path.GetElem()...)
+	if len(elems) > ELEM_INDEX_INSTANCE {
+		if dbkey, ok := c.instances[elems[ELEM_INDEX_INSTANCE].GetName()]; ok {
+			return dbkey
+		}
+	}
+	return c.dbkey
+}
+
+// redisMapKey returns the RedisDbMap key of the database a table path belongs to.
+func (tblPath *tablePath) redisMapKey() string {
+	return tblPath.dbNamespace + ":" + tblPath.dbContainer + ":" + tblPath.dbName
+}
+
 // Initialize RedisDbMap
 func initRedisDbMap() {
 	dbkeys, err := sdcfg.GetDbAllInstances()
@@ -529,6 +677,10 @@ func NewMixedDbClient(paths []*gnmipb.Path, prefix *gnmipb.Path, origin string,
 		return &client, nil
 	}
 
+	if hasInstanceWildcard(prefix, paths) {
+		return client.newFanoutClient(paths, targetDbName)
+	}
+
 	target, dbkey, err := client.ParseDatabase(prefix, paths)
 	if err != nil {
 		return nil, err
@@ -564,6 +716,56 @@ func NewMixedDbClient(paths []*gnmipb.Path, prefix *gnmipb.Path, origin string,
 	return &client, nil
 }
 
+// newFanoutClient completes a client whose paths select several database
+// instances with a wildcard namespace/container element, e.g. CONFIG_DB/*/PORT
+// or COUNTERS_DB/asic*/COUNTERS/Ethernet0. The paths are expanded to one path
+// per matching instance, and each expanded path is read from its own instance.
+// Such a client supports Get and Subscribe only.
+func (c *MixedDbClient) newFanoutClient(paths []*gnmipb.Path, targetDbName *string) (Client, error) {
+	target := ""
+	for _, path := range paths {
+		elems := append(append([]*gnmipb.PathElem{}, c.prefix.GetElem()...), path.GetElem()...)
+		if len(elems) < 2 {
+			return nil, status.Error(codes.Unimplemented, "Invalid elem length")
+		}
+		if target == "" {
+			target = elems[ELEM_INDEX_DATABASE].GetName()
+		} else if target != elems[ELEM_INDEX_DATABASE].GetName() {
+			return nil, status.Error(codes.Unimplemented, "Target conflict in path")
+		}
+	}
+
+	if err := c.loadInstances(target); err != nil {
+		return nil, err
+	}
+	expanded, err := c.expandPaths(paths)
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+	for _, path := range expanded {
+		elems := append(append([]*gnmipb.PathElem{}, c.prefix.GetElem()...), path.GetElem()...)
+		if _, ok := c.instances[elems[ELEM_INDEX_INSTANCE].GetName()]; !ok {
+			c.Close()
+			return nil, status.Errorf(codes.Unimplemented, "Invalid target: namespace/container %s",
+				elems[ELEM_INDEX_INSTANCE].GetName())
+		}
+	}
+
+	// The first instance is used where a single database is needed, e.g. for the separator
+	dbkey := c.dbkeyForPath(expanded[0])
+	c.dbkey = swsscommon.NewSonicDBKey()
+	c.dbkey.SetNetns(dbkey.GetNetns())
+	c.dbkey.SetContainerName(dbkey.GetContainerName())
+	c.mapkey = dbkey.GetNetns() + ":" + dbkey.GetContainerName()
+
+	c.target = target
+	*targetDbName = target
+	c.paths = expanded
+	c.workPath = common_utils.GNMI_WORK_PATH
+	return c, nil
+}
+
 // gnmiFullPath builds the full path from the prefix and path.
 func (c *MixedDbClient) gnmiFullPath(prefix, path *gnmipb.Path) (*gnmipb.Path, error) {
 	origin := ""
@@ -589,17 +791,6 @@ func (c *MixedDbClient) gnmiFullPath(prefix, path *gnmipb.Path) (*gnmipb.Path, e
 	return fullPath, nil
 }
 
-func (c *MixedDbClient) getAllDbtablePath(paths []*gnmipb.Path) (pathList [][]tablePath, err error) {
-	for _, path := range paths {
-		tblPaths, err := c.getDbtablePath(path, nil)
-		if err != nil {
-			return nil, err
-		}
-		pathList = append(pathList, tblPaths)
-	}
-	return pathList, nil
-}
-
 func (c *MixedDbClient) getDbtablePath(path *gnmipb.Path, value *gnmipb.TypedValue) ([]tablePath, error) {
 	var buffer bytes.Buffer
 	var dbPath string
@@ -610,8 +801,9 @@ func (c *MixedDbClient) getDbtablePath(path *gnmipb.Path, value *gnmipb.TypedVal
 		return nil, err
 	}
 
+	dbkey := c.dbkeyForPath(path)
 	stringSlice := []string{c.target}
-	separator, _ := GetTableKeySeparatorByDBKey(c.target, c.dbkey)
+	separator, _ := GetTableKeySeparatorByDBKey(c.target, dbkey)
 	elems := fullPath.GetElem()
 	if elems != nil {
 		for i, elem := range elems {
@@ -630,7 +822,8 @@ func (c *MixedDbClient) getDbtablePath(path *gnmipb.Path, value *gnmipb.TypedVal
 		dbPath = buffer.String()
 	}
 
-	tblPath.dbNamespace = c.dbkey.GetNetns()
+	tblPath.dbNamespace = dbkey.GetNetns()
+	tblPath.dbContainer = dbkey.GetContainerName()
 	tblPath.dbName = c.target
 	tblPath.tableName = ""
 	if len(stringSlice) > 1 {
@@ -672,9 +865,9 @@ func (c *MixedDbClient) getDbtablePath(path *gnmipb.Path, value *gnmipb.TypedVal
 		mappedKey = stringSlice[2]
 	}
 
-	redisDb, ok := RedisDbMap[c.mapkey+":"+tblPath.dbName]
+	redisDb, ok := RedisDbMap[tblPath.redisMapKey()]
 	if !ok {
-		return nil, fmt.Errorf("Redis Client not present for dbName %v mapkey %v map %+v", tblPath.dbName, c.mapkey, RedisDbMap)
+		return nil, fmt.Errorf("Redis Client not present for dbName %v mapkey %v map %+v", tblPath.dbName, tblPath.redisMapKey(), RedisDbMap)
 	}
 
 	// The expect real db path could be in one of the formats:
@@ -788,9 +981,9 @@ func (c *MixedDbClient) makeJSON_redis(msi *map[string]interface{}, key *string,
 // If only table name provided in the tablePath, find all keys in the table, otherwise
 // Use tableName + tableKey as key to get all field value paires
 func (c *MixedDbClient) tableData2Msi(tblPath *tablePath, useKey bool, op *string, msi *map[string]interface{}) error {
-	redisDb, ok := RedisDbMap[c.mapkey+":"+tblPath.dbName]
+	redisDb, ok := RedisDbMap[tblPath.redisMapKey()]
 	if !ok {
-		return fmt.Errorf("Redis Client not present for dbName %v mapkey %v", tblPath.dbName, c.mapkey)
+		return fmt.Errorf("Redis Client not present for dbName %v mapkey %v", tblPath.dbName, tblPath.redisMapKey())
 	}
 
 	var pattern string
@@ -914,9 +1107,9 @@ func (c *MixedDbClient) tableData2TypedValue(tblPaths []tablePath, op *string) (
 	var useKey bool
 	msi := make(map[string]interface{})
 	for _, tblPath := range tblPaths {
-		redisDb, ok := RedisDbMap[c.mapkey+":"+tblPath.dbName]
+		redisDb, ok := RedisDbMap[tblPath.redisMapKey()]
 		if !ok {
-			return nil, fmt.Errorf("Redis Client not present for dbName %v mapkey %v", tblPath.dbName, c.mapkey)
+			return nil, fmt.Errorf("Redis Client not present for dbName %v mapkey %v", tblPath.dbName, tblPath.redisMapKey())
 		}
 
 		if tblPath.jsonField == "" { // Not asked to include field in json value, which means not wildcard query
@@ -1009,17 +1202,31 @@ func ConvertDbEntry(inputData map[string]interface{}) map[string]string {
 	return outputData
 }
 
-func (c *MixedDbClient) handleTableData(tblPaths []tablePath) error {
+// planTableData computes the writes needed to apply tblPaths, without modifying the database.
+// Each write is labelled with operation, so that failures can be attributed to it.
+func (c *MixedDbClient) planTableData(tblPaths []tablePath, operation string) ([]dbWrite, error) {
 	var pattern string
 	var dbkeys []string
 	var err error
 	var res interface{}
+	var writes []dbWrite
 
 	for _, tblPath := range tblPaths {
-		log.V(5).Infof("handleTableData: tblPath %v", tblPath)
-		redisDb, ok := RedisDbMap[c.mapkey+":"+tblPath.dbName]
+		log.V(5).Infof("planTableData: tblPath %v", tblPath)
+		redisDb, ok := RedisDbMap[tblPath.redisMapKey()]
 		if !ok {
-			return fmt.Errorf("Redis Client not present for dbName %v mapkey %v", tblPath.dbName, c.mapkey)
+			return nil, fmt.Errorf("Redis Client not present for dbName %v mapkey %v", tblPath.dbName, tblPath.redisMapKey())
+		}
+
+		newWrite := func(tableKey string, values map[string]string) dbWrite {
+			return dbWrite{
+				operation: operation,
+				redisDb:   redisDb,
+				table:     tblPath.tableName,
+				delimitor: tblPath.delimitor,
+				key:       tableKey,
+				values:    values,
+			}
 		}
 
 		if tblPath.jsonField == "" { // Not asked to include field in json value, which means not wildcard query
@@ -1028,7 +1235,7 @@ func (c *MixedDbClient) handleTableData(tblPaths []tablePath) error {
 				if len(tblPaths) != 1 {
 					log.V(2).Infof("WARNING: more than one path exists for field granularity query: %v", tblPaths)
 				}
-				return fmt.Errorf("Unsupported path %v, can't update field", tblPath)
+				return nil, fmt.Errorf("Unsupported path %v, can't update field", tblPath)
 			}
 		}
 
@@ -1045,7 +1252,7 @@ func (c *MixedDbClient) handleTableData(tblPaths []tablePath) error {
 				dbkeys, err = redisDb.Keys(pattern).Result()
 				if err != nil {
 					log.V(2).Infof("redis Keys failed for %v, pattern %s", tblPath, pattern)
-					return fmt.Errorf("redis Keys failed for %v, pattern %s %v", tblPath, pattern, err)
+					return nil, fmt.Errorf("redis Keys failed for %v, pattern %s %v", tblPath, pattern, err)
 				}
 			} else {
 				// both table name and key provided
@@ -1054,11 +1261,7 @@ func (c *MixedDbClient) handleTableData(tblPaths []tablePath) error {
 
 			for _, dbkey := range dbkeys {
 				tableKey := strings.TrimPrefix(dbkey, tblPath.tableName+tblPath.delimitor)
-				err = c.DbDelTable(tblPath.tableName, tableKey)
-				if err != nil {
-					log.V(2).Infof("swsscommon delete failed for  %v, dbkey %s", tblPath, dbkey)
-					return err
-				}
+				writes = append(writes, newWrite(tableKey, nil))
 			}
 		} else if tblPath.operation == opAdd {
 			if tblPath.tableKey != "" {
@@ -1066,61 +1269,498 @@ func (c *MixedDbClient) handleTableData(tblPaths []tablePath) error {
 				if len(tblPath.jsonValue) != 0 {
 					res, err = parseJson([]byte(tblPath.jsonValue))
 					if err != nil {
-						return err
+						return nil, err
 					}
 					if vtable, ok := res.(map[string]interface{}); ok {
-						outputData := ConvertDbEntry(vtable)
-						err = c.DbSetTable(tblPath.tableName, tblPath.tableKey, outputData)
-						if err != nil {
-							log.V(2).Infof("swsscommon update failed for  %v, value %v", tblPath, outputData)
-							return err
-						}
+						writes = append(writes, newWrite(tblPath.tableKey, ConvertDbEntry(vtable)))
 					} else {
-						return fmt.Errorf("Key %v: Unsupported value %v type %v", tblPath.tableKey, res, reflect.TypeOf(res))
+						return nil, fmt.Errorf("Key %v: Unsupported value %v type %v", tblPath.tableKey, res, reflect.TypeOf(res))
 					}
 				} else {
 					// protobytes can be empty
 					// If jsonValue is empty, use protoValue
 					vtable := make(map[string]interface{})
 					vtable["pb"] = tblPath.protoValue
-					outputData := ConvertDbEntry(vtable)
-					err = c.DbSetTable(tblPath.tableName, tblPath.tableKey, outputData)
-					if err != nil {
-						log.V(2).Infof("swsscommon update failed for  %v, value %v", tblPath, outputData)
-						return err
-					}
+					writes = append(writes, newWrite(tblPath.tableKey, ConvertDbEntry(vtable)))
 				}
 			} else {
 				if len(tblPath.jsonValue) == 0 {
-					return fmt.Errorf("No valid value: %v", tblPath)
+					return nil, fmt.Errorf("No valid value: %v", tblPath)
 				}
 				res, err = parseJson([]byte(tblPath.jsonValue))
 				if err != nil {
-					return err
+					return nil, err
 				}
 				if vtable, ok := res.(map[string]interface{}); ok {
 					for tableKey, tres := range vtable {
 						if vt, ret := tres.(map[string]interface{}); ret {
-							outputData := ConvertDbEntry(vt)
-							err = c.DbSetTable(tblPath.tableName, tableKey, outputData)
-							if err != nil {
-								log.V(2).Infof("swsscommon update failed for  %v, value %v", tblPath, outputData)
-								return err
-							}
+							writes = append(writes, newWrite(tableKey, ConvertDbEntry(vt)))
 						} else {
-							return fmt.Errorf("Key %v: Unsupported value %v type %v", tableKey, tres, reflect.TypeOf(tres))
+							return nil, fmt.Errorf("Key %v: Unsupported value %v type %v", tableKey, tres, reflect.TypeOf(tres))
 						}
 					}
 				} else {
-					return fmt.Errorf("Unsupported value %v type %v", res, reflect.TypeOf(res))
+					return nil, fmt.Errorf("Unsupported value %v type %v", res, reflect.TypeOf(res))
 				}
 			}
 		} else {
-			return fmt.Errorf("Unsupported operation %v", tblPath.operation)
+			return nil, fmt.Errorf("Unsupported operation %v", tblPath.operation)
 		}
 
 	}
-	return nil
+	return writes, nil
+}
+
+// dbWrite is a single ProducerStateTable operation planned for a SetRequest.
+type dbWrite struct {
+	operation string        // SetRequest operation the write belongs to, e.g. "update[0] /DASH_QOS/qos_01"
+	redisDb   *redis.Client // Database holding the table, used to snapshot the key
+	table     string
+	delimitor string
+	key       string
+	values    map[string]string // nil for delete
+}
+
+func (w *dbWrite) dbKey() string {
+	return w.table + w.delimitor + w.key
+}
+
+// id identifies the key of the write across database instances.
+func (w *dbWrite) id() string {
+	return fmt.Sprintf("%p:%s", w.redisDb, w.dbKey())
+}
+
+// keyState is the content of a key, as the producer of its table sees it.
+type keyState struct {
+	exists bool
+	values map[string]string
+}
+
+// apply updates the state with a write. A set merges its fields, as the consumer of the table does.
+func (s *keyState) apply(w *dbWrite) {
+	if w.values == nil {
+		s.exists, s.values = false, map[string]string{}
+		return
+	}
+	merged := make(map[string]string, len(s.values)+len(w.values))
+	for field, value := range s.values {
+		merged[field] = value
+	}
+	for field, value := range w.values {
+		merged[field] = value
+	}
+	s.exists, s.values = true, merged
+}
+
+// dbSnapshot is the content of a key before a SetRequest was applied.
+type dbSnapshot struct {
+	write *dbWrite
+	keyState
+}
+
+// equal returns if both states have the same content.
+func (s keyState) equal(other keyState) bool {
+	if s.exists != other.exists || len(s.values) != len(other.values) {
+		return false
+	}
+	for field, value := range s.values {
+		if other.values[field] != value {
+			return false
+		}
+	}
+	return true
+}
+
+const (
+	// ZMQ_SENT_TTL is how long the content of a key sent through ZMQ is remembered. The copy of
+	// the table in redis has caught up well before then.
+	ZMQ_SENT_TTL = time.Minute
+	// ZMQ_SENT_MAX bounds the number of keys remembered. The oldest are forgotten first.
+	ZMQ_SENT_MAX = 16384
+)
+
+// zmqSentState is the content of a key of a ZMQ table, and when it was sent.
+type zmqSentState struct {
+	keyState
+	sent time.Time
+}
+
+// zmqSent is the content of the keys of ZMQ tables as last sent by this process. ZMQ writes go
+// straight to the DPU and only reach the copy of the table in redis later, so that copy can't
+// tell what a rollback has to restore. Keys are forgotten once redis holds the content sent,
+// or after ZMQ_SENT_TTL.
+var zmqSent = struct {
+	sync.Mutex
+	states map[string]zmqSentState
+}{states: map[string]zmqSentState{}}
+
+// pruneZmqSent forgets the keys sent ZMQ_SENT_TTL before now, then the oldest keys beyond
+// ZMQ_SENT_MAX. zmqSent must be locked.
+func pruneZmqSent(now time.Time) {
+	for id, state := range zmqSent.states {
+		if now.Sub(state.sent) >= ZMQ_SENT_TTL {
+			delete(zmqSent.states, id)
+		}
+	}
+
+	excess := len(zmqSent.states) - ZMQ_SENT_MAX
+	if excess <= 0 {
+		return
+	}
+	ids := make([]string, 0, len(zmqSent.states))
+	for id := range zmqSent.states {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return zmqSent.states[ids[i]].sent.Before(zmqSent.states[ids[j]].sent)
+	})
+	for _, id := range ids[:excess] {
+		delete(zmqSent.states, id)
+	}
+}
+
+// setOperation labels a SetRequest operation for error reporting.
+func setOperation(kind string, index int, path *gnmipb.Path) string {
+	elems := make([]string, 0, len(path.GetElem()))
+	for _, elem := range path.GetElem() {
+		elems = append(elems, elem.GetName())
+	}
+	return fmt.Sprintf("%s[%d] /%s", kind, index, strings.Join(elems, "/"))
+}
+
+// producerView reads the content a key of a ProducerStateTable will have once its consumer has
+// processed the pending operations: the consumed content, dropped if a delete is pending, with
+// the fields of a pending set on top.
+func producerView(w *dbWrite) (keyState, error) {
+	var consumed, pending *redis.StringStringMapCmd
+	var deleted *redis.BoolCmd
+	_, err := w.redisDb.TxPipelined(func(pipe redis.Pipeliner) error {
+		consumed = pipe.HGetAll(w.dbKey())
+		deleted = pipe.SIsMember(w.table+"_DEL_SET", w.key)
+		pending = pipe.HGetAll("_" + w.dbKey())
+		return nil
+	})
+	if err != nil {
+		return keyState{}, err
+	}
+
+	values := map[string]string{}
+	if !deleted.Val() {
+		for field, value := range consumed.Val() {
+			values[field] = value
+		}
+	}
+	for field, value := range pending.Val() {
+		values[field] = value
+	}
+	return keyState{exists: len(values) > 0, values: values}, nil
+}
+
+// isZmqTable returns if writes to table are sent through ZMQ, see GetTable.
+func (c *MixedDbClient) isZmqTable(table string) bool {
+	return strings.HasPrefix(table, DASH_TABLE_PREFIX) && c.zmqClient != nil
+}
+
+// writerView returns the content of the key of w, as the producer of its table sees it.
+// ZMQ tables have no pending state in redis: the keys recently sent by this process are
+// taken from zmqSent until the copy of the table in redis holds what was sent, and the
+// others from that copy.
+func (c *MixedDbClient) writerView(w *dbWrite) (keyState, error) {
+	if !c.isZmqTable(w.table) {
+		return producerView(w)
+	}
+	values, err := w.redisDb.HGetAll(w.dbKey()).Result()
+	if err != nil {
+		return keyState{}, err
+	}
+	state := keyState{exists: len(values) > 0, values: values}
+
+	zmqSent.Lock()
+	defer zmqSent.Unlock()
+	sent, ok := zmqSent.states[w.id()]
+	if !ok {
+		return state, nil
+	}
+	if time.Since(sent.sent) >= ZMQ_SENT_TTL || sent.keyState.equal(state) {
+		// Expired, or acknowledged by redis
+		delete(zmqSent.states, w.id())
+		return state, nil
+	}
+	return sent.keyState, nil
+}
+
+// snapshotWrites records the content of every key touched by writes before they are applied,
+// in order of first use.
+func (c *MixedDbClient) snapshotWrites(writes []dbWrite) ([]dbSnapshot, error) {
+	var snapshots []dbSnapshot
+	seen := map[string]bool{}
+	for i := range writes {
+		w := &writes[i]
+		if seen[w.id()] {
+			continue
+		}
+		seen[w.id()] = true
+
+		state, err := c.writerView(w)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to snapshot %s: %v", w.operation, w.dbKey(), err)
+		}
+		snapshots = append(snapshots, dbSnapshot{write: w, keyState: state})
+	}
+	return snapshots, nil
+}
+
+// statesAfter returns the content of the key of each snapshot once writes are applied on top of it.
+func statesAfter(snapshots []dbSnapshot, writes []dbWrite) map[string]*keyState {
+	states := make(map[string]*keyState, len(snapshots))
+	for _, snapshot := range snapshots {
+		state := snapshot.keyState
+		states[snapshot.write.id()] = &state
+	}
+	for i := range writes {
+		if state, ok := states[writes[i].id()]; ok {
+			state.apply(&writes[i])
+		}
+	}
+	return states
+}
+
+// recordZmqSent records the content of the keys of ZMQ tables in states as sent by this process.
+// Keys whose content is unknown, after a failed rollback, are forgotten.
+func (c *MixedDbClient) recordZmqSent(snapshots []dbSnapshot, states map[string]*keyState) {
+	zmqSent.Lock()
+	defer zmqSent.Unlock()
+	now := time.Now()
+	for _, snapshot := range snapshots {
+		if !c.isZmqTable(snapshot.write.table) {
+			continue
+		}
+		id := snapshot.write.id()
+		if state, ok := states[id]; ok {
+			zmqSent.states[id] = zmqSentState{keyState: *state, sent: now}
+		} else {
+			delete(zmqSent.states, id)
+		}
+	}
+	pruneZmqSent(now)
+}
+
+// bufferTables pipelines writes to the ProducerStateTables used by writes, so that they reach
+// redis together when flushed. ZMQ tables send every write immediately and are not buffered.
+//
+// Returns the buffered tables, which must be passed to flushTables.
+func (c *MixedDbClient) bufferTables(writes []dbWrite) ([]swsscommon.ProducerStateTable, error) {
+	var buffered []swsscommon.ProducerStateTable
+	seen := map[string]bool{}
+	for _, w := range writes {
+		if seen[w.table] {
+			continue
+		}
+		seen[w.table] = true
+
+		pt := c.GetTable(w.table)
+		if _, ok := c.zmqTableMap[w.table]; ok {
+			continue
+		}
+		if err := ProducerStateTableSetBufferedWrapper(pt, true); err != nil {
+			c.flushTables(buffered)
+			return nil, err
+		}
+		buffered = append(buffered, pt)
+	}
+	return buffered, nil
+}
+
+// flushTables sends the pipelined writes of the buffered tables, and stops buffering them.
+func (c *MixedDbClient) flushTables(buffered []swsscommon.ProducerStateTable) error {
+	var flushErr error
+	for _, pt := range buffered {
+		if err := ProducerStateTableFlushWrapper(pt); err != nil && flushErr == nil {
+			flushErr = err
+		}
+		if err := ProducerStateTableSetBufferedWrapper(pt, false); err != nil && flushErr == nil {
+			flushErr = err
+		}
+	}
+	return flushErr
+}
+
+func (c *MixedDbClient) applyWrite(w *dbWrite) error {
+	if w.values == nil {
+		return c.DbDelTable(w.table, w.key)
+	}
+	return c.DbSetTable(w.table, w.key, w.values)
+}
+
+// retractPendingFieldsScript removes fields from the pending set of a ProducerStateTable key,
+// only if none of them has reached the consumer yet. A pending set left without fields is
+// dropped, so that the consumer does not see the key at all.
+//
+// KEYS: table key, pending key, key set, delete set. ARGV: key, fields.
+var retractPendingFieldsScript = redis.NewScript(`
+local deleted = redis.call('SISMEMBER', KEYS[4], ARGV[1]) == 1
+for i = 2, #ARGV do
+	if redis.call('HEXISTS', KEYS[2], ARGV[i]) == 0 then
+		return 0
+	end
+	if not deleted and redis.call('HEXISTS', KEYS[1], ARGV[i]) == 1 then
+		return 0
+	end
+end
+for i = 2, #ARGV do
+	redis.call('HDEL', KEYS[2], ARGV[i])
+end
+if not deleted and redis.call('EXISTS', KEYS[2]) == 0 then
+	redis.call('SREM', KEYS[3], ARGV[1])
+end
+return 1
+`)
+
+// retractPendingFields removes fields from the key of w before its consumer sees them.
+// Returns false if any of them already reached the consumer, or if the table is a ZMQ table.
+func (c *MixedDbClient) retractPendingFields(w *dbWrite, fields []string) (bool, error) {
+	if c.isZmqTable(w.table) {
+		return false, nil
+	}
+	keys := []string{w.dbKey(), "_" + w.dbKey(), w.table + "_KEY_SET", w.table + "_DEL_SET"}
+	args := make([]interface{}, 0, len(fields)+1)
+	args = append(args, w.key)
+	for _, field := range fields {
+		args = append(args, field)
+	}
+	retracted, err := retractPendingFieldsScript.Run(w.redisDb, keys, args...).Int()
+	if err != nil {
+		return false, err
+	}
+	return retracted == 1, nil
+}
+
+// restoreKey restores the key of snapshot from its current content, so that the consumer of the
+// table only sees what changed: fields changed by the SetRequest are set back, fields it added
+// are removed, and the key is deleted only if the SetRequest created it.
+func (c *MixedDbClient) restoreKey(snapshot *dbSnapshot, current *keyState) error {
+	w := snapshot.write
+	if !snapshot.exists {
+		if !current.exists {
+			return nil
+		}
+		return c.DbDelTable(w.table, w.key)
+	}
+
+	changed := map[string]string{}
+	for field, value := range snapshot.values {
+		if v, ok := current.values[field]; !current.exists || !ok || v != value {
+			changed[field] = value
+		}
+	}
+	var added []string
+	if current.exists {
+		for field := range current.values {
+			if _, ok := snapshot.values[field]; !ok {
+				added = append(added, field)
+			}
+		}
+	}
+
+	if len(added) > 0 {
+		retracted, err := c.retractPendingFields(w, added)
+		if err != nil {
+			return err
+		}
+		if !retracted {
+			// A set can't remove fields the consumer already has, only a delete can
+			if err := c.DbDelTable(w.table, w.key); err != nil {
+				return err
+			}
+			return c.DbSetTable(w.table, w.key, snapshot.values)
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+	return c.DbSetTable(w.table, w.key, changed)
+}
+
+// rollbackWrites restores every snapshotted key to its previous content, after applied were written.
+// The current content of keys of ProducerStateTables is read back from redis, since a failed
+// flush may have written only part of them.
+//
+// Returns the content of the keys after the rollback, without the keys it failed to restore.
+func (c *MixedDbClient) rollbackWrites(snapshots []dbSnapshot, applied []dbWrite) (map[string]*keyState, error) {
+	states := statesAfter(snapshots, applied)
+	var rollbackErr error
+	for i := len(snapshots) - 1; i >= 0; i-- {
+		snapshot := &snapshots[i]
+		id := snapshot.write.id()
+		current := states[id]
+		var err error
+		if !c.isZmqTable(snapshot.write.table) {
+			var view keyState
+			view, err = producerView(snapshot.write)
+			current = &view
+		}
+		if err == nil {
+			err = c.restoreKey(snapshot, current)
+		}
+		if err != nil {
+			log.V(2).Infof("rollback failed for %s: %v", snapshot.write.dbKey(), err)
+			if rollbackErr == nil {
+				rollbackErr = fmt.Errorf("failed to restore %s: %v", snapshot.write.dbKey(), err)
+			}
+			delete(states, id)
+			continue
+		}
+		state := snapshot.keyState
+		states[id] = &state
+	}
+	return states, rollbackErr
+}
+
+// applyWrites applies all writes of a SetRequest, or none of them.
+//
+// The touched keys are snapshotted before anything is written. If any write fails, every touched
+// key is restored and the returned error names the operation which failed.
+func (c *MixedDbClient) applyWrites(writes []dbWrite) error {
+	if len(writes) == 0 {
+		return nil
+	}
+
+	snapshots, err := c.snapshotWrites(writes)
+	if err != nil {
+		return status.Error(codes.Unavailable, err.Error())
+	}
+
+	buffered, err := c.bufferTables(writes)
+	if err != nil {
+		return status.Errorf(codes.Unavailable, "failed to pipeline writes: %v", err)
+	}
+
+	var failure error
+	applied := writes
+	for i := range writes {
+		if err := c.applyWrite(&writes[i]); err != nil {
+			log.V(2).Infof("swsscommon write failed for %s %s: %v", writes[i].operation, writes[i].dbKey(), err)
+			failure = fmt.Errorf("%s failed: %v", writes[i].operation, err)
+			// The failed write may still have been sent
+			applied = writes[:i+1]
+			break
+		}
+	}
+	// Writes before a failure are flushed too, so that the rollback sees them in redis
+	if err := c.flushTables(buffered); err != nil && failure == nil {
+		failure = fmt.Errorf("failed to flush writes: %v", err)
+	}
+	if failure == nil {
+		c.recordZmqSent(snapshots, statesAfter(snapshots, writes))
+		return nil
+	}
+
+	states, rollbackErr := c.rollbackWrites(snapshots, applied)
+	c.recordZmqSent(snapshots, states)
+	if rollbackErr != nil {
+		return status.Errorf(codes.Internal, "%v; rollback failed: %v", failure, rollbackErr)
+	}
+	return status.Errorf(codes.Aborted, "%v; all changes were rolled back", failure)
 }
 
 /* Populate the JsonPatch corresponding each GNMI operation. */
@@ -1174,36 +1814,27 @@ func (c *MixedDbClient) ConvertToJsonPatch(prefix *gnmipb.Path, path *gnmipb.Pat
 	return nil
 }
 
-func RunPyCode(text string) error {
-	defer C.Py_Finalize()
-	C.Py_Initialize()
-	PyCodeInC := C.CString(text)
-	defer C.free(unsafe.Pointer(PyCodeInC))
-	CRet := C.PyRun_SimpleString(PyCodeInC)
-	if int(CRet) != 0 {
-		return fmt.Errorf("Python failure")
+var yangLoadFailure sync.Once
+
+// ValidateYangConfig validates a CONFIG_DB config against the SONiC YANG models.
+// Invalid configs are rejected with InvalidArgument, listing the path of each invalid leaf.
+// If the models cannot be loaded, the failure is logged once and configs are not validated.
+func ValidateYangConfig(config map[string]interface{}) error {
+	validator, err := yangvalidator.Load()
+	if err != nil {
+		yangLoadFailure.Do(func() {
+			log.Errorf("Failed to load YANG models, configs will not be validated: %v", err)
+		})
+		return nil
+	}
+	err = validator.Validate(config)
+	if err != nil {
+		log.V(2).Infof("Yang validation error: %v", err)
+		return status.Error(codes.InvalidArgument, err.Error())
 	}
 	return nil
 }
 
-var PyCodeForYang string = `
-import sonic_yang
-import json
-
-yang_parser = sonic_yang.SonicYang("/usr/local/yang-models")
-yang_parser.loadYangModel()
-filename = "%s"
-with open(filename, 'r') as fp:
-	text = fp.read()
-
-	try:
-		yang_parser.loadData(configdbJson=json.loads(text))
-		yang_parser.validate_data_tree()
-	except sonic_yang.SonicYangException as e:
-		print("Yang validation error: {}".format(str(e)))
-		raise
-`
-
 func (c *MixedDbClient) SetIncrementalConfig(delete []*gnmipb.Path, replace []*gnmipb.Update, update []*gnmipb.Update) error {
 	var err error
 
@@ -1382,19 +2013,21 @@ func (c *MixedDbClient) SetFullConfig(delete []*gnmipb.Path, replace []*gnmipb.U
 		return fmt.Errorf("Value encoding is not IETF JSON")
 	}
 	content := []byte(ietf_json_val)
-	fileName := c.workPath + "/config_db.json.tmp"
-	err := ioutil.WriteFile(fileName, content, 0644)
+	res, err := parseJson(content)
 	if err != nil {
 		return err
 	}
-
-	PyCodeInGo := fmt.Sprintf(PyCodeForYang, fileName)
-	err = RunPyCode(PyCodeInGo)
+	config, ok := res.(map[string]interface{})
+	if !ok {
+		return status.Error(codes.InvalidArgument, "Full config must be a JSON object")
+	}
+	fileName := c.workPath + "/config_db.json.tmp"
+	err = ioutil.WriteFile(fileName, content, 0644)
 	if err != nil {
-		return fmt.Errorf("Yang validation failed!")
+		return err
 	}
 
-	return nil
+	return ValidateYangConfig(config)
 }
 
 func (c *MixedDbClient) ReplaceFullConfig(delete []*gnmipb.Path, replace []*gnmipb.Update, update []*gnmipb.Update) error {
@@ -1419,44 +2052,46 @@ func (c *MixedDbClient) ReplaceFullConfig(delete []*gnmipb.Path, replace []*gnmi
 	return err
 }
 
+// SetDB applies a SetRequest to APPL_DB or DPU_APPL_DB as a single transaction.
+// Every operation is planned before any is written, so invalid operations leave the database
+// untouched, and failed writes are rolled back.
 func (c *MixedDbClient) SetDB(delete []*gnmipb.Path, replace []*gnmipb.Update, update []*gnmipb.Update) error {
-	/* DELETE */
-	deletePathList, err := c.getAllDbtablePath(delete)
-	if err != nil {
-		return err
-	}
-
-	for _, tblPaths := range deletePathList {
-		err = c.handleTableData(tblPaths)
+	var writes []dbWrite
+	plan := func(operation string, path *gnmipb.Path, value *gnmipb.TypedValue) error {
+		tblPaths, err := c.getDbtablePath(path, value)
 		if err != nil {
 			return err
 		}
+		planned, err := c.planTableData(tblPaths, operation)
+		if err != nil {
+			return fmt.Errorf("%s: %v", operation, err)
+		}
+		writes = append(writes, planned...)
+		return nil
 	}
 
-	/* REPLACE */
-	for _, item := range replace {
-		tblPaths, err := c.getDbtablePath(item.GetPath(), item.GetVal())
-		if err != nil {
+	/* DELETE */
+	for i, path := range delete {
+		if err := plan(setOperation("delete", i, path), path, nil); err != nil {
 			return err
 		}
-		err = c.handleTableData(tblPaths)
-		if err != nil {
+	}
+
+	/* REPLACE */
+	for i, item := range replace {
+		if err := plan(setOperation("replace", i, item.GetPath()), item.GetPath(), item.GetVal()); err != nil {
 			return err
 		}
 	}
 
 	/* UPDATE */
-	for _, item := range update {
-		tblPaths, err := c.getDbtablePath(item.GetPath(), item.GetVal())
-		if err != nil {
-			return err
-		}
-		err = c.handleTableData(tblPaths)
-		if err != nil {
+	for i, item := range update {
+		if err := plan(setOperation("update", i, item.GetPath()), item.GetPath(), item.GetVal()); err != nil {
 			return err
 		}
 	}
-	return nil
+
+	return c.applyWrites(writes)
 }
 
 func (c *MixedDbClient) SetConfigDB(delete []*gnmipb.Path, replace []*gnmipb.Update, update []*gnmipb.Update) error {
@@ -1492,6 +2127,9 @@ func (c *MixedDbClient) SetConfigDB(delete []*gnmipb.Path, replace []*gnmipb.Upd
 }
 
 func (c *MixedDbClient) Set(delete []*gnmipb.Path, replace []*gnmipb.Update, update []*gnmipb.Update) error {
+	if c.instances != nil {
+		return status.Error(codes.Unimplemented, "Set RPC does not support wildcard namespace/container")
+	}
 	if c.target == "CONFIG_DB" {
 		return c.SetConfigDB(delete, replace, update)
 	} else if c.target == DPU_APPL_DB_NAME || c.target == APPL_DB_NAME {
@@ -1562,7 +2200,8 @@ func (c *MixedDbClient) GetCheckPoint() ([]*spb.Value, error) {
 }
 
 func (c *MixedDbClient) Get(w *sync.WaitGroup) ([]*spb.Value, error) {
-	if c.target == "CONFIG_DB" {
+	// The check point holds a single namespace, so it is not used for wildcard requests
+	if c.target == "CONFIG_DB" && c.instances == nil {
 		ret, err := c.GetCheckPoint()
 		if err == nil {
 			return ret, err
@@ -1570,25 +2209,10 @@ func (c *MixedDbClient) Get(w *sync.WaitGroup) ([]*spb.Value, error) {
 		log.V(6).Infof("Error #%v", err)
 	}
 
-	var values []*spb.Value
 	ts := time.Now()
-	if c.paths != nil {
-		for _, gnmiPath := range c.paths {
-			tblPaths, err := c.getDbtablePath(gnmiPath, nil)
-			if err != nil {
-				return nil, err
-			}
-			val, err := c.tableData2TypedValue(tblPaths, nil)
-			if err != nil {
-				return nil, err
-			}
-			values = append(values, &spb.Value{
-				Prefix:    c.prefix,
-				Path:      gnmiPath,
-				Timestamp: ts.UnixNano(),
-				Val:       val,
-			})
-		}
+	values, err := c.getDbValues(ts)
+	if err != nil {
+		return nil, err
 	}
 
 	log.V(6).Infof("Getting #%v", values)
@@ -1596,10 +2220,76 @@ func (c *MixedDbClient) Get(w *sync.WaitGroup) ([]*spb.Value, error) {
 	return values, nil
 }
 
+// getDbValues reads a snapshot of every path of the client from redis.
+// The database instance for the paths is resolved by ParseDatabase when the
+// client is created, so Get, ONCE and POLL read from the same namespace/container.
+func (c *MixedDbClient) getDbValues(ts time.Time) ([]*spb.Value, error) {
+	var values []*spb.Value
+	for _, gnmiPath := range c.paths {
+		tblPaths, err := c.getDbtablePath(gnmiPath, nil)
+		if err != nil {
+			return nil, err
+		}
+		val, err := c.tableData2TypedValue(tblPaths, nil)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, &spb.Value{
+			Prefix:    c.prefix,
+			Path:      gnmiPath,
+			Timestamp: ts.UnixNano(),
+			Val:       val,
+		})
+	}
+	return values, nil
+}
+
+// putDbSnapshot enqueues one snapshot of every path followed by a sync_response.
+// Any error is reported to the subscriber as a fatal message, which terminates the RPC.
+func (c *MixedDbClient) putDbSnapshot() error {
+	values, err := c.getDbValues(time.Now())
+	if err != nil {
+		putFatalMsg(c.q, err.Error())
+		return err
+	}
+	for _, spbv := range values {
+		c.q.Put(Value{spbv})
+		log.V(6).Infof("Added spbv #%v", spbv)
+	}
+
+	c.q.Put(Value{
+		&spb.Value{
+			Timestamp:    time.Now().UnixNano(),
+			SyncResponse: true,
+		},
+	})
+	return nil
+}
+
+// OnceRun implements Subscription "ONCE" mode: a single snapshot of every
+// path, followed by sync_response.
 func (c *MixedDbClient) OnceRun(q *queue.PriorityQueue, once chan struct{}, w *sync.WaitGroup, subscribe *gnmipb.SubscriptionList) {
-	return
+	c.w = w
+	defer c.w.Done()
+	c.q = q
+	c.channel = once
+
+	_, more := <-c.channel
+	if !more {
+		log.V(1).Infof("%v once channel closed, exiting onceDb routine", c)
+		return
+	}
+
+	t1 := time.Now()
+	if err := c.putDbSnapshot(); err != nil {
+		log.V(2).Infof("Unable to get snapshot due to err: %v", err)
+		return
+	}
+	log.V(4).Infof("Sync done, once time taken: %v ms", int64(time.Since(t1)/time.Millisecond))
 }
 
+// PollRun implements Subscription "POLL" mode: a snapshot of every path,
+// followed by sync_response, for each poll trigger.
 func (c *MixedDbClient) PollRun(q *queue.PriorityQueue, poll chan struct{}, w *sync.WaitGroup, subscribe *gnmipb.SubscriptionList) {
 	c.w = w
 	defer c.w.Done()
@@ -1613,35 +2303,10 @@ func (c *MixedDbClient) PollRun(q *queue.PriorityQueue, poll chan struct{}, w *s
 			return
 		}
 		t1 := time.Now()
-		for _, gnmiPath := range c.paths {
-			tblPaths, err := c.getDbtablePath(gnmiPath, nil)
-			if err != nil {
-				log.V(2).Infof("Unable to get table path due to err: %v", err)
-				return
-			}
-			val, err := c.tableData2TypedValue(tblPaths, nil)
-			if err != nil {
-				log.V(2).Infof("Unable to create gnmi TypedValue due to err: %v", err)
-				return
-			}
-
-			spbv := &spb.Value{
-				Prefix:       c.prefix,
-				Path:         gnmiPath,
-				Timestamp:    time.Now().UnixNano(),
-				SyncResponse: false,
-				Val:          val,
-			}
-			c.q.Put(Value{spbv})
-			log.V(6).Infof("Added spbv #%v", spbv)
+		if err := c.putDbSnapshot(); err != nil {
+			log.V(2).Infof("Unable to get snapshot due to err: %v", err)
+			return
 		}
-
-		c.q.Put(Value{
-			&spb.Value{
-				Timestamp:    time.Now().UnixNano(),
-				SyncResponse: true,
-			},
-		})
 		log.V(4).Infof("Sync done, poll time taken: %v ms", int64(time.Since(t1)/time.Millisecond))
 	}
 }
@@ -1674,19 +2339,35 @@ func (c *MixedDbClient) StreamRun(q *queue.PriorityQueue, stop chan struct{}, w
 		for _, sub := range subscribe.GetSubscription() {
 			log.V(2).Infof("Sub mode: %v, path: %v", sub.GetMode(), sub.GetPath())
 			subMode := sub.GetMode()
-
-			if subMode == gnmipb.SubscriptionMode_SAMPLE {
-				c.w.Add(1)      // wait group to indicate the streaming session is complete.
-				c.synced.Add(1) // wait group to indicate whether sync_response is sent.
-				go c.streamSampleSubscription(sub, subscribe.GetUpdatesOnly())
-			} else if subMode == gnmipb.SubscriptionMode_ON_CHANGE {
-				c.w.Add(1)
-				c.synced.Add(1)
-				go c.streamOnChangeSubscription(sub.GetPath())
-			} else {
+			if subMode != gnmipb.SubscriptionMode_SAMPLE && subMode != gnmipb.SubscriptionMode_ON_CHANGE {
 				putFatalMsg(c.q, fmt.Sprintf("unsupported subscription mode, %v", subMode))
 				return
 			}
+
+			// A wildcard namespace/container subscribes to every matching instance
+			gnmiPaths, err := c.expandPath(sub.GetPath())
+			if err != nil {
+				putFatalMsg(c.q, err.Error())
+				return
+			}
+			for _, gnmiPath := range gnmiPaths {
+				if subMode == gnmipb.SubscriptionMode_SAMPLE {
+					instanceSub := &gnmipb.Subscription{
+						Path:              gnmiPath,
+						Mode:              subMode,
+						SampleInterval:    sub.GetSampleInterval(),
+						SuppressRedundant: sub.GetSuppressRedundant(),
+						HeartbeatInterval: sub.GetHeartbeatInterval(),
+					}
+					c.w.Add(1)      // wait group to indicate the streaming session is complete.
+					c.synced.Add(1) // wait group to indicate whether sync_response is sent.
+					go c.streamSampleSubscription(instanceSub, subscribe.GetUpdatesOnly())
+				} else {
+					c.w.Add(1)
+					c.synced.Add(1)
+					go c.streamOnChangeSubscription(gnmiPath)
+				}
+			}
 		}
 	}
 
@@ -1767,9 +2448,9 @@ func (c *MixedDbClient) dbFieldSubscribe(gnmiPath *gnmipb.Path, onChange bool, i
 	}
 	tblPath := tblPaths[0]
 	// run redis get directly for field value
-	redisDb, ok := RedisDbMap[c.mapkey+":"+tblPath.dbName]
+	redisDb, ok := RedisDbMap[tblPath.redisMapKey()]
 	if !ok {
-		msg := fmt.Sprintf("RedisDbMap not exist:  %v", c.mapkey+":"+tblPath.dbName)
+		msg := fmt.Sprintf("RedisDbMap not exist:  %v", tblPath.redisMapKey())
 		putFatalMsg(c.q, msg)
 		c.synced.Done()
 		return
@@ -2012,9 +2693,9 @@ func (c *MixedDbClient) dbTableKeySubscribe(gnmiPath *gnmipb.Path, interval time
 			prefixLen = len(pattern)
 			pattern += "*"
 		}
-		redisDb, ok := RedisDbMap[c.mapkey+":"+tblPath.dbName]
+		redisDb, ok := RedisDbMap[tblPath.redisMapKey()]
 		if !ok {
-			handleFatalMsg(fmt.Sprintf("RedisDbMap not exist:  %v", c.mapkey+":"+tblPath.dbName))
+			handleFatalMsg(fmt.Sprintf("RedisDbMap not exist:  %v", tblPath.redisMapKey()))
 			return
 		}
 		pubsub := redisDb.PSubscribe(pattern)
@@ -2127,6 +2808,9 @@ func (c *MixedDbClient) Close() error {
 	if c.dbkey != nil {
 		swsscommon.DeleteSonicDBKey(c.dbkey)
 	}
+	for _, dbkey := range c.instances {
+		swsscommon.DeleteSonicDBKey(dbkey)
+	}
 
 	return nil
 }
@@ -1,9 +1,16 @@
 package client
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -28,11 +35,20 @@ const (
 
 type dataGetFunc func() ([]byte, error)
 
+// dataGetByNameFunc gets the data of the entries with name matching pattern, for
+// paths keyed by name like containers[name=swss]. Pattern "*" gets all the entries.
+type dataGetByNameFunc func(pattern string) ([]byte, error)
+
 type path2DataFunc struct {
 	path    []string
 	getFunc dataGetFunc
 }
 
+type path2NameDataFunc struct {
+	path    []string
+	getFunc dataGetByNameFunc
+}
+
 type statsRing struct {
 	writeIdx uint64 // slot index to write next
 	buff     []*linuxproc.Stat
@@ -58,8 +74,27 @@ func InvalidateVersionFileStash() {
 }
 
 var (
-	clientTrie *Trie
-	statsR     statsRing
+	clientTrie   *Trie
+	clientTrieMu sync.RWMutex // protects clientTrie from getters registered at runtime
+	statsR       statsRing
+
+	// NonDbOnChangeInterval is the interval at which the getters of ON_CHANGE
+	// subscriptions are sampled to detect changes.
+	NonDbOnChangeInterval = MinSampleInterval
+
+	// NonDbCpuPercentThreshold is the change of a cpu_percent leaf, in percentage points,
+	// below which ON_CHANGE subscriptions do not report it. Cpu usage varies on nearly
+	// every sample, without it ON_CHANGE would behave like SAMPLE.
+	NonDbCpuPercentThreshold = 1.0
+
+	// Root of procfs and of the hwmon devices in sysfs, overridden by UTs only.
+	procRoot  = "/proc"
+	hwmonRoot = "/sys/class/hwmon"
+
+	// sonicDaemons are the processes reported by OTHERS/proc/daemons and OTHERS/processes,
+	// by command name.
+	sonicDaemons = []string{"orchagent", "syncd", "bgpd", "zebra", "fpmsyncd", "portsyncd",
+		"neighsyncd", "teamd", "lldpd", "snmpd", "redis-server", "telemetry"}
 
 	versionFileStash sonicVersionYmlStash
 
@@ -101,9 +136,47 @@ var (
 			path:    []string{"OTHERS", "osversion", "build"},
 			getFunc: dataGetFunc(getBuildVersion),
 		},
+		{ // Get proc net dev
+			path:    []string{"OTHERS", "proc", "net", "dev"},
+			getFunc: dataGetFunc(getProcNetDev),
+		},
+		{ // Get temperature sensors of hwmon
+			path:    []string{"OTHERS", "platform", "temperature"},
+			getFunc: dataGetFunc(getPlatformTemperature),
+		},
+		{ // Get cpu and memory usage of SONiC daemons
+			path:    []string{"OTHERS", "proc", "daemons"},
+			getFunc: dataGetFunc(getDaemonStats),
+		},
+	}
+
+	// path2NameDataFuncTbl is used to populate trie tree with the paths keyed by name
+	path2NameDataFuncTbl = []path2NameDataFunc{
+		{ // Get cpu, memory, pids and io usage of containers
+			path:    []string{"OTHERS", "containers"},
+			getFunc: dataGetByNameFunc(getContainerStats),
+		},
+		{ // Get cpu and memory usage of processes
+			path:    []string{"OTHERS", "processes"},
+			getFunc: dataGetByNameFunc(getProcessStats),
+		},
 	}
 )
 
+// RegisterNonDbPath adds getter for path, starting with the OTHERS target, to the
+// non-DB paths. It can be called while the server runs, subscriptions created
+// afterwards can use path.
+func RegisterNonDbPath(path []string, getter func() ([]byte, error)) {
+	clientTrieMu.Lock()
+	n := clientTrie.Add(path, dataGetFunc(getter))
+	clientTrieMu.Unlock()
+	if n.meta.(dataGetFunc) == nil {
+		log.V(1).Infof("Failed to add trie node for %v", path)
+	} else {
+		log.V(2).Infof("Add trie node for %v", path)
+	}
+}
+
 func (t *Trie) clientTriePopulate() {
 	for _, pt := range path2DataFuncTbl {
 		n := t.Add(pt.path, pt.getFunc)
@@ -114,6 +187,14 @@ func (t *Trie) clientTriePopulate() {
 		}
 
 	}
+	for _, pt := range path2NameDataFuncTbl {
+		n := t.Add(pt.path, pt.getFunc)
+		if n.meta.(dataGetByNameFunc) == nil {
+			log.V(1).Infof("Failed to add trie node for %v with %v", pt.path, pt.getFunc)
+		} else {
+			log.V(2).Infof("Add trie node for %v with %v", pt.path, pt.getFunc)
+		}
+	}
 }
 
 type cpuStat struct {
@@ -335,6 +416,219 @@ func getBuildVersion() ([]byte, error) {
 	return b, nil
 }
 
+func getProcNetDev() ([]byte, error) {
+	netStats, err := linuxproc.ReadNetworkStat(filepath.Join(procRoot, "net", "dev"))
+	if err != nil {
+		log.V(2).Infof("%v", err)
+		return nil, err
+	}
+	b, err := json.Marshal(netStats)
+	if err != nil {
+		log.V(2).Infof("%v", err)
+		return b, err
+	}
+	log.V(4).Infof("getProcNetDev, output %v", string(b))
+	return b, nil
+}
+
+// hwmonSensor is a temperature sensor of a hwmon device, in degrees Celsius
+type hwmonSensor struct {
+	Label       string   `json:"label,omitempty"`
+	Temperature float64  `json:"temperature"`
+	High        *float64 `json:"high,omitempty"`
+	Critical    *float64 `json:"critical,omitempty"`
+}
+
+type hwmonDevice struct {
+	Name    string                  `json:"name"`
+	Sensors map[string]*hwmonSensor `json:"sensors"`
+}
+
+// readHwmonTemp reads a temperature file of hwmon, in millidegree Celsius.
+func readHwmonTemp(path string) (float64, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	milli, err := strconv.ParseInt(strings.TrimSpace(string(b)), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return float64(milli) / 1000, nil
+}
+
+func readHwmonString(path string) string {
+	b, _ := ioutil.ReadFile(path)
+	return strings.TrimSpace(string(b))
+}
+
+// getPlatformTemperature reads the temp<N>_input sensors of the hwmon devices, by device and sensor.
+func getPlatformTemperature() ([]byte, error) {
+	devices := map[string]*hwmonDevice{}
+	inputs, _ := filepath.Glob(filepath.Join(hwmonRoot, "*", "temp*_input"))
+	for _, input := range inputs {
+		dir := filepath.Dir(input)
+		sensor := strings.TrimSuffix(filepath.Base(input), "_input")
+		temp, err := readHwmonTemp(input)
+		if err != nil {
+			log.V(4).Infof("getPlatformTemperature, failed to read %v: %v", input, err)
+			continue
+		}
+		device, ok := devices[filepath.Base(dir)]
+		if !ok {
+			device = &hwmonDevice{Name: readHwmonString(filepath.Join(dir, "name")), Sensors: map[string]*hwmonSensor{}}
+			devices[filepath.Base(dir)] = device
+		}
+		s := &hwmonSensor{
+			Label:       readHwmonString(filepath.Join(dir, sensor+"_label")),
+			Temperature: temp,
+		}
+		if high, err := readHwmonTemp(filepath.Join(dir, sensor+"_max")); err == nil {
+			s.High = &high
+		}
+		if crit, err := readHwmonTemp(filepath.Join(dir, sensor+"_crit")); err == nil {
+			s.Critical = &crit
+		}
+		device.Sensors[sensor] = s
+	}
+	b, err := json.Marshal(devices)
+	if err != nil {
+		log.V(2).Infof("%v", err)
+		return b, err
+	}
+	log.V(4).Infof("getPlatformTemperature, output %v", string(b))
+	return b, nil
+}
+
+// Clock ticks per second of the cpu times in /proc/<pid>/stat
+const clockTicks = 100
+
+// processStat is the cpu and memory usage of a process
+type processStat struct {
+	Pid        uint64  `json:"pid"`
+	CpuPercent float64 `json:"cpu_percent"`
+	Utime      uint64  `json:"utime"`
+	Stime      uint64  `json:"stime"`
+	RssKb      uint64  `json:"rss_kb"`
+	VmSizeKb   uint64  `json:"vm_size_kb"`
+	Threads    uint64  `json:"threads"`
+}
+
+// cpuSample is the cpu time used by a process or container, in seconds, at a read
+type cpuSample struct {
+	used float64
+	at   time.Time
+}
+
+// Samples of cpu usage older than cpuSampleExpiry are dropped
+const cpuSampleExpiry = 10 * time.Minute
+
+// cpuSamples keeps the last cpu time read of the processes and containers, by key, to
+// compute their cpu usage.
+var cpuSamples = struct {
+	mu      sync.Mutex
+	samples map[string]cpuSample
+}{samples: map[string]cpuSample{}}
+
+// cpuPercent returns the cpu usage of key since its previous read, given the cpu time
+// used so far in seconds, 0 on the first read.
+func cpuPercent(key string, used float64, now time.Time) float64 {
+	cpuSamples.mu.Lock()
+	defer cpuSamples.mu.Unlock()
+	for k, sample := range cpuSamples.samples {
+		if now.Sub(sample.at) > cpuSampleExpiry {
+			delete(cpuSamples.samples, k)
+		}
+	}
+	last, ok := cpuSamples.samples[key]
+	cpuSamples.samples[key] = cpuSample{used: used, at: now}
+	elapsed := now.Sub(last.at).Seconds()
+	if !ok || elapsed <= 0 || used < last.used {
+		return 0
+	}
+	return (used - last.used) / elapsed * 100
+}
+
+// readProcessStats reads the stats of the processes of procRoot with a command name
+// matching pattern, by name. Pattern "*" matches the processes named in sonicDaemons,
+// and telemetry itself.
+func readProcessStats(pattern string) (map[string][]*processStat, error) {
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid process name %q: %v", pattern, err)
+	}
+	daemons := map[string]bool{}
+	for _, name := range sonicDaemons {
+		daemons[name] = true
+	}
+	self := uint64(os.Getpid())
+	match := func(pid uint64, comm string) bool {
+		if pattern == "*" {
+			return daemons[comm] || pid == self
+		}
+		ok, _ := filepath.Match(pattern, comm)
+		return ok
+	}
+
+	stats := map[string][]*processStat{}
+	now := time.Now()
+	entries, _ := ioutil.ReadDir(procRoot)
+	for _, entry := range entries {
+		if _, err := strconv.ParseUint(entry.Name(), 10, 64); err != nil || !entry.IsDir() {
+			continue
+		}
+		dir := filepath.Join(procRoot, entry.Name())
+		stat, err := linuxproc.ReadProcessStat(filepath.Join(dir, "stat"))
+		if err != nil {
+			continue // exited
+		}
+		comm := strings.Trim(stat.Comm, "()")
+		if !match(stat.Pid, comm) {
+			continue
+		}
+		status, err := linuxproc.ReadProcessStatus(filepath.Join(dir, "status"))
+		if err != nil {
+			continue
+		}
+		used := float64(stat.Utime+stat.Stime) / clockTicks
+		stats[comm] = append(stats[comm], &processStat{
+			Pid:        stat.Pid,
+			CpuPercent: cpuPercent("pid:"+entry.Name(), used, now),
+			Utime:      stat.Utime,
+			Stime:      stat.Stime,
+			RssKb:      status.VmRSS,
+			VmSizeKb:   status.VmSize,
+			Threads:    status.Threads,
+		})
+	}
+	for _, procs := range stats {
+		sort.Slice(procs, func(i, j int) bool { return procs[i].Pid < procs[j].Pid })
+	}
+	if len(stats) == 0 && !strings.ContainsAny(pattern, "*?[") {
+		return nil, fmt.Errorf("no process named %s", pattern)
+	}
+	return stats, nil
+}
+
+func getDaemonStats() ([]byte, error) {
+	return getProcessStats("*")
+}
+
+// getProcessStats gets the stats of the processes named pattern, by name.
+func getProcessStats(pattern string) ([]byte, error) {
+	stats, err := readProcessStats(pattern)
+	if err != nil {
+		log.V(2).Infof("%v", err)
+		return nil, err
+	}
+	b, err := json.Marshal(stats)
+	if err != nil {
+		log.V(2).Infof("%v", err)
+		return b, err
+	}
+	log.V(4).Infof("getProcessStats, output %v", string(b))
+	return b, nil
+}
+
 func WriteStatsToBuffer(stat *linuxproc.Stat) {
 	statsR.mu.Lock()
 	statsR.buff[statsR.writeIdx] = stat
@@ -387,15 +681,25 @@ func lookupGetFunc(prefix, path *gnmipb.Path) (dataGetFunc, error) {
 	elems := fullPath.GetElem()
 	if elems != nil {
 		for i, elem := range elems {
-			// TODO: Usage of key field
 			log.V(6).Infof("index %d elem : %#v %#v", i, elem.GetName(), elem.GetKey())
 			stringSlice = append(stringSlice, elem.GetName())
 		}
 	}
+	clientTrieMu.RLock()
 	n, ok := clientTrie.Find(stringSlice)
+	clientTrieMu.RUnlock()
 	if ok {
-		getter := n.meta.(dataGetFunc)
-		return getter, nil
+		switch getter := n.meta.(type) {
+		case dataGetFunc:
+			return getter, nil
+		case dataGetByNameFunc:
+			// The name key of the last element selects the entries, all by default
+			pattern := "*"
+			if name, ok := elems[len(elems)-1].GetKey()["name"]; ok {
+				pattern = name
+			}
+			return func() ([]byte, error) { return getter(pattern) }, nil
+		}
 	}
 	return nil, fmt.Errorf("%v not found in clientTrie tree", stringSlice)
 }
@@ -422,7 +726,9 @@ func (c *NonDbClient) String() string {
 		c.prefix.GetTarget(), c.sendMsg, c.recvMsg)
 }
 
-// StreamRun implements stream subscription for non-DB queries. It supports SAMPLE mode only.
+// StreamRun implements stream subscription for non-DB queries. It supports SAMPLE and ON_CHANGE modes.
+// For ON_CHANGE, the getters are sampled every NonDbOnChangeInterval, and only the leaves
+// of their JSON value that changed are sent.
 func (c *NonDbClient) StreamRun(q *queue.PriorityQueue, stop chan struct{}, w *sync.WaitGroup, subscribe *gnmipb.SubscriptionList) {
 	c.w = w
 	defer c.w.Done()
@@ -432,18 +738,22 @@ func (c *NonDbClient) StreamRun(q *queue.PriorityQueue, stop chan struct{}, w *s
 
 	// Validate all subs
 	for _, sub := range subscribe.GetSubscription() {
-		subMode := sub.GetMode()
-		if subMode != gnmipb.SubscriptionMode_SAMPLE {
+		var interval time.Duration
+		switch subMode := sub.GetMode(); subMode {
+		case gnmipb.SubscriptionMode_SAMPLE:
+			var err error
+			interval, err = validateSampleInterval(sub)
+			if err != nil {
+				putFatalMsg(c.q, err.Error())
+				return
+			}
+		case gnmipb.SubscriptionMode_ON_CHANGE:
+			interval = NonDbOnChangeInterval
+		default:
 			putFatalMsg(c.q, fmt.Sprintf("Unsupported subscription mode: %v.", subMode))
 			return
 		}
 
-		interval, err := validateSampleInterval(sub)
-		if err != nil {
-			putFatalMsg(c.q, err.Error())
-			return
-		}
-
 		gnmiPath := sub.GetPath()
 		_, ok := c.path2Getter[gnmiPath]
 		if !ok {
@@ -459,10 +769,16 @@ func (c *NonDbClient) StreamRun(q *queue.PriorityQueue, stop chan struct{}, w *s
 		return
 	}
 
+	// Leaves last sent for ON_CHANGE subs
+	leaves := make(map[*gnmipb.Subscription]map[string]*gnmipb.Update)
 	for sub := range validatedSubs {
 		gnmiPath := sub.GetPath()
 		getter, _ := c.path2Getter[gnmiPath]
-		runGetterAndSend(c, gnmiPath, getter)
+		if sub.GetMode() == gnmipb.SubscriptionMode_ON_CHANGE {
+			leaves[sub] = runGetterAndSendChanges(c, gnmiPath, getter, nil)
+		} else {
+			runGetterAndSend(c, gnmiPath, getter)
+		}
 	}
 
 	c.q.Put(Value{
@@ -474,7 +790,11 @@ func (c *NonDbClient) StreamRun(q *queue.PriorityQueue, stop chan struct{}, w *s
 
 	// Start a GO routine for each sub as they might have different intervals
 	for sub, interval := range validatedSubs {
-		go streamSample(c, stop, sub, interval)
+		if sub.GetMode() == gnmipb.SubscriptionMode_ON_CHANGE {
+			go streamOnChange(c, stop, sub, interval, leaves[sub])
+		} else {
+			go streamSample(c, stop, sub, interval)
+		}
 	}
 
 	log.V(1).Infof("Started non-db sampling routines for %s ", c)
@@ -500,6 +820,156 @@ func streamSample(c *NonDbClient, stop chan struct{}, sub *gnmipb.Subscription,
 	}
 }
 
+// streamOnChange implements the sampling loop for an ON_CHANGE subscription, sending
+// the changes from leaves, the leaves sent for the sub so far.
+func streamOnChange(c *NonDbClient, stop chan struct{}, sub *gnmipb.Subscription, interval time.Duration, leaves map[string]*gnmipb.Update) {
+	log.V(1).Infof("Starting on change routine sub: '%s' client: '%s'", sub, c)
+
+	gnmiPath := sub.GetPath()
+	getter, _ := c.path2Getter[gnmiPath] // this is already a validated sub, getter should be there.
+
+	for {
+		select {
+		case <-stop:
+			log.V(1).Infof("Stopping NonDbClient.streamOnChange routine for sub '%s'", sub)
+			return
+		case <-time.After(interval):
+			leaves = runGetterAndSendChanges(c, gnmiPath, getter, leaves)
+		}
+	}
+}
+
+// runGetterAndSendChanges runs a given getter method and puts the leaves of its result
+// changed from last to client queue, as a single notification. All the leaves are sent
+// when last is nil. It returns the leaves sent so far.
+func runGetterAndSendChanges(c *NonDbClient, gnmiPath *gnmipb.Path, getter dataGetFunc, last map[string]*gnmipb.Update) map[string]*gnmipb.Update {
+	v, err := getter()
+	if err != nil {
+		log.V(3).Infof("runGetterAndSendChanges getter error %v, %v", gnmiPath, err)
+		return last
+	}
+	leaves, err := jsonLeaves(gnmiPath, v)
+	if err != nil {
+		log.V(3).Infof("runGetterAndSendChanges invalid json for %v, %v", gnmiPath, err)
+		return last
+	}
+
+	updates, deletes := diffLeaves(last, leaves)
+	if last != nil && len(updates) == 0 && len(deletes) == 0 {
+		return leaves
+	}
+	spbv := &spb.Value{
+		Notification: &gnmipb.Notification{
+			Prefix:    c.prefix,
+			Timestamp: time.Now().UnixNano(),
+			Update:    updates,
+			Delete:    deletes,
+		},
+	}
+	if err := c.q.Put(Value{spbv}); err != nil {
+		log.V(3).Infof("Failed to put for %v, %v", gnmiPath, err)
+		return last
+	}
+	log.V(6).Infof("Added spbv #%v", spbv)
+	return leaves
+}
+
+// jsonLeaves flattens the JSON value data at path into its leaves, by path string.
+// Object members are path elements below path, and array items are keyed by their index.
+func jsonLeaves(path *gnmipb.Path, data []byte) (map[string]*gnmipb.Update, error) {
+	var v interface{}
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	if err := d.Decode(&v); err != nil {
+		return nil, err
+	}
+	leaves := make(map[string]*gnmipb.Update)
+	addJsonLeaves(leaves, path.GetElem(), "", v)
+	return leaves, nil
+}
+
+func addJsonLeaves(leaves map[string]*gnmipb.Update, elems []*gnmipb.PathElem, key string, v interface{}) {
+	child := func(elem *gnmipb.PathElem) []*gnmipb.PathElem {
+		return append(append([]*gnmipb.PathElem{}, elems...), elem)
+	}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for name, value := range v {
+			addJsonLeaves(leaves, child(&gnmipb.PathElem{Name: name}), key+"/"+name, value)
+		}
+	case []interface{}:
+		for i, value := range v {
+			index := strconv.Itoa(i)
+			if n := len(elems); n > 0 && len(elems[n-1].GetKey()) == 0 {
+				// key the last element, e.g. cpus[index=0]
+				last := &gnmipb.PathElem{Name: elems[n-1].GetName(), Key: map[string]string{"index": index}}
+				addJsonLeaves(leaves, append(append([]*gnmipb.PathElem{}, elems[:n-1]...), last), key+"[index="+index+"]", value)
+			} else {
+				addJsonLeaves(leaves, child(&gnmipb.PathElem{Name: index}), key+"/"+index, value)
+			}
+		}
+	default:
+		b, _ := json.Marshal(v)
+		leaves[key] = &gnmipb.Update{
+			Path: &gnmipb.Path{Elem: elems},
+			Val: &gnmipb.TypedValue{
+				Value: &gnmipb.TypedValue_JsonIetfVal{
+					JsonIetfVal: b,
+				}},
+		}
+	}
+}
+
+// leafChanged reports whether a leaf changed from prev to cur. cpu_percent leaves change
+// when they differ by NonDbCpuPercentThreshold at least.
+func leafChanged(key string, prev, cur *gnmipb.Update) bool {
+	a, b := prev.GetVal().GetJsonIetfVal(), cur.GetVal().GetJsonIetfVal()
+	if bytes.Equal(a, b) {
+		return false
+	}
+	if strings.HasSuffix(key, "/cpu_percent") {
+		x, errx := strconv.ParseFloat(string(a), 64)
+		y, erry := strconv.ParseFloat(string(b), 64)
+		if errx == nil && erry == nil {
+			return math.Abs(x-y) >= NonDbCpuPercentThreshold
+		}
+	}
+	return true
+}
+
+// diffLeaves returns the leaves of cur that are new or changed from last, and the
+// paths of the leaves of last removed in cur. Leaves whose change is below their
+// threshold are not reported, and are reset in cur to their value in last so that
+// later changes are measured from the value sent.
+func diffLeaves(last, cur map[string]*gnmipb.Update) ([]*gnmipb.Update, []*gnmipb.Path) {
+	var updates []*gnmipb.Update
+	var deletes []*gnmipb.Path
+	keys := make([]string, 0, len(cur))
+	for key := range cur {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		prev, ok := last[key]
+		if !ok || leafChanged(key, prev, cur[key]) {
+			updates = append(updates, cur[key])
+		} else {
+			cur[key] = prev
+		}
+	}
+	keys = keys[:0]
+	for key := range last {
+		if _, ok := cur[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		deletes = append(deletes, last[key].GetPath())
+	}
+	return updates, deletes
+}
+
 // runGetterAndSend runs a given getter method and puts the result to client queue.
 func runGetterAndSend(c *NonDbClient, gnmiPath *gnmipb.Path, getter dataGetFunc) error {
 	v, err := getter()
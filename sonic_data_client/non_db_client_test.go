@@ -0,0 +1,437 @@
+package client
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Workiva/go-datastructures/queue"
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+func writeFixture(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func resetCpuSamples() {
+	cpuSamples.mu.Lock()
+	cpuSamples.samples = map[string]cpuSample{}
+	cpuSamples.mu.Unlock()
+}
+
+func TestJsonLeaves(t *testing.T) {
+	path := &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "platform"}, {Name: "cpu"}}}
+	last, err := jsonLeaves(path, []byte(`{"cpu_all": {"id": "cpu", "1s": 10}, "cpus": [{"id": "cpu0", "1s": 5}]}`))
+	if err != nil {
+		t.Fatalf("jsonLeaves failed: %v", err)
+	}
+	leaf, ok := last["/cpus[index=0]/1s"]
+	if len(last) != 4 || !ok {
+		t.Fatalf("Unexpected leaves %v", last)
+	}
+	if elems := leaf.GetPath().GetElem(); len(elems) != 4 || elems[2].GetName() != "cpus" ||
+		elems[2].GetKey()["index"] != "0" || elems[3].GetName() != "1s" || string(leaf.GetVal().GetJsonIetfVal()) != "5" {
+		t.Errorf("Unexpected leaf %v", leaf)
+	}
+
+	cur, err := jsonLeaves(path, []byte(`{"cpu_all": {"id": "cpu", "1s": 12}, "cpus": []}`))
+	if err != nil {
+		t.Fatalf("jsonLeaves failed: %v", err)
+	}
+	updates, deletes := diffLeaves(last, cur)
+	if len(updates) != 1 || string(updates[0].GetVal().GetJsonIetfVal()) != "12" {
+		t.Errorf("Expected cpu_all/1s to be updated, got %v", updates)
+	}
+	if len(deletes) != 2 || deletes[0].GetElem()[2].GetName() != "cpus" {
+		t.Errorf("Expected cpus[index=0] leaves to be deleted, got %v", deletes)
+	}
+	if updates, deletes := diffLeaves(cur, cur); len(updates) != 0 || len(deletes) != 0 {
+		t.Errorf("Expected no change, got %v %v", updates, deletes)
+	}
+
+	if _, err := jsonLeaves(path, []byte(`{"cpu_all"`)); err == nil {
+		t.Errorf("Expected invalid json to fail")
+	}
+}
+
+func TestDiffLeavesCpuPercentThreshold(t *testing.T) {
+	path := &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "proc"}, {Name: "daemons"}}}
+	leaves := func(data string) map[string]*gnmipb.Update {
+		l, err := jsonLeaves(path, []byte(data))
+		if err != nil {
+			t.Fatalf("jsonLeaves failed: %v", err)
+		}
+		return l
+	}
+	last := leaves(`{"orchagent": [{"pid": 10, "cpu_percent": 2.0}]}`)
+
+	// Small variations are not sent, and are measured from the value last sent
+	cur := leaves(`{"orchagent": [{"pid": 10, "cpu_percent": 2.6}]}`)
+	if updates, _ := diffLeaves(last, cur); len(updates) != 0 {
+		t.Errorf("Expected a change below the threshold not to be sent, got %v", updates)
+	}
+	next := leaves(`{"orchagent": [{"pid": 10, "cpu_percent": 3.2}]}`)
+	updates, _ := diffLeaves(cur, next)
+	if len(updates) != 1 || string(updates[0].GetVal().GetJsonIetfVal()) != "3.2" {
+		t.Errorf("Expected cpu_percent to be sent once changed by the threshold, got %v", updates)
+	}
+
+	// Other leaves are sent on any change
+	if updates, _ := diffLeaves(next, leaves(`{"orchagent": [{"pid": 11, "cpu_percent": 3.2}]}`)); len(updates) != 1 {
+		t.Errorf("Expected pid to be sent, got %v", updates)
+	}
+}
+
+func TestNonDbClientStreamOnChange(t *testing.T) {
+	var mu sync.Mutex
+	value := `{"temperature": 40, "state": "ok"}`
+	RegisterNonDbPath([]string{"OTHERS", "test", "onchange"}, func() ([]byte, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return []byte(value), nil
+	})
+	setValue := func(v string) {
+		mu.Lock()
+		value = v
+		mu.Unlock()
+	}
+	interval := NonDbOnChangeInterval
+	NonDbOnChangeInterval = 10 * time.Millisecond
+	defer func() { NonDbOnChangeInterval = interval }()
+
+	prefix := &gnmipb.Path{Target: "OTHERS"}
+	path := &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "test"}, {Name: "onchange"}}}
+	c, err := NewNonDbClient([]*gnmipb.Path{path}, prefix)
+	if err != nil {
+		t.Fatalf("NewNonDbClient failed: %v", err)
+	}
+
+	q := queue.NewPriorityQueue(1, false)
+	stop := make(chan struct{})
+	var w sync.WaitGroup
+	w.Add(1)
+	go c.StreamRun(q, stop, &w, &gnmipb.SubscriptionList{
+		Subscription: []*gnmipb.Subscription{{Path: path, Mode: gnmipb.SubscriptionMode_ON_CHANGE}},
+	})
+	defer func() {
+		close(stop)
+		w.Wait()
+	}()
+
+	next := func() *gnmipb.Notification {
+		t.Helper()
+		timer := time.AfterFunc(5*time.Second, q.Dispose)
+		defer timer.Stop()
+		items, err := q.Get(1)
+		if err != nil {
+			t.Fatalf("No value received: %v", err)
+		}
+		v := items[0].(Value)
+		if v.GetSyncResponse() {
+			return nil
+		}
+		return v.GetNotification()
+	}
+
+	if n := next(); len(n.GetUpdate()) != 2 || n.GetPrefix() != prefix {
+		t.Fatalf("Expected all leaves first, got %v", n)
+	}
+	if n := next(); n != nil {
+		t.Fatalf("Expected sync response, got %v", n)
+	}
+
+	setValue(`{"temperature": 41, "state": "ok"}`)
+	n := next()
+	if len(n.GetUpdate()) != 1 || len(n.GetDelete()) != 0 || string(n.GetUpdate()[0].GetVal().GetJsonIetfVal()) != "41" {
+		t.Fatalf("Expected temperature update only, got %v", n)
+	}
+	if elems := n.GetUpdate()[0].GetPath().GetElem(); len(elems) != 3 || elems[2].GetName() != "temperature" {
+		t.Errorf("Unexpected update path %v", elems)
+	}
+
+	setValue(`{"temperature": 41}`)
+	n = next()
+	if len(n.GetUpdate()) != 0 || len(n.GetDelete()) != 1 || n.GetDelete()[0].GetElem()[2].GetName() != "state" {
+		t.Fatalf("Expected state delete only, got %v", n)
+	}
+}
+
+func TestNonDbClientStreamUnsupportedMode(t *testing.T) {
+	path := &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "proc"}, {Name: "uptime"}}}
+	c, err := NewNonDbClient([]*gnmipb.Path{path}, &gnmipb.Path{Target: "OTHERS"})
+	if err != nil {
+		t.Fatalf("NewNonDbClient failed: %v", err)
+	}
+	q := queue.NewPriorityQueue(1, false)
+	var w sync.WaitGroup
+	w.Add(1)
+	c.StreamRun(q, make(chan struct{}), &w, &gnmipb.SubscriptionList{
+		Subscription: []*gnmipb.Subscription{{Path: path, Mode: gnmipb.SubscriptionMode_TARGET_DEFINED}},
+	})
+	items, err := q.Get(1)
+	if err != nil || items[0].(Value).GetFatal() == "" {
+		t.Errorf("Expected fatal error for TARGET_DEFINED mode, got %v, %v", items, err)
+	}
+}
+
+func TestGetPlatformTemperature(t *testing.T) {
+	root := t.TempDir()
+	writeFixture(t, filepath.Join(root, "hwmon0", "name"), "coretemp\n")
+	writeFixture(t, filepath.Join(root, "hwmon0", "temp1_input"), "45000\n")
+	writeFixture(t, filepath.Join(root, "hwmon0", "temp1_label"), "Package id 0\n")
+	writeFixture(t, filepath.Join(root, "hwmon0", "temp1_max"), "80000\n")
+	writeFixture(t, filepath.Join(root, "hwmon0", "temp1_crit"), "100000\n")
+	writeFixture(t, filepath.Join(root, "hwmon1", "name"), "acpitz\n")
+	writeFixture(t, filepath.Join(root, "hwmon1", "temp2_input"), "27800\n")
+	writeFixture(t, filepath.Join(root, "hwmon1", "temp3_input"), "invalid\n")
+	writeFixture(t, filepath.Join(root, "hwmon2", "name"), "fans\n")
+	defer func(r string) { hwmonRoot = r }(hwmonRoot)
+	hwmonRoot = root
+
+	b, err := getPlatformTemperature()
+	if err != nil {
+		t.Fatalf("getPlatformTemperature failed: %v", err)
+	}
+	var devices map[string]hwmonDevice
+	if err := json.Unmarshal(b, &devices); err != nil {
+		t.Fatalf("Invalid json %s: %v", b, err)
+	}
+	if len(devices) != 2 {
+		t.Fatalf("Expected 2 devices with temperature sensors, got %s", b)
+	}
+	core := devices["hwmon0"].Sensors["temp1"]
+	if devices["hwmon0"].Name != "coretemp" || core == nil || core.Label != "Package id 0" || core.Temperature != 45 ||
+		core.High == nil || *core.High != 80 || core.Critical == nil || *core.Critical != 100 {
+		t.Errorf("Unexpected hwmon0 sensors %s", b)
+	}
+	acpi := devices["hwmon1"].Sensors
+	if len(acpi) != 1 || acpi["temp2"].Temperature != 27.8 || acpi["temp2"].High != nil {
+		t.Errorf("Unexpected hwmon1 sensors %s", b)
+	}
+}
+
+func TestGetProcNetDev(t *testing.T) {
+	root := t.TempDir()
+	writeFixture(t, filepath.Join(root, "net", "dev"), `Inter-|   Receive                                                |  Transmit
+ face |bytes    packets errs drop fifo frame compressed multicast|bytes    packets errs drop fifo colls carrier compressed
+    lo:    1000      10    0    0    0     0          0         0     1000      10    0    0    0     0       0          0
+  eth0:  123456     100    1    2    0     0          0         3    65432      90    0    4    0     0       0          0
+`)
+	defer func(r string) { procRoot = r }(procRoot)
+	procRoot = root
+
+	b, err := getProcNetDev()
+	if err != nil {
+		t.Fatalf("getProcNetDev failed: %v", err)
+	}
+	var stats []map[string]interface{}
+	if err := json.Unmarshal(b, &stats); err != nil {
+		t.Fatalf("Invalid json %s: %v", b, err)
+	}
+	if len(stats) != 2 || stats[1]["iface"] != "eth0" || stats[1]["rxbytes"] != float64(123456) || stats[1]["txdrop"] != float64(4) {
+		t.Errorf("Unexpected net dev stats %s", b)
+	}
+
+	procRoot = t.TempDir()
+	if b, err := getProcNetDev(); err == nil {
+		t.Errorf("Expected a missing net dev file to fail, got %s", b)
+	}
+}
+
+func TestReadProcessStats(t *testing.T) {
+	resetCpuSamples()
+	root := t.TempDir()
+	proc := func(pid, comm string, utime, rss int) {
+		writeFixture(t, filepath.Join(root, pid, "stat"),
+			pid+" ("+comm+") S 1 1 1 0 -1 4194560 100 0 0 0 "+strconv.Itoa(utime)+" 10 0 0 20 0 4 0 100 1000000 200 0")
+		writeFixture(t, filepath.Join(root, pid, "status"),
+			"Name:\t"+comm+"\nVmSize:\t  200000 kB\nVmRSS:\t  "+strconv.Itoa(rss)+" kB\nThreads:\t4\n")
+	}
+	proc("100", "orchagent", 50, 30000)
+	proc("200", "syncd", 20, 90000)
+	proc("201", "syncd", 10, 1000)
+	proc("300", "bash", 10, 1000)
+	proc(strconv.Itoa(os.Getpid()), "gnmi.test", 10, 1000)
+	writeFixture(t, filepath.Join(root, "meminfo"), "MemTotal: 1000 kB\n")
+	defer func(r string) { procRoot = r }(procRoot)
+	procRoot = root
+
+	stats, err := readProcessStats("*")
+	if err != nil {
+		t.Fatalf("readProcessStats failed: %v", err)
+	}
+	if len(stats) != 3 || len(stats["orchagent"]) != 1 || len(stats["syncd"]) != 2 || len(stats["gnmi.test"]) != 1 {
+		t.Fatalf("Expected SONiC daemons and telemetry itself, got %v", stats)
+	}
+	orchagent := stats["orchagent"][0]
+	if orchagent.Pid != 100 || orchagent.Utime != 50 || orchagent.Stime != 10 || orchagent.RssKb != 30000 ||
+		orchagent.VmSizeKb != 200000 || orchagent.Threads != 4 || orchagent.CpuPercent != 0 {
+		t.Errorf("Unexpected orchagent stats %+v", orchagent)
+	}
+	if stats["syncd"][0].Pid != 200 || stats["syncd"][1].Pid != 201 {
+		t.Errorf("Expected syncd processes sorted by pid, got %+v %+v", stats["syncd"][0], stats["syncd"][1])
+	}
+
+	// cpu usage since the previous read
+	time.Sleep(100 * time.Millisecond)
+	proc("100", "orchagent", 55, 30000)
+	stats, _ = readProcessStats("orch*")
+	if len(stats) != 1 {
+		t.Fatalf("Expected orchagent only, got %v", stats)
+	}
+	if cpu := stats["orchagent"][0].CpuPercent; cpu <= 0 || cpu > 100 {
+		t.Errorf("Expected orchagent cpu usage, got %v", cpu)
+	}
+
+	if stats, err := readProcessStats("bash"); err != nil || len(stats["bash"]) != 1 {
+		t.Errorf("Expected bash by name, got %v, %v", stats, err)
+	}
+	if _, err := readProcessStats("bgpd"); err == nil {
+		t.Errorf("Expected missing process to fail")
+	}
+	if stats, err := readProcessStats("bgp*"); err != nil || len(stats) != 0 {
+		t.Errorf("Expected no process for bgp*, got %v, %v", stats, err)
+	}
+	if _, err := readProcessStats("[a-"); err == nil {
+		t.Errorf("Expected invalid name to fail")
+	}
+	if _, err := getDaemonStats(); err != nil {
+		t.Errorf("getDaemonStats failed: %v", err)
+	}
+}
+
+func TestLookupGetFuncByName(t *testing.T) {
+	root := t.TempDir()
+	writeFixture(t, filepath.Join(root, "100", "stat"), "100 (orchagent) S 1 1 1 0 -1 4194560 100 0 0 0 50 10 0 0 20 0 4 0 100 1000000 200 0")
+	writeFixture(t, filepath.Join(root, "100", "status"), "Name:\torchagent\nVmRSS:\t  30000 kB\n")
+	defer func(r string) { procRoot = r }(procRoot)
+	procRoot = root
+
+	prefix := &gnmipb.Path{Target: "OTHERS"}
+	for _, test := range []struct {
+		key     map[string]string
+		wantErr bool
+	}{
+		{key: nil},
+		{key: map[string]string{"name": "*"}},
+		{key: map[string]string{"name": "orchagent"}},
+		{key: map[string]string{"name": "syncd"}, wantErr: true},
+	} {
+		getter, err := lookupGetFunc(prefix, &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "processes", Key: test.key}}})
+		if err != nil {
+			t.Fatalf("lookupGetFunc failed for %v: %v", test.key, err)
+		}
+		b, err := getter()
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("Expected getter to fail for %v, got %s", test.key, b)
+			}
+			continue
+		}
+		var stats map[string][]processStat
+		if err := json.Unmarshal(b, &stats); err != nil || len(stats["orchagent"]) != 1 {
+			t.Errorf("Unexpected processes for %v: %s, %v", test.key, b, err)
+		}
+	}
+
+	if _, err := lookupGetFunc(prefix, &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "processes"}, {Name: "orchagent"}}}); err == nil {
+		t.Errorf("Expected lookup below processes to fail")
+	}
+}
+
+func TestReadContainerStatsV2(t *testing.T) {
+	resetCpuSamples()
+	root, docker := t.TempDir(), t.TempDir()
+	writeFixture(t, filepath.Join(root, "cgroup.controllers"), "cpu io memory pids\n")
+	// systemd and cgroupfs drivers
+	swss := filepath.Join(root, "system.slice", "docker-aaa.scope")
+	writeFixture(t, filepath.Join(swss, "cpu.stat"), "usage_usec 2000000\nuser_usec 1500000\nsystem_usec 500000\nnr_periods 0\n")
+	writeFixture(t, filepath.Join(swss, "memory.current"), "104857600\n")
+	writeFixture(t, filepath.Join(swss, "memory.max"), "max\n")
+	writeFixture(t, filepath.Join(swss, "pids.current"), "42\n")
+	writeFixture(t, filepath.Join(swss, "pids.max"), "4096\n")
+	writeFixture(t, filepath.Join(swss, "io.stat"), "8:0 rbytes=4096 wbytes=8192 rios=1 wios=2 dbytes=0 dios=0\n8:16 rbytes=4096 wbytes=0 rios=1 wios=0 dbytes=0 dios=0\n")
+	syncd := filepath.Join(root, "docker", "bbb")
+	writeFixture(t, filepath.Join(syncd, "cpu.stat"), "usage_usec 1000\n")
+	writeFixture(t, filepath.Join(syncd, "memory.max"), "1073741824\n")
+	writeFixture(t, filepath.Join(docker, "containers", "aaa", "config.v2.json"), `{"ID": "aaa", "Name": "/swss"}`)
+	writeFixture(t, filepath.Join(docker, "containers", "bbb", "config.v2.json"), `{"ID": "bbb", "Name": "/syncd"}`)
+	// no cpu.stat, stopped
+	writeFixture(t, filepath.Join(root, "docker", "ccc", "memory.current"), "0\n")
+	defer func(c, d string) { cgroupRoot, dockerRoot = c, d }(cgroupRoot, dockerRoot)
+	cgroupRoot, dockerRoot = root, docker
+
+	stats, err := readContainerStats("*")
+	if err != nil {
+		t.Fatalf("readContainerStats failed: %v", err)
+	}
+	if len(stats) != 2 || stats["swss"] == nil || stats["syncd"] == nil {
+		t.Fatalf("Expected swss and syncd containers, got %v", stats)
+	}
+	want := containerStat{Id: "aaa", CpuUsageUsec: 2000000, CpuUserUsec: 1500000, CpuSystemUsec: 500000, MemoryUsage: 104857600,
+		PidsCurrent: 42, PidsLimit: 4096, IoReadBytes: 8192, IoWriteBytes: 8192, IoReads: 2, IoWrites: 2}
+	if *stats["swss"] != want {
+		t.Errorf("Unexpected swss stats %+v, want %+v", *stats["swss"], want)
+	}
+	if stats["syncd"].Id != "bbb" || stats["syncd"].MemoryLimit != 1073741824 {
+		t.Errorf("Unexpected syncd stats %+v", *stats["syncd"])
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	writeFixture(t, filepath.Join(swss, "cpu.stat"), "usage_usec 2050000\n")
+	stats, err = readContainerStats("swss")
+	if err != nil || len(stats) != 1 {
+		t.Fatalf("Expected swss only, got %v, %v", stats, err)
+	}
+	if cpu := stats["swss"].CpuPercent; cpu <= 0 || cpu > 50 {
+		t.Errorf("Expected swss cpu usage, got %v", cpu)
+	}
+	if _, err := readContainerStats("bgp"); err == nil {
+		t.Errorf("Expected missing container to fail")
+	}
+}
+
+func TestReadContainerStatsV1(t *testing.T) {
+	resetCpuSamples()
+	root, docker := t.TempDir(), t.TempDir()
+	writeFixture(t, filepath.Join(root, "cpuacct", "docker", "aaa", "cpuacct.usage"), "3000000000\n")
+	writeFixture(t, filepath.Join(root, "cpuacct", "docker", "aaa", "cpuacct.stat"), "user 200\nsystem 100\n")
+	writeFixture(t, filepath.Join(root, "memory", "docker", "aaa", "memory.usage_in_bytes"), "52428800\n")
+	writeFixture(t, filepath.Join(root, "memory", "docker", "aaa", "memory.limit_in_bytes"), "9223372036854771712\n")
+	writeFixture(t, filepath.Join(root, "pids", "docker", "aaa", "pids.current"), "7\n")
+	writeFixture(t, filepath.Join(root, "pids", "docker", "aaa", "pids.max"), "max\n")
+	writeFixture(t, filepath.Join(root, "blkio", "docker", "aaa", "blkio.throttle.io_service_bytes"),
+		"8:0 Read 1024\n8:0 Write 2048\n8:0 Sync 0\n8:0 Total 3072\nTotal 3072\n")
+	writeFixture(t, filepath.Join(root, "blkio", "docker", "aaa", "blkio.throttle.io_serviced"), "8:0 Read 3\n8:0 Write 4\n")
+	writeFixture(t, filepath.Join(docker, "containers", "aaa", "config.v2.json"), `{"ID": "aaa", "Name": "/bgp"}`)
+	// unknown to docker, named by id
+	writeFixture(t, filepath.Join(root, "cpuacct", "system.slice", "docker-ddd.scope", "cpuacct.usage"), "0\n")
+	defer func(c, d string) { cgroupRoot, dockerRoot = c, d }(cgroupRoot, dockerRoot)
+	cgroupRoot, dockerRoot = root, docker
+
+	stats, err := readContainerStats("*")
+	if err != nil {
+		t.Fatalf("readContainerStats failed: %v", err)
+	}
+	if len(stats) != 2 || stats["bgp"] == nil || stats["ddd"] == nil {
+		t.Fatalf("Expected bgp and ddd containers, got %v", stats)
+	}
+	want := containerStat{Id: "aaa", CpuUsageUsec: 3000000, CpuUserUsec: 2000000, CpuSystemUsec: 1000000, MemoryUsage: 52428800,
+		PidsCurrent: 7, IoReadBytes: 1024, IoWriteBytes: 2048, IoReads: 3, IoWrites: 4}
+	if *stats["bgp"] != want {
+		t.Errorf("Unexpected bgp stats %+v, want %+v", *stats["bgp"], want)
+	}
+	if _, err := getContainerStats("b*"); err != nil {
+		t.Errorf("getContainerStats failed: %v", err)
+	}
+}
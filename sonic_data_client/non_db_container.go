@@ -0,0 +1,278 @@
+package client
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/golang/glog"
+)
+
+var (
+	// Root of the cgroup file system and of docker data, overridden by UTs only.
+	cgroupRoot = "/sys/fs/cgroup"
+	dockerRoot = "/var/lib/docker"
+)
+
+// Limits of cgroup v1 at or above noCgroupLimit mean no limit
+const noCgroupLimit = 1 << 62
+
+// containerStat is the resource usage of a docker container, from its cgroup.
+// Limits are 0 when not set.
+type containerStat struct {
+	Id            string  `json:"id"`
+	CpuPercent    float64 `json:"cpu_percent"`
+	CpuUsageUsec  uint64  `json:"cpu_usage_usec"`
+	CpuUserUsec   uint64  `json:"cpu_user_usec"`
+	CpuSystemUsec uint64  `json:"cpu_system_usec"`
+	MemoryUsage   uint64  `json:"memory_usage"`
+	MemoryLimit   uint64  `json:"memory_limit"`
+	PidsCurrent   uint64  `json:"pids_current"`
+	PidsLimit     uint64  `json:"pids_limit"`
+	IoReadBytes   uint64  `json:"io_read_bytes"`
+	IoWriteBytes  uint64  `json:"io_write_bytes"`
+	IoReads       uint64  `json:"io_reads"`
+	IoWrites      uint64  `json:"io_writes"`
+}
+
+// readCgroupUint reads a file of a single number, or max for no limit, as 0.
+func readCgroupUint(path string) (uint64, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	s := strings.TrimSpace(string(b))
+	if s == "max" {
+		return 0, nil
+	}
+	v, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if v >= noCgroupLimit {
+		return 0, nil
+	}
+	return v, nil
+}
+
+// readCgroupKeyed reads a file of "key value" lines, like cpu.stat.
+func readCgroupKeyed(path string) (map[string]uint64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	values := map[string]uint64{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		if v, err := strconv.ParseUint(fields[1], 10, 64); err == nil {
+			values[fields[0]] = v
+		}
+	}
+	return values, scanner.Err()
+}
+
+// cgroupV2 returns if cgroupRoot is a cgroup v2 unified hierarchy.
+func cgroupV2() bool {
+	_, err := os.Stat(filepath.Join(cgroupRoot, "cgroup.controllers"))
+	return err == nil
+}
+
+// containerCgroups returns the cgroup directories of the docker containers by container
+// id, in controller for cgroup v1. Both the systemd and cgroupfs cgroup drivers of docker
+// are supported.
+func containerCgroups(controller string) map[string]string {
+	dirs := map[string]string{}
+	root := filepath.Join(cgroupRoot, controller)
+	scopes, _ := filepath.Glob(filepath.Join(root, "system.slice", "docker-*.scope"))
+	for _, dir := range scopes {
+		dirs[strings.TrimSuffix(strings.TrimPrefix(filepath.Base(dir), "docker-"), ".scope")] = dir
+	}
+	entries, _ := ioutil.ReadDir(filepath.Join(root, "docker"))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			dirs[entry.Name()] = filepath.Join(root, "docker", entry.Name())
+		}
+	}
+	return dirs
+}
+
+// containerName returns the name of container id from its docker config, or the id.
+func containerName(id string) string {
+	b, err := ioutil.ReadFile(filepath.Join(dockerRoot, "containers", id, "config.v2.json"))
+	if err != nil {
+		return id
+	}
+	var config struct {
+		Name string
+	}
+	if err := json.Unmarshal(b, &config); err != nil || config.Name == "" {
+		return id
+	}
+	return strings.TrimPrefix(config.Name, "/")
+}
+
+// readContainerStatV2 reads the stats of the container of cgroup v2 dir.
+func readContainerStatV2(dir string, stat *containerStat) error {
+	cpu, err := readCgroupKeyed(filepath.Join(dir, "cpu.stat"))
+	if err != nil {
+		return err
+	}
+	stat.CpuUsageUsec, stat.CpuUserUsec, stat.CpuSystemUsec = cpu["usage_usec"], cpu["user_usec"], cpu["system_usec"]
+	stat.MemoryUsage, _ = readCgroupUint(filepath.Join(dir, "memory.current"))
+	stat.MemoryLimit, _ = readCgroupUint(filepath.Join(dir, "memory.max"))
+	stat.PidsCurrent, _ = readCgroupUint(filepath.Join(dir, "pids.current"))
+	stat.PidsLimit, _ = readCgroupUint(filepath.Join(dir, "pids.max"))
+
+	// io.stat lines are per device, e.g. "8:0 rbytes=4096 wbytes=0 rios=1 wios=0 dbytes=0 dios=0"
+	b, _ := ioutil.ReadFile(filepath.Join(dir, "io.stat"))
+	for _, line := range strings.Split(string(b), "\n") {
+		for _, field := range strings.Fields(line) {
+			kv := strings.SplitN(field, "=", 2)
+			if len(kv) != 2 {
+				continue
+			}
+			v, err := strconv.ParseUint(kv[1], 10, 64)
+			if err != nil {
+				continue
+			}
+			switch kv[0] {
+			case "rbytes":
+				stat.IoReadBytes += v
+			case "wbytes":
+				stat.IoWriteBytes += v
+			case "rios":
+				stat.IoReads += v
+			case "wios":
+				stat.IoWrites += v
+			}
+		}
+	}
+	return nil
+}
+
+// readBlkioTotals sums the Read and Write lines of a blkio file of cgroup v1, like
+// blkio.throttle.io_service_bytes.
+func readBlkioTotals(path string) (read, write uint64) {
+	b, _ := ioutil.ReadFile(path)
+	for _, line := range strings.Split(string(b), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			continue
+		}
+		v, err := strconv.ParseUint(fields[2], 10, 64)
+		if err != nil {
+			continue
+		}
+		switch fields[1] {
+		case "Read":
+			read += v
+		case "Write":
+			write += v
+		}
+	}
+	return read, write
+}
+
+// readContainerStatV1 reads the stats of container id from the cgroup v1 controllers.
+func readContainerStatV1(id string, dirs map[string]map[string]string, stat *containerStat) error {
+	cpuacct, ok := dirs["cpuacct"][id]
+	if !ok {
+		return fmt.Errorf("no cpuacct cgroup")
+	}
+	usage, err := readCgroupUint(filepath.Join(cpuacct, "cpuacct.usage"))
+	if err != nil {
+		return err
+	}
+	stat.CpuUsageUsec = usage / 1000
+	// cpuacct.stat is in clock ticks
+	if cpu, err := readCgroupKeyed(filepath.Join(cpuacct, "cpuacct.stat")); err == nil {
+		stat.CpuUserUsec = cpu["user"] * 1000000 / clockTicks
+		stat.CpuSystemUsec = cpu["system"] * 1000000 / clockTicks
+	}
+	if dir, ok := dirs["memory"][id]; ok {
+		stat.MemoryUsage, _ = readCgroupUint(filepath.Join(dir, "memory.usage_in_bytes"))
+		stat.MemoryLimit, _ = readCgroupUint(filepath.Join(dir, "memory.limit_in_bytes"))
+	}
+	if dir, ok := dirs["pids"][id]; ok {
+		stat.PidsCurrent, _ = readCgroupUint(filepath.Join(dir, "pids.current"))
+		stat.PidsLimit, _ = readCgroupUint(filepath.Join(dir, "pids.max"))
+	}
+	if dir, ok := dirs["blkio"][id]; ok {
+		stat.IoReadBytes, stat.IoWriteBytes = readBlkioTotals(filepath.Join(dir, "blkio.throttle.io_service_bytes"))
+		stat.IoReads, stat.IoWrites = readBlkioTotals(filepath.Join(dir, "blkio.throttle.io_serviced"))
+	}
+	return nil
+}
+
+// readContainerStats reads the stats of the docker containers with a name matching
+// pattern, by name.
+func readContainerStats(pattern string) (map[string]*containerStat, error) {
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid container name %q: %v", pattern, err)
+	}
+
+	v2 := cgroupV2()
+	var ids map[string]string
+	v1Dirs := map[string]map[string]string{}
+	if v2 {
+		ids = containerCgroups("")
+	} else {
+		for _, controller := range []string{"cpuacct", "memory", "pids", "blkio"} {
+			v1Dirs[controller] = containerCgroups(controller)
+		}
+		ids = v1Dirs["cpuacct"]
+	}
+
+	stats := map[string]*containerStat{}
+	now := time.Now()
+	for id, dir := range ids {
+		name := containerName(id)
+		if ok, _ := filepath.Match(pattern, name); !ok {
+			continue
+		}
+		stat := &containerStat{Id: id}
+		var err error
+		if v2 {
+			err = readContainerStatV2(dir, stat)
+		} else {
+			err = readContainerStatV1(id, v1Dirs, stat)
+		}
+		if err != nil {
+			log.V(4).Infof("Failed to read stats of container %s: %v", name, err)
+			continue // stopped
+		}
+		stat.CpuPercent = cpuPercent("container:"+id, float64(stat.CpuUsageUsec)/1000000, now)
+		stats[name] = stat
+	}
+	if len(stats) == 0 && !strings.ContainsAny(pattern, "*?[") {
+		return nil, fmt.Errorf("no container named %s", pattern)
+	}
+	return stats, nil
+}
+
+// getContainerStats gets the stats of the containers named pattern, by name.
+func getContainerStats(pattern string) ([]byte, error) {
+	stats, err := readContainerStats(pattern)
+	if err != nil {
+		log.V(2).Infof("%v", err)
+		return nil, err
+	}
+	b, err := json.Marshal(stats)
+	if err != nil {
+		log.V(2).Infof("%v", err)
+		return b, err
+	}
+	log.V(4).Infof("getContainerStats, output %v", string(b))
+	return b, nil
+}
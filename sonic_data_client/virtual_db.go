@@ -916,6 +916,9 @@ func ClearMappings() {
 			delete(counterMap, entry)
 		}
 	}
+	for port := range countersPGNameMap {
+		delete(countersPGNameMap, port)
+	}
 }
 
 func AliasToPortNameMap() map[string]string {
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	log "github.com/golang/glog"
+	gnmi "github.com/sonic-net/sonic-gnmi/gnmi_server"
+	"gopkg.in/yaml.v3"
+)
+
+// configFile is a YAML or JSON file setting the command line flags of telemetry, by name, e.g.
+//
+//	port: 50051
+//	client_auth: password,jwt
+//	threshold: 200
+//	listener:
+//	  - name=agent,addr=unix:/var/run/gnmi/gnmi.sock,readonly=true
+//
+// Flags given on the command line take precedence over the file. When the file changes,
+// the settings of liveSettings are applied to the running server, while the others are
+// reported and applied when telemetry restarts.
+type configFile struct {
+	path string
+	fs   *flag.FlagSet
+	// flags given on the command line
+	cmdLine map[string]bool
+	// values of the flags currently applied from the file
+	values map[string][]string
+}
+
+// liveSettings are the flags applied to the running server on reload, by setting live
+// or the settings of the gnmi package.
+var liveSettings = map[string]func(telemetryCfg *TelemetryConfig, live *gnmi.LiveConfig, value string) error{
+	"threshold": func(telemetryCfg *TelemetryConfig, live *gnmi.LiveConfig, value string) error {
+		threshold, err := strconv.Atoi(value)
+		if err != nil || threshold < 0 {
+			return fmt.Errorf("threshold must be >= 0")
+		}
+		*telemetryCfg.Threshold, live.Threshold = threshold, threshold
+		return nil
+	},
+	"v": func(telemetryCfg *TelemetryConfig, live *gnmi.LiveConfig, value string) error {
+		level, err := strconv.Atoi(value)
+		if err != nil || level < 0 {
+			return fmt.Errorf("log level must be >= 0")
+		}
+		if err := flag.Set("v", value); err != nil {
+			return err
+		}
+		*telemetryCfg.LogLevel, live.LogLevel = level, level
+		return nil
+	},
+	"idle_conn_duration": func(telemetryCfg *TelemetryConfig, live *gnmi.LiveConfig, value string) error {
+		duration, err := strconv.Atoi(value)
+		if err != nil || duration < 0 {
+			return fmt.Errorf("idle_conn_duration must be >= 0, 0 meaning inf")
+		}
+		*telemetryCfg.IdleConnDuration, live.IdleConnDuration = duration, duration
+		return nil
+	},
+	"client_auth": func(telemetryCfg *TelemetryConfig, live *gnmi.LiveConfig, value string) error {
+		userAuth := defaultUserAuth(telemetryCfg)
+		if value != "" {
+			userAuth = gnmi.NewAuthTypes()
+			if err := userAuth.Set(value); err != nil {
+				return err
+			}
+		}
+		if userAuth.Enabled("cert") && *telemetryCfg.CaCert == "" {
+			userAuth.Unset("cert")
+			log.Warning("client_auth mode cert requires ca_crt option. Disabling cert mode authentication.")
+		}
+		if userAuth.Enabled("oidc") && *telemetryCfg.OidcIssuer == "" {
+			return fmt.Errorf("oidc_issuer must be set for client_auth mode oidc")
+		}
+		telemetryCfg.UserAuth, live.UserAuth = userAuth, userAuth
+		return nil
+	},
+	"jwt_refresh_int": func(telemetryCfg *TelemetryConfig, live *gnmi.LiveConfig, value string) error {
+		interval, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		*telemetryCfg.JwtRefInt = interval
+		gnmi.SetJwtRefreshInt(time.Duration(interval * uint64(time.Second)))
+		return nil
+	},
+	"jwt_valid_int": func(telemetryCfg *TelemetryConfig, live *gnmi.LiveConfig, value string) error {
+		interval, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		*telemetryCfg.JwtValInt = interval
+		gnmi.SetJwtValidInt(time.Duration(interval * uint64(time.Second)))
+		return nil
+	},
+	"crl_expire_duration": func(telemetryCfg *TelemetryConfig, live *gnmi.LiveConfig, value string) error {
+		duration, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		*telemetryCfg.CrlExpireDuration = duration
+		gnmi.SetCrlExpireDuration(time.Duration(duration) * time.Second)
+		return nil
+	},
+	"ocsp_policy": func(telemetryCfg *TelemetryConfig, live *gnmi.LiveConfig, value string) error {
+		if err := gnmi.SetOcspPolicy(value); err != nil {
+			return err
+		}
+		*telemetryCfg.OcspPolicy = value
+		return nil
+	},
+}
+
+func newConfigFile(path string, fs *flag.FlagSet) *configFile {
+	c := &configFile{
+		path:    filepath.Clean(path),
+		fs:      fs,
+		cmdLine: map[string]bool{},
+		values:  map[string][]string{},
+	}
+	fs.Visit(func(f *flag.Flag) {
+		c.cmdLine[f.Name] = true
+	})
+	return c
+}
+
+// repeatable returns if flag name can be given several times.
+func (c *configFile) repeatable(name string) bool {
+	_, ok := c.fs.Lookup(name).Value.(*listenerSpecs)
+	return ok
+}
+
+// read parses the file into the values of the flags it sets.
+func (c *configFile) read() (map[string][]string, error) {
+	data, err := ioutil.ReadFile(c.path)
+	if err != nil {
+		return nil, err
+	}
+	var settings map[string]interface{}
+	if err := yaml.Unmarshal(data, &settings); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %v", c.path, err)
+	}
+
+	values := map[string][]string{}
+	for name, setting := range settings {
+		if c.fs.Lookup(name) == nil || name == "config_file" {
+			return nil, fmt.Errorf("invalid config file %s: unknown setting %q", c.path, name)
+		}
+		var value []string
+		switch setting := setting.(type) {
+		case nil:
+			continue
+		case []interface{}:
+			for _, v := range setting {
+				value = append(value, fmt.Sprint(v))
+			}
+		default:
+			if fmt.Sprint(setting) == "" {
+				continue
+			}
+			value = []string{fmt.Sprint(setting)}
+		}
+		if !c.repeatable(name) {
+			value = []string{strings.Join(value, ",")}
+		}
+		values[name] = value
+	}
+	return values, nil
+}
+
+// load sets the flags not given on the command line from the file.
+func (c *configFile) load() error {
+	values, err := c.read()
+	if err != nil {
+		return err
+	}
+	for name, value := range values {
+		if c.cmdLine[name] {
+			log.V(1).Infof("%s given on the command line, ignoring its value in config file %s", name, c.path)
+			continue
+		}
+		for _, v := range value {
+			if err := c.fs.Set(name, v); err != nil {
+				return fmt.Errorf("invalid %s in config file %s: %v", name, c.path, err)
+			}
+		}
+		if name == "v" {
+			// also the log level of glog, as when given on the command line
+			flag.Set("v", value[0])
+		}
+		c.values[name] = value
+	}
+	return nil
+}
+
+// reload applies the live settings changed in the file to cfg. It returns the settings
+// applied, and those that changed but need a restart of telemetry.
+func (c *configFile) reload(telemetryCfg *TelemetryConfig, cfg *gnmi.Config) (applied []string, restart []string, err error) {
+	values, err := c.read()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	names := map[string]bool{}
+	for name := range values {
+		names[name] = true
+	}
+	for name := range c.values {
+		names[name] = true
+	}
+	var changed []string
+	for name := range names {
+		if c.cmdLine[name] || strings.Join(values[name], "\n") == strings.Join(c.values[name], "\n") {
+			continue
+		}
+		changed = append(changed, name)
+	}
+	sort.Strings(changed)
+
+	live := cfg.Live()
+	for _, name := range changed {
+		apply, ok := liveSettings[name]
+		if !ok {
+			restart = append(restart, name)
+			continue
+		}
+		// a setting removed from the file gets back its default value
+		value, ok := values[name]
+		if !ok {
+			value = []string{c.fs.Lookup(name).DefValue}
+			if name == "client_auth" {
+				value = []string{""}
+			}
+		}
+		if err := apply(telemetryCfg, &live, value[0]); err != nil {
+			log.Errorf("Invalid %s in config file %s, keeping the current value: %v", name, c.path, err)
+			continue
+		}
+		if _, ok := values[name]; ok {
+			c.values[name] = value
+		} else {
+			delete(c.values, name)
+		}
+		applied = append(applied, name)
+	}
+	cfg.ApplyLive(live)
+	return applied, restart, nil
+}
+
+// watch reloads the file whenever it is written, created or renamed.
+func (c *configFile) watch(telemetryCfg *TelemetryConfig, cfg *gnmi.Config) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	if err := watcher.Add(filepath.Dir(c.path)); err != nil {
+		watcher.Close()
+		return fmt.Errorf("could not watch '%s': %v", filepath.Dir(c.path), err)
+	}
+
+	go func() {
+		defer watcher.Close()
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) != c.path || event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) == 0 {
+					continue
+				}
+				applied, restart, err := c.reload(telemetryCfg, cfg)
+				if err != nil {
+					log.Errorf("Failed to reload config file %s after %v, keeping current settings: %v", c.path, event, err)
+					continue
+				}
+				if len(applied) > 0 {
+					log.Infof("Applied %s from config file %s", strings.Join(applied, ", "), c.path)
+				}
+				if len(restart) > 0 {
+					log.Warningf("Config file %s changed %s, restart telemetry to apply", c.path, strings.Join(restart, ", "))
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Errorf("Error watching config file %s: %v", c.path, err)
+			}
+		}
+	}()
+	return nil
+}
@@ -27,7 +27,6 @@ import (
 	"github.com/sonic-net/sonic-gnmi/swsscommon"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
-	"google.golang.org/grpc/keepalive"
 )
 
 type ServerControlValue int
@@ -38,8 +37,21 @@ const (
 	ServerRestart ServerControlValue = iota // 2
 )
 
+// listenerSpecs collects the specs of the repeatable listener flag
+type listenerSpecs []string
+
+func (l *listenerSpecs) String() string {
+	return strings.Join(*l, " ")
+}
+
+func (l *listenerSpecs) Set(spec string) error {
+	*l = append(*l, spec)
+	return nil
+}
+
 type TelemetryConfig struct {
 	UserAuth              gnmi.AuthTypes
+	Listeners             listenerSpecs
 	Port                  *int
 	LogLevel              *int
 	CaCert                *string
@@ -53,16 +65,32 @@ type TelemetryConfig struct {
 	AllowNoClientCert     *bool
 	JwtRefInt             *uint64
 	JwtValInt             *uint64
+	JwtKeyFile            *string
+	JwtSigningMethod      *string
+	JwtKeyGrace           *uint64
+	JwtRevocationFile     *string
+	OidcIssuer            *string
+	OidcAudience          *string
+	OidcJwksFile          *string
+	OidcUsernameClaim     *string
+	OidcRolesClaim        *string
+	OidcRoleMap           *string
 	GnmiTranslibWrite     *bool
 	GnmiNativeWrite       *bool
 	Threshold             *int
 	WithMasterArbitration *bool
+	MaRolePaths           *string
 	WithSaveOnSet         *bool
 	IdleConnDuration      *int
 	Vrf                   *string
 	EnableCrl             *bool
 	CrlExpireDuration     *int
+	EnableOcsp            *bool
+	OcspPolicy            *string
+	ClientCertIdentity    *string
 	ImgDirPath            *string
+	ConfigFile            *string
+	configFile            *configFile
 }
 
 func main() {
@@ -105,6 +133,12 @@ func runTelemetry(args []string) error {
 
 	go signalHandler(serverControlSignal, sigchannel, stopSignalHandler, &wg)
 
+	if telemetryCfg.configFile != nil {
+		if err := telemetryCfg.configFile.watch(telemetryCfg, cfg); err != nil {
+			log.Warningf("Config file %s will not be reloaded on change: %v", *telemetryCfg.ConfigFile, err)
+		}
+	}
+
 	wg.Add(1)
 
 	go startGNMIServer(telemetryCfg, cfg, serverControlSignal, stopSignalHandler, &wg)
@@ -154,7 +188,7 @@ func parseOSArgs() ([]string, []string) {
 
 func setupFlags(fs *flag.FlagSet) (*TelemetryConfig, *gnmi.Config, error) {
 	telemetryCfg := &TelemetryConfig{
-		UserAuth:              gnmi.AuthTypes{"password": false, "cert": false, "jwt": false},
+		UserAuth:              gnmi.AuthTypes{"password": false, "cert": false, "jwt": false, "oidc": false},
 		Port:                  fs.Int("port", -1, "port to listen on"),
 		LogLevel:              fs.Int("v", 2, "log level of process"),
 		CaCert:                fs.String("ca_crt", "", "CA certificate for client certificate validation. Optional."),
@@ -168,34 +202,50 @@ func setupFlags(fs *flag.FlagSet) (*TelemetryConfig, *gnmi.Config, error) {
 		AllowNoClientCert:     fs.Bool("allow_no_client_auth", false, "When set, telemetry server will request but not require a client certificate."),
 		JwtRefInt:             fs.Uint64("jwt_refresh_int", 900, "Seconds before JWT expiry the token can be refreshed."),
 		JwtValInt:             fs.Uint64("jwt_valid_int", 3600, "Seconds that JWT token is valid for."),
+		JwtKeyFile:            fs.String("jwt_key_file", "", "File with the JWT signing key, a secret for HS256 or a PEM private key for RS256/ES256. A random key is generated when not set."),
+		JwtSigningMethod:      fs.String("jwt_signing_method", "HS256", "JWT signing method of jwt_key_file - HS256, RS256 or ES256"),
+		JwtKeyGrace:           fs.Uint64("jwt_key_grace", 3600, "Seconds tokens signed with a replaced JWT key are still accepted."),
+		OidcIssuer:            fs.String("oidc_issuer", "", "Issuer of the tokens accepted by client_auth mode oidc."),
+		OidcAudience:          fs.String("oidc_audience", "", "Audience the tokens of client_auth mode oidc must be issued for. Not checked when empty."),
+		OidcJwksFile:          fs.String("oidc_jwks_file", "", "JWKS file with the public keys of the oidc_issuer, reloaded when it changes."),
+		OidcUsernameClaim:     fs.String("oidc_username_claim", "sub", "Token claim holding the user name."),
+		OidcRolesClaim:        fs.String("oidc_roles_claim", "groups", "Token claim holding the groups or roles of the user, nested claims separated by dots."),
+		OidcRoleMap:           fs.String("oidc_role_map", "", "Roles granted per group, as group=role[,role...][;group=role...]. Groups are used as roles when not set."),
+		JwtRevocationFile:     fs.String("jwt_revocation_file", "", "File where revoked JWT tokens are persisted. Revocations are kept in memory only when not set."),
 		GnmiTranslibWrite:     fs.Bool("gnmi_translib_write", gnmi.ENABLE_TRANSLIB_WRITE, "Enable gNMI translib write for management framework"),
 		GnmiNativeWrite:       fs.Bool("gnmi_native_write", gnmi.ENABLE_NATIVE_WRITE, "Enable gNMI native write"),
 		Threshold:             fs.Int("threshold", 100, "max number of client connections"),
 		WithMasterArbitration: fs.Bool("with-master-arbitration", false, "Enables master arbitration policy."),
+		MaRolePaths:           fs.String("ma_role_paths", "", "Paths each master arbitration role may write, as role=path[,path...][;role=path...]. Roles not listed may write any path."),
 		WithSaveOnSet:         fs.Bool("with-save-on-set", false, "Enables save-on-set."),
 		IdleConnDuration:      fs.Int("idle_conn_duration", 5, "Seconds before server closes idle connections"),
 		Vrf:                   fs.String("vrf", "", "VRF name, when zmq_address belong on a VRF, need VRF name to bind ZMQ."),
 		EnableCrl:             fs.Bool("enable_crl", false, "Enable certificate revocation list"),
 		CrlExpireDuration:     fs.Int("crl_expire_duration", 86400, "Certificate revocation list cache expire duration"),
+		EnableOcsp:            fs.Bool("enable_ocsp", false, "Check client certificate revocation with OCSP"),
+		OcspPolicy:            fs.String("ocsp_policy", gnmi.OCSP_SOFT_FAIL, "Accept (soft-fail) or reject (hard-fail) client certificates whose OCSP status can't be determined"),
+		ClientCertIdentity:    fs.String("client_cert_identity", "cn", "Client certificate parts the identity is taken from, in order of precedence - cn,dns,uri,email"),
 		ImgDirPath:            fs.String("img_dir", "/tmp/host_tmp", "Directory path where image will be transferred."),
+		ConfigFile:            fs.String("config_file", "", "YAML or JSON file setting flags by name, reloaded when it changes. Flags given on the command line take precedence."),
 	}
 
-	fs.Var(&telemetryCfg.UserAuth, "client_auth", "Client auth mode(s) - none,cert,password")
+	fs.Var(&telemetryCfg.UserAuth, "client_auth", "Client auth mode(s) - none,cert,password,jwt,oidc")
+	fs.Var(&telemetryCfg.Listeners, "listener", "Additional listener serving the same services, as addr=<host:port>|unix:<path>[,name=<name>][,vrf=<vrf>][,tls=true|false]"+
+		"[,client_cert=require|request|none][,auth=<mode>[+<mode>...]][,readonly=true|false]. Can be repeated.")
 	fs.Parse(os.Args[1:])
 
-	var defUserAuth gnmi.AuthTypes
-	if *telemetryCfg.GnmiTranslibWrite {
-		//In read/write mode we want to enable auth by default.
-		defUserAuth = gnmi.AuthTypes{"password": true, "cert": false, "jwt": true}
-	} else {
-		defUserAuth = gnmi.AuthTypes{"jwt": false, "password": false, "cert": false}
+	if *telemetryCfg.ConfigFile != "" {
+		telemetryCfg.configFile = newConfigFile(*telemetryCfg.ConfigFile, fs)
+		if err := telemetryCfg.configFile.load(); err != nil {
+			return nil, nil, err
+		}
 	}
 
 	if isFlagPassed(fs, "client_auth") {
 		log.V(1).Infof("client_auth provided")
 	} else {
 		log.V(1).Infof("client_auth not provided, using defaults.")
-		telemetryCfg.UserAuth = defUserAuth
+		telemetryCfg.UserAuth = defaultUserAuth(telemetryCfg)
 	}
 
 	switch {
@@ -229,8 +279,19 @@ func setupFlags(fs *flag.FlagSet) (*TelemetryConfig, *gnmi.Config, error) {
 	}
 
 	// Move to new function
-	gnmi.JwtRefreshInt = time.Duration(*telemetryCfg.JwtRefInt * uint64(time.Second))
-	gnmi.JwtValidInt = time.Duration(*telemetryCfg.JwtValInt * uint64(time.Second))
+	gnmi.SetJwtRefreshInt(time.Duration(*telemetryCfg.JwtRefInt * uint64(time.Second)))
+	gnmi.SetJwtValidInt(time.Duration(*telemetryCfg.JwtValInt * uint64(time.Second)))
+	gnmi.JwtKeyGracePeriod = time.Duration(*telemetryCfg.JwtKeyGrace * uint64(time.Second))
+	if *telemetryCfg.JwtRevocationFile != "" {
+		if err := gnmi.LoadJwtRevocationList(*telemetryCfg.JwtRevocationFile); err != nil {
+			return nil, nil, fmt.Errorf("failed to load jwt_revocation_file: %v", err)
+		}
+	}
+	if *telemetryCfg.JwtKeyFile != "" {
+		if err := gnmi.LoadJwtSigningKey(*telemetryCfg.JwtKeyFile, *telemetryCfg.JwtSigningMethod); err != nil {
+			return nil, nil, fmt.Errorf("failed to load jwt_key_file: %v", err)
+		}
+	}
 
 	cfg := &gnmi.Config{}
 	cfg.Port = int64(*telemetryCfg.Port)
@@ -242,8 +303,79 @@ func setupFlags(fs *flag.FlagSet) (*TelemetryConfig, *gnmi.Config, error) {
 	cfg.ConfigTableName = *telemetryCfg.ConfigTableName
 	cfg.Vrf = *telemetryCfg.Vrf
 	cfg.EnableCrl = *telemetryCfg.EnableCrl
+	cfg.EnableOcsp = *telemetryCfg.EnableOcsp
+	cfg.EnableMasterArbitration = *telemetryCfg.WithMasterArbitration
+	if !*telemetryCfg.NoTLS {
+		if *telemetryCfg.CaCert == "" && telemetryCfg.UserAuth.Enabled("cert") {
+			telemetryCfg.UserAuth.Unset("cert")
+			log.Warning("client_auth mode cert requires ca_crt option. Disabling cert mode authentication.")
+		}
+		// Set before the server starts, as a config file reload can replace it from then on
+		cfg.UserAuth = telemetryCfg.UserAuth
+	}
+
+	if *telemetryCfg.MaRolePaths != "" {
+		rolePaths, err := gnmi.ParseRolePaths(*telemetryCfg.MaRolePaths)
+		if err != nil {
+			return nil, nil, err
+		}
+		cfg.RolePaths = rolePaths
+	}
+
+	if telemetryCfg.UserAuth.Enabled("oidc") {
+		switch {
+		case *telemetryCfg.OidcIssuer == "":
+			return nil, nil, fmt.Errorf("oidc_issuer must be set for client_auth mode oidc.")
+		case *telemetryCfg.OidcJwksFile == "":
+			return nil, nil, fmt.Errorf("oidc_jwks_file must be set for client_auth mode oidc.")
+		}
+		cfg.Oidc = &gnmi.OidcConfig{
+			Issuer:        *telemetryCfg.OidcIssuer,
+			Audience:      *telemetryCfg.OidcAudience,
+			JwksFile:      *telemetryCfg.OidcJwksFile,
+			UsernameClaim: *telemetryCfg.OidcUsernameClaim,
+			RolesClaim:    *telemetryCfg.OidcRolesClaim,
+		}
+		if *telemetryCfg.OidcRoleMap != "" {
+			roleMap, err := gnmi.ParseOidcRoleMap(*telemetryCfg.OidcRoleMap)
+			if err != nil {
+				return nil, nil, err
+			}
+			cfg.Oidc.RoleMap = roleMap
+		}
+		if err := cfg.Oidc.LoadJwks(); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	for _, spec := range telemetryCfg.Listeners {
+		listener, err := gnmi.ParseListenerConfig(spec)
+		if err != nil {
+			return nil, nil, err
+		}
+		if listener.TLS && *telemetryCfg.NoTLS {
+			return nil, nil, fmt.Errorf("listener %s: tls can't be enabled with noTLS.", listener.Name)
+		}
+		if listener.UserAuth.Enabled("oidc") && cfg.Oidc == nil {
+			return nil, nil, fmt.Errorf("listener %s: client_auth mode oidc must be enabled to use it on a listener.", listener.Name)
+		}
+		cfg.Listeners = append(cfg.Listeners, listener)
+	}
 
 	gnmi.SetCrlExpireDuration(time.Duration(*telemetryCfg.CrlExpireDuration) * time.Second)
+	if err := gnmi.SetOcspPolicy(*telemetryCfg.OcspPolicy); err != nil {
+		return nil, nil, err
+	}
+
+	var identitySources []string
+	for _, source := range strings.Split(*telemetryCfg.ClientCertIdentity, ",") {
+		if source = strings.TrimSpace(source); source != "" {
+			identitySources = append(identitySources, source)
+		}
+	}
+	if err := gnmi.SetClientCertIdentitySources(identitySources); err != nil {
+		return nil, nil, err
+	}
 
 	// TODO: After other dependent projects are migrated to ZmqPort, remove ZmqAddress
 	zmqAddress := *telemetryCfg.ZmqAddress
@@ -263,6 +395,15 @@ func setupFlags(fs *flag.FlagSet) (*TelemetryConfig, *gnmi.Config, error) {
 	return telemetryCfg, cfg, nil
 }
 
+// defaultUserAuth returns the auth modes used when client_auth is not given.
+func defaultUserAuth(telemetryCfg *TelemetryConfig) gnmi.AuthTypes {
+	if *telemetryCfg.GnmiTranslibWrite {
+		//In read/write mode we want to enable auth by default.
+		return gnmi.AuthTypes{"password": true, "cert": false, "jwt": true, "oidc": false}
+	}
+	return gnmi.AuthTypes{"jwt": false, "password": false, "cert": false, "oidc": false}
+}
+
 func isFlagPassed(fs *flag.FlagSet, name string) bool {
 	found := false
 	fs.Visit(func(f *flag.Flag) {
@@ -371,6 +512,7 @@ func startGNMIServer(telemetryCfg *TelemetryConfig, cfg *gnmi.Config, serverCont
 		}
 
 		var opts []grpc.ServerOption
+		var serverTLS *tls.Config
 		var certLoaded int32
 		atomic.StoreInt32(&certLoaded, 0) // Not loaded
 
@@ -459,28 +601,23 @@ func startGNMIServer(telemetryCfg *TelemetryConfig, cfg *gnmi.Config, serverCont
 					continue
 				}
 				tlsCfg.ClientCAs = certPool
-			} else {
-				if telemetryCfg.UserAuth.Enabled("cert") {
-					telemetryCfg.UserAuth.Unset("cert")
-					log.Warning("client_auth mode cert requires ca_crt option. Disabling cert mode authentication.")
-				}
 			}
 
 			atomic.StoreInt32(&certLoaded, 1) // Certs have loaded
+			serverTLS = tlsCfg
 
-			keep_alive_params := keepalive.ServerParameters{
-				MaxConnectionIdle: time.Duration(*telemetryCfg.IdleConnDuration) * time.Second, // duration in which idle connection will be closed, default is inf
-			}
-
+			// Idle connections are closed by the server after cfg.IdleConnDuration,
+			// which can change while it runs
 			opts = []grpc.ServerOption{grpc.Creds(credentials.NewTLS(tlsCfg))}
 
-			if *telemetryCfg.IdleConnDuration > 0 { // non inf case
-				opts = append(opts, grpc.KeepaliveParams(keep_alive_params))
+			if *telemetryCfg.JwtKeyFile != "" {
+				// The key was loaded at startup, keep it if the file became invalid since
+				if err := gnmi.LoadJwtSigningKey(*telemetryCfg.JwtKeyFile, *telemetryCfg.JwtSigningMethod); err != nil {
+					log.Errorf("Failed to reload JWT signing key, keeping the current key: %v", err)
+				}
+			} else {
+				gnmi.GenerateJwtSecretKey()
 			}
-
-			cfg.UserAuth = telemetryCfg.UserAuth
-
-			gnmi.GenerateJwtSecretKey()
 		}
 
 		// Setup interceptor chain (includes DPU proxy with Redis-based routing)
@@ -493,6 +630,20 @@ func startGNMIServer(telemetryCfg *TelemetryConfig, cfg *gnmi.Config, serverCont
 
 		opts = append(opts, currentServerChain.GetServerOptions()...)
 
+		for _, listener := range cfg.Listeners {
+			listener.Opts = nil
+			if listener.TLS {
+				listenerTLS := serverTLS.Clone()
+				listenerTLS.ClientAuth = listener.ClientCert
+				if listenerTLS.ClientCAs == nil && listener.UserAuth.Enabled("cert") {
+					listener.UserAuth.Unset("cert")
+					log.Warningf("Listener %s: client_auth mode cert requires ca_crt option. Disabling cert mode authentication.", listener.Name)
+				}
+				listener.Opts = append(listener.Opts, grpc.Creds(credentials.NewTLS(listenerTLS)))
+			}
+			listener.Opts = append(listener.Opts, currentServerChain.GetServerOptions()...)
+		}
+
 		s, err := gnmi.NewServer(cfg, opts)
 		if err != nil {
 			log.Errorf("Failed to create gNMI server: %v", err)
@@ -502,11 +653,7 @@ func startGNMIServer(telemetryCfg *TelemetryConfig, cfg *gnmi.Config, serverCont
 			s.SaveStartupConfig = gnmi.SaveOnSetEnabled
 		}
 
-		if *telemetryCfg.WithMasterArbitration {
-			s.ReqFromMaster = gnmi.ReqFromMasterEnabledMA
-		}
-
-		log.V(1).Infof("Auth Modes: %v", telemetryCfg.UserAuth)
+		log.V(1).Infof("Auth Modes: %v", cfg.Live().UserAuth)
 		log.V(1).Infof("Starting RPC server on address: %s", s.Address())
 
 		go func() {
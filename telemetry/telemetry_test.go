@@ -142,6 +142,158 @@ func TestFlags(t *testing.T) {
 	}
 }
 
+func TestListenerFlags(t *testing.T) {
+	originalArgs := os.Args
+	defer func() {
+		os.Args = originalArgs
+	}()
+
+	fs := flag.NewFlagSet("testListenerFlags", flag.ContinueOnError)
+	os.Args = []string{"cmd", "-port", "8081", "-insecure",
+		"-listener", "name=mgmt,addr=:50052,vrf=mgmt,auth=cert",
+		"-listener", "name=agent,addr=unix:/var/run/gnmi/gnmi.sock,readonly=true"}
+	_, cfg, err := setupFlags(fs)
+	if err != nil {
+		t.Fatalf("Expected err to be nil, got err %v", err)
+	}
+	if len(cfg.Listeners) != 2 || cfg.Listeners[0].Vrf != "mgmt" || !cfg.Listeners[1].ReadOnly {
+		t.Errorf("Unexpected listeners %+v", cfg.Listeners)
+	}
+
+	invalidArgs := [][]string{
+		{"cmd", "-port", "8081", "-insecure", "-listener", "addr=unix:/var/run/gnmi/gnmi.sock,vrf=mgmt"},
+		{"cmd", "-port", "8081", "-noTLS", "-listener", "addr=:50052"},
+		{"cmd", "-port", "8081", "-insecure", "-listener", "addr=:50052,auth=oidc"},
+	}
+	for _, args := range invalidArgs {
+		fs := flag.NewFlagSet("testListenerFlags", flag.ContinueOnError)
+		os.Args = args
+		if _, _, err := setupFlags(fs); err == nil {
+			t.Errorf("Expected %v to fail", args)
+		}
+	}
+}
+
+func TestJwtKeyFileFlags(t *testing.T) {
+	originalArgs := os.Args
+	defer func() {
+		os.Args = originalArgs
+	}()
+
+	keyFile := filepath.Join(t.TempDir(), "jwt.key")
+	if err := ioutil.WriteFile(keyFile, []byte("0123456789abcdef0123456789abcdef"), 0600); err != nil {
+		t.Fatalf("Failed to write key file: %v", err)
+	}
+	fs := flag.NewFlagSet("testJwtKeyFileFlags", flag.ContinueOnError)
+	os.Args = []string{"cmd", "-port", "8081", "-noTLS", "-jwt_key_file", keyFile}
+	if _, _, err := setupFlags(fs); err != nil {
+		t.Fatalf("Expected err to be nil, got err %v", err)
+	}
+
+	// The server does not start with a key file it can't load
+	invalidArgs := [][]string{
+		{"cmd", "-port", "8081", "-noTLS", "-jwt_key_file", filepath.Join(t.TempDir(), "missing.key")},
+		{"cmd", "-port", "8081", "-noTLS", "-jwt_key_file", keyFile, "-jwt_signing_method", "RS256"},
+	}
+	for _, args := range invalidArgs {
+		fs := flag.NewFlagSet("testJwtKeyFileFlags", flag.ContinueOnError)
+		os.Args = args
+		if _, _, err := setupFlags(fs); err == nil {
+			t.Errorf("Expected %v to fail", args)
+		}
+	}
+}
+
+func TestConfigFile(t *testing.T) {
+	originalArgs := os.Args
+	defer func() {
+		os.Args = originalArgs
+	}()
+	defer gnmi.SetOcspPolicy(gnmi.GetOcspPolicy())
+
+	path := filepath.Join(t.TempDir(), "telemetry.yaml")
+	writeConfig := func(config string) {
+		if err := ioutil.WriteFile(path, []byte(config), 0644); err != nil {
+			t.Fatalf("Failed to write config file: %v", err)
+		}
+	}
+	writeConfig(`
+port: 9090
+threshold: 50
+idle_conn_duration: 10
+client_auth: [password, jwt]
+listener:
+  - name=agent,addr=unix:/var/run/gnmi/gnmi.sock,readonly=true
+`)
+
+	fs := flag.NewFlagSet("testConfigFile", flag.ContinueOnError)
+	os.Args = []string{"cmd", "-port", "8081", "-noTLS", "-config_file", path}
+	telemetryCfg, cfg, err := setupFlags(fs)
+	if err != nil {
+		t.Fatalf("Expected err to be nil, got err %v", err)
+	}
+	// the command line takes precedence over the file
+	if cfg.Port != 8081 || cfg.Threshold != 50 || cfg.IdleConnDuration != 10 || len(cfg.Listeners) != 1 {
+		t.Errorf("Unexpected config %+v", cfg)
+	}
+	if !telemetryCfg.UserAuth.Enabled("password") || !telemetryCfg.UserAuth.Enabled("jwt") {
+		t.Errorf("Expected client_auth from config file, got %v", telemetryCfg.UserAuth)
+	}
+
+	writeConfig(`
+port: 9091
+threshold: 200
+client_auth: password
+ocsp_policy: hard-fail
+listener:
+  - name=agent,addr=unix:/var/run/gnmi/gnmi.sock,readonly=true
+`)
+	applied, restart, err := telemetryCfg.configFile.reload(telemetryCfg, cfg)
+	if err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if !reflect.DeepEqual(applied, []string{"client_auth", "idle_conn_duration", "ocsp_policy", "threshold"}) {
+		t.Errorf("Unexpected applied settings %v", applied)
+	}
+	if len(restart) != 0 {
+		t.Errorf("Expected port given on the command line to be ignored, got %v", restart)
+	}
+	live := cfg.Live()
+	if live.Threshold != 200 || live.IdleConnDuration != 5 || !live.UserAuth.Enabled("password") || live.UserAuth.Enabled("jwt") {
+		t.Errorf("Unexpected live config %+v", live)
+	}
+	if gnmi.GetOcspPolicy() != gnmi.OCSP_HARD_FAIL {
+		t.Errorf("Expected ocsp_policy to be applied")
+	}
+
+	// settings needing a restart are reported, invalid ones are not applied
+	writeConfig(`
+threshold: -1
+client_auth: password
+ocsp_policy: hard-fail
+img_dir: /tmp/images
+`)
+	applied, restart, err = telemetryCfg.configFile.reload(telemetryCfg, cfg)
+	if err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if len(applied) != 0 || !reflect.DeepEqual(restart, []string{"img_dir", "listener"}) {
+		t.Errorf("Unexpected applied %v and restart %v settings", applied, restart)
+	}
+	if cfg.Live().Threshold != 200 || cfg.ImgDir == "/tmp/images" {
+		t.Errorf("Unexpected config %+v", cfg)
+	}
+
+	writeConfig("threshold: [")
+	if _, _, err := telemetryCfg.configFile.reload(telemetryCfg, cfg); err == nil {
+		t.Errorf("Expected invalid config file to fail")
+	}
+	writeConfig("thresold: 10")
+	if _, _, err := telemetryCfg.configFile.reload(telemetryCfg, cfg); err == nil {
+		t.Errorf("Expected unknown setting to fail")
+	}
+}
+
 func TestStartGNMIServer(t *testing.T) {
 	testServerCert := "../testdata/certs/testserver.cert"
 	testServerKey := "../testdata/certs/testserver.key"
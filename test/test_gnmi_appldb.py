@@ -273,6 +273,29 @@ class TestGNMIApplDb:
         assert ret != 0, 'Invalid path'
         assert 'Unsupported path' in msg
 
+    def test_gnmi_update_atomic_01(self):
+        clear_appl_db('DASH_QOS')
+        clear_appl_db('DASH_VNET')
+        valid_path = '/sonic-db:APPL_DB/localhost/DASH_QOS/qos_01'
+        invalid_path = '/sonic-db:APPL_DB/localhost/DASH_VNET/Vnet3721'
+        file_object = open('update_valid.txt', 'w')
+        file_object.write(json.dumps({'bw': '10001', 'cps': '1001', 'flows': '101'}))
+        file_object.close()
+        file_object = open('update_invalid.txt', 'w')
+        file_object.write('x')
+        file_object.close()
+        update_list = [valid_path + ':@./update_valid.txt', invalid_path + ':@./update_invalid.txt']
+
+        ret, msg = gnmi_set([], update_list, [])
+        assert ret != 0, 'Invalid json ietf value'
+        assert 'update[1] /DASH_VNET/Vnet3721' in msg, msg
+
+        # The valid update must not be applied on its own
+        ret, msg_list = gnmi_get(['/sonic-db:APPL_DB/localhost/_DASH_QOS/qos_01'])
+        if ret == 0:
+            for msg in msg_list:
+                assert msg == '{}', 'Partial update was applied'
+
     def test_gnmi_invalid_origin_01(self):
         path1 = '/sonic-db:APPL_DB/localhost/DASH_QOS'
         path2 = '/sonic-yang:APPL_DB/localhost/DASH_QOS'
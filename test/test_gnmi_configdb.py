@@ -452,6 +452,28 @@ class TestGNMIConfigDb:
         assert ret != 0, "Failed to detect invalid replace path"
         assert "Invalid elem length" in msg, msg
 
+    def test_gnmi_get_wildcard_instance_01(self):
+        get_list = ['/sonic-db:CONFIG_DB/*/DEVICE_METADATA']
+
+        ret, msg_list = gnmi_get(get_list)
+        assert ret == 0, 'Fail to get with wildcard namespace: ' + msg_list[0]
+        assert len(msg_list) == 1, 'Single database should resolve to localhost only'
+        assert 'bgp_asn' in msg_list[0], 'Invalid result: ' + msg_list[0]
+
+    def test_gnmi_get_wildcard_instance_invalid_01(self):
+        get_list = ['/sonic-db:CONFIG_DB/asic*/DEVICE_METADATA']
+
+        ret, msg_list = gnmi_get(get_list)
+        assert ret != 0, 'Wildcard without matching namespace should fail'
+
+    def test_gnmi_poll_wildcard_instance_01(self):
+        path = "/CONFIG_DB/*/DEVICE_METADATA"
+        cnt = 3
+        interval = 1
+        ret, msg = gnmi_subscribe_poll(path, interval, cnt, timeout=0)
+        assert ret == 0, 'Fail to subscribe: ' + msg
+        assert msg.count("bgp_asn") == cnt, 'Invalid result: ' + msg
+
     def test_gnmi_poll_01(self):
         path = "/CONFIG_DB/localhost/DEVICE_METADATA"
         cnt = 3
@@ -1,7 +1,7 @@
 
 import os
 import time
-from utils import gnmi_get, gnmi_subscribe_poll, gnmi_subscribe_stream_sample
+from utils import gnmi_get, gnmi_subscribe_once, gnmi_subscribe_poll, gnmi_subscribe_stream_sample
 
 import pytest
 
@@ -48,6 +48,23 @@ class TestGNMICountersDb:
         assert ret == 0, 'Fail to subscribe: ' + msg
         assert msg.count("oid:0x1000000000003") == cnt, 'Invalid result: ' + msg
 
+    def test_gnmi_once_table_01(self):
+        path = "/COUNTERS_DB/localhost/COUNTERS_PORT_NAME_MAP/Ethernet10"
+        ret, msg = gnmi_subscribe_once(path, timeout=10)
+        assert ret == 0, 'Fail to subscribe: ' + msg
+        assert msg.count("COUNTERS_PORT_NAME_MAP") == 1, 'Invalid result: ' + msg
+
+    def test_gnmi_once_table_02(self):
+        path = "/COUNTERS_DB/localhost/COUNTERS/oid:0x1000000000003"
+        ret, msg = gnmi_subscribe_once(path, timeout=10)
+        assert ret == 0, 'Fail to subscribe: ' + msg
+        assert msg.count("oid:0x1000000000003") == 1, 'Invalid result: ' + msg
+
+    def test_gnmi_once_invalid_path(self):
+        path = "/COUNTERS_DB/localhost/INVALID_TABLE"
+        ret, msg = gnmi_subscribe_once(path, timeout=10)
+        assert ret != 0, 'Subscribe to invalid path should fail: ' + msg
+
     def test_gnmi_stream_sample_01(self):
         # Subscribe table
         path = "/COUNTERS_DB/localhost/COUNTERS_PORT_NAME_MAP"
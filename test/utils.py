@@ -168,6 +168,19 @@ def gnmi_subscribe_poll(gnmi_path, interval, count, timeout):
     ret, msg = run_cmd(cmd)
     return ret, msg
 
+def gnmi_subscribe_once(gnmi_path, timeout):
+    path = os.getcwd()
+    cmd = path + '/build/bin/gnmi_cli '
+    cmd += '-client_types=gnmi -a 127.0.0.1:8080 -logtostderr -insecure '
+    # Use sonic-db as default origin
+    cmd += '-origin=sonic-db '
+    if timeout:
+        cmd += '-streaming_timeout=%u ' % timeout
+    cmd += '-query_type=once '
+    cmd += '-q %s' % (gnmi_path)
+    ret, msg = run_cmd(cmd)
+    return ret, msg
+
 def gnmi_subscribe_stream_sample(gnmi_path, interval, count, timeout):
     path = os.getcwd()
     cmd = path + '/build/bin/gnmi_cli '
@@ -0,0 +1,25 @@
+#!/bin/bash
+# Stands in for vtysh in tests, printing the canned JSON output of a command from testdata.
+# "vtysh -n 0 -c 'show ip route vrf Vrf1 json'" prints VTYSH_SHOW_IP_ROUTE_VRF_VRF1_JSON_ASIC0.txt
+
+dir=$(dirname "$0")
+asic=""
+command=""
+while getopts "n:c:" opt; do
+    case $opt in
+        n) asic="$OPTARG" ;;
+        c) command="$OPTARG" ;;
+        *) exit 1 ;;
+    esac
+done
+
+name=$(echo "vtysh $command" | tr 'a-z' 'A-Z' | tr ' ./:' '____')
+if [ -n "$asic" ]; then
+    name="${name}_ASIC${asic}"
+fi
+
+if [ ! -f "$dir/$name.txt" ]; then
+    echo "% Unknown command: $command"
+    exit 1
+fi
+cat "$dir/$name.txt"